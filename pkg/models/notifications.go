@@ -1,6 +1,11 @@
 package models
 
-import "errors"
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+)
 
 var ErrInvalidEmailCode = errors.New("invalid or expired email code")
 var ErrSmtpNotEnabled = errors.New("SMTP not configured, check your grafana.ini config file's [smtp] section")
@@ -37,6 +42,37 @@ type SendWebhookSync struct {
 	HttpMethod  string
 	HttpHeader  map[string]string
 	ContentType string
+	// ProxyURL overrides the proxy from the environment, if set.
+	ProxyURL string
+	// Timeout overrides the default timeout of the request, if set.
+	Timeout time.Duration
+	// TLSConfig overrides the default TLS configuration, e.g. to present a
+	// client certificate, if set.
+	TLSConfig *tls.Config
+	// TLSConfigKey identifies TLSConfig, so that requests with the same key
+	// share their connections. Requests with the same key must have
+	// equivalent TLS configurations. Connections of requests with a
+	// TLSConfig but no key are closed after the request.
+	TLSConfigKey string
+	// OnResponse is called with the status and body of every response, if
+	// set, e.g. to log them for troubleshooting.
+	OnResponse func(status string, body []byte)
+	// IdempotencyKey is the same for every attempt to send the same request,
+	// so that receivers supporting it can drop duplicates of retries. It is
+	// sent in the IdempotencyHeader, if set.
+	IdempotencyKey    string
+	IdempotencyHeader string
+}
+
+// WebhookStatusError is returned by the SendWebhookSync handler when the
+// remote endpoint responds with a non-2xx status code.
+type WebhookStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e WebhookStatusError) Error() string {
+	return fmt.Sprintf("Webhook response status %v", e.Status)
 }
 
 type SendResetPasswordEmailCommand struct {
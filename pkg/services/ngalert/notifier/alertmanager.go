@@ -101,6 +101,7 @@ type Alertmanager struct {
 
 	stageMetrics      *notify.Metrics
 	dispatcherMetrics *dispatch.DispatcherMetrics
+	deliveryMetrics   *channels.DeliveryMetrics
 
 	reloadConfigMtx sync.RWMutex
 	config          []byte
@@ -114,6 +115,7 @@ func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Aler
 		marker:            types.NewMarker(m.Registerer),
 		stageMetrics:      notify.NewMetrics(m.Registerer),
 		dispatcherMetrics: dispatch.NewDispatcherMetrics(m.Registerer),
+		deliveryMetrics:   channels.NewDeliveryMetrics(m.Registerer),
 		Store:             store,
 		Metrics:           m,
 	}
@@ -303,7 +305,7 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	}
 
 	// With the templates persisted, create the template list using the paths.
-	tmpl, err := template.FromGlobs(paths...)
+	tmpl, err := channels.NewTemplate(paths)
 	if err != nil {
 		return err
 	}
@@ -395,56 +397,23 @@ func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableAp
 			}
 			secureSettings[k] = d
 		}
-		var (
-			cfg = &channels.NotificationChannelConfig{
-				UID:                   r.UID,
-				Name:                  r.Name,
-				Type:                  r.Type,
-				DisableResolveMessage: r.DisableResolveMessage,
-				Settings:              r.Settings,
-				SecureSettings:        secureSettings,
-			}
-			n   NotificationChannel
-			err error
-		)
-		switch r.Type {
-		case "email":
-			n, err = channels.NewEmailNotifier(cfg, tmpl) // Email notifier already has a default template.
-		case "pagerduty":
-			n, err = channels.NewPagerdutyNotifier(cfg, tmpl)
-		case "pushover":
-			n, err = channels.NewPushoverNotifier(cfg, tmpl)
-		case "slack":
-			n, err = channels.NewSlackNotifier(cfg, tmpl)
-		case "telegram":
-			n, err = channels.NewTelegramNotifier(cfg, tmpl)
-		case "victorops":
-			n, err = channels.NewVictoropsNotifier(cfg, tmpl)
-		case "teams":
-			n, err = channels.NewTeamsNotifier(cfg, tmpl)
-		case "dingding":
-			n, err = channels.NewDingDingNotifier(cfg, tmpl)
-		case "kafka":
-			n, err = channels.NewKafkaNotifier(cfg, tmpl)
-		case "webhook":
-			n, err = channels.NewWebHookNotifier(cfg, tmpl)
-		case "sensugo":
-			n, err = channels.NewSensuGoNotifier(cfg, tmpl)
-		case "discord":
-			n, err = channels.NewDiscordNotifier(cfg, tmpl)
-		case "alertmanager":
-			n, err = channels.NewAlertmanagerNotifier(cfg, tmpl)
-		case "googlechat":
-			n, err = channels.NewGoogleChatNotifier(cfg, tmpl)
-		case "line":
-			n, err = channels.NewLineNotifier(cfg, tmpl)
-		case "threema":
-			n, err = channels.NewThreemaNotifier(cfg, tmpl)
-		case "opsgenie":
-			n, err = channels.NewOpsgenieNotifier(cfg, tmpl)
-		default:
-			return nil, fmt.Errorf("notifier %s is not supported", r.Type)
+		cfg := &channels.NotificationChannelConfig{
+			UID:                   r.UID,
+			Name:                  r.Name,
+			Type:                  r.Type,
+			DisableResolveMessage: r.DisableResolveMessage,
+			Settings:              r.Settings,
+			SecureSettings:        secureSettings,
+			ImagesDir:             am.Settings.ImagesDir,
+			SecretRefs: channels.SecretRefOptions{
+				Enabled:   am.Settings.AlertingSecretReferencesEnabled,
+				Dir:       am.Settings.AlertingSecretsDir,
+				EnvPrefix: am.Settings.AlertingSecretsEnvPrefix,
+			},
+			FileDir: am.Settings.AlertingFileNotifierDir,
+			Metrics: am.deliveryMetrics,
 		}
+		n, err := channels.BuildNotifier(cfg, tmpl)
 		if err != nil {
 			return nil, err
 		}
@@ -104,6 +104,75 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 		},
 	}
 
+	// Options of the notifiers that send HTTP requests through the shared
+	// webhook sender.
+	httpNotifierOptions := []alerting.NotifierOption{
+		{
+			Label:        "Max retries",
+			Element:      alerting.ElementTypeInput,
+			InputType:    alerting.InputTypeText,
+			Placeholder:  "0",
+			Description:  "Number of times to retry failed requests, with exponential backoff.",
+			PropertyName: "max_retries",
+		},
+		{
+			Label:        "HTTP proxy",
+			Element:      alerting.ElementTypeInput,
+			InputType:    alerting.InputTypeText,
+			Placeholder:  "http://proxy.example.com:3128",
+			Description:  "Proxy to send requests through instead of the proxy from the environment.",
+			PropertyName: "http_proxy",
+		},
+		{
+			Label:        "Timeout",
+			Element:      alerting.ElementTypeInput,
+			InputType:    alerting.InputTypeText,
+			Placeholder:  "30s",
+			Description:  "Time to wait for a response to each request.",
+			PropertyName: "timeout",
+		},
+		{
+			Label:        "TLS CA certificate",
+			Element:      alerting.ElementTypeTextArea,
+			Description:  "PEM encoded CA certificate to verify the server certificate with, instead of the system CAs.",
+			PropertyName: "tls_ca_cert",
+		},
+		{
+			Label:        "TLS client certificate",
+			Element:      alerting.ElementTypeTextArea,
+			Description:  "PEM encoded certificate to authenticate with. Requires the TLS client key.",
+			PropertyName: "tls_client_cert",
+		},
+		{
+			Label:        "TLS client key",
+			Element:      alerting.ElementTypeTextArea,
+			Description:  "PEM encoded key of the TLS client certificate.",
+			PropertyName: "tls_client_key",
+			Secure:       true,
+		},
+		{
+			Label:        "Skip TLS verification",
+			Element:      alerting.ElementTypeCheckbox,
+			Description:  "Doesn't verify the server certificate. Only meant for gateways with self-signed certificates.",
+			PropertyName: "tls_skip_verify",
+		},
+		{
+			Label:        "HTTP headers",
+			Element:      alerting.ElementTypeTextArea,
+			Placeholder:  `{"X-Team": "{{ .CommonLabels.team }}"}`,
+			Description:  "JSON object of headers to add to requests. Values can use template variables.",
+			PropertyName: "http_headers",
+		},
+		{
+			Label:        "User agent",
+			Element:      alerting.ElementTypeInput,
+			InputType:    alerting.InputTypeText,
+			Placeholder:  "Grafana/<version>",
+			Description:  "User-Agent header of requests.",
+			PropertyName: "user_agent",
+		},
+	}
+
 	return []*alerting.NotifierPlugin{
 		{
 			Type:        "dingding",
@@ -163,6 +232,60 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					PropertyName: "kafkaTopic",
 					Required:     true,
 				},
+				{
+					Label:   "Record key",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "none",
+							Label: "None",
+						},
+						{
+							Value: "group_key",
+							Label: "Alert group",
+						},
+					},
+					Description:  "Keying records by alert group puts all notifications of a group on the same partition, in order.",
+					PropertyName: "key_strategy",
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Username to authenticate with the REST proxy.",
+					PropertyName: "username",
+				},
+				{
+					Label:        "Password",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "password",
+					Secure:       true,
+				},
+				{
+					Label:   "Compression",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "",
+							Label: "None",
+						},
+						{
+							Value: "gzip",
+							Label: "gzip",
+						},
+					},
+					Description:  "Compress request bodies larger than the compression threshold.",
+					PropertyName: "compress",
+				},
+				{
+					Label:        "Compression threshold",
+					Description:  "Size in bytes a request body must exceed to be compressed. Defaults to 1024.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1024",
+					PropertyName: "compress_threshold",
+				},
 			},
 		},
 		{
@@ -203,8 +326,16 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					Element:      alerting.ElementTypeInput,
 					InputType:    alerting.InputTypeText,
 					Placeholder:  "Pagerduty Integration Key",
+					Description:  "Required unless the routing key is set.",
 					PropertyName: "integrationKey",
-					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Routing Key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					Description:  "The integration key under the name the Prometheus Alertmanager uses. Used if the integration key is empty.",
+					PropertyName: "routing_key",
 					Secure:       true,
 				},
 				{
@@ -252,6 +383,13 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					InputType:    alerting.InputTypeText,
 					PropertyName: "group",
 				},
+				{
+					Label:        "Source",
+					Description:  "The unique location of the affected system, for example a hostname. Defaults to the hostname of the Grafana server. You can use templates for the source",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "source",
+				},
 				{ // New in 8.0.
 					Label:        "Summary",
 					Description:  "You can use templates for summary",
@@ -289,6 +427,14 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 						},
 					},
 				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value selects the message type of firing alerts when no message type is set.",
+					PropertyName: "severity_label",
+				},
 			},
 		},
 		{
@@ -334,6 +480,20 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					SelectOptions: pushoverPriorityOptions,
 					PropertyName:  "okPriority",
 				},
+				{
+					Label:        "Priority from severity",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Derive the alerting priority from the severity label: emergency for critical and high for warning alerts. Alerts with other severities use the alerting priority.",
+					PropertyName: "severity_priority",
+				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value, such as critical or warning, sets the priority of the alerts.",
+					PropertyName: "severity_label",
+				},
 				{
 					Description:  "How often (in seconds) the Pushover servers will send the same alerting or OK notification to the user.",
 					Label:        "Retry (Only used for Emergency Priority)",
@@ -554,6 +714,22 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					Placeholder:  `{{ template "default.message" . }}`,
 					PropertyName: "message",
 				},
+				{
+					Label:   "Card format",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "message_card",
+							Label: "Message card",
+						},
+						{
+							Value: "adaptive_card",
+							Label: "Adaptive card",
+						},
+					},
+					Description:  "Adaptive cards replace the legacy message cards of Teams connectors.",
+					PropertyName: "card_format",
+				},
 			},
 		},
 		{
@@ -575,7 +751,7 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					Label:        "Chat ID",
 					Element:      alerting.ElementTypeInput,
 					InputType:    alerting.InputTypeText,
-					Description:  "Integer Telegram Chat Identifier",
+					Description:  "Integer Telegram Chat Identifier or the @username of a channel",
 					PropertyName: "chatid",
 					Required:     true,
 				},
@@ -585,6 +761,34 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					Placeholder:  `{{ template "default.message" . }}`,
 					PropertyName: "message",
 				},
+				{
+					Label:   "Parse Mode",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "HTML",
+							Label: "HTML",
+						},
+						{
+							Value: "MarkdownV2",
+							Label: "MarkdownV2",
+						},
+					},
+					Description:  "Format the message is written in. Label and annotation values are escaped for it.",
+					PropertyName: "parse_mode",
+				},
+				{
+					Label:        "Disable Web Page Preview",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Do not show previews of the links in the message.",
+					PropertyName: "disable_web_page_preview",
+				},
+				{
+					Label:        "Silent",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Deliver the message without a notification sound.",
+					PropertyName: "silent",
+				},
 			},
 		},
 		{
@@ -635,6 +839,44 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					InputType:    alerting.InputTypeText,
 					PropertyName: "maxAlerts",
 				},
+				{
+					Label:        "Body Template",
+					Description:  "Go template rendering the whole request body instead of the default JSON payload. toJson encodes any value as JSON.",
+					Element:      alerting.ElementTypeTextArea,
+					PropertyName: "body_template",
+				},
+				{
+					Label:        "Content Type",
+					Description:  "Content type of a body rendered from the body template. Defaults to application/json.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "application/json",
+					PropertyName: "content_type",
+				},
+				{
+					Label:   "Compression",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "",
+							Label: "None",
+						},
+						{
+							Value: "gzip",
+							Label: "gzip",
+						},
+					},
+					Description:  "Compress request bodies larger than the compression threshold.",
+					PropertyName: "compress",
+				},
+				{
+					Label:        "Compression threshold",
+					Description:  "Size in bytes a request body must exceed to be compressed. Defaults to 1024.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1024",
+					PropertyName: "compress_threshold",
+				},
 			},
 		},
 		{
@@ -651,8 +893,50 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					PropertyName: "url",
 					Required:     true,
 				},
+				{
+					Label:        "Failover",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Send the alerts only to the first of the URLs that accepts them, instead of to all of them. Leave it off for replicas of a highly available Alertmanager, as they don't share alerts.",
+					PropertyName: "failover",
+				},
 			},
 		},
+		{
+			Type:        "alertmanager-webhook",
+			Name:        "Alertmanager webhook",
+			Description: "Sends notifications in the format of the webhook receiver of the Prometheus Alertmanager",
+			Heading:     "Alertmanager webhook settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "http://localhost:9095/alerts",
+					PropertyName: "url",
+					Required:     true,
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "username",
+				},
+				{
+					Label:        "Password",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "password",
+					Secure:       true,
+				},
+				{
+					Label:        "Max Alerts",
+					Description:  "Max alerts to include in a notification. Remaining alerts in the same batch will be ignored above this number. 0 means no limit.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "max_alerts",
+				},
+			}, httpNotifierOptions...),
+		},
 		{
 			Type:        "discord",
 			Name:        "Discord",
@@ -675,6 +959,26 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					PropertyName: "url",
 					Required:     true,
 				},
+				{
+					Label:        "Avatar URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "avatar_url",
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "Grafana",
+					PropertyName: "username",
+				},
+				{
+					Label:        "Embed Description",
+					Description:  "You can use templates for the description",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ .CommonAnnotations.summary }}`,
+					PropertyName: "description",
+				},
 			},
 		},
 		{
@@ -691,6 +995,12 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					PropertyName: "url",
 					Required:     true,
 				},
+				{
+					Label:        "Reply in thread",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Posts the notifications of an alert group as replies to a single thread.",
+					PropertyName: "reply_in_thread",
+				},
 			},
 		},
 		{
@@ -698,7 +1008,7 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 			Name:        "LINE",
 			Description: "Send notifications to LINE notify",
 			Heading:     "LINE notify settings",
-			Options: []alerting.NotifierOption{
+			Options: append([]alerting.NotifierOption{
 				{
 					Label:        "Token",
 					Element:      alerting.ElementTypeInput,
@@ -707,100 +1017,1340 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 					PropertyName: "token",
 					Required:     true,
 					Secure:       true,
-				}},
-		},
-		{
-			Type:        "threema",
-			Name:        "Threema Gateway",
-			Description: "Sends notifications to Threema using the Threema Gateway",
-			Heading:     "Threema Gateway settings",
-			Info: "Notifications can be configured for any Threema Gateway ID of type \"Basic\". End-to-End IDs are not currently supported." +
-				"The Threema Gateway ID can be set up at https://gateway.threema.ch/.",
-			Options: []alerting.NotifierOption{
+				},
 				{
-					Label:          "Gateway ID",
-					Element:        alerting.ElementTypeInput,
-					InputType:      alerting.InputTypeText,
-					Placeholder:    "*3MAGWID",
-					Description:    "Your 8 character Threema Gateway ID (starting with a *).",
-					PropertyName:   "gateway_id",
-					Required:       true,
-					ValidationRule: "\\*[0-9A-Z]{7}",
+					Label:        "Title",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "title",
 				},
 				{
-					Label:          "Recipient ID",
-					Element:        alerting.ElementTypeInput,
-					InputType:      alerting.InputTypeText,
-					Placeholder:    "YOUR3MID",
-					Description:    "The 8 character Threema ID that should receive the alerts.",
-					PropertyName:   "recipient_id",
-					Required:       true,
-					ValidationRule: "[0-9A-Z]{8}",
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
 				},
 				{
-					Label:        "API Secret",
+					Label:        "Resolved message",
+					Element:      alerting.ElementTypeTextArea,
+					Description:  "Message for resolved alerts. Defaults to the message.",
+					PropertyName: "resolved_message",
+				},
+				{
+					Label:        "Template",
 					Element:      alerting.ElementTypeInput,
 					InputType:    alerting.InputTypeText,
-					Description:  "Your Threema Gateway API secret.",
-					PropertyName: "api_secret",
-					Required:     true,
-					Secure:       true,
+					Description:  "Name of a notification template to render the message with. Must not be set together with a message.",
+					PropertyName: "template",
 				},
-			},
-		},
-		{
-			Type:        "opsgenie",
-			Name:        "OpsGenie",
-			Description: "Sends notifications to OpsGenie",
-			Heading:     "OpsGenie settings",
-			Options: []alerting.NotifierOption{
 				{
-					Label:        "API Key",
+					Label:        "Compact",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Sends a single line per alert instead of the default message. Must not be set together with a message or template.",
+					PropertyName: "compact",
+				},
+				{
+					Label:        "Sticker package ID",
 					Element:      alerting.ElementTypeInput,
 					InputType:    alerting.InputTypeText,
-					Placeholder:  "OpsGenie API Key",
-					PropertyName: "apiKey",
-					Required:     true,
-					Secure:       true,
+					Description:  "Package of the sticker to attach to notifications. Requires a sticker ID.",
+					PropertyName: "sticker_package_id",
 				},
 				{
-					Label:        "Alert API Url",
+					Label:        "Sticker ID",
 					Element:      alerting.ElementTypeInput,
 					InputType:    alerting.InputTypeText,
-					Placeholder:  "https://api.opsgenie.com/v2/alerts",
-					PropertyName: "apiUrl",
-					Required:     true,
+					Description:  "Sticker to attach to notifications. Requires a sticker package ID.",
+					PropertyName: "sticker_id",
 				},
 				{
-					Label:        "Auto close incidents",
-					Element:      alerting.ElementTypeCheckbox,
-					Description:  "Automatically close alerts in OpsGenie once the alert goes back to ok.",
-					PropertyName: "autoClose",
-				}, {
-					Label:        "Override priority",
+					Label:        "Image URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://grafana.example.com/public/img/attachments/",
+					Description:  "URL that the paths of the screenshots of alerts are relative to. The screenshot of the first alert that has one is attached to the message.",
+					PropertyName: "image",
+				},
+				{
+					Label:        "Upload image",
 					Element:      alerting.ElementTypeCheckbox,
-					Description:  "Allow the alert priority to be set using the og_priority annotation",
-					PropertyName: "overridePriority",
+					Description:  "Uploads the screenshot of the alerts from the images directory of Grafana instead of linking the image URL.",
+					PropertyName: "upload_image",
 				},
 				{
-					Label:   "Send notification tags as",
+					Label:        "Link path",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "/alerting/list",
+					Description:  "Path of the Grafana page that the link to the alerts points to, relative to the Grafana URL.",
+					PropertyName: "link_path",
+				},
+				{
+					Label:   "Language",
 					Element: alerting.ElementTypeSelect,
 					SelectOptions: []alerting.SelectOption{
 						{
-							Value: channels.OpsgenieSendTags,
-							Label: "Tags",
+							Value: "en",
+							Label: "English",
 						},
 						{
-							Value: channels.OpsgenieSendDetails,
-							Label: "Extra Properties",
+							Value: "de",
+							Label: "German",
 						},
 						{
-							Value: channels.OpsgenieSendBoth,
-							Label: "Tags & Extra Properties",
+							Value: "ja",
+							Label: "Japanese",
 						},
 					},
-					Description:  "Send the common annotations to Opsgenie as either Extra Properties, Tags or both",
-					PropertyName: "sendTagsAs",
+					Description:  "Language of the default title and message.",
+					PropertyName: "locale",
+				},
+				{
+					Label:        "Include summary",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Starts the message with the number of alerts of each severity.",
+					PropertyName: "include_summary",
+				},
+				{
+					Label:        "Include source",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Adds the link to the source of each alert to the default message.",
+					PropertyName: "include_source",
+				},
+				{
+					Label:        "Annotation fields",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `["summary", "description"]`,
+					Description:  "JSON list of the annotations to show in the default message, in order. All annotations are shown if empty.",
+					PropertyName: "annotation_fields",
+				},
+				{
+					Label:        "Label order",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `["severity", "team"]`,
+					Description:  "JSON list of the labels to list first in the default message, in order. The other labels follow sorted by name.",
+					PropertyName: "label_order",
+				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value, such as critical or warning, the summary counts the alerts by.",
+					PropertyName: "severity_label",
+				},
+				{
+					Label:        "Maximum value length",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Maximum number of characters of label and annotation values in messages. 0 doesn't limit them.",
+					PropertyName: "max_value_length",
+				},
+				{
+					Label:        "Max alerts",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Maximum number of alerts to list in the default message. The others are summarized in a line. 0 lists all alerts.",
+					PropertyName: "max_alerts",
+				},
+				{
+					Label:        "Quiet hours",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"start": "22:00", "end": "07:00", "timezone": "Europe/Berlin", "send_resolved": true}`,
+					Description:  "JSON object with the daily time window in which notifications of firing alerts aren't sent. Notifications of resolved alerts are sent unless send_resolved is false.",
+					PropertyName: "quiet_hours",
+				},
+				{
+					Label:        "Circuit breaker threshold",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Number of failed sends in a row after which sends fail right away until the cooldown is over. 0 disables the circuit breaker.",
+					PropertyName: "circuit_breaker_threshold",
+				},
+				{
+					Label:        "Circuit breaker cooldown",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1m",
+					Description:  "Time after which an open circuit breaker lets a send through again.",
+					PropertyName: "circuit_breaker_cooldown",
+				},
+				{
+					Label:        "Debug HTTP",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Logs the requests and responses of the notifier at debug level, without its secrets.",
+					PropertyName: "debug_http",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "line-messaging",
+			Name:        "LINE Messaging API",
+			Description: "Sends notifications to a LINE user, group or room with the LINE Messaging API",
+			Heading:     "LINE Messaging API settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Channel access token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "LINE channel access token",
+					PropertyName: "token",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "To",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "ID of the user, group or room that receives the notifications.",
+					PropertyName: "to",
+					Required:     true,
+				},
+				{
+					Label:        "Title",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "title",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:        "Resolved message",
+					Element:      alerting.ElementTypeTextArea,
+					Description:  "Message for resolved alerts. Defaults to the message.",
+					PropertyName: "resolved_message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "threema",
+			Name:        "Threema Gateway",
+			Description: "Sends notifications to Threema using the Threema Gateway",
+			Heading:     "Threema Gateway settings",
+			Info: "Notifications can be configured for any Threema Gateway ID of type \"Basic\", or of type \"End-to-End\" with end-to-end encryption. " +
+				"The Threema Gateway ID can be set up at https://gateway.threema.ch/.",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:          "Gateway ID",
+					Element:        alerting.ElementTypeInput,
+					InputType:      alerting.InputTypeText,
+					Placeholder:    "*3MAGWID",
+					Description:    "Your 8 character Threema Gateway ID (starting with a *). Required unless credentials are set.",
+					PropertyName:   "gateway_id",
+					ValidationRule: "\\*[0-9A-Z]{7}",
+				},
+				{
+					Label:          "Recipient ID",
+					Element:        alerting.ElementTypeInput,
+					InputType:      alerting.InputTypeText,
+					Placeholder:    "YOUR3MID",
+					Description:    "The 8 character Threema IDs that should receive the alerts, separated by commas.",
+					PropertyName:   "recipient_id",
+					Required:       true,
+					ValidationRule: "[0-9A-Z]{8}(\\s*,\\s*[0-9A-Z]{8})*",
+				},
+				{
+					Label:        "Routes",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `[{"matchers": ["team=\"db\""], "recipient_id": "ABCDEFGH"}]`,
+					Description:  "JSON list of routes that send the alerts matching all of their label matchers to their recipients instead. The first matching route wins.",
+					PropertyName: "routes",
+				},
+				{
+					Label:        "API Secret",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Your Threema Gateway API secret. Required unless credentials are set.",
+					PropertyName: "api_secret",
+					Secure:       true,
+				},
+				{
+					Label:        "Credentials",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `[{"gateway_id": "*3MAGWID", "api_secret": "..."}, {"gateway_id": "*BACKUP1", "api_secret": "..."}]`,
+					Description:  "JSON list of Threema Gateway IDs and their API secrets, which are tried in order until one of them can send a message. Replaces the Gateway ID and API secret.",
+					PropertyName: "credentials",
+					Secure:       true,
+				},
+				{
+					Label:        "Gateway URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://msgapi.threema.ch/send_simple",
+					Description:  "Overrides the URL of the Threema Gateway, for example to send through a proxy.",
+					PropertyName: "gateway_url",
+				},
+				{
+					Label:   "Encryption",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "simple",
+							Label: "None (Basic ID)",
+						},
+						{
+							Value: "e2e",
+							Label: "End-to-end (End-to-End ID)",
+						},
+					},
+					PropertyName: "encryption",
+				},
+				{
+					Label:        "Private key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					Description:  "The 64 hexadecimal characters of the private key of the Gateway ID, to encrypt messages end-to-end.",
+					PropertyName: "private_key",
+					ShowWhen: alerting.ShowWhen{
+						Field: "encryption",
+						Is:    "e2e",
+					},
+					Secure: true,
+				},
+				{
+					Label:        "Public keys",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"YOUR3MID": "<64 hexadecimal characters>"}`,
+					Description:  "JSON object of Threema IDs and their public keys. The keys of other recipients are looked up with the Threema Gateway.",
+					PropertyName: "public_keys",
+					ShowWhen: alerting.ShowWhen{
+						Field: "encryption",
+						Is:    "e2e",
+					},
+				},
+				{
+					Label:        "Title",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "title",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:        "Template",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Name of a notification template to render the message with. Must not be set together with a message.",
+					PropertyName: "template",
+				},
+				{
+					Label:        "Compact",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Sends a single line per alert instead of the default message. Must not be set together with a message or template.",
+					PropertyName: "compact",
+				},
+				{
+					Label:        "Max message size",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "3500",
+					Description:  "Maximum size of a message in bytes, at least 256. Longer messages are truncated.",
+					PropertyName: "max_message_size",
+				},
+				{
+					Label:        "Max alerts per message",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Splits large alert groups into several messages with at most this many alerts each. 0 doesn't split them.",
+					PropertyName: "max_alerts_per_message",
+				},
+				{
+					Label:        "Max alerts",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Maximum number of alerts to list in the default message. The others are summarized in a line. 0 lists all alerts.",
+					PropertyName: "max_alerts",
+				},
+				{
+					Label:        "Maximum value length",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Maximum number of characters of label and annotation values in messages. 0 doesn't limit them.",
+					PropertyName: "max_value_length",
+				},
+				{
+					Label:        "Label order",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `["severity", "team"]`,
+					Description:  "JSON list of the labels to list first in the default message, in order. The other labels follow sorted by name.",
+					PropertyName: "label_order",
+				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value, such as critical or warning, selects the emoji of the message.",
+					PropertyName: "severity_label",
+				},
+				{
+					Label:        "Priority label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "priority",
+					Description:  "Label whose value, from P1 to P5, selects the emoji of the message. Takes precedence over the severity label.",
+					PropertyName: "priority_label",
+				},
+				{
+					Label:        "Firing emoji",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Emoji that the title of notifications of firing alerts starts with. Replaces the emoji of the priority and severity.",
+					PropertyName: "firing_emoji",
+				},
+				{
+					Label:        "Resolved emoji",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Emoji that the title of notifications of resolved alerts starts with.",
+					PropertyName: "resolved_emoji",
+				},
+				{
+					Label:        "Emoji expression",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `labels.severity == "critical" ? "🔥" : status == "resolved" ? "🟢" : ""`,
+					Description:  "Expression that selects the emoji of the title from the status and the common labels of the alerts. Takes precedence over the other emojis unless it selects none.",
+					PropertyName: "emoji_expression",
+				},
+				{
+					Label:        "Drop matchers",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `["severity=\"info\""]`,
+					Description:  "JSON list of label matchers. Alerts that match all of them aren't sent.",
+					PropertyName: "drop_matchers",
+				},
+				{
+					Label:        "Include URL",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Adds a link to the alerts in Grafana to the message.",
+					PropertyName: "include_url",
+				},
+				{
+					Label:        "Link path",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "/alerting/list",
+					Description:  "Path of the Grafana page that the link to the alerts points to, relative to the Grafana URL.",
+					PropertyName: "link_path",
+				},
+				{
+					Label:        "Include runbook",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Adds the runbook_url annotation of each alert to the message.",
+					PropertyName: "include_runbook",
+				},
+				{
+					Label:        "Include source",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Adds the link to the source of each alert to the default message.",
+					PropertyName: "include_source",
+				},
+				{
+					Label:        "Rate limit",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Maximum number of messages per minute that all channels with this Gateway ID send. If the channels set different limits, the lowest one applies. 0 doesn't limit them.",
+					PropertyName: "rate_limit",
+				},
+				{
+					Label:        "Deduplication interval",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "5m",
+					Description:  "Time during which a message isn't sent again to a recipient that received it last. Disabled if empty.",
+					PropertyName: "dedup_interval",
+				},
+				{
+					Label:        "Recipient backoff",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "30s",
+					Description:  "Time during which a recipient that a send failed for is skipped. It doubles with each failure in a row. Disabled if empty.",
+					PropertyName: "recipient_backoff",
+				},
+				{
+					Label:        "Maximum recipient backoff",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1h",
+					Description:  "Time that the recipient backoff grows to at most.",
+					PropertyName: "recipient_backoff_max",
+				},
+				{
+					Label:   "Language",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "en",
+							Label: "English",
+						},
+						{
+							Value: "de",
+							Label: "German",
+						},
+						{
+							Value: "ja",
+							Label: "Japanese",
+						},
+					},
+					Description:  "Language of the default title and message.",
+					PropertyName: "locale",
+				},
+				{
+					Label:   "Format",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "markdown",
+							Label: "Markdown",
+						},
+						{
+							Value: "text",
+							Label: "Plain text",
+						},
+					},
+					Description:  "Plain text removes the Markdown of the message, for clients that show it as is.",
+					PropertyName: "format",
+				},
+				{
+					Label:        "Concurrency",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "4",
+					Description:  "Maximum number of recipients to send to at a time.",
+					PropertyName: "concurrency",
+				},
+				{
+					Label:        "Quiet hours",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"start": "22:00", "end": "07:00", "timezone": "Europe/Berlin", "send_resolved": true}`,
+					Description:  "JSON object with the daily time window in which notifications of firing alerts aren't sent. Notifications of resolved alerts are sent unless send_resolved is false.",
+					PropertyName: "quiet_hours",
+				},
+				{
+					Label:        "Circuit breaker threshold",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Number of failed sends in a row after which sends fail right away until the cooldown is over. 0 disables the circuit breaker.",
+					PropertyName: "circuit_breaker_threshold",
+				},
+				{
+					Label:        "Circuit breaker cooldown",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1m",
+					Description:  "Time after which an open circuit breaker lets a send through again.",
+					PropertyName: "circuit_breaker_cooldown",
+				},
+				{
+					Label:        "Debug HTTP",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Logs the requests and responses of the notifier at debug level, without its secrets.",
+					PropertyName: "debug_http",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "opsgenie",
+			Name:        "OpsGenie",
+			Description: "Sends notifications to OpsGenie",
+			Heading:     "OpsGenie settings",
+			Options: []alerting.NotifierOption{
+				{
+					Label:        "API Key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "OpsGenie API Key",
+					PropertyName: "apiKey",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Alert API Url",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://api.opsgenie.com/v2/alerts",
+					Description:  "Overrides the URL of the region.",
+					PropertyName: "apiUrl",
+				},
+				{
+					Label:   "Region",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "us",
+							Label: "US",
+						},
+						{
+							Value: "eu",
+							Label: "EU",
+						},
+					},
+					PropertyName: "region",
+				},
+				{
+					Label:        "Responder teams",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "db, platform",
+					Description:  "Comma-separated names of the teams to assign the alerts to.",
+					PropertyName: "responder_teams",
+				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value, such as critical or warning, sets the priority of the alerts.",
+					PropertyName: "severity_label",
+				},
+				{
+					Label:        "Auto close incidents",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Automatically close alerts in OpsGenie once the alert goes back to ok.",
+					PropertyName: "autoClose",
+				}, {
+					Label:        "Override priority",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Allow the alert priority to be set using the og_priority annotation",
+					PropertyName: "overridePriority",
+				},
+				{
+					Label:   "Send notification tags as",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: channels.OpsgenieSendTags,
+							Label: "Tags",
+						},
+						{
+							Value: channels.OpsgenieSendDetails,
+							Label: "Extra Properties",
+						},
+						{
+							Value: channels.OpsgenieSendBoth,
+							Label: "Tags & Extra Properties",
+						},
+					},
+					Description:  "Send the common annotations to Opsgenie as either Extra Properties, Tags or both",
+					PropertyName: "sendTagsAs",
+				},
+			},
+		},
+		{
+			Type:        "webex",
+			Name:        "Cisco Webex Teams",
+			Description: "Sends notifications to a Cisco Webex Teams room or person",
+			Heading:     "Webex settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Bot Token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "The access token of the Webex bot that sends the messages.",
+					PropertyName: "bot_token",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Room ID",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "The ID of the room to post messages to. Either a room ID or a person email is required.",
+					PropertyName: "room_id",
+				},
+				{
+					Label:        "Person Email",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "The email address of the person to send direct messages to, instead of a room.",
+					PropertyName: "to_person_email",
+				},
+				{
+					Label:        "Title",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "title",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "jira",
+			Name:        "Jira",
+			Description: "Opens an issue in Jira for firing alerts",
+			Heading:     "Jira settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://example.atlassian.net",
+					PropertyName: "url",
+					Required:     true,
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "The user to authenticate as, with an API token as password on Jira Cloud.",
+					PropertyName: "username",
+				},
+				{
+					Label:        "Password",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "password",
+					Secure:       true,
+				},
+				{
+					Label:        "Personal Access Token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					Description:  "The personal access token to authenticate with on Jira Server and Data Center, instead of a username and password.",
+					PropertyName: "api_token",
+					Secure:       true,
+				},
+				{
+					Label:        "Project",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "OPS",
+					Description:  "The key of the project to open issues in.",
+					PropertyName: "project",
+					Required:     true,
+				},
+				{
+					Label:        "Issue Type",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "Bug",
+					PropertyName: "issue_type",
+					Required:     true,
+				},
+				{
+					Label:        "Summary",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "summary",
+				},
+				{
+					Label:        "Description",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "description",
+				},
+				{
+					Label:        "Deduplicate",
+					Element:      alerting.ElementTypeCheckbox,
+					Description:  "Don't open an issue for an alert group that has an unresolved issue.",
+					PropertyName: "dedup",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "wecom",
+			Name:        "WeCom",
+			Description: "Sends notifications to a WeCom (WeChat Work) group robot",
+			Heading:     "WeCom settings",
+			Info:        "Configure either the webhook URL of the group robot or its key.",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Webhook URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxxxxxxx",
+					PropertyName: "url",
+					Secure:       true,
+				},
+				{
+					Label:        "Key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					Description:  "Key of the group robot, instead of the webhook URL.",
+					PropertyName: "key",
+					Secure:       true,
+				},
+				{
+					Label:   "Message type",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "text",
+							Label: "Text",
+						},
+						{
+							Value: "markdown",
+							Label: "Markdown",
+						},
+					},
+					PropertyName: "msgtype",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "matrix",
+			Name:        "Matrix",
+			Description: "Sends notifications to a Matrix room with the client-server API",
+			Heading:     "Matrix settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Homeserver URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://matrix.example.com",
+					PropertyName: "homeserver_url",
+					Required:     true,
+				},
+				{
+					Label:        "Room ID",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "!abcdefghijklmnop:example.com",
+					PropertyName: "room_id",
+					Required:     true,
+				},
+				{
+					Label:        "Access token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					Description:  "Access token of the user that sends the notifications, who must have joined the room.",
+					PropertyName: "access_token",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:        "Formatted message",
+					Element:      alerting.ElementTypeTextArea,
+					Description:  "Optional HTML version of the message, shown by clients that support it.",
+					PropertyName: "formatted_message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "mattermost",
+			Name:        "Mattermost",
+			Description: "Sends notifications to Mattermost via incoming webhooks",
+			Heading:     "Mattermost settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Webhook URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "Mattermost incoming webhook url",
+					PropertyName: "url",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Channel",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Overrides the channel of the webhook, if the webhook allows it.",
+					PropertyName: "channel",
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Overrides the username of the webhook, if the webhook allows it.",
+					PropertyName: "username",
+				},
+				{
+					Label:        "Icon URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Overrides the profile picture of the webhook, if the webhook allows it.",
+					PropertyName: "icon_url",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "signal",
+			Name:        "Signal",
+			Description: "Sends notifications to Signal through the REST API of signal-cli",
+			Heading:     "Signal settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "signal-cli URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "http://localhost:8080",
+					Description:  "URL of the REST API of signal-cli.",
+					PropertyName: "signal_cli_url",
+					Required:     true,
+				},
+				{
+					Label:        "Number",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "+4915112345678",
+					Description:  "Number of the Signal account registered with signal-cli that sends the notifications.",
+					PropertyName: "number",
+					Required:     true,
+				},
+				{
+					Label:        "Recipients",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "+4915187654321, +4917612345678",
+					Description:  "Numbers or group IDs that should receive the alerts, separated by commas.",
+					PropertyName: "recipients",
+					Required:     true,
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "servicenow",
+			Name:        "ServiceNow",
+			Description: "Opens incidents in ServiceNow",
+			Heading:     "ServiceNow settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Instance URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://example.service-now.com",
+					PropertyName: "instance_url",
+					Required:     true,
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "username",
+					Required:     true,
+				},
+				{
+					Label:        "Password",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "password",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Short description",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "short_description",
+				},
+				{
+					Label:        "Description",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "description",
+				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value, such as critical or warning, sets the urgency and impact of the incident.",
+					PropertyName: "severity_label",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "zulip",
+			Name:        "Zulip",
+			Description: "Sends notifications to a stream in Zulip",
+			Heading:     "Zulip settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Realm URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://example.zulipchat.com",
+					PropertyName: "realm_url",
+					Required:     true,
+				},
+				{
+					Label:        "Bot email",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "grafana-bot@example.zulipchat.com",
+					PropertyName: "bot_email",
+					Required:     true,
+				},
+				{
+					Label:        "API key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					Description:  "API key of the bot.",
+					PropertyName: "api_key",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Stream",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "stream",
+					Required:     true,
+				},
+				{
+					Label:        "Topic",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ .CommonLabels.alertname }}`,
+					PropertyName: "topic",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "rocketchat",
+			Name:        "Rocket.Chat",
+			Description: "Sends notifications to Rocket.Chat via incoming webhooks",
+			Heading:     "Rocket.Chat settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Webhook URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "Rocket.Chat incoming webhook url",
+					PropertyName: "url",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Channel",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "#alerts",
+					Description:  "Overrides the channel of the webhook.",
+					PropertyName: "channel",
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Overrides the username of the webhook.",
+					PropertyName: "username",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "gotify",
+			Name:        "Gotify",
+			Description: "Sends notifications to a Gotify server",
+			Heading:     "Gotify settings",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Server URL",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://gotify.example.com",
+					PropertyName: "server_url",
+					Required:     true,
+				},
+				{
+					Label:        "Application token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "app_token",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "Title",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "title",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:        "Severity label",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "severity",
+					Description:  "Label whose value, such as critical or warning, sets the priority of the message.",
+					PropertyName: "severity_label",
+				},
+				{
+					Label:        "Priorities",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"critical": 8, "warning": 5}`,
+					Description:  "JSON object of severities and the priorities of their messages, which extends the default priorities.",
+					PropertyName: "priorities",
+				},
+				{
+					Label:        "Default priority",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1",
+					Description:  "Priority of messages whose severity has no priority.",
+					PropertyName: "default_priority",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "twilio",
+			Name:        "Twilio SMS",
+			Description: "Sends notifications as SMS with Twilio",
+			Heading:     "Twilio settings",
+			Info:        "Failed SMS are only retried if Twilio rejected them because of its rate limit, so that no SMS is sent twice.",
+			Options: append([]alerting.NotifierOption{
+				{
+					Label:        "Account SID",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "ACxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx",
+					PropertyName: "account_sid",
+					Required:     true,
+				},
+				{
+					Label:        "Auth token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "auth_token",
+					Required:     true,
+					Secure:       true,
+				},
+				{
+					Label:        "From",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "+15017122661",
+					Description:  "Twilio phone number that sends the SMS.",
+					PropertyName: "from",
+					Required:     true,
+				},
+				{
+					Label:        "To",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "+15558675310, +15558675311",
+					Description:  "Phone numbers that should receive the SMS, separated by commas.",
+					PropertyName: "to",
+					Required:     true,
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.title" . }}`,
+					Description:  "Text of the SMS, at most 1600 characters. Defaults to the title and message.",
+					PropertyName: "message",
+				},
+				{
+					Label:        "Concurrency",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "4",
+					Description:  "Maximum number of recipients to send to at a time.",
+					PropertyName: "concurrency",
+				},
+				{
+					Label:        "Quiet hours",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"start": "22:00", "end": "07:00", "timezone": "Europe/Berlin", "send_resolved": true}`,
+					Description:  "JSON object with the daily time window in which notifications of firing alerts aren't sent. Notifications of resolved alerts are sent unless send_resolved is false.",
+					PropertyName: "quiet_hours",
+				},
+				{
+					Label:        "Circuit breaker threshold",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "0",
+					Description:  "Number of failed sends in a row after which sends fail right away until the cooldown is over. 0 disables the circuit breaker.",
+					PropertyName: "circuit_breaker_threshold",
+				},
+				{
+					Label:        "Circuit breaker cooldown",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "1m",
+					Description:  "Time after which an open circuit breaker lets a send through again.",
+					PropertyName: "circuit_breaker_cooldown",
+				},
+			}, httpNotifierOptions...),
+		},
+		{
+			Type:        "sns",
+			Name:        "Amazon SNS",
+			Description: "Publishes notifications to an Amazon SNS topic",
+			Heading:     "Amazon SNS settings",
+			Info:        "Without an access key, the credentials are taken from the environment or the instance role of Grafana.",
+			Options: []alerting.NotifierOption{
+				{
+					Label:        "Topic ARN",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "arn:aws:sns:us-east-1:123456789012:alerts",
+					PropertyName: "topic_arn",
+					Required:     true,
+				},
+				{
+					Label:        "Region",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Description:  "Overrides the region of the topic ARN.",
+					PropertyName: "region",
+				},
+				{
+					Label:        "Access key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "access_key",
+				},
+				{
+					Label:        "Secret key",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "secret_key",
+					Secure:       true,
+				},
+				{
+					Label:        "Subject",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "subject",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:   "Message format",
+					Element: alerting.ElementTypeSelect,
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "text",
+							Label: "Text",
+						},
+						{
+							Value: "json",
+							Label: "JSON",
+						},
+					},
+					Description:  "JSON publishes the subject and message together with the status and labels of the alerts.",
+					PropertyName: "message_format",
+				},
+				{
+					Label:        "Message attributes",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"severity": "severity"}`,
+					Description:  "JSON object of message attributes and the labels they are taken from, so that subscriptions can filter on labels.",
+					PropertyName: "message_attributes",
+				},
+			},
+		},
+		{
+			Type:        "file",
+			Name:        "File",
+			Description: "Appends notifications to a local file as JSON lines, e.g. for debugging or auditing",
+			Heading:     "File settings",
+			Info:        "The file must be in the directory set by file_notifier_dir in the [alerting] section of the Grafana configuration.",
+			Options: []alerting.NotifierOption{
+				{
+					Label:        "Path",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "notifications.log",
+					Description:  "Path of the file, relative to the directory of the Grafana configuration for notification files.",
+					PropertyName: "path",
+					Required:     true,
+				},
+				{
+					Label:        "Title",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  `{{ template "default.title" . }}`,
+					PropertyName: "title",
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:        "Max size",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "10485760",
+					Description:  "Size in bytes above which the file is rotated.",
+					PropertyName: "max_size",
+				},
+				{
+					Label:        "Max backups",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "3",
+					Description:  "Number of rotated files to keep.",
+					PropertyName: "max_backups",
 				},
 			},
 		},
@@ -0,0 +1,53 @@
+package channels
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+const (
+	// firingMarker and resolvedMarker tell firing and resolved alerts apart
+	// in notifications of groups that have both.
+	firingMarker   = "\U0001F534" // Red circle
+	resolvedMarker = "\u2705"     // Check mark button
+)
+
+// isMixedGroup reports whether as has both firing and resolved alerts.
+func isMixedGroup(as []*types.Alert) bool {
+	firing, resolved := countAlertStates(as)
+	return firing > 0 && resolved > 0
+}
+
+func countAlertStates(as []*types.Alert) (firing, resolved int) {
+	for _, a := range as {
+		if a.Resolved() {
+			resolved++
+		} else {
+			firing++
+		}
+	}
+	return firing, resolved
+}
+
+// alertStateSummary returns a line that counts the firing and resolved
+// alerts of as, such as "🔴 2 firing, ✅ 1 resolved".
+func alertStateSummary(as []*types.Alert) string {
+	firing, resolved := countAlertStates(as)
+	return fmt.Sprintf("%s %d firing, %s %d resolved", firingMarker, firing, resolvedMarker, resolved)
+}
+
+// alertStateLines returns a line for each alert of as that identifies it by
+// its labels, preceded by the marker of its state.
+func alertStateLines(as []*types.Alert) string {
+	var lines strings.Builder
+	for _, a := range as {
+		marker := firingMarker
+		if a.Resolved() {
+			marker = resolvedMarker
+		}
+		fmt.Fprintf(&lines, "%s %s\n", marker, a.Labels)
+	}
+	return lines.String()
+}
@@ -0,0 +1,79 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestNotifiersMixedAlerts(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1", "instance": "db-1"},
+			},
+		}, {
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "alert1", "instance": "db-2"},
+				StartsAt: time.Now().Add(-time.Hour),
+				EndsAt:   time.Now().Add(-time.Minute),
+			},
+		}, {
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1", "instance": "db-3"},
+			},
+		},
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+		t.Run(name, func(t *testing.T) {
+			var body string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+			ok, err := n.Notify(notifyContext(), alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			text := values.Get("text") + values.Get("message")
+
+			require.Contains(t, text, "\n\U0001F534 2 firing, ✅ 1 resolved\n")
+			require.Contains(t, text, "\U0001F534 {alertname=\"alert1\", instance=\"db-1\"}\n"+
+				"✅ {alertname=\"alert1\", instance=\"db-2\"}\n"+
+				"\U0001F534 {alertname=\"alert1\", instance=\"db-3\"}\n")
+		})
+	}
+
+	t.Run("Firing takes precedence for the emoji", func(t *testing.T) {
+		n := notifiersWithHTTPOptions(t, tmpl, nil)["threema"].(*ThreemaNotifier)
+		message, err := n.renderMessage(notifyContext(), alerts, threemaPage{alerts: alerts})
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(message, "\u26A0\uFE0F [FIRING:2] "), message)
+	})
+
+	t.Run("Groups with a single state are not marked", func(t *testing.T) {
+		n := notifiersWithHTTPOptions(t, tmpl, nil)["threema"].(*ThreemaNotifier)
+		message, err := n.renderMessage(notifyContext(), alerts[:1], threemaPage{alerts: alerts[:1]})
+		require.NoError(t, err)
+		require.NotContains(t, message, "firing,")
+		require.NotContains(t, message, "\U0001F534")
+	})
+}
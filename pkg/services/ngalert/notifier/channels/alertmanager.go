@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
@@ -13,6 +14,7 @@ import (
 	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 )
 
 // NewAlertmanagerNotifier returns a new Alertmanager notifier.
@@ -33,9 +35,9 @@ func NewAlertmanagerNotifier(model *NotificationChannelConfig, t *template.Templ
 			continue
 		}
 
-		uS = strings.TrimSuffix(uS, "/") + "/api/v1/alerts"
+		uS = strings.TrimSuffix(uS, "/") + "/api/v2/alerts"
 		u, err := url.Parse(uS)
-		if err != nil {
+		if err != nil || u.Scheme == "" || u.Host == "" {
 			return nil, alerting.ValidationError{Reason: "Invalid url property in settings"}
 		}
 
@@ -52,6 +54,7 @@ func NewAlertmanagerNotifier(model *NotificationChannelConfig, t *template.Templ
 			Settings:              model.Settings,
 		}),
 		urls:              urls,
+		failover:          model.Settings.Get("failover").MustBool(false),
 		basicAuthUser:     basicAuthUser,
 		basicAuthPassword: basicAuthPassword,
 		logger:            log.New("alerting.notifier.prometheus-alertmanager"),
@@ -66,16 +69,21 @@ type AlertmanagerNotifier struct {
 	basicAuthUser     string
 	basicAuthPassword string
 	logger            log.Logger
+	// failover sends the alerts only to the first of urls that accepts them.
+	// Otherwise they are sent to all of them, as the replicas of a highly
+	// available Alertmanager don't share alerts with each other.
+	failover bool
 }
 
-// Notify sends alert notifications to Alertmanager.
+// Notify sends the alerts to every Alertmanager, or to the first one that
+// accepts them with failover. It fails if no Alertmanager accepts them.
 func (n *AlertmanagerNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	n.logger.Debug("Sending Alertmanager alert", "alertmanager", n.Name)
 	if len(as) == 0 {
 		return true, nil
 	}
 
-	body, err := json.Marshal(as)
+	body, err := json.Marshal(alertmanagerAlerts(as))
 	if err != nil {
 		return false, err
 	}
@@ -88,7 +96,14 @@ func (n *AlertmanagerNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 			body:     body,
 		}, n.logger); err != nil {
 			n.logger.Warn("Failed to send to Alertmanager", "error", err, "alertmanager", n.Name, "url", u.String())
+			if ctx.Err() != nil {
+				return false, err
+			}
 			errCnt++
+			continue
+		}
+		if n.failover {
+			return true, nil
 		}
 	}
 
@@ -101,6 +116,50 @@ func (n *AlertmanagerNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 	return true, nil
 }
 
+// alertmanagerAlert is an alert in the format of the /api/v2/alerts endpoint
+// of the Alertmanager.
+type alertmanagerAlert struct {
+	Labels       model.LabelSet `json:"labels"`
+	Annotations  model.LabelSet `json:"annotations,omitempty"`
+	StartsAt     *time.Time     `json:"startsAt,omitempty"`
+	EndsAt       *time.Time     `json:"endsAt,omitempty"`
+	GeneratorURL string         `json:"generatorURL,omitempty"`
+}
+
+// alertmanagerAlerts converts as to the format of the Alertmanager. Resolved
+// alerts end in the past, whereas firing alerts end in the future or have no
+// end, so the Alertmanager keeps their status.
+func alertmanagerAlerts(as []*types.Alert) []alertmanagerAlert {
+	alerts := make([]alertmanagerAlert, 0, len(as))
+	for _, a := range as {
+		alert := alertmanagerAlert{
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			GeneratorURL: a.GeneratorURL,
+		}
+		if !a.StartsAt.IsZero() {
+			startsAt := a.StartsAt
+			alert.StartsAt = &startsAt
+		}
+		if !a.EndsAt.IsZero() {
+			endsAt := a.EndsAt
+			alert.EndsAt = &endsAt
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
 func (n *AlertmanagerNotifier) SendResolved() bool {
 	return !n.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (n *AlertmanagerNotifier) Type() string {
+	return "alertmanager"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (n *AlertmanagerNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -2,9 +2,11 @@ package channels
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -27,6 +29,7 @@ func TestAlertmanagerNotifier(t *testing.T) {
 		name         string
 		settings     string
 		alerts       []*types.Alert
+		expBody      string
 		expInitError error
 		expMsgError  error
 	}{
@@ -41,6 +44,29 @@ func TestAlertmanagerNotifier(t *testing.T) {
 					},
 				},
 			},
+			expBody: `[{"labels": {"__alert_rule_uid__": "rule uid", "alertname": "alert1", "lbl1": "val1"}, "annotations": {"ann1": "annv1"}}]`,
+		}, {
+			name:     "Firing and resolved alerts",
+			settings: `{"url": "https://alertmanager.com"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:       model.LabelSet{"alertname": "alert1"},
+						StartsAt:     time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+						EndsAt:       time.Date(2021, 6, 1, 10, 5, 0, 0, time.UTC),
+						GeneratorURL: "http://localhost/alerting/1/edit",
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert2"},
+						StartsAt: time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+			expBody: `[
+				{"labels": {"alertname": "alert1"}, "startsAt": "2021-06-01T10:00:00Z", "endsAt": "2021-06-01T10:05:00Z", "generatorURL": "http://localhost/alerting/1/edit"},
+				{"labels": {"alertname": "alert2"}, "startsAt": "2021-06-01T09:00:00Z"}
+			]`,
 		}, {
 			name:         "Error in initing: missing URL",
 			settings:     `{}`,
@@ -51,6 +77,10 @@ func TestAlertmanagerNotifier(t *testing.T) {
 				"url": "://alertmanager.com"
 			}`,
 			expInitError: alerting.ValidationError{Reason: "Invalid url property in settings"},
+		}, {
+			name:         "Error in initing: relative URL",
+			settings:     `{"url": "https://alertmanager.com, alertmanager.com"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid url property in settings"},
 		},
 	}
 	for _, c := range cases {
@@ -73,12 +103,14 @@ func TestAlertmanagerNotifier(t *testing.T) {
 			require.NoError(t, err)
 
 			var body []byte
+			var urls []string
 			origSendHTTPRequest := sendHTTPRequest
 			t.Cleanup(func() {
 				sendHTTPRequest = origSendHTTPRequest
 			})
 			sendHTTPRequest = func(ctx context.Context, url *url.URL, cfg httpCfg, logger log.Logger) ([]byte, error) {
 				body = cfg.body
+				urls = append(urls, url.String())
 				return nil, nil
 			}
 
@@ -94,10 +126,79 @@ func TestAlertmanagerNotifier(t *testing.T) {
 			require.NoError(t, err)
 			require.True(t, ok)
 
-			expBody, err := json.Marshal(c.alerts)
-			require.NoError(t, err)
+			require.JSONEq(t, c.expBody, string(body))
+			require.Equal(t, []string{"https://alertmanager.com/api/v2/alerts"}, urls)
+		})
+	}
+}
+
+func TestAlertmanagerNotifierMultipleURLs(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	newNotifier := func(t *testing.T, failover bool) *AlertmanagerNotifier {
+		t.Helper()
+		sn, err := NewAlertmanagerNotifier(&NotificationChannelConfig{
+			Name: "Alertmanager",
+			Type: "alertmanager",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"url":      "http://am1:9093, http://am2:9093/, http://am3:9093",
+				"failover": failover,
+			}),
+		}, tmpl)
+		require.NoError(t, err)
+		return sn
+	}
+
+	var urls []string
+	failing := map[string]bool{}
+	origSendHTTPRequest := sendHTTPRequest
+	t.Cleanup(func() {
+		sendHTTPRequest = origSendHTTPRequest
+	})
+	sendHTTPRequest = func(ctx context.Context, url *url.URL, cfg httpCfg, logger log.Logger) ([]byte, error) {
+		urls = append(urls, url.String())
+		if failing[url.Host] {
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	}
+	allURLs := []string{"http://am1:9093/api/v2/alerts", "http://am2:9093/api/v2/alerts", "http://am3:9093/api/v2/alerts"}
+
+	t.Run("Sends to every Alertmanager by default", func(t *testing.T) {
+		urls = nil
+		failing = map[string]bool{"am2:9093": true}
+		ok, err := newNotifier(t, false).Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, allURLs, urls)
+	})
+
+	t.Run("Stops at the first Alertmanager that accepts the alerts with failover", func(t *testing.T) {
+		urls = nil
+		failing = map[string]bool{}
+		ok, err := newNotifier(t, true).Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"http://am1:9093/api/v2/alerts"}, urls)
+	})
+
+	t.Run("Fails over to the next Alertmanager", func(t *testing.T) {
+		urls = nil
+		failing = map[string]bool{"am1:9093": true, "am2:9093": true}
+		ok, err := newNotifier(t, true).Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, allURLs, urls)
+	})
 
-			require.JSONEq(t, string(expBody), string(body))
+	for _, failover := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Fails if no Alertmanager accepts the alerts, failover %t", failover), func(t *testing.T) {
+			urls = nil
+			failing = map[string]bool{"am1:9093": true, "am2:9093": true, "am3:9093": true}
+			ok, err := newNotifier(t, failover).Notify(notifyContext(), firingAlert())
+			require.EqualError(t, err, "failed to send alert to Alertmanager")
+			require.False(t, ok)
+			require.Equal(t, allURLs, urls)
 		})
 	}
 }
@@ -0,0 +1,164 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+	"github.com/grafana/grafana/pkg/services/ngalert/logging"
+)
+
+// alertmanagerWebhookVersion is the version of the webhook payload of the
+// Alertmanager that is sent.
+const alertmanagerWebhookVersion = "4"
+
+// AlertmanagerWebhookNotifier is responsible for sending alert notifications
+// in the format of the webhook receiver of the Prometheus Alertmanager, so
+// that its consumers work with Grafana unchanged.
+type AlertmanagerWebhookNotifier struct {
+	old_notifiers.NotifierBase
+	URL         string
+	User        string
+	Password    string
+	MaxAlerts   int
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewAlertmanagerWebhookNotifier is the constructor for the Alertmanager
+// webhook notifier.
+func NewAlertmanagerWebhookNotifier(model *NotificationChannelConfig, t *template.Template) (*AlertmanagerWebhookNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	rawURL := model.Settings.Get("url").MustString()
+	if rawURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+	u, err := validateURL("webhook URL", rawURL, urlValidationOptions{requireHost: true})
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := model.ResolvedSecret("password")
+	if err != nil {
+		return nil, err
+	}
+
+	maxAlerts, ok := intSetting(model.Settings, "max_alerts", 0)
+	if !ok || maxAlerts < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max alerts: Must not be negative"}
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.alertmanager-webhook")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &AlertmanagerWebhookNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:         u,
+		User:        model.Settings.Get("username").MustString(),
+		Password:    password,
+		MaxAlerts:   maxAlerts,
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+// Notify sends the alerts to the webhook in the format of the Alertmanager.
+func (an *AlertmanagerWebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	status := types.Alerts(as...).Status()
+	if status == model.AlertResolved && !an.SendResolved() {
+		return true, nil
+	}
+
+	logger := an.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Alertmanager webhook notification", "notification", an.Name)
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Like the Alertmanager, only the alerts that are sent are part of the
+	// template data, and the others are counted.
+	as, numTruncated := truncateAlerts(an.MaxAlerts, as)
+	data := notify.GetTemplateData(ctx, an.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(an.log)))
+	body, err := json.Marshal(webhook.Message{
+		Data:            data,
+		Version:         alertmanagerWebhookVersion,
+		GroupKey:        groupKey.String(),
+		TruncatedAlerts: uint64(numTruncated),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:         an.URL,
+		User:        an.User,
+		Password:    an.Password,
+		HttpMethod:  "POST",
+		ContentType: "application/json",
+		Body:        string(body),
+	}
+	if err := an.httpOptions.apply(ctx, cmd, an.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, an.retry)
+	an.metrics.observe("alertmanager-webhook", status, start, err)
+	if err != nil {
+		logger.Error("Failed to send Alertmanager webhook notification", "error", err, "webhook", an.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (an *AlertmanagerWebhookNotifier) SendResolved() bool {
+	return !an.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (an *AlertmanagerWebhookNotifier) Type() string {
+	return "alertmanager-webhook"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (an *AlertmanagerWebhookNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
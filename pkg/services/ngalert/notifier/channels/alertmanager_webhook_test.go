@@ -0,0 +1,174 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestAlertmanagerWebhookNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	startsAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "instance": "db-1"},
+			Annotations:  model.LabelSet{"summary": "Disk is full"},
+			StartsAt:     startsAt,
+			GeneratorURL: "http://localhost/alerting/grafana/abc/view",
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "instance": "db-2"},
+			Annotations: model.LabelSet{"summary": "Disk is full"},
+			StartsAt:    startsAt,
+			EndsAt:      startsAt.Add(time.Hour),
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       string
+		expUser      string
+		expPassword  string
+		expInitError error
+	}{
+		{
+			name:     "Firing and resolved alerts",
+			settings: `{"url": "http://localhost:9095/alerts", "username": "user", "password": "pass"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expMsg: fmt.Sprintf(`{
+				"version": "4",
+				"groupKey": "{}:{alertname=\"alert1\"}",
+				"truncatedAlerts": 0,
+				"status": "firing",
+				"receiver": "team-db",
+				"groupLabels": {"alertname": "alert1"},
+				"commonLabels": {"alertname": "alert1"},
+				"commonAnnotations": {"summary": "Disk is full"},
+				"externalURL": "http://localhost",
+				"alerts": [
+					{
+						"status": "firing",
+						"labels": {"alertname": "alert1", "instance": "db-1"},
+						"annotations": {"summary": "Disk is full"},
+						"startsAt": "2021-05-01T10:00:00Z",
+						"endsAt": "0001-01-01T00:00:00Z",
+						"generatorURL": "http://localhost/alerting/grafana/abc/view",
+						"fingerprint": %q
+					},
+					{
+						"status": "resolved",
+						"labels": {"alertname": "alert1", "instance": "db-2"},
+						"annotations": {"summary": "Disk is full"},
+						"startsAt": "2021-05-01T10:00:00Z",
+						"endsAt": "2021-05-01T11:00:00Z",
+						"generatorURL": "",
+						"fingerprint": %q
+					}
+				]
+			}`, firing.Fingerprint().String(), resolved.Fingerprint().String()),
+			expUser:     "user",
+			expPassword: "pass",
+		}, {
+			name:     "Truncated alerts",
+			settings: `{"url": "http://localhost:9095/alerts", "max_alerts": "1"}`,
+			alerts:   []*types.Alert{firing, resolved},
+			expMsg: fmt.Sprintf(`{
+				"version": "4",
+				"groupKey": "{}:{alertname=\"alert1\"}",
+				"truncatedAlerts": 1,
+				"status": "firing",
+				"receiver": "team-db",
+				"groupLabels": {"alertname": "alert1"},
+				"commonLabels": {"alertname": "alert1", "instance": "db-1"},
+				"commonAnnotations": {"summary": "Disk is full"},
+				"externalURL": "http://localhost",
+				"alerts": [
+					{
+						"status": "firing",
+						"labels": {"alertname": "alert1", "instance": "db-1"},
+						"annotations": {"summary": "Disk is full"},
+						"startsAt": "2021-05-01T10:00:00Z",
+						"endsAt": "0001-01-01T00:00:00Z",
+						"generatorURL": "http://localhost/alerting/grafana/abc/view",
+						"fingerprint": %q
+					}
+				]
+			}`, firing.Fingerprint().String()),
+		}, {
+			name:         "Missing URL",
+			settings:     `{}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find url property in settings"},
+		}, {
+			name:         "Invalid URL",
+			settings:     `{"url": "localhost:9095/alerts"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid webhook URL: Must be an http or https URL"},
+		}, {
+			name:         "Invalid max alerts",
+			settings:     `{"url": "http://localhost:9095/alerts", "max_alerts": -1}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid max alerts: Must not be negative"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "alertmanager_webhook_testing",
+				Type:     "alertmanager-webhook",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewAlertmanagerWebhookNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var cmd *models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				cmd = webhook
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), `{}:{alertname="alert1"}`)
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+			ctx = notify.WithReceiverName(ctx, "team-db")
+			ok, err := pn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.NotNil(t, cmd)
+			require.Equal(t, "http://localhost:9095/alerts", cmd.Url)
+			require.Equal(t, "POST", cmd.HttpMethod)
+			require.Equal(t, "application/json", cmd.ContentType)
+			require.Equal(t, c.expUser, cmd.User)
+			require.Equal(t, c.expPassword, cmd.Password)
+			require.JSONEq(t, c.expMsg, cmd.Body)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// defaultRecipientBackoffMax is the longest a recipient is skipped for if the
+// maximum backoff isn't configured.
+const defaultRecipientBackoffMax = time.Hour
+
+// errRecipientBackoff is returned for recipients that are skipped because
+// they have been failing. It is a soft error: the recipient isn't notified,
+// but it doesn't fail the notification as a whole.
+var errRecipientBackoff = errors.New("recipient is backing off")
+
+// recipientBackoff skips recipients across notifications after they failed,
+// for a time that doubles with every consecutive failure. Unlike the retries
+// of a single notification, it remembers failures between notifications. A
+// nil recipientBackoff never skips anything.
+type recipientBackoff struct {
+	initial time.Duration
+	max     time.Duration
+	clock   Clock
+
+	mtx        sync.Mutex
+	recipients map[string]backoffEntry
+}
+
+type backoffEntry struct {
+	failures int
+	until    time.Time
+}
+
+func newRecipientBackoff(initial, maxBackoff time.Duration, clk Clock) *recipientBackoff {
+	return &recipientBackoff{
+		initial:    initial,
+		max:        maxBackoff,
+		clock:      clk,
+		recipients: map[string]backoffEntry{},
+	}
+}
+
+// parseRecipientBackoff reads the recipient backoff settings of a
+// notification channel. It returns nil if the backoff is disabled, which is
+// the default.
+func parseRecipientBackoff(settings *simplejson.Json) (*recipientBackoff, error) {
+	s := settings.Get("recipient_backoff").MustString()
+	if s == "" {
+		return nil, nil
+	}
+	initial, err := time.ParseDuration(s)
+	if err != nil || initial <= 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid recipient backoff: Must be a positive duration such as 30s"}
+	}
+
+	maxBackoff := defaultRecipientBackoffMax
+	if s := settings.Get("recipient_backoff_max").MustString(); s != "" {
+		maxBackoff, err = time.ParseDuration(s)
+		if err != nil || maxBackoff < initial {
+			return nil, alerting.ValidationError{Reason: "Invalid maximum recipient backoff: Must be a duration of at least the recipient backoff"}
+		}
+	} else if maxBackoff < initial {
+		maxBackoff = initial
+	}
+
+	return newRecipientBackoff(initial, maxBackoff, realClock), nil
+}
+
+// wait returns an error wrapping errRecipientBackoff if recipient must be
+// skipped. Otherwise the caller must report the result of the send with
+// failed or succeeded.
+func (b *recipientBackoff) wait(recipient string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	e, ok := b.recipients[recipient]
+	if !ok {
+		return nil
+	}
+	if remaining := e.until.Sub(b.clock.Now()); remaining > 0 {
+		return fmt.Errorf("%w after %d consecutive failures, retrying in %s", errRecipientBackoff, e.failures, remaining.Round(time.Second))
+	}
+	return nil
+}
+
+// failed records a failed send to recipient and skips it until the backoff
+// of its consecutive failures elapsed.
+func (b *recipientBackoff) failed(recipient string) {
+	if b == nil {
+		return
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	e := b.recipients[recipient]
+	e.failures++
+	e.until = b.clock.Now().Add(b.backoff(e.failures))
+	b.recipients[recipient] = e
+}
+
+// succeeded records a successful send to recipient, which resets its backoff.
+func (b *recipientBackoff) succeeded(recipient string) {
+	if b == nil {
+		return
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	delete(b.recipients, recipient)
+}
+
+// backoff returns how long a recipient is skipped for after the given number
+// of consecutive failures.
+func (b *recipientBackoff) backoff(failures int) time.Duration {
+	d := b.initial
+	for i := 1; i < failures && d < b.max; i++ {
+		d *= 2
+	}
+	if d > b.max {
+		return b.max
+	}
+	return d
+}
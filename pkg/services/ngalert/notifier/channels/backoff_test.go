@@ -0,0 +1,182 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestParseRecipientBackoff(t *testing.T) {
+	cases := []struct {
+		name        string
+		settings    map[string]interface{}
+		expDisabled bool
+		expInitial  time.Duration
+		expMax      time.Duration
+		expErr      error
+	}{
+		{name: "disabled by default", settings: map[string]interface{}{}, expDisabled: true},
+		{name: "default maximum", settings: map[string]interface{}{"recipient_backoff": "30s"}, expInitial: 30 * time.Second, expMax: time.Hour},
+		{
+			name:       "custom maximum",
+			settings:   map[string]interface{}{"recipient_backoff": "30s", "recipient_backoff_max": "10m"},
+			expInitial: 30 * time.Second,
+			expMax:     10 * time.Minute,
+		}, {
+			name:       "default maximum below the backoff",
+			settings:   map[string]interface{}{"recipient_backoff": "2h"},
+			expInitial: 2 * time.Hour,
+			expMax:     2 * time.Hour,
+		}, {
+			name:     "invalid backoff",
+			settings: map[string]interface{}{"recipient_backoff": "30"},
+			expErr:   alerting.ValidationError{Reason: "Invalid recipient backoff: Must be a positive duration such as 30s"},
+		}, {
+			name:     "maximum below the backoff",
+			settings: map[string]interface{}{"recipient_backoff": "30s", "recipient_backoff_max": "10s"},
+			expErr:   alerting.ValidationError{Reason: "Invalid maximum recipient backoff: Must be a duration of at least the recipient backoff"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := parseRecipientBackoff(simplejson.NewFromAny(c.settings))
+			if c.expErr != nil {
+				require.Equal(t, c.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			if c.expDisabled {
+				require.Nil(t, b)
+				return
+			}
+			require.Equal(t, c.expInitial, b.initial)
+			require.Equal(t, c.expMax, b.max)
+		})
+	}
+}
+
+func TestRecipientBackoff(t *testing.T) {
+	mockClock := clock.NewMock()
+	b := newRecipientBackoff(time.Minute, 5*time.Minute, mockClock)
+
+	// The backoff doubles with every consecutive failure up to the maximum.
+	for _, exp := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		require.NoError(t, b.wait("a"))
+		b.failed("a")
+		mockClock.Add(exp - time.Second)
+		require.ErrorIs(t, b.wait("a"), errRecipientBackoff)
+		mockClock.Add(time.Second)
+	}
+
+	// Other recipients are not affected.
+	require.NoError(t, b.wait("b"))
+
+	// A success resets the backoff.
+	require.NoError(t, b.wait("a"))
+	b.succeeded("a")
+	b.failed("a")
+	mockClock.Add(time.Minute)
+	require.NoError(t, b.wait("a"))
+}
+
+func TestThreemaNotifierRecipientBackoff(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	tn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":        "*1234567",
+			"recipient_id":      "87654321,ABCDEFGH",
+			"api_secret":        "supersecret12345",
+			"recipient_backoff": "1m",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	mockClock := clock.NewMock()
+	tn.backoff.clock = mockClock
+
+	var mtx sync.Mutex
+	var sentTo []string
+	failing := map[string]bool{"ABCDEFGH": true}
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		mtx.Lock()
+		defer mtx.Unlock()
+		to := values.Get("to")
+		sentTo = append(sentTo, to)
+		if failing[to] {
+			return errors.New("recipient unreachable")
+		}
+		return nil
+	})
+
+	// Recipients are sent to concurrently, so they are returned sorted.
+	send := func() ([]string, NotifyResult, error) {
+		sentTo = nil
+		result, err := tn.NotifyDetailed(notifyContext(), firingAlert())
+		sort.Strings(sentTo)
+		return sentTo, result, err
+	}
+
+	// The failure of a recipient fails the notification.
+	sent, result, err := send()
+	require.Error(t, err)
+	require.Equal(t, []string{"87654321", "ABCDEFGH"}, sent)
+	require.Equal(t, 1, result.Delivered())
+
+	// Within its backoff the recipient is skipped with a soft error.
+	mockClock.Add(30 * time.Second)
+	sent, result, err = send()
+	require.NoError(t, err)
+	require.Equal(t, []string{"87654321"}, sent)
+	require.Len(t, result.Failed(), 1)
+	require.Equal(t, "ABCDEFGH", result.Failed()[0].Target)
+	require.ErrorIs(t, result.Failed()[0].Err, errRecipientBackoff)
+
+	// After the backoff it is tried again, and backs off twice as long if it
+	// still fails.
+	mockClock.Add(30 * time.Second)
+	sent, _, err = send()
+	require.Error(t, err)
+	require.Equal(t, []string{"87654321", "ABCDEFGH"}, sent)
+
+	mockClock.Add(time.Minute)
+	sent, _, err = send()
+	require.NoError(t, err)
+	require.Equal(t, []string{"87654321"}, sent)
+
+	// Once it recovers, its backoff is reset.
+	mockClock.Add(time.Minute)
+	failing["ABCDEFGH"] = false
+	sent, result, err = send()
+	require.NoError(t, err)
+	require.Equal(t, []string{"87654321", "ABCDEFGH"}, sent)
+	require.Equal(t, 2, result.Delivered())
+
+	failing["ABCDEFGH"] = true
+	_, _, err = send()
+	require.Error(t, err)
+	mockClock.Add(time.Minute)
+	sent, _, err = send()
+	require.Error(t, err)
+	require.Equal(t, []string{"87654321", "ABCDEFGH"}, sent)
+}
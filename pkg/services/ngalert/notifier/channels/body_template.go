@@ -0,0 +1,63 @@
+package channels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	tmpltext "text/template"
+
+	"github.com/prometheus/alertmanager/template"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// bodyTemplate renders the whole body of a request from a Go text template,
+// for receivers that expect a schema of their own. Besides the functions of
+// the notification templates it can use toJson to encode any value as JSON.
+type bodyTemplate struct {
+	tmpl        *tmpltext.Template
+	contentType string
+}
+
+// parseBodyTemplate reads the body_template and content_type settings of a
+// notification channel. It returns nil if no body template is configured.
+func parseBodyTemplate(settings *simplejson.Json) (*bodyTemplate, error) {
+	text := settings.Get("body_template").MustString()
+	if text == "" {
+		return nil, nil
+	}
+
+	tmpl, err := tmpltext.New("body_template").
+		Option("missingkey=zero").
+		Funcs(tmpltext.FuncMap(template.DefaultFuncs)).
+		Funcs(tmpltext.FuncMap(templateFuncs)).
+		Funcs(tmpltext.FuncMap{"toJson": toJSON}).
+		Parse(text)
+	if err != nil {
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid body template: %s", err)}
+	}
+
+	return &bodyTemplate{
+		tmpl:        tmpl,
+		contentType: settings.Get("content_type").MustString("application/json"),
+	}, nil
+}
+
+// render executes the body template with data.
+func (b *bodyTemplate) render(data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := b.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render body template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// toJSON encodes v as JSON.
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,190 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// defaultCircuitBreakerCooldown is how long a circuit stays open if the
+// cooldown isn't configured.
+const defaultCircuitBreakerCooldown = time.Minute
+
+// errCircuitOpen is returned for sends that are short-circuited because the
+// gateway or recipient has been failing.
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState int
+
+const (
+	// circuitClosed lets all sends through.
+	circuitClosed circuitState = iota
+	// circuitOpen fails all sends right away until the cooldown elapsed.
+	circuitOpen
+	// circuitHalfOpen lets a single probe through after the cooldown. The
+	// circuit closes if it succeeds and opens again if it fails.
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops sending to a gateway or recipient, identified by a
+// key, after a number of consecutive failures, so that doomed sends don't
+// pile up latency. A nil circuitBreaker never short-circuits anything.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     Clock
+
+	mtx      sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+	// probing is set while the probe of a half-open circuit is in flight.
+	probing bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, clk Clock) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     clk,
+		circuits:  map[string]*circuit{},
+	}
+}
+
+// parseCircuitBreaker reads the circuit breaker settings of a notification
+// channel. It returns nil if the circuit breaker is disabled, which is the
+// default.
+func parseCircuitBreaker(settings *simplejson.Json) (*circuitBreaker, error) {
+	threshold, ok := intSetting(settings, "circuit_breaker_threshold", 0)
+	if !ok || threshold < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid circuit breaker threshold: Must not be negative"}
+	}
+
+	cooldown := defaultCircuitBreakerCooldown
+	if s := settings.Get("circuit_breaker_cooldown").MustString(); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, alerting.ValidationError{Reason: "Invalid circuit breaker cooldown: Must be a positive duration such as 1m"}
+		}
+		cooldown = d
+	}
+
+	if threshold == 0 {
+		return nil, nil
+	}
+	return newCircuitBreaker(threshold, cooldown, realClock), nil
+}
+
+// allow returns an error wrapping errCircuitOpen if sends to key must fail
+// right away. Otherwise the caller must report the result of the send with
+// done, or call release if nothing was sent after all.
+func (cb *circuitBreaker) allow(key string) error {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+	c, ok := cb.circuits[key]
+	if !ok {
+		return nil
+	}
+
+	switch c.state {
+	case circuitOpen:
+		remaining := c.openedAt.Add(cb.cooldown).Sub(cb.clock.Now())
+		if remaining > 0 {
+			return fmt.Errorf("%w after %d consecutive failures, retrying in %s", errCircuitOpen, c.failures, remaining.Round(time.Second))
+		}
+		c.state = circuitHalfOpen
+		c.probing = true
+	case circuitHalfOpen:
+		if c.probing {
+			return fmt.Errorf("%w after %d consecutive failures, waiting for a probe", errCircuitOpen, c.failures)
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+// done records the result of a send to key that was allowed. It must only
+// be passed errors of the gateway, as other errors don't tell anything about
+// it. Sends that were cancelled are ignored for the same reason.
+func (cb *circuitBreaker) done(key string, err error) {
+	if cb == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		cb.release(key)
+		return
+	}
+
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+	if err == nil {
+		delete(cb.circuits, key)
+		return
+	}
+
+	c, ok := cb.circuits[key]
+	if !ok {
+		c = &circuit{}
+		cb.circuits[key] = c
+	}
+
+	c.failures++
+	if c.state == circuitHalfOpen || c.failures >= cb.threshold {
+		c.state = circuitOpen
+		c.openedAt = cb.clock.Now()
+	}
+	c.probing = false
+}
+
+// release gives up a send to key that was allowed without recording a
+// result, for example because the request couldn't be built. If the send was
+// a probe, the next send probes the gateway instead.
+func (cb *circuitBreaker) release(key string) {
+	if cb == nil {
+		return
+	}
+
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+	if c, ok := cb.circuits[key]; ok {
+		c.probing = false
+	}
+}
+
+// state returns the state of the circuit of key.
+func (cb *circuitBreaker) state(key string) circuitState {
+	if cb == nil {
+		return circuitClosed
+	}
+
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+	if c, ok := cb.circuits[key]; ok {
+		return c.state
+	}
+	return circuitClosed
+}
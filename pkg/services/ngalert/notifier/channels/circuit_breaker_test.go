@@ -0,0 +1,219 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestParseCircuitBreaker(t *testing.T) {
+	cases := []struct {
+		name        string
+		settings    map[string]interface{}
+		expDisabled bool
+		expCooldown time.Duration
+		expErr      error
+	}{
+		{name: "disabled by default", settings: map[string]interface{}{}, expDisabled: true},
+		{name: "default cooldown", settings: map[string]interface{}{"circuit_breaker_threshold": 5}, expCooldown: time.Minute},
+		{
+			name:        "custom cooldown",
+			settings:    map[string]interface{}{"circuit_breaker_threshold": 5, "circuit_breaker_cooldown": "30s"},
+			expCooldown: 30 * time.Second,
+		}, {
+			name:        "threshold from a text field",
+			settings:    map[string]interface{}{"circuit_breaker_threshold": "5"},
+			expCooldown: time.Minute,
+		}, {
+			name:     "negative threshold",
+			settings: map[string]interface{}{"circuit_breaker_threshold": -1},
+			expErr:   alerting.ValidationError{Reason: "Invalid circuit breaker threshold: Must not be negative"},
+		}, {
+			name:     "threshold that isn't a number",
+			settings: map[string]interface{}{"circuit_breaker_threshold": "five"},
+			expErr:   alerting.ValidationError{Reason: "Invalid circuit breaker threshold: Must not be negative"},
+		}, {
+			name:     "invalid cooldown",
+			settings: map[string]interface{}{"circuit_breaker_threshold": 5, "circuit_breaker_cooldown": "soon"},
+			expErr:   alerting.ValidationError{Reason: "Invalid circuit breaker cooldown: Must be a positive duration such as 1m"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cb, err := parseCircuitBreaker(simplejson.NewFromAny(c.settings))
+			if c.expErr != nil {
+				require.Equal(t, c.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			if c.expDisabled {
+				require.Nil(t, cb)
+				return
+			}
+			require.Equal(t, c.expCooldown, cb.cooldown)
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	mockClock := clock.NewMock()
+	cb := newCircuitBreaker(3, time.Minute, mockClock)
+	failure := errors.New("gateway unavailable")
+
+	send := func(key string, err error) {
+		t.Helper()
+		require.NoError(t, cb.allow(key))
+		cb.done(key, err)
+	}
+
+	// The circuit opens after three consecutive failures.
+	send("a", failure)
+	send("a", failure)
+	require.Equal(t, circuitClosed, cb.state("a"))
+	send("a", failure)
+	require.Equal(t, circuitOpen, cb.state("a"))
+	require.ErrorIs(t, cb.allow("a"), errCircuitOpen)
+
+	// Other keys are not affected.
+	send("b", failure)
+	require.Equal(t, circuitClosed, cb.state("b"))
+
+	// A single probe is allowed after the cooldown, and the circuit opens
+	// again if it fails.
+	mockClock.Add(59 * time.Second)
+	require.ErrorIs(t, cb.allow("a"), errCircuitOpen)
+	mockClock.Add(time.Second)
+	require.NoError(t, cb.allow("a"))
+	require.Equal(t, circuitHalfOpen, cb.state("a"))
+	require.ErrorIs(t, cb.allow("a"), errCircuitOpen)
+	cb.done("a", failure)
+	require.Equal(t, circuitOpen, cb.state("a"))
+	require.ErrorIs(t, cb.allow("a"), errCircuitOpen)
+
+	// A cancelled probe doesn't tell anything and lets the next one through.
+	mockClock.Add(time.Minute)
+	require.NoError(t, cb.allow("a"))
+	cb.done("a", context.Canceled)
+	require.Equal(t, circuitHalfOpen, cb.state("a"))
+
+	// So does a probe that was released without sending anything.
+	require.NoError(t, cb.allow("a"))
+	cb.release("a")
+	require.Equal(t, circuitHalfOpen, cb.state("a"))
+
+	// The circuit closes once a probe succeeds, and failures are counted
+	// from scratch again.
+	send("a", nil)
+	require.Equal(t, circuitClosed, cb.state("a"))
+	send("a", failure)
+	send("a", failure)
+	require.Equal(t, circuitClosed, cb.state("a"))
+
+	// A success resets the consecutive failures.
+	send("b", nil)
+	send("b", failure)
+	send("b", failure)
+	require.Equal(t, circuitClosed, cb.state("b"))
+}
+
+func TestLineNotifierCircuitBreaker(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewLineNotifier(&NotificationChannelConfig{
+		Name: "line_testing",
+		Type: "line",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"token":                     "sometoken",
+			"circuit_breaker_threshold": 2,
+			"circuit_breaker_cooldown":  "5m",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	mockClock := clock.NewMock()
+	pn.breaker.clock = mockClock
+
+	sent := 0
+	var sendErr error
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent++
+		return sendErr
+	})
+
+	sendErr = errors.New("gateway unavailable")
+	for i := 0; i < 2; i++ {
+		_, err := pn.Notify(notifyContext(), firingAlert())
+		require.EqualError(t, err, "gateway unavailable")
+	}
+	require.Equal(t, 2, sent)
+
+	// The gateway isn't called while the circuit is open.
+	ok, err := pn.Notify(notifyContext(), firingAlert())
+	require.False(t, ok)
+	require.ErrorIs(t, err, errCircuitOpen)
+	require.Equal(t, 2, sent)
+
+	// After the cooldown a notification probes the gateway again.
+	sendErr = nil
+	mockClock.Add(5 * time.Minute)
+	ok, err = pn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 3, sent)
+	require.Equal(t, circuitClosed, pn.breaker.state(LineNotifyURL))
+}
+
+func TestThreemaNotifierCircuitBreakerIgnoresRateLimit(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	tn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":                "*BREAK01",
+			"recipient_id":              "87654321",
+			"api_secret":                "supersecret12345",
+			"rate_limit":                1,
+			"circuit_breaker_threshold": 1,
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	sent := 0
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent++
+		return nil
+	})
+
+	ok, err := tn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The rate limit of the gateway fails the next message before anything
+	// is sent, which says nothing about whether the gateway is healthy.
+	ctx, cancel := context.WithTimeout(notifyContext(), 100*time.Millisecond)
+	defer cancel()
+	_, err = tn.Notify(ctx, firingAlert())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rate limit of gateway *BREAK01 exceeded")
+	require.Equal(t, 1, sent)
+	require.Equal(t, circuitClosed, tn.breaker.state("87654321"))
+}
@@ -0,0 +1,18 @@
+package channels
+
+import (
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// Clock tells the time for the time-dependent parts of notifiers, such as
+// deduplication windows and retry backoff. It is a subset of clock.Clock, so
+// notifiers use clock.New() and tests can control time with clock.NewMock().
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the clock notifiers use outside of tests.
+var realClock Clock = clock.New()
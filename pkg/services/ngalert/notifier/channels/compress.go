@@ -0,0 +1,72 @@
+package channels
+
+import (
+	"bytes"
+	"compress/gzip"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+const (
+	compressGzip = "gzip"
+
+	// defaultCompressThreshold is the size in bytes above which bodies are
+	// compressed if the threshold isn't configured. Smaller bodies hardly
+	// get any smaller.
+	defaultCompressThreshold = 1024
+)
+
+// compressOptions control the compression of request bodies. The zero value
+// doesn't compress anything.
+type compressOptions struct {
+	gzip bool
+	// threshold is the size in bytes bodies must exceed to be compressed.
+	threshold int
+}
+
+// parseCompressOptions reads the compress and compress_threshold settings of
+// a notification channel.
+func parseCompressOptions(settings *simplejson.Json) (compressOptions, error) {
+	var opts compressOptions
+	switch settings.Get("compress").MustString() {
+	case "":
+		return opts, nil
+	case compressGzip:
+		opts.gzip = true
+	default:
+		return opts, alerting.ValidationError{Reason: "Invalid compression: Must be gzip"}
+	}
+
+	threshold, ok := intSetting(settings, "compress_threshold", defaultCompressThreshold)
+	if !ok || threshold < 0 {
+		return opts, alerting.ValidationError{Reason: "Invalid compression threshold: Must not be negative"}
+	}
+	opts.threshold = threshold
+	return opts, nil
+}
+
+// apply compresses the body of cmd if it exceeds the threshold, and sets
+// the Content-Encoding header accordingly.
+func (o compressOptions) apply(cmd *models.SendWebhookSync) error {
+	if !o.gzip || len(cmd.Body) <= o.threshold {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(cmd.Body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	cmd.Body = buf.String()
+	if cmd.HttpHeader == nil {
+		cmd.HttpHeader = map[string]string{}
+	}
+	cmd.HttpHeader["Content-Encoding"] = compressGzip
+	return nil
+}
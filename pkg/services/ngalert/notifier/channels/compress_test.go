@@ -0,0 +1,123 @@
+package channels
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestCompressOptions(t *testing.T) {
+	gunzip := func(t *testing.T, s string) string {
+		t.Helper()
+		r, err := gzip.NewReader(bytes.NewReader([]byte(s)))
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return string(b)
+	}
+	large := strings.Repeat(`{"alert":"firing"}`, 100)
+
+	cases := []struct {
+		name        string
+		settings    map[string]interface{}
+		body        string
+		expCompress bool
+		expError    error
+	}{
+		{
+			name:     "Disabled by default",
+			settings: map[string]interface{}{},
+			body:     large,
+		}, {
+			name:        "Bodies over the threshold are compressed",
+			settings:    map[string]interface{}{"compress": "gzip"},
+			body:        large,
+			expCompress: true,
+		}, {
+			name:     "Bodies under the threshold are left alone",
+			settings: map[string]interface{}{"compress": "gzip"},
+			body:     `{"alert":"firing"}`,
+		}, {
+			name:        "Configured threshold",
+			settings:    map[string]interface{}{"compress": "gzip", "compress_threshold": 10},
+			body:        `{"alert":"firing"}`,
+			expCompress: true,
+		}, {
+			name:        "Threshold from a text field",
+			settings:    map[string]interface{}{"compress": "gzip", "compress_threshold": "10"},
+			body:        `{"alert":"firing"}`,
+			expCompress: true,
+		}, {
+			name:     "Invalid compression",
+			settings: map[string]interface{}{"compress": "zstd"},
+			expError: alerting.ValidationError{Reason: "Invalid compression: Must be gzip"},
+		}, {
+			name:     "Invalid threshold",
+			settings: map[string]interface{}{"compress": "gzip", "compress_threshold": -1},
+			expError: alerting.ValidationError{Reason: "Invalid compression threshold: Must not be negative"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			opts, err := parseCompressOptions(simplejson.NewFromAny(c.settings))
+			if c.expError != nil {
+				require.Equal(t, c.expError, err)
+				return
+			}
+			require.NoError(t, err)
+
+			cmd := &models.SendWebhookSync{Body: c.body}
+			require.NoError(t, opts.apply(cmd))
+			if c.expCompress {
+				require.Equal(t, "gzip", cmd.HttpHeader["Content-Encoding"])
+				require.Equal(t, c.body, gunzip(t, cmd.Body))
+			} else {
+				require.Equal(t, c.body, cmd.Body)
+				require.NotContains(t, cmd.HttpHeader, "Content-Encoding")
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierCompress(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	wn, err := NewWebHookNotifier(&NotificationChannelConfig{
+		Name:     "webhook_testing",
+		Type:     "webhook",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost/test", "compress": "gzip", "compress_threshold": 100}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	var payload *models.SendWebhookSync
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		payload = webhook
+		return nil
+	})
+
+	_, err = wn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.Equal(t, "gzip", payload.HttpHeader["Content-Encoding"])
+
+	r, err := gzip.NewReader(strings.NewReader(payload.Body))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"status":"firing"`)
+}
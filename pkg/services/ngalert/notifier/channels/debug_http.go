@@ -0,0 +1,107 @@
+package channels
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// redactedSecret replaces secrets in debug logs.
+const redactedSecret = "[REDACTED]"
+
+// sensitiveHeaderParts are parts of the names of headers that carry
+// credentials, such as Authorization or X-Api-Key. Their values are redacted
+// as a whole, since they don't have to be among the secrets of the channel,
+// e.g. if they are set in the HTTP headers setting.
+var sensitiveHeaderParts = []string{"auth", "cookie", "key", "password", "secret", "signature", "token"}
+
+// httpDebugLogger logs the requests a notifier sends and the responses it
+// receives at debug level, to troubleshoot failing notifications. Secrets
+// are redacted from everything it logs. A nil httpDebugLogger logs nothing.
+type httpDebugLogger struct {
+	log     log.Logger
+	secrets []string
+}
+
+// parseDebugHTTP reads the debug_http setting of a notification channel. It
+// returns nil unless it is enabled. secrets are the secrets of the channel,
+// such as API tokens, which must never be logged.
+func parseDebugHTTP(settings *simplejson.Json, logger log.Logger, secrets ...string) *httpDebugLogger {
+	if !settings.Get("debug_http").MustBool(false) {
+		return nil
+	}
+
+	d := &httpDebugLogger{log: logger}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		// Secrets are usually sent in headers, JSON or form bodies, the
+		// latter of which encode them.
+		d.secrets = append(d.secrets, secret)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			d.secrets = append(d.secrets, escaped)
+		}
+	}
+	// Replace longer secrets first, so that a secret containing another is
+	// redacted as a whole.
+	sort.Slice(d.secrets, func(i, j int) bool {
+		return len(d.secrets[i]) > len(d.secrets[j])
+	})
+	return d
+}
+
+// redact replaces all secrets in s.
+func (d *httpDebugLogger) redact(s string) string {
+	for _, secret := range d.secrets {
+		s = strings.ReplaceAll(s, secret, redactedSecret)
+	}
+	return s
+}
+
+// isSensitiveHeader reports whether the header name carries credentials.
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range sensitiveHeaderParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// instrument logs cmd and makes it log the responses it receives.
+func (d *httpDebugLogger) instrument(cmd *models.SendWebhookSync) {
+	if d == nil {
+		return
+	}
+
+	// The password of basic authentication isn't a setting of every
+	// channel, so it is redacted for each command.
+	redact := d.redact
+	if password := cmd.Password; password != "" {
+		redact = func(s string) string {
+			return d.redact(strings.ReplaceAll(s, password, redactedSecret))
+		}
+	}
+
+	headers := make([]string, 0, len(cmd.HttpHeader))
+	for name, value := range cmd.HttpHeader {
+		if isSensitiveHeader(name) {
+			value = redactedSecret
+		}
+		headers = append(headers, name+": "+redact(value))
+	}
+	sort.Strings(headers)
+	d.log.Debug("Sending HTTP request", "url", redact(cmd.Url), "method", cmd.HttpMethod,
+		"headers", strings.Join(headers, "; "), "body", redact(cmd.Body))
+
+	target := redact(cmd.Url)
+	cmd.OnResponse = func(status string, body []byte) {
+		d.log.Debug("Received HTTP response", "url", target, "status", status, "body", redact(string(body)))
+	}
+}
@@ -0,0 +1,133 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestHTTPDebugLoggerRedact(t *testing.T) {
+	d := parseDebugHTTP(simplejson.NewFromAny(map[string]interface{}{"debug_http": true}), log.New("test"), "s3cr+t/key", "", "key")
+
+	require.Equal(t, "secret=[REDACTED]&to=87654321", d.redact("secret=s3cr%2Bt%2Fkey&to=87654321"))
+	require.Equal(t, `{"token":"[REDACTED]","other":"[REDACTED]"}`, d.redact(`{"token":"s3cr+t/key","other":"key"}`))
+	require.Equal(t, "nothing to hide", d.redact("nothing to hide"))
+
+	require.Nil(t, parseDebugHTTP(simplejson.New(), log.New("test"), "s3cr+t/key"))
+}
+
+func TestHTTPDebugLoggerInstrument(t *testing.T) {
+	d := parseDebugHTTP(simplejson.NewFromAny(map[string]interface{}{"debug_http": true}), log.New("test"), "channelsecret")
+	var fields map[string]string
+	d.log.SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+		fields = map[string]string{}
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			fields[fmt.Sprint(r.Ctx[i])] = fmt.Sprint(r.Ctx[i+1])
+		}
+		return nil
+	}))
+
+	d.instrument(&models.SendWebhookSync{
+		Url:        "https://example.org/hook?password=basicpass",
+		HttpMethod: "POST",
+		User:       "user",
+		Password:   "basicpass",
+		HttpHeader: map[string]string{
+			"Authorization":       "Bearer templated-token",
+			"X-Api-Key":           "templated-key",
+			"Proxy-Authorization": "Basic dXNlcjpwYXNz",
+			"X-Team":              "db",
+			"X-Echo":              "channelsecret",
+		},
+		Body: `{"password":"basicpass"}`,
+	})
+
+	require.Equal(t, "https://example.org/hook?password=[REDACTED]", fields["url"])
+	require.Equal(t, "Authorization: [REDACTED]; Proxy-Authorization: [REDACTED]; X-Api-Key: [REDACTED]; X-Echo: [REDACTED]; X-Team: db", fields["headers"])
+	require.Equal(t, `{"password":"[REDACTED]"}`, fields["body"])
+}
+
+func TestNotifiersDebugHTTP(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	secrets := map[string]string{"threema": "supersecret12345", "line": "sometoken"}
+
+	t.Run("Requests and responses are logged without secrets", func(t *testing.T) {
+		notifiers := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"debug_http": true})
+		loggers := map[string]log.Logger{
+			"threema": notifiers["threema"].(*ThreemaNotifier).log,
+			"line":    notifiers["line"].(*LineNotifier).log,
+		}
+
+		for name, n := range notifiers {
+			t.Run(name, func(t *testing.T) {
+				records := map[string]map[string]string{}
+				loggers[name].SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+					fields := map[string]string{}
+					for i := 0; i+1 < len(r.Ctx); i += 2 {
+						fields[fmt.Sprint(r.Ctx[i])] = fmt.Sprint(r.Ctx[i+1])
+					}
+					records[r.Msg] = fields
+					return nil
+				}))
+
+				bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+					require.NotNil(t, webhook.OnResponse)
+					webhook.OnResponse("200 OK", []byte("accepted with "+secrets[name]))
+					return nil
+				})
+				_, err := n.Notify(notifyContext(), firingAlert())
+				require.NoError(t, err)
+
+				request, ok := records["Sending HTTP request"]
+				require.True(t, ok, "missing request log line")
+				require.Contains(t, request["body"], "alert1")
+				require.Contains(t, request["url"], "https://")
+				require.Equal(t, "POST", request["method"])
+				if name == "line" {
+					require.Contains(t, request["headers"], "Authorization: [REDACTED]")
+				}
+
+				response, ok := records["Received HTTP response"]
+				require.True(t, ok, "missing response log line")
+				require.Equal(t, "200 OK", response["status"])
+				require.Equal(t, "accepted with [REDACTED]", response["body"])
+
+				for _, fields := range records {
+					for _, v := range fields {
+						require.NotContains(t, v, secrets[name])
+					}
+				}
+			})
+		}
+
+		threemaRequest := url.Values{}
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			threemaRequest, err = url.ParseQuery(webhook.Body)
+			return err
+		})
+		_, err := notifiers["threema"].Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.Equal(t, secrets["threema"], threemaRequest.Get("secret"), "the secret must only be redacted in the logs")
+	})
+
+	t.Run("Nothing is logged by default", func(t *testing.T) {
+		for _, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+			cmd := sendAndCapture(t, n)
+			require.Nil(t, cmd.OnResponse)
+		}
+	})
+}
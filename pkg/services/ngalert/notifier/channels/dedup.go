@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// deduplicator suppresses notifications that are identical to the last one
+// sent to the same recipient within an interval. Notifications are told apart
+// by a key that identifies their content. A nil deduplicator never
+// suppresses anything.
+type deduplicator struct {
+	interval time.Duration
+	clock    Clock
+
+	mtx  sync.Mutex
+	last map[string]dedupEntry
+}
+
+type dedupEntry struct {
+	hash   [sha256.Size]byte
+	sentAt time.Time
+}
+
+func newDeduplicator(interval time.Duration, clk Clock) *deduplicator {
+	return &deduplicator{
+		interval: interval,
+		clock:    clk,
+		last:     map[string]dedupEntry{},
+	}
+}
+
+func dedupHash(recipient, key string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(recipient + "\x00" + key))
+}
+
+// isDuplicate reports whether the message identified by key was the last
+// message sent to recipient within the interval.
+func (d *deduplicator) isDuplicate(recipient, key string) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	last, ok := d.last[recipient]
+	return ok && last.hash == dedupHash(recipient, key) && d.clock.Now().Sub(last.sentAt) < d.interval
+}
+
+// sent records that the message identified by key was sent to recipient.
+func (d *deduplicator) sent(recipient, key string) {
+	if d == nil {
+		return
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.last[recipient] = dedupEntry{hash: dedupHash(recipient, key), sentAt: d.clock.Now()}
+}
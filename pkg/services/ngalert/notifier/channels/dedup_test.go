@@ -0,0 +1,164 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestThreemaNotifierDedup(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, settings map[string]interface{}) (*ThreemaNotifier, error) {
+		s := map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321,ABCDEFGH",
+			"api_secret":   "supersecret12345",
+		}
+		for k, v := range settings {
+			s[k] = v
+		}
+		return NewThreemaNotifier(&NotificationChannelConfig{
+			Name:     "threema_testing",
+			Type:     "threema",
+			Settings: simplejson.NewFromAny(s),
+		}, tmpl)
+	}
+
+	var mtx sync.Mutex
+	var sentTo []string
+	var sendErr error
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		mtx.Lock()
+		defer mtx.Unlock()
+		sentTo = append(sentTo, values.Get("to"))
+		return sendErr
+	})
+
+	// Recipients are sent to concurrently, so they are returned sorted.
+	send := func(t *testing.T, tn *ThreemaNotifier, alert *types.Alert) []string {
+		sentTo = nil
+		ok, err := tn.Notify(notifyContext(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		sort.Strings(sentTo)
+		return sentTo
+	}
+
+	t.Run("Suppresses identical messages within the interval", func(t *testing.T) {
+		tn, err := newNotifier(t, map[string]interface{}{"dedup_interval": "5m"})
+		require.NoError(t, err)
+		mock := clock.NewMock()
+		tn.dedup.clock = mock
+
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+
+		mock.Add(4 * time.Minute)
+		require.Empty(t, send(t, tn, firingAlert()))
+
+		// A different message is sent right away.
+		other := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}}
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, other))
+
+		// The first message is no longer the last one sent.
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+	})
+
+	t.Run("Ignores how long the alerts have been firing", func(t *testing.T) {
+		tn, err := newNotifier(t, map[string]interface{}{"dedup_interval": "5m", "locale": "de"})
+		require.NoError(t, err)
+		mock := clock.NewMock()
+		mock.Set(time.Now())
+		tn.dedup.clock = mock
+		origClock := templateClock
+		templateClock = mock
+		t.Cleanup(func() {
+			templateClock = origClock
+		})
+
+		alert := &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: mock.Now().Add(-time.Hour),
+		}}
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, alert))
+		mock.Add(4 * time.Minute)
+		require.Empty(t, send(t, tn, alert))
+	})
+
+	t.Run("Suppresses identical pages", func(t *testing.T) {
+		tn, err := newNotifier(t, map[string]interface{}{"dedup_interval": "5m", "max_alerts_per_message": 1})
+		require.NoError(t, err)
+		mock := clock.NewMock()
+		tn.dedup.clock = mock
+
+		sendPages := func(alerts ...*types.Alert) []string {
+			sentTo = nil
+			ok, err := tn.Notify(notifyContext(), alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			sort.Strings(sentTo)
+			return sentTo
+		}
+		alert2 := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "instance": "2"}}}
+		require.Equal(t, []string{"87654321", "87654321", "ABCDEFGH", "ABCDEFGH"}, sendPages(firingAlert(), alert2))
+
+		mock.Add(time.Minute)
+		require.Empty(t, sendPages(firingAlert(), alert2))
+	})
+
+	t.Run("Sends again after the interval", func(t *testing.T) {
+		tn, err := newNotifier(t, map[string]interface{}{"dedup_interval": "5m"})
+		require.NoError(t, err)
+		mock := clock.NewMock()
+		tn.dedup.clock = mock
+
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+		mock.Add(5 * time.Minute)
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+	})
+
+	t.Run("Failed sends are not deduplicated", func(t *testing.T) {
+		tn, err := newNotifier(t, map[string]interface{}{"dedup_interval": "5m"})
+		require.NoError(t, err)
+
+		sendErr = errors.New("gateway unavailable")
+		_, err = tn.Notify(notifyContext(), firingAlert())
+		require.Error(t, err)
+		sendErr = nil
+
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		tn, err := newNotifier(t, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+		require.Equal(t, []string{"87654321", "ABCDEFGH"}, send(t, tn, firingAlert()))
+	})
+
+	t.Run("Invalid interval", func(t *testing.T) {
+		_, err := newNotifier(t, map[string]interface{}{"dedup_interval": "5"})
+		require.Equal(t, alerting.ValidationError{Reason: "Invalid Threema dedup interval: Must be a positive duration such as 5m"}.Error(), err.Error())
+	})
+}
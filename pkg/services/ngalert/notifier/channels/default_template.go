@@ -33,7 +33,7 @@ const DefaultTemplateString = `
 {{ end }}
 `
 
-func templateForTests(t *testing.T) *template.Template {
+func templateForTests(t *testing.T, opts ...TemplateOption) *template.Template {
 	f, err := ioutil.TempFile("/tmp", "template")
 	require.NoError(t, err)
 
@@ -44,7 +44,7 @@ func templateForTests(t *testing.T) *template.Template {
 	_, err = f.WriteString(DefaultTemplateString)
 	require.NoError(t, err)
 
-	tmpl, err := template.FromGlobs(f.Name())
+	tmpl, err := NewTemplate([]string{f.Name()}, opts...)
 	require.NoError(t, err)
 
 	return tmpl
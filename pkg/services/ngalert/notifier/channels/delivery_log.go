@@ -0,0 +1,37 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// logDelivery logs a successful delivery of the notification for as to
+// recipient as a single structured event, for auditing. With the JSON log
+// format, every field is a key of its own. The delivery started at start.
+func logDelivery(ctx context.Context, logger log.Logger, integration, recipient string, as []*types.Alert, start time.Time) {
+	fields := append([]interface{}{
+		"integration", integration,
+		"recipient", maskRecipient(recipient),
+		"latencyMs", time.Since(start).Milliseconds(),
+	}, notificationLogContext(ctx, as)...)
+	logger.Info("Delivered notification", fields...)
+}
+
+// maskRecipient masks all but the last quarter of recipient, and at most four
+// characters of it, so that audit logs can tell recipients apart without
+// revealing them. Recipients may be tokens, such as LINE Notify tokens, which
+// must not be recoverable from the logs.
+func maskRecipient(recipient string) string {
+	runes := []rune(recipient)
+	n := len(runes)
+	visible := n / 4
+	if visible > 4 {
+		visible = 4
+	}
+	return strings.Repeat("*", n-visible) + string(runes[n-visible:])
+}
@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestMaskRecipient(t *testing.T) {
+	require.Equal(t, "******21", maskRecipient("87654321"))
+	require.Equal(t, "*****************4321", maskRecipient("abcdefghijklmnopq4321"))
+	require.Equal(t, "***", maskRecipient("abc"))
+	require.Equal(t, "", maskRecipient(""))
+}
+
+func TestNotifiersLogDelivery(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	notifiers := notifiersWithHTTPOptions(t, tmpl, nil)
+	loggers := map[string]log.Logger{
+		"threema": notifiers["threema"].(*ThreemaNotifier).log,
+		"line":    notifiers["line"].(*LineNotifier).log,
+	}
+	expRecipients := map[string]string{
+		"threema": "******21",
+		"line":    "*******en",
+	}
+
+	for name, n := range notifiers {
+		t.Run(name, func(t *testing.T) {
+			var records []*log15.Record
+			loggers[name].SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+				if r.Lvl == log15.LvlInfo {
+					records = append(records, r)
+				}
+				return nil
+			}))
+
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				return nil
+			})
+			_, err := n.Notify(notifyContext(), firingAlert())
+			require.NoError(t, err)
+
+			require.Len(t, records, 1)
+			require.Equal(t, "Delivered notification", records[0].Msg)
+			fields := map[string]interface{}{}
+			for i := 0; i+1 < len(records[0].Ctx); i += 2 {
+				fields[records[0].Ctx[i].(string)] = records[0].Ctx[i+1]
+			}
+			expKey, err := notify.ExtractGroupKey(notifyContext())
+			require.NoError(t, err)
+			require.Equal(t, name, fields["integration"])
+			require.Equal(t, expRecipients[name], fields["recipient"])
+			require.Equal(t, expKey.String(), fields["groupKey"])
+			require.Equal(t, 1, fields["alerts"])
+			require.Equal(t, "firing", fmt.Sprint(fields["status"]))
+			require.IsType(t, int64(0), fields["latencyMs"])
+		})
+	}
+}
@@ -80,7 +80,7 @@ func (dd *DingDingNotifier) Notify(ctx context.Context, as ...*types.Alert) (boo
 
 	data := notify.GetTemplateData(ctx, dd.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(dd.log)))
 	var tmplErr error
-	tmpl := notify.TmplText(dd.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, dd.tmpl, data, &tmplErr)
 
 	message := tmpl(dd.Message)
 	title := tmpl(`{{ template "default.title" . }}`)
@@ -133,3 +133,13 @@ func (dd *DingDingNotifier) Notify(ctx context.Context, as ...*types.Alert) (boo
 func (dd *DingDingNotifier) SendResolved() bool {
 	return !dd.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (dd *DingDingNotifier) Type() string {
+	return "dingding"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (dd *DingDingNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -21,12 +24,35 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+const (
+	// The limits of Discord embeds, in characters.
+	discordMaxTitleLength       = 256
+	discordMaxDescriptionLength = 4096
+	discordMaxFields            = 25
+	discordMaxFieldNameLength   = 256
+	discordMaxFieldValueLength  = 1024
+	discordMaxEmbedLength       = 6000
+	// discordOmittedFieldLength is the room kept for the field that tells how
+	// many alerts were left out of the embed.
+	discordOmittedFieldLength = 32
+)
+
 type DiscordNotifier struct {
 	old_notifiers.NotifierBase
-	log        log.Logger
-	tmpl       *template.Template
-	Content    string
-	WebhookURL string
+	log         log.Logger
+	tmpl        *template.Template
+	Content     string
+	Description string
+	WebhookURL  string
+	Username    string
+	AvatarURL   string
+}
+
+// discordField is a field of a Discord embed.
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
 }
 
 func NewDiscordNotifier(model *NotificationChannelConfig, t *template.Template) (*DiscordNotifier, error) {
@@ -38,6 +64,16 @@ func NewDiscordNotifier(model *NotificationChannelConfig, t *template.Template)
 	if discordURL == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find webhook url property in settings"}
 	}
+	if u, err := url.Parse(discordURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Discord webhook URL: Must be an absolute URL"}
+	}
+
+	avatarURL := model.Settings.Get("avatar_url").MustString()
+	if avatarURL != "" {
+		if u, err := url.Parse(avatarURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, alerting.ValidationError{Reason: "Invalid Discord avatar URL: Must be an absolute URL"}
+		}
+	}
 
 	content := model.Settings.Get("message").MustString(`{{ template "default.message" . }}`)
 
@@ -50,10 +86,13 @@ func NewDiscordNotifier(model *NotificationChannelConfig, t *template.Template)
 			Settings:              model.Settings,
 			SecureSettings:        model.SecureSettings,
 		}),
-		Content:    content,
-		WebhookURL: discordURL,
-		log:        log.New("alerting.notifier.discord"),
-		tmpl:       t,
+		Content:     content,
+		Description: model.Settings.Get("description").MustString(`{{ .CommonAnnotations.summary }}`),
+		WebhookURL:  discordURL,
+		Username:    model.Settings.Get("username").MustString("Grafana"),
+		AvatarURL:   avatarURL,
+		log:         log.New("alerting.notifier.discord"),
+		tmpl:        t,
 	}, nil
 }
 
@@ -62,24 +101,39 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	alerts := types.Alerts(as...)
 
 	bodyJSON := simplejson.New()
-	bodyJSON.Set("username", "Grafana")
+	bodyJSON.Set("username", d.Username)
+	if d.AvatarURL != "" {
+		bodyJSON.Set("avatar_url", d.AvatarURL)
+	}
 
 	var tmplErr error
-	tmpl := notify.TmplText(d.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, d.tmpl, data, &tmplErr)
 	if d.Content != "" {
 		bodyJSON.Set("content", tmpl(d.Content))
 	}
 
+	footerText := "Grafana v" + setting.BuildVersion
 	footer := map[string]interface{}{
-		"text":     "Grafana v" + setting.BuildVersion,
+		"text":     footerText,
 		"icon_url": "https://grafana.com/assets/img/fav32.png",
 	}
 
+	title := truncateWithEllipsis(tmpl(`{{ template "default.title" . }}`), discordMaxTitleLength)
+	description := truncateWithEllipsis(tmpl(d.Description), discordMaxDescriptionLength)
+
 	embed := simplejson.New()
-	embed.Set("title", tmpl(`{{ template "default.title" . }}`))
+	embed.Set("title", title)
+	if description != "" {
+		embed.Set("description", description)
+	}
 	embed.Set("footer", footer)
 	embed.Set("type", "rich")
 
+	// The fields get what is left of the characters of the embed.
+	budget := discordMaxEmbedLength - utf8.RuneCountInString(title) - utf8.RuneCountInString(description) -
+		utf8.RuneCountInString(footerText)
+	embed.Set("fields", discordFields(as, budget))
+
 	color, _ := strconv.ParseInt(strings.TrimLeft(getAlertStatusColor(alerts.Status()), "#"), 16, 0)
 	embed.Set("color", color)
 
@@ -116,3 +170,68 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 func (d DiscordNotifier) SendResolved() bool {
 	return !d.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (d DiscordNotifier) Type() string {
+	return "discord"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (d DiscordNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
+
+// discordFields returns a field for every alert in as with the labels of the
+// alert. If the fields exceed the number of fields of an embed or budget
+// characters, the last field tells how many alerts were left out instead.
+func discordFields(as []*types.Alert, budget int) []discordField {
+	fields := make([]discordField, 0, len(as))
+	for i, a := range as {
+		f := discordAlertField(a)
+		size := utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+
+		fits := size <= budget && len(fields) < discordMaxFields
+		if i < len(as)-1 {
+			// Keep room for the field about the alerts that are left out.
+			fits = size <= budget-discordOmittedFieldLength && len(fields) < discordMaxFields-1
+		}
+		if !fits {
+			fields = append(fields, discordField{
+				Name:  "...",
+				Value: fmt.Sprintf("%d more alerts", len(as)-i),
+			})
+			break
+		}
+
+		fields = append(fields, f)
+		budget -= size
+	}
+	return fields
+}
+
+// discordAlertField returns the field of a Discord embed for a, which is
+// named after the alert and lists its other labels.
+func discordAlertField(a *types.Alert) discordField {
+	name := string(a.Labels[model.AlertNameLabel])
+	if name == "" {
+		name = "Alert"
+	}
+
+	labels := template.KV{}
+	for k, v := range a.Labels {
+		labels[string(k)] = string(v)
+	}
+	var lines []string
+	for _, pair := range removePrivateItems(labels).Remove([]string{string(model.AlertNameLabel)}).SortedPairs() {
+		lines = append(lines, fmt.Sprintf("%s = %s", pair.Name, pair.Value))
+	}
+	value := strings.Join(lines, "\n")
+	if value == "" {
+		value = "No labels"
+	}
+
+	return discordField{
+		Name:  truncateWithEllipsis(name, discordMaxFieldNameLength),
+		Value: truncateWithEllipsis(value, discordMaxFieldValueLength),
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/url"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -55,6 +58,9 @@ func TestDiscordNotifier(t *testing.T) {
 					"title": "[FIRING:1]  (val1)",
 					"url":   "http://localhost/alerting/list",
 					"type":  "rich",
+					"fields": []interface{}{
+						map[string]interface{}{"name": "alert1", "value": "lbl1 = val1", "inline": false},
+					},
 				}},
 				"username": "Grafana",
 			},
@@ -91,12 +97,90 @@ func TestDiscordNotifier(t *testing.T) {
 					"title": "[FIRING:2]  ",
 					"url":   "http://localhost/alerting/list",
 					"type":  "rich",
+					"fields": []interface{}{
+						map[string]interface{}{"name": "alert1", "value": "lbl1 = val1", "inline": false},
+						map[string]interface{}{"name": "alert1", "value": "lbl1 = val2", "inline": false},
+					},
 				}},
 				"username": "Grafana",
 			},
 			expInitError: nil,
 			expMsgError:  nil,
 		},
+		{
+			name: "Custom username, avatar and description",
+			settings: `{
+				"url": "http://localhost",
+				"message": "",
+				"username": "Alerts Bot",
+				"avatar_url": "https://example.org/avatar.png",
+				"description": "{{ .CommonLabels.lbl1 }} is down"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"__alert_rule_uid__": "rule uid", "alertname": "alert1", "lbl1": "val1"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"embeds": []interface{}{map[string]interface{}{
+					"color": 1.4037554e+07,
+					"footer": map[string]interface{}{
+						"icon_url": "https://grafana.com/assets/img/fav32.png",
+						"text":     "Grafana v",
+					},
+					"title":       "[FIRING:1]  (rule uid val1)",
+					"description": "val1 is down",
+					"url":         "http://localhost/alerting/list",
+					"type":        "rich",
+					"fields": []interface{}{
+						map[string]interface{}{"name": "alert1", "value": "lbl1 = val1", "inline": false},
+					},
+				}},
+				"username":   "Alerts Bot",
+				"avatar_url": "https://example.org/avatar.png",
+			},
+		},
+		{
+			name:     "Summary annotation as description",
+			settings: `{"url": "http://localhost", "message": ""}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1"},
+						Annotations: model.LabelSet{"summary": "Disk full"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"embeds": []interface{}{map[string]interface{}{
+					"color": 1.4037554e+07,
+					"footer": map[string]interface{}{
+						"icon_url": "https://grafana.com/assets/img/fav32.png",
+						"text":     "Grafana v",
+					},
+					"title":       "[FIRING:1]  ",
+					"description": "Disk full",
+					"url":         "http://localhost/alerting/list",
+					"type":        "rich",
+					"fields": []interface{}{
+						map[string]interface{}{"name": "alert1", "value": "No labels", "inline": false},
+					},
+				}},
+				"username": "Grafana",
+			},
+		},
+		{
+			name:         "Invalid webhook URL",
+			settings:     `{"url": "discord.com/api/webhooks/1234/abcd"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Discord webhook URL: Must be an absolute URL"},
+		},
+		{
+			name:         "Invalid avatar URL",
+			settings:     `{"url": "http://localhost", "avatar_url": "avatar.png"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Discord avatar URL: Must be an absolute URL"},
+		},
 		{
 			name:         "Error in initialization",
 			settings:     `{}`,
@@ -156,3 +240,96 @@ func TestDiscordNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscordNotifierFieldLimits(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	dn, err := NewDiscordNotifier(&NotificationChannelConfig{
+		Name:     "discord_testing",
+		Type:     "discord",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost"}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	type embed struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Footer      struct{ Text string }
+		Fields      []discordField `json:"fields"`
+	}
+	send := func(t *testing.T, as []*types.Alert) embed {
+		var body string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			body = webhook.Body
+			return nil
+		})
+		ok, err := dn.Notify(notifyContext(), as...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var msg struct {
+			Embeds []embed `json:"embeds"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &msg))
+		require.Len(t, msg.Embeds, 1)
+		return msg.Embeds[0]
+	}
+	embedLength := func(e embed) int {
+		n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description) + utf8.RuneCountInString(e.Footer.Text)
+		for _, f := range e.Fields {
+			n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+		}
+		return n
+	}
+
+	t.Run("At most 25 fields", func(t *testing.T) {
+		var as []*types.Alert
+		for i := 0; i < 30; i++ {
+			as = append(as, &types.Alert{Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i)), "lbl1": "val1"},
+			}})
+		}
+
+		e := send(t, as)
+		require.Len(t, e.Fields, 25)
+		require.Equal(t, "alert0", e.Fields[0].Name)
+		require.Equal(t, "alert23", e.Fields[23].Name)
+		require.Equal(t, discordField{Name: "...", Value: "6 more alerts"}, e.Fields[24])
+	})
+
+	t.Run("At most 6000 characters", func(t *testing.T) {
+		var as []*types.Alert
+		for i := 0; i < 10; i++ {
+			as = append(as, &types.Alert{Alert: model.Alert{
+				Labels: model.LabelSet{
+					"alertname": model.LabelValue(fmt.Sprintf("alert%d", i)),
+					"query":     model.LabelValue(strings.Repeat("x", 1500)),
+				},
+			}})
+		}
+
+		e := send(t, as)
+		require.LessOrEqual(t, embedLength(e), 6000)
+		require.Len(t, e.Fields, 6)
+		require.Equal(t, 1024, utf8.RuneCountInString(e.Fields[0].Value))
+		require.True(t, strings.HasSuffix(e.Fields[0].Value, "…"))
+		require.Equal(t, discordField{Name: "...", Value: "5 more alerts"}, e.Fields[5])
+	})
+
+	t.Run("All alerts fit", func(t *testing.T) {
+		var as []*types.Alert
+		for i := 0; i < 25; i++ {
+			as = append(as, &types.Alert{Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i))},
+			}})
+		}
+
+		e := send(t, as)
+		require.Len(t, e.Fields, 25)
+		require.Equal(t, "alert24", e.Fields[24].Name)
+	})
+}
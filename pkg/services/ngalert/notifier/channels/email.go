@@ -121,3 +121,13 @@ func (en *EmailNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 func (en *EmailNotifier) SendResolved() bool {
 	return !en.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (en *EmailNotifier) Type() string {
+	return "email"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (en *EmailNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
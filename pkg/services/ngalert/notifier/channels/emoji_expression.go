@@ -0,0 +1,435 @@
+package channels
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// emojiExpression selects the emoji of a notification from its status and
+// the labels its alerts have in common. The expression language borrows its
+// syntax from CEL but is deliberately minimal, so that it can't do anything
+// but compute a value:
+//
+//	labels.severity == "critical" && labels.team == "db" ? "🔥" : status == "resolved" ? "🟢" : ""
+//
+// It supports double-quoted string literals, true and false, status,
+// labels.name and labels["name"] to refer to labels (missing labels are
+// empty), the comparisons ==, != and =~ (anchored regular expression match),
+// !, &&, ||, parentheses and the conditional operator.
+type emojiExpression struct {
+	root exprNode
+}
+
+// parseEmojiExpression parses s, which must not be empty.
+func parseEmojiExpression(s string) (*emojiExpression, error) {
+	tokens, err := lexExpression(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != exprEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return &emojiExpression{root: root}, nil
+}
+
+// eval returns the emoji for a notification with the given status and
+// common labels.
+func (e *emojiExpression) eval(status string, labels map[string]string) (string, error) {
+	v, err := e.root.eval(exprEnv{status: status, labels: labels})
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expression must result in a string, got %v", v)
+	}
+	return s, nil
+}
+
+type exprTokenKind int
+
+const (
+	exprEOF exprTokenKind = iota
+	exprString
+	exprIdent
+	exprOperator
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	// text is the unquoted value of strings and the source of anything else.
+	text string
+	pos  int
+}
+
+// exprOperators are the operators of the expression language, longest first.
+var exprOperators = []string{"==", "!=", "=~", "&&", "||", "!", "(", ")", "[", "]", "?", ":", "."}
+
+func lexExpression(s string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			text, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d", i)
+			}
+			tokens = append(tokens, exprToken{kind: exprString, text: text, pos: i})
+			i = end + 1
+		case isIdentByte(c, false):
+			end := i
+			for end < len(s) && isIdentByte(s[end], true) {
+				end++
+			}
+			tokens = append(tokens, exprToken{kind: exprIdent, text: s[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range exprOperators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", c, i)
+			}
+			tokens = append(tokens, exprToken{kind: exprOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: exprEOF, pos: len(s)}), nil
+}
+
+// isIdentByte reports whether c can be part of an identifier. Only its
+// first character must not be a digit.
+func isIdentByte(c byte, digit bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || digit && '0' <= c && c <= '9'
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != exprEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *exprParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == exprOperator && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		if tok.kind == exprEOF {
+			return fmt.Errorf("expected %q at the end", op)
+		}
+		return fmt.Errorf("expected %q at position %d", op, tok.pos)
+	}
+	return nil
+}
+
+func (p *exprParser) parseConditional() (exprNode, error) {
+	cond, err := p.parseOr()
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||") {
+		var right exprNode
+		right, err = p.parseAnd()
+		left = logicalNode{or: true, left: left, right: right}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	for err == nil && p.accept("&&") {
+		var right exprNode
+		right, err = p.parseComparison()
+		left = logicalNode{left: left, right: right}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "=~"} {
+		if p.accept(op) {
+			pos := p.peek().pos
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			node := comparisonNode{op: op, left: left, right: right}
+			// Regular expressions in string literals are compiled right
+			// away, so that invalid ones are reported when the setting is
+			// saved rather than when notifying.
+			if lit, ok := right.(literalNode); ok && op == "=~" {
+				pattern, ok := lit.value.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected a regular expression at position %d", pos)
+				}
+				if node.re, err = compileAnchoredRegexp(pattern); err != nil {
+					return nil, fmt.Errorf("invalid regular expression %q at position %d: %w", pattern, pos, err)
+				}
+			}
+			return node, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == exprString:
+		return literalNode{value: tok.text}, nil
+	case tok.kind == exprIdent && tok.text == "true":
+		return literalNode{value: true}, nil
+	case tok.kind == exprIdent && tok.text == "false":
+		return literalNode{value: false}, nil
+	case tok.kind == exprIdent && tok.text == "status":
+		return statusNode{}, nil
+	case tok.kind == exprIdent && tok.text == "labels":
+		if p.accept(".") {
+			name := p.next()
+			if name.kind != exprIdent {
+				return nil, fmt.Errorf("expected a label name at position %d", name.pos)
+			}
+			return labelNode{name: name.text}, nil
+		}
+		if p.accept("[") {
+			name := p.next()
+			if name.kind != exprString {
+				return nil, fmt.Errorf("expected a label name at position %d", name.pos)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return labelNode{name: name.text}, nil
+		}
+		return nil, fmt.Errorf("expected a label at position %d", tok.pos)
+	case tok.kind == exprIdent:
+		return nil, fmt.Errorf("unknown identifier %q at position %d", tok.text, tok.pos)
+	case tok.kind == exprOperator && tok.text == "(":
+		inner, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tok.kind == exprEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+// exprEnv is what expressions are evaluated against.
+type exprEnv struct {
+	status string
+	labels map[string]string
+}
+
+// exprNode is a node of a parsed expression. Its value is a string or a bool.
+type exprNode interface {
+	eval(env exprEnv) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(exprEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type statusNode struct{}
+
+func (statusNode) eval(env exprEnv) (interface{}, error) {
+	return env.status, nil
+}
+
+type labelNode struct {
+	name string
+}
+
+func (n labelNode) eval(env exprEnv) (interface{}, error) {
+	return env.labels[n.name], nil
+}
+
+type notNode struct {
+	operand exprNode
+}
+
+func (n notNode) eval(env exprEnv) (interface{}, error) {
+	v, err := evalBool(n.operand, env, "!")
+	return !v, err
+}
+
+type logicalNode struct {
+	or          bool
+	left, right exprNode
+}
+
+func (n logicalNode) eval(env exprEnv) (interface{}, error) {
+	op := "&&"
+	if n.or {
+		op = "||"
+	}
+	left, err := evalBool(n.left, env, op)
+	if err != nil || left == n.or {
+		return left, err
+	}
+	return evalBool(n.right, env, op)
+}
+
+type comparisonNode struct {
+	op          string
+	left, right exprNode
+	// re is the compiled regular expression of =~ if right is a literal.
+	re *regexp.Regexp
+}
+
+func (n comparisonNode) eval(env exprEnv) (interface{}, error) {
+	left, err := evalString(n.left, env, n.op)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evalString(n.right, env, n.op)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	default:
+		re := n.re
+		if re == nil {
+			if re, err = compileAnchoredRegexp(right); err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", right, err)
+			}
+		}
+		return re.MatchString(left), nil
+	}
+}
+
+// compileAnchoredRegexp compiles pattern so that it must match whole strings.
+func compileAnchoredRegexp(pattern string) (*regexp.Regexp, error) {
+	// The pattern is compiled on its own first, so that errors refer to it
+	// rather than to the anchored expression.
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+type conditionalNode struct {
+	cond, then, otherwise exprNode
+}
+
+func (n conditionalNode) eval(env exprEnv) (interface{}, error) {
+	cond, err := evalBool(n.cond, env, "?")
+	if err != nil {
+		return nil, err
+	}
+	if cond {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+func evalBool(n exprNode, env exprEnv, op string) (bool, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("operand of %s must be a bool, got %q", op, v)
+	}
+	return b, nil
+}
+
+func evalString(n exprNode, env exprEnv, op string) (string, error) {
+	v, err := n.eval(env)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("operand of %s must be a string, got %v", op, v)
+	}
+	return s, nil
+}
@@ -0,0 +1,67 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmojiExpression(t *testing.T) {
+	labels := map[string]string{"severity": "critical", "team": "db", "k8s-namespace": "prod", "pattern": "("}
+
+	cases := []struct {
+		name    string
+		expr    string
+		status  string
+		exp     string
+		expErr  string
+		evalErr string
+	}{
+		{name: "literal", expr: `"🔥"`, exp: "🔥"},
+		{name: "label comparison", expr: `labels.severity == "critical" ? "🔥" : "⚠️"`, exp: "🔥"},
+		{name: "combination of labels", expr: `labels.severity == "critical" && labels.team == "web" ? "🔥" : labels.team == "db" ? "🗄️" : ""`, exp: "🗄️"},
+		{name: "or and not", expr: `!(labels.team == "web" || labels.team == "api") ? "✔" : "✘"`, exp: "✔"},
+		{name: "label by index", expr: `labels["k8s-namespace"] != "prod" ? "🧪" : "🏭"`, exp: "🏭"},
+		{name: "missing label is empty", expr: `labels.missing == "" ? "∅" : "?"`, exp: "∅"},
+		{name: "regular expression is anchored", expr: `labels.severity =~ "crit" ? "partial" : labels.severity =~ "crit.*" ? "full" : ""`, exp: "full"},
+		{name: "status", expr: `status == "resolved" ? "🟢" : "🔴"`, status: "resolved", exp: "🟢"},
+		{name: "escaped quotes", expr: `"\"quoted\""`, exp: `"quoted"`},
+		{name: "empty result", expr: `labels.team == "web" ? "🌐" : ""`, exp: ""},
+		{name: "unknown identifier", expr: `severity == "critical"`, expErr: `unknown identifier "severity" at position 0`},
+		{name: "missing operand", expr: `labels.severity ==`, expErr: "unexpected end of expression"},
+		{name: "missing else branch", expr: `labels.severity == "critical" ? "🔥"`, expErr: `expected ":" at the end`},
+		{name: "unterminated string", expr: `labels.severity == "critical`, expErr: "unterminated string at position 19"},
+		{name: "trailing tokens", expr: `"a" "b"`, expErr: `unexpected "b" at position 4`},
+		{name: "unsupported character", expr: `labels.severity + "x"`, expErr: `unexpected '+' at position 16`},
+		{name: "comparison of a bool", expr: `(labels.team == "db") == "true" ? "a" : "b"`, evalErr: "operand of == must be a string, got true"},
+		{name: "condition is not a bool", expr: `labels.team ? "a" : "b"`, evalErr: `operand of ? must be a bool, got "db"`},
+		{name: "result is not a string", expr: `labels.team == "db"`, evalErr: "expression must result in a string, got true"},
+		{name: "invalid regular expression", expr: `labels.team =~ "(" ? "a" : "b"`, expErr: "invalid regular expression \"(\" at position 15: error parsing regexp: missing closing ): `(`"},
+		{name: "regular expression is not a string", expr: `labels.team =~ true ? "a" : "b"`, expErr: "expected a regular expression at position 15"},
+		{name: "invalid regular expression in a label", expr: `labels.team =~ labels.pattern ? "a" : "b"`, evalErr: "invalid regular expression \"(\""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expr, err := parseEmojiExpression(c.expr)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			status := c.status
+			if status == "" {
+				status = "firing"
+			}
+			emoji, err := expr.eval(status, labels)
+			if c.evalErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.evalErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, emoji)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package channels
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+const (
+	// defaultFanoutConcurrency is the default number of recipients that are
+	// sent to at the same time.
+	defaultFanoutConcurrency = 4
+	// defaultFanoutJitter is the default upper bound of the random wait
+	// between two sends of a worker.
+	defaultFanoutJitter = 50 * time.Millisecond
+)
+
+// fanoutOptions controls how a notification is sent to multiple recipients.
+type fanoutOptions struct {
+	// concurrency is the maximum number of recipients sent to at a time.
+	concurrency int
+	// maxJitter is the upper bound of the random wait between two sends of
+	// a worker, so that large recipient lists don't hit the gateway in
+	// bursts.
+	maxJitter time.Duration
+	clock     Clock
+}
+
+// parseFanoutOptions reads the settings for sending to multiple recipients.
+func parseFanoutOptions(settings *simplejson.Json) (fanoutOptions, error) {
+	concurrency, ok := intSetting(settings, "concurrency", defaultFanoutConcurrency)
+	if !ok || concurrency <= 0 {
+		return fanoutOptions{}, alerting.ValidationError{Reason: "Invalid concurrency: Must be positive"}
+	}
+	return fanoutOptions{
+		concurrency: concurrency,
+		maxJitter:   defaultFanoutJitter,
+		clock:       realClock,
+	}, nil
+}
+
+// fanout calls send for every recipient with at most opts.concurrency calls
+// running at a time. It returns the error of every recipient, in the order of
+// recipients. Once ctx is done, the remaining recipients fail with its error
+// without being sent to.
+func fanout(ctx context.Context, recipients []string, opts fanoutOptions, send func(ctx context.Context, recipient string) error) []error {
+	errs := make([]error, len(recipients))
+	next := make(chan int)
+
+	workers := opts.concurrency
+	if workers > len(recipients) {
+		workers = len(recipients)
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			first := true
+			for i := range next {
+				if !first && opts.maxJitter > 0 {
+					// nolint:gosec
+					jitter := time.Duration(rand.Int63n(int64(opts.maxJitter)))
+					select {
+					case <-ctx.Done():
+					case <-opts.clock.After(jitter):
+					}
+				}
+				first = false
+
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = send(ctx, recipients[i])
+			}
+		}()
+	}
+
+	for i := range recipients {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return errs
+}
+
+// fanoutErrors returns the number of recipients that failed and their errors,
+// separated by semicolons.
+func fanoutErrors(errs []error) (int, string) {
+	var msgs []string
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return len(msgs), strings.Join(msgs, "; ")
+}
@@ -0,0 +1,111 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestFanout(t *testing.T) {
+	recipients := make([]string, 20)
+	for i := range recipients {
+		recipients[i] = fmt.Sprintf("recipient%d", i)
+	}
+
+	t.Run("caps the concurrency", func(t *testing.T) {
+		opts := fanoutOptions{concurrency: 3, maxJitter: time.Millisecond, clock: realClock}
+
+		var mtx sync.Mutex
+		running, maxRunning := 0, 0
+		var attempted []string
+		errs := fanout(context.Background(), recipients, opts, func(_ context.Context, recipient string) error {
+			mtx.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			attempted = append(attempted, recipient)
+			mtx.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mtx.Lock()
+			running--
+			mtx.Unlock()
+			return nil
+		})
+
+		require.Equal(t, make([]error, len(recipients)), errs)
+		require.ElementsMatch(t, recipients, attempted)
+		require.LessOrEqual(t, maxRunning, 3)
+		require.Greater(t, maxRunning, 1, "recipients should be sent to concurrently")
+	})
+
+	t.Run("attempts every recipient when some fail", func(t *testing.T) {
+		opts := fanoutOptions{concurrency: 4, clock: realClock}
+
+		var mtx sync.Mutex
+		var attempted []string
+		errs := fanout(context.Background(), recipients, opts, func(_ context.Context, recipient string) error {
+			mtx.Lock()
+			attempted = append(attempted, recipient)
+			mtx.Unlock()
+			if recipient == "recipient3" || recipient == "recipient17" {
+				return fmt.Errorf("%s: unreachable", recipient)
+			}
+			return nil
+		})
+
+		require.ElementsMatch(t, recipients, attempted)
+		failed, msg := fanoutErrors(errs)
+		require.Equal(t, 2, failed)
+		require.Equal(t, "recipient3: unreachable; recipient17: unreachable", msg)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		opts := fanoutOptions{concurrency: 2, clock: realClock}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var mtx sync.Mutex
+		sent := 0
+		errs := fanout(ctx, recipients, opts, func(_ context.Context, recipient string) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			sent++
+			cancel()
+			return nil
+		})
+
+		require.LessOrEqual(t, sent, 2)
+		failed, _ := fanoutErrors(errs)
+		require.Equal(t, len(recipients)-sent, failed)
+		for _, err := range errs {
+			if err != nil {
+				require.True(t, errors.Is(err, context.Canceled))
+			}
+		}
+	})
+}
+
+func TestParseFanoutOptions(t *testing.T) {
+	opts, err := parseFanoutOptions(simplejson.New())
+	require.NoError(t, err)
+	require.Equal(t, defaultFanoutConcurrency, opts.concurrency)
+	require.Equal(t, defaultFanoutJitter, opts.maxJitter)
+
+	opts, err = parseFanoutOptions(simplejson.NewFromAny(map[string]interface{}{"concurrency": 10}))
+	require.NoError(t, err)
+	require.Equal(t, 10, opts.concurrency)
+
+	_, err = parseFanoutOptions(simplejson.NewFromAny(map[string]interface{}{"concurrency": 0}))
+	require.Equal(t, alerting.ValidationError{Reason: "Invalid concurrency: Must be positive"}.Error(), err.Error())
+}
@@ -0,0 +1,288 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+const (
+	// defaultFileMaxSize is the default size in bytes above which the file
+	// is rotated.
+	defaultFileMaxSize = 10 << 20
+	// defaultFileMaxBackups is the default number of rotated files to keep.
+	defaultFileMaxBackups = 3
+)
+
+// FileNotifier is responsible for appending
+// alert notifications to a local file, e.g. for debugging or auditing.
+type FileNotifier struct {
+	old_notifiers.NotifierBase
+	Path       string
+	dir        string
+	Title      string
+	Message    string
+	MaxSize    int64
+	MaxBackups int
+	clock      Clock
+	metrics    *DeliveryMetrics
+	log        log.Logger
+	tmpl       *template.Template
+}
+
+// fileRecord is a line of the file: the template data of the notification
+// with the rendered title and message.
+type fileRecord struct {
+	Time     time.Time `json:"time"`
+	GroupKey string    `json:"groupKey"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	*template.Data
+}
+
+// NewFileNotifier is the constructor for the file notifier
+func NewFileNotifier(model *NotificationChannelConfig, t *template.Template) (*FileNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	path := model.Settings.Get("path").MustString()
+	if path == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find file path in settings"}
+	}
+	if model.FileDir == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid file path: Grafana has no directory to write notifications to"}
+	}
+	// Relative paths are relative to the directory of the server.
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(model.FileDir, path)
+	}
+	path = filepath.Clean(path)
+	if !fileInDir(model.FileDir, path) {
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid file path: Must be in %s", model.FileDir)}
+	}
+
+	title := model.Settings.Get("title").MustString()
+	if title == "" {
+		title = `{{ template "default.title" . }}`
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxSize, ok := intSetting(model.Settings, "max_size", defaultFileMaxSize)
+	if !ok || maxSize <= 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid file max size: Must be a positive number of bytes"}
+	}
+	maxBackups, ok := intSetting(model.Settings, "max_backups", defaultFileMaxBackups)
+	if !ok || maxBackups <= 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid file max backups: Must be positive"}
+	}
+
+	return &FileNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		Path:       path,
+		dir:        model.FileDir,
+		Title:      title,
+		Message:    message,
+		MaxSize:    int64(maxSize),
+		MaxBackups: maxBackups,
+		clock:      realClock,
+		metrics:    model.Metrics,
+		log:        log.New("alerting.notifier.file"),
+		tmpl:       t,
+	}, nil
+}
+
+// Notify appends the alert notification to the file
+func (fn *FileNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := fn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Writing notification to file", "notification", fn.Name, "path", fn.Path)
+
+	data := notify.GetTemplateData(ctx, fn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, fn.tmpl, data, &tmplErr)
+
+	// The group key is missing only when notifying outside of the
+	// Alertmanager, so write it as empty rather than failing.
+	groupKey, _ := notify.ExtractGroupKey(ctx)
+	record := fileRecord{
+		Time:     fn.clock.Now(),
+		GroupKey: groupKey.String(),
+		Title:    tmpl(fn.Title),
+		Message:  tmpl(fn.Message),
+		Data:     data,
+	}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template file notification: %w", tmplErr)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = fn.write(append(line, '\n'))
+	fn.metrics.observe("file", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to write notification to file", "error", err, "path", fn.Path)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// write appends line to the file. The file is rotated first if line would
+// make it exceed the maximum size, unless it is empty.
+func (fn *FileNotifier) write(line []byte) error {
+	unlock := lockFile(fn.Path)
+	defer unlock()
+
+	if err := os.MkdirAll(fn.dir, 0750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", fn.dir, err)
+	}
+	// The path was checked lexically by the constructor, but a symbolic
+	// link may still lead out of the directory. Check the part of the path
+	// that exists before creating the rest of it.
+	existing := filepath.Dir(fn.Path)
+	for fileInDir(fn.dir, existing) {
+		if _, err := os.Lstat(existing); !os.IsNotExist(err) {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	if !pathInDir(fn.dir, existing) {
+		return fmt.Errorf("directory of %s is not in %s", fn.Path, fn.dir)
+	}
+	if err := os.MkdirAll(filepath.Dir(fn.Path), 0750); err != nil {
+		return fmt.Errorf("failed to create directory of %s: %w", fn.Path, err)
+	}
+
+	info, err := os.Lstat(fn.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", fn.Path)
+	}
+	if err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > fn.MaxSize {
+		if err := fn.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", fn.Path, err)
+		}
+	}
+
+	f, err := os.OpenFile(filepath.Clean(fn.Path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fileLock serializes the writes to a file.
+type fileLock struct {
+	mtx sync.Mutex
+	// refs is the number of writers holding or waiting for the lock.
+	refs int
+}
+
+var (
+	// fileLocks are the locks of the files being written, by clean path.
+	// Several channels may write the same file, and a channel rebuilt by a
+	// reload of the configuration writes it while the old one may still do.
+	fileLocks    = map[string]*fileLock{}
+	fileLocksMtx sync.Mutex
+)
+
+// lockFile locks the file at path for writing and rotating it, and returns
+// the function that unlocks it.
+func lockFile(path string) func() {
+	path = filepath.Clean(path)
+
+	fileLocksMtx.Lock()
+	l, ok := fileLocks[path]
+	if !ok {
+		l = &fileLock{}
+		fileLocks[path] = l
+	}
+	l.refs++
+	fileLocksMtx.Unlock()
+
+	l.mtx.Lock()
+	return func() {
+		l.mtx.Unlock()
+
+		fileLocksMtx.Lock()
+		defer fileLocksMtx.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(fileLocks, path)
+		}
+	}
+}
+
+// rotate moves the file to path.1, path.1 to path.2 and so on, dropping the
+// oldest backup.
+func (fn *FileNotifier) rotate() error {
+	for i := fn.MaxBackups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", fn.Path, i), fmt.Sprintf("%s.%d", fn.Path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(fn.Path, fn.Path+".1")
+}
+
+// fileInDir reports whether the clean, absolute path p is in the directory
+// dir or one of its subdirectories, without resolving symbolic links, as p
+// may not exist yet.
+func fileInDir(dir, p string) bool {
+	if !filepath.IsAbs(dir) {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(dir), p)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (fn *FileNotifier) SendResolved() bool {
+	return !fn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (fn *FileNotifier) Type() string {
+	return "file"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (fn *FileNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,251 @@
+package channels
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestFileNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, dir string, settings map[string]interface{}) (*FileNotifier, error) {
+		return NewFileNotifier(&NotificationChannelConfig{
+			Name:     "file_testing",
+			Type:     "file",
+			Settings: simplejson.NewFromAny(settings),
+			FileDir:  dir,
+		}, tmpl)
+	}
+
+	readRecords := func(t *testing.T, path string) []map[string]interface{} {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, f.Close()) }()
+
+		var records []map[string]interface{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			records = append(records, record)
+		}
+		require.NoError(t, scanner.Err())
+		return records
+	}
+
+	t.Run("Appends a record per notification", func(t *testing.T) {
+		// The directory is created on the first write.
+		dir := t.TempDir()
+		path := filepath.Join(dir, "alerts", "notifications.log")
+		fn, err := newNotifier(t, dir, map[string]interface{}{"path": "alerts/notifications.log", "message": "{{ len .Alerts.Firing }} firing"})
+		require.NoError(t, err)
+		require.Equal(t, path, fn.Path)
+		mock := clock.NewMock()
+		mock.Set(time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC))
+		fn.clock = mock
+
+		ok, err := fn.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		mock.Add(time.Minute)
+		resolved := &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "alert1"},
+				StartsAt: time.Now().Add(-2 * time.Hour),
+				EndsAt:   time.Now().Add(-time.Hour),
+			},
+		}
+		ok, err = fn.Notify(notifyContext(), resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		records := readRecords(t, path)
+		require.Len(t, records, 2)
+
+		require.Equal(t, "2021-06-01T12:00:00Z", records[0]["time"])
+		require.Equal(t, "alertname", records[0]["groupKey"])
+		require.Equal(t, "firing", records[0]["status"])
+		require.Equal(t, "1 firing", records[0]["message"])
+		require.Equal(t, map[string]interface{}{"alertname": "alert1"}, records[0]["commonLabels"])
+		require.Len(t, records[0]["alerts"], 1)
+
+		require.Equal(t, "2021-06-01T12:01:00Z", records[1]["time"])
+		require.Equal(t, "resolved", records[1]["status"])
+		require.Equal(t, "0 firing", records[1]["message"])
+	})
+
+	t.Run("Rotates at the maximum size", func(t *testing.T) {
+		send := func(fn *FileNotifier, name string) {
+			alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name)}}}
+			ok, err := fn.Notify(notifyContext(), alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		// Allow for one and a half records per file.
+		dir := t.TempDir()
+		probe, err := newNotifier(t, dir, map[string]interface{}{"path": filepath.Join(dir, "probe.log")})
+		require.NoError(t, err)
+		send(probe, "first")
+		info, err := os.Stat(probe.Path)
+		require.NoError(t, err)
+
+		path := filepath.Join(dir, "notifications.log")
+		fn, err := newNotifier(t, dir, map[string]interface{}{"path": path, "max_size": info.Size() * 3 / 2, "max_backups": 2})
+		require.NoError(t, err)
+
+		send(fn, "first")
+		send(fn, "second")
+		send(fn, "third")
+		send(fn, "fourth")
+
+		alertnames := func(path string) []string {
+			var names []string
+			for _, r := range readRecords(t, path) {
+				names = append(names, r["commonLabels"].(map[string]interface{})["alertname"].(string))
+			}
+			return names
+		}
+		require.Equal(t, []string{"fourth"}, alertnames(path))
+		require.Equal(t, []string{"third"}, alertnames(path+".1"))
+		require.Equal(t, []string{"second"}, alertnames(path+".2"))
+		_, err = os.Stat(path + ".3")
+		require.True(t, os.IsNotExist(err), "only max_backups rotated files should be kept")
+	})
+
+	t.Run("Serializes writes of notifiers to the same file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "notifications.log")
+		settings := map[string]interface{}{"path": path, "max_size": 4096, "max_backups": 100}
+		first, err := newNotifier(t, dir, settings)
+		require.NoError(t, err)
+		second, err := newNotifier(t, dir, settings)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for _, fn := range []*FileNotifier{first, second} {
+			fn := fn
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					ok, err := fn.Notify(notifyContext(), firingAlert())
+					require.NoError(t, err)
+					require.True(t, ok)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Every record is complete and none is lost by interleaved rotations.
+		files, err := filepath.Glob(path + "*")
+		require.NoError(t, err)
+		records := 0
+		for _, f := range files {
+			records += len(readRecords(t, f))
+		}
+		require.Equal(t, 40, records)
+		require.Empty(t, fileLocks)
+	})
+
+	t.Run("Fails if the file cannot be written", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notadir"), nil, 0600))
+		fn, err := newNotifier(t, dir, map[string]interface{}{"path": filepath.Join(dir, "notadir", "notifications.log")})
+		require.NoError(t, err)
+
+		ok, err := fn.Notify(notifyContext(), firingAlert())
+		require.False(t, ok)
+		require.Error(t, err)
+	})
+
+	t.Run("Fails if a symbolic link leads out of the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		outside := t.TempDir()
+		require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "target.log"), nil, 0600))
+		require.NoError(t, os.Symlink(filepath.Join(outside, "target.log"), filepath.Join(dir, "file.log")))
+
+		for _, p := range []string{"link/notifications.log", "file.log"} {
+			fn, err := newNotifier(t, dir, map[string]interface{}{"path": p})
+			require.NoError(t, err)
+
+			ok, err := fn.Notify(notifyContext(), firingAlert())
+			require.False(t, ok)
+			require.Error(t, err)
+		}
+		info, err := os.Stat(filepath.Join(outside, "target.log"))
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+		_, err = os.Stat(filepath.Join(outside, "notifications.log"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	invalidCases := []struct {
+		name     string
+		dir      string
+		settings map[string]interface{}
+		expErr   error
+	}{
+		{
+			name:     "Path missing",
+			dir:      "/var/log/grafana",
+			settings: map[string]interface{}{},
+			expErr:   alerting.ValidationError{Reason: "Could not find file path in settings"},
+		}, {
+			name:     "No directory",
+			settings: map[string]interface{}{"path": "/var/log/grafana/alerts.log"},
+			expErr:   alerting.ValidationError{Reason: "Invalid file path: Grafana has no directory to write notifications to"},
+		}, {
+			name:     "Absolute path out of the directory",
+			dir:      "/var/log/grafana",
+			settings: map[string]interface{}{"path": "/etc/grafana/grafana.ini"},
+			expErr:   alerting.ValidationError{Reason: "Invalid file path: Must be in /var/log/grafana"},
+		}, {
+			name:     "Relative path out of the directory",
+			dir:      "/var/log/grafana",
+			settings: map[string]interface{}{"path": "../../../etc/grafana/grafana.ini"},
+			expErr:   alerting.ValidationError{Reason: "Invalid file path: Must be in /var/log/grafana"},
+		}, {
+			name:     "Path of the directory",
+			dir:      "/var/log/grafana",
+			settings: map[string]interface{}{"path": "/var/log/grafana/"},
+			expErr:   alerting.ValidationError{Reason: "Invalid file path: Must be in /var/log/grafana"},
+		}, {
+			name:     "Invalid max size",
+			dir:      "/var/log/grafana",
+			settings: map[string]interface{}{"path": "alerts.log", "max_size": 0},
+			expErr:   alerting.ValidationError{Reason: "Invalid file max size: Must be a positive number of bytes"},
+		}, {
+			name:     "Invalid max backups",
+			dir:      "/var/log/grafana",
+			settings: map[string]interface{}{"path": "alerts.log", "max_backups": -1},
+			expErr:   alerting.ValidationError{Reason: "Invalid file max backups: Must be positive"},
+		},
+	}
+	for _, c := range invalidCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := newNotifier(t, c.dir, c.settings)
+			require.Equal(t, c.expErr.Error(), err.Error())
+		})
+	}
+}
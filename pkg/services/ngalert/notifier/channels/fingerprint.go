@@ -0,0 +1,44 @@
+package channels
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Fingerprinter is implemented by notifiers that can identify a notification
+// for deduplication outside of Grafana.
+type Fingerprinter interface {
+	// Fingerprint returns the fingerprint of the notification for the
+	// alerts, see notificationFingerprint.
+	Fingerprint(ctx context.Context, as ...*types.Alert) string
+}
+
+// notificationFingerprint returns a deterministic ID of the notification for
+// the alert group of ctx and the alerts as. It is the hash of the group key
+// and the sorted label sets of the alerts, so it doesn't depend on the order
+// of as and doesn't leak label values. Notifications without a group key,
+// such as test notifications, are identified by their alerts only.
+func notificationFingerprint(ctx context.Context, as []*types.Alert) string {
+	labelSets := make([]string, 0, len(as))
+	for _, a := range as {
+		labelSets = append(labelSets, a.Labels.String())
+	}
+	sort.Strings(labelSets)
+
+	h := sha256.New()
+	if groupKey, err := notify.ExtractGroupKey(ctx); err == nil {
+		_, _ = h.Write([]byte(groupKey.String()))
+	}
+	for _, labels := range labelSets {
+		// Label sets can't contain a zero byte unescaped, so it separates
+		// them unambiguously.
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(labels))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package channels
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationFingerprint(t *testing.T) {
+	alert := func(name string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name), "team": "db"}}}
+	}
+	as := []*types.Alert{alert("alert1"), alert("alert2"), alert("alert3")}
+	ctx := notify.WithGroupKey(context.Background(), "{}:{team=\"db\"}")
+
+	fingerprint := notificationFingerprint(ctx, as)
+	require.Regexp(t, regexp.MustCompile(`^[0-9a-f]{64}$`), fingerprint)
+
+	permutations := [][]int{{0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	for _, p := range permutations {
+		permuted := []*types.Alert{as[p[0]], as[p[1]], as[p[2]]}
+		require.Equal(t, fingerprint, notificationFingerprint(ctx, permuted), "permutation %v", p)
+	}
+
+	otherGroup := notify.WithGroupKey(context.Background(), "{}:{team=\"web\"}")
+	require.NotEqual(t, fingerprint, notificationFingerprint(otherGroup, as))
+	require.NotEqual(t, fingerprint, notificationFingerprint(ctx, as[:2]))
+	require.NotEqual(t, fingerprint, notificationFingerprint(ctx, []*types.Alert{alert("alert1"), alert("alert2"), alert("alert4")}))
+	// Test notifications have no group key, but still get a fingerprint.
+	require.NotEqual(t, fingerprint, notificationFingerprint(context.Background(), as))
+}
+
+func TestNotifiersFingerprint(t *testing.T) {
+	tmpl := templateForTests(t)
+	as := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}},
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+		t.Run(name, func(t *testing.T) {
+			f, ok := n.(Fingerprinter)
+			require.True(t, ok)
+			require.Equal(t, notificationFingerprint(notifyContext(), as), f.Fingerprint(notifyContext(), as...))
+			require.Equal(t, f.Fingerprint(notifyContext(), as...), f.Fingerprint(notifyContext(), as[1], as[0]))
+		})
+	}
+}
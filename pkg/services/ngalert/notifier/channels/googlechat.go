@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"time"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -19,20 +21,32 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+// googleChatReplyOption makes Google Chat post messages with a thread key
+// into the thread of that key, or start it if it doesn't exist yet.
+const googleChatReplyOption = "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+
 // GoogleChatNotifier is responsible for sending
 // alert notifications to Google chat.
 type GoogleChatNotifier struct {
 	old_notifiers.NotifierBase
-	URL  string
-	log  log.Logger
-	tmpl *template.Template
+	URL           string
+	ReplyInThread bool
+	log           log.Logger
+	tmpl          *template.Template
 }
 
 func NewGoogleChatNotifier(model *NotificationChannelConfig, t *template.Template) (*GoogleChatNotifier, error) {
-	url := model.Settings.Get("url").MustString()
-	if url == "" {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	webhookURL := model.Settings.Get("url").MustString()
+	if webhookURL == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
 	}
+	if u, err := url.Parse(webhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Google Chat webhook URL: Must be an absolute URL"}
+	}
 
 	return &GoogleChatNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
@@ -42,9 +56,10 @@ func NewGoogleChatNotifier(model *NotificationChannelConfig, t *template.Templat
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		URL:  url,
-		log:  log.New("alerting.notifier.googlechat"),
-		tmpl: t,
+		URL:           webhookURL,
+		ReplyInThread: model.Settings.Get("reply_in_thread").MustBool(false),
+		log:           log.New("alerting.notifier.googlechat"),
+		tmpl:          t,
 	}, nil
 }
 
@@ -54,17 +69,26 @@ func (gcn *GoogleChatNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 
 	data := notify.GetTemplateData(ctx, gcn.tmpl, as, gokit_log.NewNopLogger())
 	var tmplErr error
-	tmpl := notify.TmplText(gcn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, gcn.tmpl, data, &tmplErr)
 
-	widgets := []widget{}
+	var sections []section
+
+	// The labels all alerts have in common identify the group.
+	if labels := data.CommonLabels.SortedPairs(); len(labels) > 0 {
+		labelSection := section{Header: "Labels"}
+		for _, l := range labels {
+			labelSection.Widgets = append(labelSection.Widgets, widget{
+				DecoratedText: &decoratedText{TopLabel: l.Name, Text: l.Value},
+			})
+		}
+		sections = append(sections, labelSection)
+	}
 
 	if msg := tmpl(`{{ template "default.message" . }}`); msg != "" {
 		// Add a text paragraph widget for the message if there is a message.
 		// Google Chat API doesn't accept an empty text property.
-		widgets = append(widgets, textParagraphWidget{
-			Text: text{
-				Text: msg,
-			},
+		sections = append(sections, section{
+			Widgets: []widget{{TextParagraph: &textParagraph{Text: msg}}},
 		})
 	}
 
@@ -72,42 +96,45 @@ func (gcn *GoogleChatNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 	if err != nil {
 		return false, err
 	}
-	// Add a button widget (link to Grafana).
-	widgets = append(widgets, buttonWidget{
-		Buttons: []button{
+	sections = append(sections, section{
+		Widgets: []widget{
+			// Add a button widget (link to Grafana).
 			{
-				TextButton: textButton{
-					Text: "OPEN IN GRAFANA",
-					OnClick: onClick{
-						OpenLink: openLink{
-							URL: ruleURL,
+				ButtonList: &buttonList{
+					Buttons: []button{
+						{
+							Text: "OPEN IN GRAFANA",
+							OnClick: onClick{
+								OpenLink: openLink{
+									URL: ruleURL,
+								},
+							},
 						},
 					},
 				},
 			},
+			// Add text paragraph widget for the build version and timestamp.
+			{
+				TextParagraph: &textParagraph{
+					Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+				},
+			},
 		},
 	})
 
-	// Add text paragraph widget for the build version and timestamp.
-	widgets = append(widgets, textParagraphWidget{
-		Text: text{
-			Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
-		},
-	})
-
-	// Nest the required structs.
+	// Nest the required structs. Clients that can't show cards, like
+	// notifications on phones, show the text instead.
 	res := &outerStruct{
-		PreviewText:  tmpl(`{{ template "default.title" . }}`),
-		FallbackText: tmpl(`{{ template "default.title" . }}`),
-		Cards: []card{
+		Text: tmpl(`{{ template "default.title" . }}`),
+		CardsV2: []cardWithID{
 			{
-				Header: header{
-					Title: tmpl(`{{ template "default.title" . }}`),
-				},
-				Sections: []section{
-					{
-						Widgets: widgets,
+				CardID: "alert",
+				Card: card{
+					Header: header{
+						Title:    tmpl(`{{ template "default.title" . }}`),
+						Subtitle: googleChatSubtitle(data),
 					},
+					Sections: sections,
 				},
 			},
 		},
@@ -117,13 +144,32 @@ func (gcn *GoogleChatNotifier) Notify(ctx context.Context, as ...*types.Alert) (
 		return false, fmt.Errorf("failed to template GoogleChat message: %w", tmplErr)
 	}
 
+	webhookURL := gcn.URL
+	// Follow-up notifications of an alert group are replies to its thread.
+	if gcn.ReplyInThread {
+		key, err := threadKey(ctx)
+		if err != nil {
+			return false, err
+		}
+		res.Thread = &thread{ThreadKey: key}
+
+		u, err := url.Parse(gcn.URL)
+		if err != nil {
+			return false, err
+		}
+		query := u.Query()
+		query.Set("messageReplyOption", googleChatReplyOption)
+		u.RawQuery = query.Encode()
+		webhookURL = u.String()
+	}
+
 	body, err := json.Marshal(res)
 	if err != nil {
 		return false, fmt.Errorf("marshal json: %w", err)
 	}
 
 	cmd := &models.SendWebhookSync{
-		Url:        gcn.URL,
+		Url:        webhookURL,
 		HttpMethod: "POST",
 		HttpHeader: map[string]string{
 			"Content-Type": "application/json; charset=UTF-8",
@@ -143,12 +189,40 @@ func (gcn *GoogleChatNotifier) SendResolved() bool {
 	return !gcn.GetDisableResolveMessage()
 }
 
-// Structs used to build a custom Google Hangouts Chat message card.
-// See: https://developers.google.com/hangouts/chat/reference/message-formats/cards
+// Type returns the kind of the notification channel.
+func (gcn *GoogleChatNotifier) Type() string {
+	return "googlechat"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (gcn *GoogleChatNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
+
+// googleChatSubtitle returns the subtitle of the card header, which tells
+// how many alerts are firing and resolved.
+func googleChatSubtitle(data *template.Data) string {
+	if data.Status == string(model.AlertResolved) {
+		return fmt.Sprintf("%d resolved", len(data.Alerts.Resolved()))
+	}
+	return fmt.Sprintf("%d firing, %d resolved", len(data.Alerts.Firing()), len(data.Alerts.Resolved()))
+}
+
+// Structs used to build a Google Chat message with a card.
+// See: https://developers.google.com/chat/api/reference/rest/v1/cards
 type outerStruct struct {
-	PreviewText  string `json:"previewText"`
-	FallbackText string `json:"fallbackText"`
-	Cards        []card `json:"cards"`
+	Text    string       `json:"text"`
+	CardsV2 []cardWithID `json:"cardsV2"`
+	Thread  *thread      `json:"thread,omitempty"`
+}
+
+type cardWithID struct {
+	CardID string `json:"cardId"`
+	Card   card   `json:"card"`
+}
+
+type thread struct {
+	ThreadKey string `json:"threadKey"`
 }
 
 type card struct {
@@ -157,33 +231,36 @@ type card struct {
 }
 
 type header struct {
-	Title string `json:"title"`
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
 }
 
 type section struct {
+	Header  string   `json:"header,omitempty"`
 	Widgets []widget `json:"widgets"`
 }
 
-// "generic" widget used to add different types of widgets (buttonWidget, textParagraphWidget, imageWidget)
-type widget interface{}
-
-type buttonWidget struct {
-	Buttons []button `json:"buttons"`
+// widget holds exactly one kind of widget.
+type widget struct {
+	TextParagraph *textParagraph `json:"textParagraph,omitempty"`
+	DecoratedText *decoratedText `json:"decoratedText,omitempty"`
+	ButtonList    *buttonList    `json:"buttonList,omitempty"`
 }
 
-type textParagraphWidget struct {
-	Text text `json:"textParagraph"`
+type textParagraph struct {
+	Text string `json:"text"`
 }
 
-type text struct {
-	Text string `json:"text"`
+type decoratedText struct {
+	TopLabel string `json:"topLabel"`
+	Text     string `json:"text"`
 }
 
-type button struct {
-	TextButton textButton `json:"textButton"`
+type buttonList struct {
+	Buttons []button `json:"buttons"`
 }
 
-type textButton struct {
+type button struct {
 	Text    string  `json:"text"`
 	OnClick onClick `json:"onClick"`
 }
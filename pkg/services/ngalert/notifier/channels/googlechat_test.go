@@ -26,6 +26,10 @@ func TestGoogleChatNotifier(t *testing.T) {
 	require.NoError(t, err)
 	tmpl.ExternalURL = externalURL
 
+	// The notifications below are sent with the group key "alertname".
+	groupThreadKey, err := threadKey(notify.WithGroupKey(context.Background(), "alertname"))
+	require.NoError(t, err)
+
 	cases := []struct {
 		name         string
 		settings     string
@@ -46,39 +50,50 @@ func TestGoogleChatNotifier(t *testing.T) {
 				},
 			},
 			expMsg: &outerStruct{
-				PreviewText:  "[FIRING:1]  (val1)",
-				FallbackText: "[FIRING:1]  (val1)",
-				Cards: []card{
+				Text: "[FIRING:1]  (val1)",
+				CardsV2: []cardWithID{
 					{
-						Header: header{
-							Title: "[FIRING:1]  (val1)",
-						},
-						Sections: []section{
-							{
-								Widgets: []widget{
-									textParagraphWidget{
-										Text: text{
-											Text: "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
+						CardID: "alert",
+						Card: card{
+							Header: header{
+								Title:    "[FIRING:1]  (val1)",
+								Subtitle: "1 firing, 0 resolved",
+							},
+							Sections: []section{
+								{
+									Header: "Labels",
+									Widgets: []widget{
+										{DecoratedText: &decoratedText{TopLabel: "alertname", Text: "alert1"}},
+										{DecoratedText: &decoratedText{TopLabel: "lbl1", Text: "val1"}},
+									},
+								}, {
+									Widgets: []widget{
+										{
+											TextParagraph: &textParagraph{
+												Text: "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
+											},
 										},
 									},
-									buttonWidget{
-										Buttons: []button{
-											{
-												TextButton: textButton{
-													Text: "OPEN IN GRAFANA",
-													OnClick: onClick{
-														OpenLink: openLink{
-															URL: "http://localhost/alerting/list",
+								}, {
+									Widgets: []widget{
+										{
+											ButtonList: &buttonList{
+												Buttons: []button{
+													{
+														Text: "OPEN IN GRAFANA",
+														OnClick: onClick{
+															OpenLink: openLink{
+																URL: "http://localhost/alerting/list",
+															},
 														},
 													},
 												},
 											},
-										},
-									},
-									textParagraphWidget{
-										Text: text{
-											// RFC822 only has the minute, hence it works in most cases.
-											Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+										}, {
+											TextParagraph: &textParagraph{
+												// RFC822 only has the minute, hence it works in most cases.
+												Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+											},
 										},
 									},
 								},
@@ -106,38 +121,177 @@ func TestGoogleChatNotifier(t *testing.T) {
 				},
 			},
 			expMsg: &outerStruct{
-				PreviewText:  "[FIRING:2]  ",
-				FallbackText: "[FIRING:2]  ",
-				Cards: []card{
+				Text: "[FIRING:2]  ",
+				CardsV2: []cardWithID{
 					{
-						Header: header{
-							Title: "[FIRING:2]  ",
+						CardID: "alert",
+						Card: card{
+							Header: header{
+								Title:    "[FIRING:2]  ",
+								Subtitle: "2 firing, 0 resolved",
+							},
+							Sections: []section{
+								{
+									Header: "Labels",
+									Widgets: []widget{
+										{DecoratedText: &decoratedText{TopLabel: "alertname", Text: "alert1"}},
+									},
+								}, {
+									Widgets: []widget{
+										{
+											TextParagraph: &textParagraph{
+												Text: "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n\n\n\n\n",
+											},
+										},
+									},
+								}, {
+									Widgets: []widget{
+										{
+											ButtonList: &buttonList{
+												Buttons: []button{
+													{
+														Text: "OPEN IN GRAFANA",
+														OnClick: onClick{
+															OpenLink: openLink{
+																URL: "http://localhost/alerting/list",
+															},
+														},
+													},
+												},
+											},
+										}, {
+											TextParagraph: &textParagraph{
+												Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+											},
+										},
+									},
+								},
+							},
 						},
-						Sections: []section{
-							{
-								Widgets: []widget{
-									textParagraphWidget{
-										Text: text{
-											Text: "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n\n\n\n\n",
+					},
+				},
+			},
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Resolved alert",
+			settings: `{"url": "http://localhost"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1"},
+						StartsAt: time.Now().Add(-2 * time.Hour),
+						EndsAt:   time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: &outerStruct{
+				Text: "[RESOLVED]  ",
+				CardsV2: []cardWithID{
+					{
+						CardID: "alert",
+						Card: card{
+							Header: header{
+								Title:    "[RESOLVED]  ",
+								Subtitle: "1 resolved",
+							},
+							Sections: []section{
+								{
+									Header: "Labels",
+									Widgets: []widget{
+										{DecoratedText: &decoratedText{TopLabel: "alertname", Text: "alert1"}},
+									},
+								}, {
+									Widgets: []widget{
+										{
+											TextParagraph: &textParagraph{
+												Text: "\n\n**Resolved**\nLabels:\n - alertname = alert1\nAnnotations:\nSource: \n\n\n",
+											},
 										},
 									},
-									buttonWidget{
-										Buttons: []button{
-											{
-												TextButton: textButton{
-													Text: "OPEN IN GRAFANA",
-													OnClick: onClick{
-														OpenLink: openLink{
-															URL: "http://localhost/alerting/list",
+								}, {
+									Widgets: []widget{
+										{
+											ButtonList: &buttonList{
+												Buttons: []button{
+													{
+														Text: "OPEN IN GRAFANA",
+														OnClick: onClick{
+															OpenLink: openLink{
+																URL: "http://localhost/alerting/list",
+															},
 														},
 													},
 												},
 											},
+										}, {
+											TextParagraph: &textParagraph{
+												Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+											},
 										},
 									},
-									textParagraphWidget{
-										Text: text{
-											Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+								},
+							},
+						},
+					},
+				},
+			},
+		}, {
+			name:     "Reply in thread",
+			settings: `{"url": "http://localhost", "reply_in_thread": true}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg: &outerStruct{
+				Text:   "[FIRING:1]  (val1)",
+				Thread: &thread{ThreadKey: groupThreadKey},
+				CardsV2: []cardWithID{
+					{
+						CardID: "alert",
+						Card: card{
+							Header: header{
+								Title:    "[FIRING:1]  (val1)",
+								Subtitle: "1 firing, 0 resolved",
+							},
+							Sections: []section{
+								{
+									Header: "Labels",
+									Widgets: []widget{
+										{DecoratedText: &decoratedText{TopLabel: "alertname", Text: "alert1"}},
+										{DecoratedText: &decoratedText{TopLabel: "lbl1", Text: "val1"}},
+									},
+								}, {
+									Widgets: []widget{
+										{
+											TextParagraph: &textParagraph{
+												Text: "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
+											},
+										},
+									},
+								}, {
+									Widgets: []widget{
+										{
+											ButtonList: &buttonList{
+												Buttons: []button{
+													{
+														Text: "OPEN IN GRAFANA",
+														OnClick: onClick{
+															OpenLink: openLink{
+																URL: "http://localhost/alerting/list",
+															},
+														},
+													},
+												},
+											},
+										}, {
+											TextParagraph: &textParagraph{
+												Text: "Grafana v" + setting.BuildVersion + " | " + (time.Now()).Format(time.RFC822),
+											},
 										},
 									},
 								},
@@ -146,12 +300,14 @@ func TestGoogleChatNotifier(t *testing.T) {
 					},
 				},
 			},
-			expInitError: nil,
-			expMsgError:  nil,
 		}, {
 			name:         "Error in initing",
 			settings:     `{}`,
 			expInitError: alerting.ValidationError{Reason: "Could not find url property in settings"},
+		}, {
+			name:         "Error in initing, invalid URL",
+			settings:     `{"url": "chat.googleapis.com/v1/spaces/AAAA/messages"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Google Chat webhook URL: Must be an absolute URL"},
 		},
 	}
 
@@ -205,3 +361,67 @@ func TestGoogleChatNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestGoogleChatNotifierThread(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(replyInThread bool) *GoogleChatNotifier {
+		pn, err := NewGoogleChatNotifier(&NotificationChannelConfig{
+			Name: "googlechat_testing",
+			Type: "googlechat",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"url":             "https://chat.googleapis.com/v1/spaces/AAAA/messages?key=k&token=t",
+				"reply_in_thread": replyInThread,
+			}),
+		}, tmpl)
+		require.NoError(t, err)
+		return pn
+	}
+
+	send := func(pn *GoogleChatNotifier, groupKey string) (*models.SendWebhookSync, outerStruct) {
+		var cmd *models.SendWebhookSync
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			cmd = webhook
+			return nil
+		})
+		ok, err := pn.Notify(notify.WithGroupKey(context.Background(), groupKey), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var msg outerStruct
+		require.NoError(t, json.Unmarshal([]byte(cmd.Body), &msg))
+		return cmd, msg
+	}
+
+	t.Run("Notifications of a group are replies to its thread", func(t *testing.T) {
+		pn := newNotifier(true)
+		cmd, first := send(pn, "{}:{alertname=\"alert1\"}")
+		_, second := send(pn, "{}:{alertname=\"alert1\"}")
+		_, other := send(pn, "{}:{alertname=\"alert2\"}")
+
+		expKey, err := threadKey(notify.WithGroupKey(context.Background(), "{}:{alertname=\"alert1\"}"))
+		require.NoError(t, err)
+		require.Equal(t, &thread{ThreadKey: expKey}, first.Thread)
+		require.Equal(t, first.Thread, second.Thread)
+		require.NotEqual(t, first.Thread, other.Thread)
+
+		u, err := url.Parse(cmd.Url)
+		require.NoError(t, err)
+		require.Equal(t, url.Values{
+			"key":                {"k"},
+			"token":              {"t"},
+			"messageReplyOption": {"REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"},
+		}, u.Query())
+	})
+
+	t.Run("No thread by default", func(t *testing.T) {
+		pn := newNotifier(false)
+		cmd, msg := send(pn, "{}:{alertname=\"alert1\"}")
+		require.Nil(t, msg.Thread)
+		require.Equal(t, "https://chat.googleapis.com/v1/spaces/AAAA/messages?key=k&token=t", cmd.Url)
+	})
+}
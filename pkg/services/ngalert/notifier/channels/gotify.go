@@ -0,0 +1,213 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+// gotifyDefaultPriority is the priority of messages for alerts whose
+// severity isn't mapped to a priority.
+const gotifyDefaultPriority = 1
+
+// GotifyNotifier is responsible for sending
+// alert notifications to Gotify.
+type GotifyNotifier struct {
+	old_notifiers.NotifierBase
+	URL             string
+	Title           string
+	Message         string
+	SeverityLabel   string
+	Priorities      map[string]int
+	DefaultPriority int
+	retry           retryOptions
+	httpOptions     httpOptions
+	metrics         *DeliveryMetrics
+	log             log.Logger
+	tmpl            *template.Template
+}
+
+// NewGotifyNotifier is the constructor for the Gotify notifier
+func NewGotifyNotifier(model *NotificationChannelConfig, t *template.Template) (*GotifyNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	serverURL := model.Settings.Get("server_url").MustString()
+	if serverURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Gotify server URL in settings"}
+	}
+	if u, err := url.Parse(serverURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Gotify server URL: Must be an absolute URL"}
+	}
+	appToken := model.DecryptedValue("app_token", model.Settings.Get("app_token").MustString())
+	if appToken == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Gotify application token in settings"}
+	}
+	messageURL, err := joinUrlPath(serverURL, "/message")
+	if err != nil {
+		return nil, err
+	}
+	messageURL += "?" + url.Values{"token": {appToken}}.Encode()
+
+	title := model.Settings.Get("title").MustString()
+	if title == "" {
+		title = `{{ template "default.title" . }}`
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	priorities, err := parseGotifyPriorities(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultPriority, ok := intSetting(model.Settings, "default_priority", gotifyDefaultPriority)
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid Gotify default priority: Must be a number"}
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.gotify")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &GotifyNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:             messageURL,
+		Title:           title,
+		Message:         message,
+		SeverityLabel:   model.Settings.Get("severity_label").MustString("severity"),
+		Priorities:      priorities,
+		DefaultPriority: defaultPriority,
+		retry:           newRetryOptions(maxRetries),
+		httpOptions:     httpOpts,
+		metrics:         model.Metrics,
+		log:             logger,
+		tmpl:            t,
+	}, nil
+}
+
+// parseGotifyPriorities reads the mapping of severities to Gotify
+// priorities, which extends the mapping of critical and warning alerts.
+func parseGotifyPriorities(settings *simplejson.Json) (map[string]int, error) {
+	priorities := map[string]int{
+		"critical": 8,
+		"warning":  5,
+	}
+	settingPriorities, ok := jsonSetting(settings, "priorities")
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid Gotify priorities: Must be an object of severities and priorities"}
+	}
+	for severity := range settingPriorities.MustMap() {
+		priority, err := settingPriorities.Get(severity).Int()
+		if err != nil {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid Gotify priority for %s: Must be a number", severity)}
+		}
+		priorities[strings.ToLower(severity)] = priority
+	}
+	return priorities, nil
+}
+
+type gotifyMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// Notify sends an alert notification to Gotify
+func (gn *GotifyNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := gn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Gotify notification", "notification", gn.Name)
+
+	data := notify.GetTemplateData(ctx, gn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, gn.tmpl, data, &tmplErr)
+
+	msg := gotifyMessage{
+		Title:    tmpl(gn.Title),
+		Message:  tmpl(gn.Message),
+		Priority: gn.priority(data.CommonLabels),
+	}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Gotify message: %w", tmplErr)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        gn.URL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+	if err := gn.httpOptions.apply(ctx, cmd, gn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, gn.retry)
+	gn.metrics.observe("gotify", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to send Gotify notification", "error", err, "webhook", gn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// priority returns the Gotify priority for the severity in labels.
+func (gn *GotifyNotifier) priority(labels template.KV) int {
+	if priority, ok := gn.Priorities[strings.ToLower(labels[gn.SeverityLabel])]; ok {
+		return priority
+	}
+	return gn.DefaultPriority
+}
+
+func (gn *GotifyNotifier) SendResolved() bool {
+	return !gn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (gn *GotifyNotifier) Type() string {
+	return "gotify"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (gn *GotifyNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,204 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestGotifyNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"server_url": "https://gotify.example.org", "app_token": "AbCdEf.123"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expURL:       "https://gotify.example.org/message?token=AbCdEf.123",
+			expMsg:       `{"title":"[FIRING:1]  (val1)","message":"\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n","priority":1}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Custom title and message",
+			settings: `{
+				"server_url": "https://example.org/gotify/",
+				"app_token": "AbCdEf.123",
+				"title": "{{ .CommonLabels.alertname }}",
+				"message": "{{ len .Alerts.Firing }} firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical"},
+					},
+				},
+			},
+			expURL:       "https://example.org/gotify/message?token=AbCdEf.123",
+			expMsg:       `{"title":"alert1","message":"1 firing","priority":8}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Server URL missing",
+			settings:     `{"app_token": "AbCdEf.123"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Gotify server URL in settings"},
+		}, {
+			name:         "Invalid server URL",
+			settings:     `{"server_url": "gotify.example.org", "app_token": "AbCdEf.123"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Gotify server URL: Must be an absolute URL"},
+		}, {
+			name:         "Token missing",
+			settings:     `{"server_url": "https://gotify.example.org"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Gotify application token in settings"},
+		}, {
+			name:         "Invalid priority",
+			settings:     `{"server_url": "https://gotify.example.org", "app_token": "AbCdEf.123", "priorities": {"error": "high"}}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Gotify priority for error: Must be a number"},
+		}, {
+			name:         "Priorities that aren't JSON",
+			settings:     `{"server_url": "https://gotify.example.org", "app_token": "AbCdEf.123", "priorities": "error=6"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Gotify priorities: Must be an object of severities and priorities"},
+		}, {
+			name:         "Invalid default priority",
+			settings:     `{"server_url": "https://gotify.example.org", "app_token": "AbCdEf.123", "default_priority": "high"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Gotify default priority: Must be a number"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "gotify_testing",
+				Type:     "gotify",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewGotifyNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			body := ""
+			webhookURL := ""
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				webhookURL = webhook.Url
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookURL)
+			require.JSONEq(t, c.expMsg, body)
+		})
+	}
+}
+
+func TestGotifyNotifierPriority(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	cases := []struct {
+		name        string
+		settings    map[string]interface{}
+		labels      map[string]string
+		expPriority int
+	}{
+		{name: "Critical", labels: map[string]string{"severity": "critical"}, expPriority: 8},
+		{name: "Warning", labels: map[string]string{"severity": "warning"}, expPriority: 5},
+		{name: "Case insensitive", labels: map[string]string{"severity": "Warning"}, expPriority: 5},
+		{name: "Unknown severity", labels: map[string]string{"severity": "info"}, expPriority: 1},
+		{name: "No severity", labels: map[string]string{}, expPriority: 1},
+		{
+			name:        "Custom mapping",
+			settings:    map[string]interface{}{"priorities": map[string]interface{}{"info": 2, "critical": 10}},
+			labels:      map[string]string{"severity": "critical"},
+			expPriority: 10,
+		}, {
+			name:        "Custom mapping extends the defaults",
+			settings:    map[string]interface{}{"priorities": map[string]interface{}{"info": 2}},
+			labels:      map[string]string{"severity": "warning"},
+			expPriority: 5,
+		}, {
+			name:        "Custom mapping from a text area",
+			settings:    map[string]interface{}{"priorities": `{"info": 2}`},
+			labels:      map[string]string{"severity": "info"},
+			expPriority: 2,
+		}, {
+			name:        "Custom default from a form",
+			settings:    map[string]interface{}{"default_priority": "3"},
+			labels:      map[string]string{"severity": "info"},
+			expPriority: 3,
+		}, {
+			name:        "Custom default",
+			settings:    map[string]interface{}{"default_priority": 3},
+			labels:      map[string]string{"severity": "info"},
+			expPriority: 3,
+		}, {
+			name:        "Custom severity label",
+			settings:    map[string]interface{}{"severity_label": "level"},
+			labels:      map[string]string{"level": "critical", "severity": "warning"},
+			expPriority: 8,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := map[string]interface{}{"server_url": "https://gotify.example.org", "app_token": "AbCdEf.123"}
+			for k, v := range c.settings {
+				settings[k] = v
+			}
+			pn, err := NewGotifyNotifier(&NotificationChannelConfig{
+				Name:     "gotify_testing",
+				Type:     "gotify",
+				Settings: simplejson.NewFromAny(settings),
+			}, tmpl)
+			require.NoError(t, err)
+
+			require.Equal(t, c.expPriority, pn.priority(c.labels))
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package channels
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// httpOptions are the transport settings shared by the notifiers that send
+// their notifications with models.SendWebhookSync.
+type httpOptions struct {
+	ProxyURL  string
+	Timeout   time.Duration
+	TLSConfig *tls.Config
+	// TLSConfigKey identifies the settings TLSConfig was parsed from, so
+	// that notifiers with the same settings share their connections.
+	TLSConfigKey string
+	// Headers are additional HTTP headers whose values are templates.
+	Headers   map[string]string
+	UserAgent string
+}
+
+// defaultHTTPTimeout is used when a notification channel has no timeout.
+const defaultHTTPTimeout = 30 * time.Second
+
+// parseHTTPOptions reads the transport settings of a notification channel.
+func parseHTTPOptions(model *NotificationChannelConfig) (httpOptions, error) {
+	settings := model.Settings
+	opts := httpOptions{
+		Timeout:   defaultHTTPTimeout,
+		UserAgent: settings.Get("user_agent").MustString(fmt.Sprintf("Grafana/%s", setting.BuildVersion)),
+	}
+
+	if proxy := settings.Get("http_proxy").MustString(); proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return opts, alerting.ValidationError{Reason: "Invalid HTTP proxy URL: Must be an absolute URL"}
+		}
+		opts.ProxyURL = proxy
+	}
+
+	if timeout := settings.Get("timeout").MustString(); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return opts, alerting.ValidationError{Reason: "Invalid HTTP timeout: Must be a positive duration such as 30s"}
+		}
+		opts.Timeout = d
+	}
+
+	headers, ok := jsonSetting(settings, "http_headers")
+	if !ok {
+		return opts, alerting.ValidationError{Reason: "Invalid HTTP headers: Must be an object of header names and values"}
+	}
+	for name, v := range headers.MustMap() {
+		value, ok := v.(string)
+		if !ok {
+			return opts, alerting.ValidationError{Reason: fmt.Sprintf("Invalid HTTP header %s: Must be a string", name)}
+		}
+		if opts.Headers == nil {
+			opts.Headers = map[string]string{}
+		}
+		opts.Headers[name] = value
+	}
+
+	tlsConfig, tlsConfigKey, err := parseTLSConfig(model)
+	if err != nil {
+		return opts, err
+	}
+	opts.TLSConfig = tlsConfig
+	opts.TLSConfigKey = tlsConfigKey
+
+	return opts, nil
+}
+
+// warnInsecure logs a warning with logger if the TLS certificate verification
+// of the channel is disabled. That is only meant for gateways with self-signed
+// certificates, so notifiers call it when they are created to make sure it
+// doesn't go unnoticed.
+func (o httpOptions) warnInsecure(logger log.Logger, model *NotificationChannelConfig) {
+	if o.TLSConfig != nil && o.TLSConfig.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification is disabled", "name", model.Name, "uid", model.UID, "setting", "tls_skip_verify")
+	}
+}
+
+// parseTLSConfig reads the PEM encoded client certificate, key and CA
+// certificate of a notification channel, and whether to skip the
+// verification of the server certificate. It returns nil if none is set.
+// The key is the hash of the settings, which identifies the configuration
+// without revealing the client key.
+func parseTLSConfig(model *NotificationChannelConfig) (*tls.Config, string, error) {
+	clientCert := model.Settings.Get("tls_client_cert").MustString()
+	clientKey := model.DecryptedValue("tls_client_key", model.Settings.Get("tls_client_key").MustString())
+	caCert := model.Settings.Get("tls_ca_cert").MustString()
+	skipVerify := model.Settings.Get("tls_skip_verify").MustBool(false)
+	if clientCert == "" && clientKey == "" && caCert == "" && !skipVerify {
+		return nil, "", nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if skipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if clientCert != "" || clientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, "", alerting.ValidationError{Reason: "Invalid TLS client certificate: Must be a PEM encoded certificate and matching key"}
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, "", alerting.ValidationError{Reason: "Invalid TLS CA certificate: Must be a PEM encoded certificate"}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	h := sha256.New()
+	for _, s := range []string{clientCert, clientKey, caCert, strconv.FormatBool(skipVerify)} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	return tlsConfig, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// client returns an HTTP client with the transport settings, for requests
+// that are not sent with models.SendWebhookSync. It is the client webhooks
+// with the same settings are sent with, so that they share a connection pool.
+// Unless the client is shared, the caller must close its idle connections
+// after sending.
+func (o httpOptions) client() (*http.Client, bool, error) {
+	return notifications.WebhookClient(&notifications.Webhook{
+		ProxyURL:     o.ProxyURL,
+		Timeout:      o.Timeout,
+		TLSConfig:    o.TLSConfig,
+		TLSConfigKey: o.TLSConfigKey,
+	})
+}
+
+// apply sets the transport settings on cmd. The additional headers are
+// rendered for as and take precedence over the headers of the notifier.
+func (o httpOptions) apply(ctx context.Context, cmd *models.SendWebhookSync, t *template.Template, as []*types.Alert) error {
+	cmd.ProxyURL = o.ProxyURL
+	cmd.Timeout = o.Timeout
+	cmd.TLSConfig = o.TLSConfig
+	cmd.TLSConfigKey = o.TLSConfigKey
+	if cmd.HttpHeader == nil {
+		cmd.HttpHeader = map[string]string{}
+	}
+	cmd.HttpHeader["User-Agent"] = o.UserAgent
+
+	if len(o.Headers) == 0 {
+		return nil
+	}
+	data := notify.GetTemplateData(ctx, t, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, t, data, &tmplErr)
+	for name, value := range o.Headers {
+		cmd.HttpHeader[name] = tmpl(value)
+	}
+	if tmplErr != nil {
+		return fmt.Errorf("failed to template HTTP headers: %w", tmplErr)
+	}
+	return nil
+}
@@ -0,0 +1,433 @@
+package channels
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestParseHTTPOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings string
+		expOpts  httpOptions
+		expErr   error
+	}{
+		{
+			name:     "No settings",
+			settings: `{}`,
+			expOpts:  httpOptions{Timeout: 30 * time.Second, UserAgent: "Grafana/" + setting.BuildVersion},
+		}, {
+			name:     "Proxy",
+			settings: `{"http_proxy": "http://proxy.internal:3128"}`,
+			expOpts:  httpOptions{ProxyURL: "http://proxy.internal:3128", Timeout: 30 * time.Second, UserAgent: "Grafana/" + setting.BuildVersion},
+		}, {
+			name:     "Proxy without scheme",
+			settings: `{"http_proxy": "proxy.internal:3128"}`,
+			expErr:   alerting.ValidationError{Reason: "Invalid HTTP proxy URL: Must be an absolute URL"},
+		}, {
+			name:     "Malformed proxy",
+			settings: `{"http_proxy": "http://proxy internal"}`,
+			expErr:   alerting.ValidationError{Reason: "Invalid HTTP proxy URL: Must be an absolute URL"},
+		}, {
+			name:     "Timeout",
+			settings: `{"timeout": "1m30s"}`,
+			expOpts:  httpOptions{Timeout: 90 * time.Second, UserAgent: "Grafana/" + setting.BuildVersion},
+		}, {
+			name:     "Headers",
+			settings: `{"http_headers": {"Authorization": "Bearer {{ .CommonLabels.team }}"}}`,
+			expOpts: httpOptions{
+				Timeout:   30 * time.Second,
+				Headers:   map[string]string{"Authorization": "Bearer {{ .CommonLabels.team }}"},
+				UserAgent: "Grafana/" + setting.BuildVersion,
+			},
+		}, {
+			name:     "Headers from a text area",
+			settings: `{"http_headers": "{\"X-Team\": \"db\"}"}`,
+			expOpts: httpOptions{
+				Timeout:   30 * time.Second,
+				Headers:   map[string]string{"X-Team": "db"},
+				UserAgent: "Grafana/" + setting.BuildVersion,
+			},
+		}, {
+			name:     "Headers that aren't JSON",
+			settings: `{"http_headers": "X-Team: db"}`,
+			expErr:   alerting.ValidationError{Reason: "Invalid HTTP headers: Must be an object of header names and values"},
+		}, {
+			name:     "Header that isn't a string",
+			settings: `{"http_headers": {"X-Retries": 3}}`,
+			expErr:   alerting.ValidationError{Reason: "Invalid HTTP header X-Retries: Must be a string"},
+		}, {
+			name:     "User agent",
+			settings: `{"user_agent": "Grafana-Alerting"}`,
+			expOpts:  httpOptions{Timeout: 30 * time.Second, UserAgent: "Grafana-Alerting"},
+		}, {
+			name:     "Invalid timeout",
+			settings: `{"timeout": "30"}`,
+			expErr:   alerting.ValidationError{Reason: "Invalid HTTP timeout: Must be a positive duration such as 30s"},
+		}, {
+			name:     "Negative timeout",
+			settings: `{"timeout": "-5s"}`,
+			expErr:   alerting.ValidationError{Reason: "Invalid HTTP timeout: Must be a positive duration such as 30s"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			opts, err := parseHTTPOptions(&NotificationChannelConfig{Settings: settingsJSON})
+			if c.expErr != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expErr.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expOpts, opts)
+		})
+	}
+}
+
+func TestParseHTTPOptionsTLS(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	otherCertPEM, otherKeyPEM := testCertificate(t)
+
+	t.Run("Client certificate and CA", func(t *testing.T) {
+		opts, err := parseHTTPOptions(&NotificationChannelConfig{Settings: simplejson.NewFromAny(map[string]interface{}{
+			"tls_client_cert": certPEM,
+			"tls_client_key":  keyPEM,
+			"tls_ca_cert":     otherCertPEM,
+		})})
+		require.NoError(t, err)
+		require.NotNil(t, opts.TLSConfig)
+		require.Len(t, opts.TLSConfig.Certificates, 1)
+		require.NotNil(t, opts.TLSConfig.RootCAs)
+	})
+
+	t.Run("Client key from the secure settings", func(t *testing.T) {
+		opts, err := parseHTTPOptions(&NotificationChannelConfig{
+			Settings:       simplejson.NewFromAny(map[string]interface{}{"tls_client_cert": certPEM}),
+			SecureSettings: securejsondata.GetEncryptedJsonData(map[string]string{"tls_client_key": keyPEM}),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, opts.TLSConfig)
+		require.Len(t, opts.TLSConfig.Certificates, 1)
+	})
+
+	t.Run("Skip verify", func(t *testing.T) {
+		opts, err := parseHTTPOptions(&NotificationChannelConfig{Settings: simplejson.NewFromAny(map[string]interface{}{"tls_skip_verify": true})})
+		require.NoError(t, err)
+		require.NotNil(t, opts.TLSConfig)
+		require.True(t, opts.TLSConfig.InsecureSkipVerify)
+	})
+
+	t.Run("Skip verify disabled", func(t *testing.T) {
+		opts, err := parseHTTPOptions(&NotificationChannelConfig{Settings: simplejson.NewFromAny(map[string]interface{}{"tls_skip_verify": false})})
+		require.NoError(t, err)
+		require.Nil(t, opts.TLSConfig)
+	})
+
+	t.Run("CA only", func(t *testing.T) {
+		opts, err := parseHTTPOptions(&NotificationChannelConfig{Settings: simplejson.NewFromAny(map[string]interface{}{"tls_ca_cert": certPEM})})
+		require.NoError(t, err)
+		require.NotNil(t, opts.TLSConfig)
+		require.Empty(t, opts.TLSConfig.Certificates)
+		require.NotNil(t, opts.TLSConfig.RootCAs)
+	})
+
+	cases := []struct {
+		name     string
+		settings map[string]interface{}
+		expErr   error
+	}{
+		{
+			name:     "Mismatched key",
+			settings: map[string]interface{}{"tls_client_cert": certPEM, "tls_client_key": otherKeyPEM},
+			expErr:   alerting.ValidationError{Reason: "Invalid TLS client certificate: Must be a PEM encoded certificate and matching key"},
+		}, {
+			name:     "Key missing",
+			settings: map[string]interface{}{"tls_client_cert": certPEM},
+			expErr:   alerting.ValidationError{Reason: "Invalid TLS client certificate: Must be a PEM encoded certificate and matching key"},
+		}, {
+			name:     "Certificate not PEM encoded",
+			settings: map[string]interface{}{"tls_client_cert": "not a certificate", "tls_client_key": keyPEM},
+			expErr:   alerting.ValidationError{Reason: "Invalid TLS client certificate: Must be a PEM encoded certificate and matching key"},
+		}, {
+			name:     "Invalid CA",
+			settings: map[string]interface{}{"tls_ca_cert": "not a certificate"},
+			expErr:   alerting.ValidationError{Reason: "Invalid TLS CA certificate: Must be a PEM encoded certificate"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseHTTPOptions(&NotificationChannelConfig{Settings: simplejson.NewFromAny(c.settings)})
+			require.Error(t, err)
+			require.Equal(t, c.expErr.Error(), err.Error())
+		})
+	}
+
+	t.Run("Notifier rejects bad key pair", func(t *testing.T) {
+		_, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":      "*1234567",
+				"recipient_id":    "87654321",
+				"api_secret":      "supersecret12345",
+				"tls_client_cert": certPEM,
+				"tls_client_key":  otherKeyPEM,
+			}),
+		}, templateForTests(t))
+		require.Error(t, err)
+		require.IsType(t, alerting.ValidationError{}, err)
+	})
+}
+
+func TestHTTPOptionsClientCache(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	otherCertPEM, otherKeyPEM := testCertificate(t)
+	client := func(settings map[string]interface{}) *http.Client {
+		t.Helper()
+		opts, err := parseHTTPOptions(&NotificationChannelConfig{Settings: simplejson.NewFromAny(settings)})
+		require.NoError(t, err)
+		c, shared, err := opts.client()
+		require.NoError(t, err)
+		require.True(t, shared)
+		return c
+	}
+	settings := func(extra map[string]interface{}) map[string]interface{} {
+		s := map[string]interface{}{
+			"http_proxy":      "http://proxy.internal:3128",
+			"timeout":         "10s",
+			"tls_client_cert": certPEM,
+			"tls_client_key":  keyPEM,
+		}
+		for k, v := range extra {
+			s[k] = v
+		}
+		return s
+	}
+
+	c := client(settings(nil))
+	require.Same(t, c, client(settings(nil)))
+	require.Same(t, client(map[string]interface{}{}), client(map[string]interface{}{}))
+
+	for name, extra := range map[string]map[string]interface{}{
+		"proxy":              {"http_proxy": "http://other-proxy.internal:3128"},
+		"timeout":            {"timeout": "20s"},
+		"client certificate": {"tls_client_cert": otherCertPEM, "tls_client_key": otherKeyPEM},
+		"CA":                 {"tls_ca_cert": otherCertPEM},
+		"skip verify":        {"tls_skip_verify": true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NotSame(t, c, client(settings(extra)))
+		})
+	}
+
+	t.Run("TLS configuration without a key", func(t *testing.T) {
+		opts := httpOptions{Timeout: time.Second, TLSConfig: &tls.Config{}}
+		first, shared, err := opts.client()
+		require.NoError(t, err)
+		require.False(t, shared)
+		second, _, err := opts.client()
+		require.NoError(t, err)
+		require.NotSame(t, first, second)
+	})
+}
+
+// testCertificate returns a PEM encoded self-signed certificate and its key.
+func testCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	certTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "grafana"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+// notifiersWithHTTPOptions builds every notifier that supports the shared
+// HTTP options from the given extra settings.
+func notifiersWithHTTPOptions(t *testing.T, tmpl *template.Template, extraSettings map[string]interface{}) map[string]notify.Notifier {
+	t.Helper()
+
+	newSettings := func(settings map[string]interface{}) *simplejson.Json {
+		for k, v := range extraSettings {
+			settings[k] = v
+		}
+		return simplejson.NewFromAny(settings)
+	}
+
+	threema, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: newSettings(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321",
+			"api_secret":   "supersecret12345",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	line, err := NewLineNotifier(&NotificationChannelConfig{
+		Name:     "line_testing",
+		Type:     "line",
+		Settings: newSettings(map[string]interface{}{"token": "sometoken"}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	return map[string]notify.Notifier{"threema": threema, "line": line}
+}
+
+// sendAndCapture notifies n about a single firing alert and returns the
+// webhook command it dispatched.
+func sendAndCapture(t *testing.T, n notify.Notifier) *models.SendWebhookSync {
+	t.Helper()
+
+	var cmd *models.SendWebhookSync
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		cmd = webhook
+		return nil
+	})
+
+	ok, err := n.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, cmd)
+
+	return cmd
+}
+
+// notifyContext returns a context with the group key and labels that
+// notifiers expect to be set by the Alertmanager.
+func notifyContext() context.Context {
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	return notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+}
+
+func firingAlert() *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1"},
+		},
+	}
+}
+
+func TestNotifiersApplyHTTPOptions(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	certPEM, keyPEM := testCertificate(t)
+	notifiers := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{
+		"http_proxy":      "http://proxy.internal:3128",
+		"timeout":         "10s",
+		"tls_client_cert": certPEM,
+		"tls_client_key":  keyPEM,
+		"tls_skip_verify": true,
+		"user_agent":      "Grafana-Alerting",
+	})
+	for name, n := range notifiers {
+		t.Run(name, func(t *testing.T) {
+			cmd := sendAndCapture(t, n)
+			require.Equal(t, "http://proxy.internal:3128", cmd.ProxyURL)
+			require.Equal(t, 10*time.Second, cmd.Timeout)
+			require.NotNil(t, cmd.TLSConfig)
+			require.Len(t, cmd.TLSConfig.Certificates, 1)
+			require.True(t, cmd.TLSConfig.InsecureSkipVerify)
+			require.NotEmpty(t, cmd.TLSConfigKey)
+			require.Equal(t, "Grafana-Alerting", cmd.HttpHeader["User-Agent"])
+		})
+	}
+
+	defaults := notifiersWithHTTPOptions(t, tmpl, nil)
+	for name, n := range defaults {
+		t.Run(name+" defaults", func(t *testing.T) {
+			cmd := sendAndCapture(t, n)
+			require.Empty(t, cmd.ProxyURL)
+			require.Equal(t, 30*time.Second, cmd.Timeout)
+			require.Nil(t, cmd.TLSConfig)
+			require.Equal(t, "Grafana/"+setting.BuildVersion, cmd.HttpHeader["User-Agent"])
+		})
+	}
+}
+
+func TestNotifiersRenderHTTPHeaders(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	notifiers := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{
+		"http_headers": map[string]interface{}{
+			"Authorization": "Bearer {{ .CommonLabels.alertname }}-token",
+			"X-Source":      "{{ .ExternalURL }}",
+		},
+	})
+	for name, n := range notifiers {
+		t.Run(name, func(t *testing.T) {
+			cmd := sendAndCapture(t, n)
+			require.Equal(t, "Bearer alert1-token", cmd.HttpHeader["Authorization"])
+			require.Equal(t, "http://localhost", cmd.HttpHeader["X-Source"])
+		})
+	}
+
+	broken := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{
+		"http_headers": map[string]interface{}{"Authorization": "Bearer {{ template \"undefined\" . }}"},
+	})
+	for name, n := range broken {
+		t.Run(name+" template error", func(t *testing.T) {
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				return nil
+			})
+			ok, err := n.Notify(notifyContext(), firingAlert())
+			require.False(t, ok)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "failed to template HTTP headers")
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package channels
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/notify"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	// idempotencyKeyHeader is the header of the IETF draft for idempotency
+	// keys, which generic webhook receivers may support.
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	// lineRetryKeyHeader is the header the LINE Messaging API drops
+	// duplicates of retried requests by.
+	lineRetryKeyHeader = "X-Line-Retry-Key"
+)
+
+// setIdempotencyKey makes cmd send an idempotency key in header, which is
+// derived from the group key of the notification and the body of cmd. Every
+// attempt to send the same content for the same group has the same key,
+// whether it is retried by sendWithRetry or the notification is sent again,
+// so that the receiver can drop duplicates of an attempt that timed out but
+// was delivered after all.
+//
+// Only notifiers whose receivers support idempotency keys set them. For the
+// others, such as the Threema Gateway and LINE Notify, it would be a no-op, so
+// they may deliver duplicates.
+func setIdempotencyKey(ctx context.Context, cmd *models.SendWebhookSync, header string) {
+	// Test notifications have no group key, but still deserve a stable key.
+	var groupKey string
+	if key, err := notify.ExtractGroupKey(ctx); err == nil {
+		groupKey = key.String()
+	}
+	cmd.IdempotencyKey = idempotencyKey(groupKey, cmd.Body)
+	cmd.IdempotencyHeader = header
+}
+
+// idempotencyKey returns the idempotency key for sending body for the group
+// with groupKey. It is formatted as a UUID, which some receivers require.
+func idempotencyKey(groupKey, body string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(groupKey))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(body))
+	sum := h.Sum(nil)
+
+	// Mark it as a name-based UUID of version 5 of the RFC 4122 variant.
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
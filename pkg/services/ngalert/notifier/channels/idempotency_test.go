@@ -0,0 +1,95 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	key := idempotencyKey("{}:{alertname=\"alert1\"}", `{"text":"firing"}`)
+	require.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), key)
+
+	require.Equal(t, key, idempotencyKey("{}:{alertname=\"alert1\"}", `{"text":"firing"}`))
+	require.NotEqual(t, key, idempotencyKey("{}:{alertname=\"alert2\"}", `{"text":"firing"}`))
+	require.NotEqual(t, key, idempotencyKey("{}:{alertname=\"alert1\"}", `{"text":"resolved"}`))
+	// The group key and body must not run into each other.
+	require.NotEqual(t, idempotencyKey("ab", "c"), idempotencyKey("a", "bc"))
+}
+
+func TestIdempotencyKeyRetries(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	ln, err := NewLineMessagingNotifier(&NotificationChannelConfig{
+		Name:     "line_messaging_testing",
+		Type:     "line-messaging",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"token": "sometoken", "to": "U1234", "max_retries": 2}),
+	}, tmpl)
+	require.NoError(t, err)
+	ln.retry.initialBackoff = time.Millisecond
+
+	var keys []string
+	respond := func(statuses ...int) {
+		keys = nil
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			require.Equal(t, lineRetryKeyHeader, webhook.IdempotencyHeader)
+			keys = append(keys, webhook.IdempotencyKey)
+			status := statuses[len(keys)-1]
+			if status != http.StatusOK {
+				return models.WebhookStatusError{StatusCode: status, Status: http.StatusText(status)}
+			}
+			return nil
+		})
+	}
+
+	t.Run("Retries have the same key", func(t *testing.T) {
+		respond(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK)
+		ok, err := ln.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, keys, 3)
+		require.NotEmpty(t, keys[0])
+		require.Equal(t, keys[0], keys[1])
+		require.Equal(t, keys[0], keys[2])
+	})
+
+	t.Run("Sending the same notification again has the same key", func(t *testing.T) {
+		respond(http.StatusOK)
+		_, err := ln.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		first := keys[0]
+
+		respond(http.StatusOK)
+		_, err = ln.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.Equal(t, first, keys[0])
+
+		respond(http.StatusOK)
+		ctx := notify.WithGroupKey(context.Background(), "otherkey")
+		_, err = ln.Notify(ctx, firingAlert())
+		require.NoError(t, err)
+		require.NotEqual(t, first, keys[0])
+	})
+
+	t.Run("Conflicts of a delivered retry key succeed", func(t *testing.T) {
+		respond(http.StatusServiceUnavailable, http.StatusConflict)
+		ok, err := ln.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, keys, 2)
+	})
+}
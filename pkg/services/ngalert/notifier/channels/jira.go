@@ -0,0 +1,333 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+const (
+	// jiraMaxSummaryLength is the longest summary Jira accepts.
+	jiraMaxSummaryLength = 255
+
+	// jiraMaxLabelLength is the longest label Jira accepts.
+	jiraMaxLabelLength = 255
+
+	// jiraDedupLabelPrefix is the prefix of the label that identifies the
+	// issues of an alert group.
+	jiraDedupLabelPrefix = "grafana-"
+)
+
+var (
+	jiraProjectKeyRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+	// jiraLabelReplacer replaces the characters Jira doesn't allow in labels.
+	jiraLabelReplacer = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", "\r", "_")
+)
+
+// JiraNotifier is responsible for opening issues in Jira.
+type JiraNotifier struct {
+	old_notifiers.NotifierBase
+	IssueURL    string
+	SearchURL   string
+	User        string
+	Password    string
+	APIToken    string
+	Project     string
+	IssueType   string
+	Summary     string
+	Description string
+	Dedup       bool
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewJiraNotifier is the constructor for the Jira notifier
+func NewJiraNotifier(model *NotificationChannelConfig, t *template.Template) (*JiraNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	rawURL := model.Settings.Get("url").MustString()
+	if rawURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Jira URL in settings"}
+	}
+	baseURL, err := validateURL("Jira URL", rawURL, urlValidationOptions{requireHost: true})
+	if err != nil {
+		return nil, err
+	}
+	issueURL, err := joinUrlPath(baseURL, "/rest/api/2/issue")
+	if err != nil {
+		return nil, err
+	}
+	searchURL, err := joinUrlPath(baseURL, "/rest/api/2/search")
+	if err != nil {
+		return nil, err
+	}
+
+	// Jira Cloud authenticates with the user and an API token as password,
+	// Jira Server and Data Center with a personal access token.
+	username := model.Settings.Get("username").MustString()
+	password, err := model.ResolvedSecret("password")
+	if err != nil {
+		return nil, err
+	}
+	apiToken, err := model.ResolvedSecret("api_token")
+	if err != nil {
+		return nil, err
+	}
+	if apiToken != "" && (username != "" || password != "") {
+		return nil, alerting.ValidationError{Reason: "Invalid Jira credentials: Must be either a username and password or a personal access token"}
+	}
+	if apiToken == "" && (username == "" || password == "") {
+		return nil, alerting.ValidationError{Reason: "Could not find Jira username and password or personal access token in settings"}
+	}
+
+	project := model.Settings.Get("project").MustString()
+	if project == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Jira project in settings"}
+	}
+	if !jiraProjectKeyRegexp.MatchString(project) {
+		return nil, alerting.ValidationError{Reason: "Invalid Jira project: Must be a project key such as OPS"}
+	}
+	issueType := strings.TrimSpace(model.Settings.Get("issue_type").MustString())
+	if issueType == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Jira issue type in settings"}
+	}
+
+	summary := model.Settings.Get("summary").MustString()
+	if summary == "" {
+		summary = `{{ template "default.title" . }}`
+	}
+	description := model.Settings.Get("description").MustString()
+	if description == "" {
+		description = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.jira")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &JiraNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		IssueURL:    issueURL,
+		SearchURL:   searchURL,
+		User:        username,
+		Password:    password,
+		APIToken:    apiToken,
+		Project:     project,
+		IssueType:   issueType,
+		Summary:     summary,
+		Description: description,
+		Dedup:       model.Settings.Get("dedup").MustBool(false),
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+type jiraIssue struct {
+	Fields jiraIssueFields `json:"fields"`
+}
+
+type jiraIssueFields struct {
+	Project     jiraKey  `json:"project"`
+	IssueType   jiraName `json:"issuetype"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+type jiraKey struct {
+	Key string `json:"key"`
+}
+
+type jiraName struct {
+	Name string `json:"name"`
+}
+
+type jiraSearch struct {
+	JQL        string   `json:"jql"`
+	MaxResults int      `json:"maxResults"`
+	Fields     []string `json:"fields"`
+}
+
+type jiraSearchResult struct {
+	Total int `json:"total"`
+}
+
+// Notify opens an issue in Jira
+func (jn *JiraNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := jn.log.New(notificationLogContext(ctx, as)...)
+
+	// Issues are only opened for firing alerts. Resolving them is left to
+	// the workflow of the project.
+	status := types.Alerts(as...).Status()
+	if status == model.AlertResolved {
+		logger.Debug("Not opening a Jira issue for resolved alerts", "notification", jn.Name)
+		return true, nil
+	}
+	logger.Debug("Executing Jira notification", "notification", jn.Name)
+
+	data := notify.GetTemplateData(ctx, jn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, jn.tmpl, data, &tmplErr)
+
+	issue := jiraIssue{Fields: jiraIssueFields{
+		Project:     jiraKey{Key: jn.Project},
+		IssueType:   jiraName{Name: jn.IssueType},
+		Summary:     truncateWithEllipsis(tmpl(jn.Summary), jiraMaxSummaryLength),
+		Description: tmpl(jn.Description),
+		Labels:      jiraLabels(data.CommonLabels),
+	}}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Jira issue: %w", tmplErr)
+	}
+
+	if jn.Dedup {
+		// Test notifications have no group key, so they can't be deduplicated.
+		if key, err := threadKey(ctx); err == nil {
+			dedupLabel := jiraDedupLabelPrefix + key
+			exists, err := jn.issueExists(ctx, as, dedupLabel)
+			if err != nil {
+				logger.Error("Failed to look up Jira issue", "error", err, "webhook", jn.Name)
+				return false, err
+			}
+			if exists {
+				logger.Debug("Not opening a Jira issue for an alert group that has an open issue", "notification", jn.Name)
+				return true, nil
+			}
+			issue.Fields.Labels = append(issue.Fields.Labels, dedupLabel)
+		}
+	}
+
+	body, err := json.Marshal(issue)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := jn.newCommand(jn.IssueURL, string(body))
+	if err := jn.httpOptions.apply(ctx, cmd, jn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, jn.retry)
+	jn.metrics.observe("jira", status, start, err)
+	if err != nil {
+		logger.Error("Failed to open Jira issue", "error", err, "webhook", jn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// issueExists reports whether the project has an unresolved issue with the
+// dedup label.
+func (jn *JiraNotifier) issueExists(ctx context.Context, as []*types.Alert, dedupLabel string) (bool, error) {
+	body, err := json.Marshal(jiraSearch{
+		JQL:        fmt.Sprintf("project = %q AND labels = %q AND statusCategory != Done", jn.Project, dedupLabel),
+		MaxResults: 1,
+		Fields:     []string{"key"},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	cmd := jn.newCommand(jn.SearchURL, string(body))
+	var respBody []byte
+	cmd.OnResponse = func(status string, body []byte) {
+		respBody = body
+	}
+	if err := jn.httpOptions.apply(ctx, cmd, jn.tmpl, as); err != nil {
+		return false, err
+	}
+	if err := sendWithRetry(ctx, cmd, jn.retry); err != nil {
+		return false, err
+	}
+
+	var result jiraSearchResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return false, fmt.Errorf("failed to parse Jira search result: %w", err)
+	}
+	return result.Total > 0, nil
+}
+
+// newCommand returns the command to POST body to u with the credentials of
+// the notifier.
+func (jn *JiraNotifier) newCommand(u, body string) *models.SendWebhookSync {
+	cmd := &models.SendWebhookSync{
+		Url:        u,
+		User:       jn.User,
+		Password:   jn.Password,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Accept": "application/json",
+		},
+		ContentType: "application/json",
+		Body:        body,
+	}
+	if jn.APIToken != "" {
+		cmd.HttpHeader["Authorization"] = "Bearer " + jn.APIToken
+	}
+	return cmd
+}
+
+// jiraLabels returns the labels of an issue for the common labels of the
+// alerts, as name=value. Jira labels must not contain whitespace.
+func jiraLabels(labels template.KV) []string {
+	result := make([]string, 0, len(labels))
+	for name, value := range labels {
+		result = append(result, truncateRunes(jiraLabelReplacer.Replace(name+"="+value), jiraMaxLabelLength))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (jn *JiraNotifier) SendResolved() bool {
+	return !jn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (jn *JiraNotifier) Type() string {
+	return "jira"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (jn *JiraNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,209 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestJiraNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       string
+		expUser      string
+		expAuth      string
+		expInitError error
+	}{
+		{
+			name:     "Basic auth",
+			settings: `{"url": "https://example.atlassian.net", "username": "grafana", "password": "secret", "project": "OPS", "issue_type": "Bug"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg:  `{"fields":{"project":{"key":"OPS"},"issuetype":{"name":"Bug"},"summary":"[FIRING:1]  (val1)","description":"\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n","labels":["alertname=alert1","lbl1=val1"]}}`,
+			expUser: "grafana",
+		}, {
+			name: "Personal access token and custom fields",
+			settings: `{
+				"url": "https://jira.example.org/",
+				"api_token": "sometoken",
+				"project": "OPS",
+				"issue_type": "Incident",
+				"summary": "{{ .CommonLabels.alertname }} is firing",
+				"description": "{{ len .Alerts.Firing }} firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "team": "core db"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "team": "core db", "lbl1": "val2"},
+					},
+				},
+			},
+			expMsg:  `{"fields":{"project":{"key":"OPS"},"issuetype":{"name":"Incident"},"summary":"alert1 is firing","description":"2 firing","labels":["alertname=alert1","team=core_db"]}}`,
+			expAuth: "Bearer sometoken",
+		}, {
+			name:         "URL missing",
+			settings:     `{"api_token": "sometoken", "project": "OPS", "issue_type": "Bug"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Jira URL in settings"},
+		}, {
+			name:         "Credentials missing",
+			settings:     `{"url": "https://example.atlassian.net", "username": "grafana", "project": "OPS", "issue_type": "Bug"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Jira username and password or personal access token in settings"},
+		}, {
+			name:         "Both credentials",
+			settings:     `{"url": "https://example.atlassian.net", "username": "grafana", "password": "secret", "api_token": "sometoken", "project": "OPS", "issue_type": "Bug"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Jira credentials: Must be either a username and password or a personal access token"},
+		}, {
+			name:         "Project missing",
+			settings:     `{"url": "https://example.atlassian.net", "api_token": "sometoken", "issue_type": "Bug"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Jira project in settings"},
+		}, {
+			name:         "Invalid project",
+			settings:     `{"url": "https://example.atlassian.net", "api_token": "sometoken", "project": "ops team", "issue_type": "Bug"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Jira project: Must be a project key such as OPS"},
+		}, {
+			name:         "Issue type missing",
+			settings:     `{"url": "https://example.atlassian.net", "api_token": "sometoken", "project": "OPS", "issue_type": " "}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Jira issue type in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "jira_testing",
+				Type:     "jira",
+				Settings: settingsJSON,
+			}
+
+			jn, err := NewJiraNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var sent []*models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				sent = append(sent, webhook)
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := jn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Len(t, sent, 1)
+			require.Regexp(t, `^https://[^/]+/rest/api/2/issue$`, sent[0].Url)
+			require.Equal(t, "POST", sent[0].HttpMethod)
+			require.Equal(t, c.expUser, sent[0].User)
+			require.Equal(t, c.expAuth, sent[0].HttpHeader["Authorization"])
+			require.JSONEq(t, c.expMsg, sent[0].Body)
+		})
+	}
+}
+
+func TestJiraNotifierDedup(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	jn, err := NewJiraNotifier(&NotificationChannelConfig{
+		Name: "jira_testing",
+		Type: "jira",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"url":        "https://example.atlassian.net",
+			"api_token":  "sometoken",
+			"project":    "OPS",
+			"issue_type": "Bug",
+			"dedup":      true,
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	key, err := threadKey(notifyContext())
+	require.NoError(t, err)
+	dedupLabel := "grafana-" + key
+
+	for _, c := range []struct {
+		name      string
+		searchRes string
+		expCreate bool
+	}{
+		{name: "No open issue", searchRes: `{"total":0,"issues":[]}`, expCreate: true},
+		{name: "Open issue", searchRes: `{"total":1,"issues":[{"key":"OPS-1"}]}`, expCreate: false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var sent []*models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				sent = append(sent, webhook)
+				if webhook.OnResponse != nil {
+					webhook.OnResponse("200 OK", []byte(c.searchRes))
+				}
+				return nil
+			})
+
+			ok, err := jn.Notify(notifyContext(), firingAlert())
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://example.atlassian.net/rest/api/2/search", sent[0].Url)
+			require.JSONEq(t, `{"jql":"project = \"OPS\" AND labels = \"`+dedupLabel+`\" AND statusCategory != Done","maxResults":1,"fields":["key"]}`, sent[0].Body)
+			require.Equal(t, "Bearer sometoken", sent[0].HttpHeader["Authorization"])
+			if !c.expCreate {
+				require.Len(t, sent, 1)
+				return
+			}
+			require.Len(t, sent, 2)
+			require.Equal(t, "https://example.atlassian.net/rest/api/2/issue", sent[1].Url)
+			require.Contains(t, sent[1].Body, `"labels":["alertname=alert1","`+dedupLabel+`"]`)
+		})
+	}
+
+	t.Run("Failed lookup", func(t *testing.T) {
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			webhook.OnResponse("200 OK", []byte("<html>"))
+			return nil
+		})
+
+		ok, err := jn.Notify(notifyContext(), firingAlert())
+		require.False(t, ok)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse Jira search result")
+	})
+}
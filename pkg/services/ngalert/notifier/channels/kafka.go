@@ -3,6 +3,8 @@ package channels
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"regexp"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
@@ -18,14 +20,30 @@ import (
 	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
 )
 
+const (
+	// kafkaKeyNone publishes records without a key, so that the REST proxy
+	// spreads them over all partitions.
+	kafkaKeyNone = "none"
+	// kafkaKeyGroupKey keys records by the alert group, so that all
+	// notifications of a group land on the same partition in order.
+	kafkaKeyGroupKey = "group_key"
+)
+
+// kafkaTopicRegexp matches the names Kafka accepts for topics.
+var kafkaTopicRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
 // KafkaNotifier is responsible for sending
 // alert notifications to Kafka.
 type KafkaNotifier struct {
 	old_notifiers.NotifierBase
-	Endpoint string
-	Topic    string
-	log      log.Logger
-	tmpl     *template.Template
+	Endpoint    string
+	Topic       string
+	KeyStrategy string
+	Username    string
+	Password    string
+	compress    compressOptions
+	log         log.Logger
+	tmpl        *template.Template
 }
 
 // NewKafkaNotifier is the constructor function for the Kafka notifier.
@@ -34,10 +52,31 @@ func NewKafkaNotifier(model *NotificationChannelConfig, t *template.Template) (*
 	if endpoint == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find kafka rest proxy endpoint property in settings"}
 	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Kafka REST proxy URL: Must be an absolute URL"}
+	}
 	topic := model.Settings.Get("kafkaTopic").MustString()
 	if topic == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find kafka topic property in settings"}
 	}
+	if !kafkaTopicRegexp.MatchString(topic) {
+		return nil, alerting.ValidationError{Reason: "Invalid Kafka topic: Must be at most 249 letters, digits, '.', '_' or '-'"}
+	}
+
+	keyStrategy := model.Settings.Get("key_strategy").MustString(kafkaKeyNone)
+	if keyStrategy != kafkaKeyNone && keyStrategy != kafkaKeyGroupKey {
+		return nil, alerting.ValidationError{Reason: "Invalid Kafka key strategy: Must be one of none, group_key"}
+	}
+
+	// The REST proxy authenticates clients with basic auth and uses its own
+	// SASL credentials towards the brokers.
+	username := model.Settings.Get("username").MustString()
+	password := model.DecryptedValue("password", model.Settings.Get("password").MustString())
+
+	compress, err := parseCompressOptions(model.Settings)
+	if err != nil {
+		return nil, err
+	}
 
 	return &KafkaNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
@@ -47,10 +86,14 @@ func NewKafkaNotifier(model *NotificationChannelConfig, t *template.Template) (*
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		Endpoint: endpoint,
-		Topic:    topic,
-		log:      log.New("alerting.notifier.kafka"),
-		tmpl:     t,
+		Endpoint:    endpoint,
+		Topic:       topic,
+		KeyStrategy: keyStrategy,
+		Username:    username,
+		Password:    password,
+		compress:    compress,
+		log:         log.New("alerting.notifier.kafka"),
+		tmpl:        t,
 	}, nil
 }
 
@@ -68,7 +111,7 @@ func (kn *KafkaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 
 	data := notify.GetTemplateData(ctx, kn.tmpl, as, gokit_log.NewNopLogger())
 	var tmplErr error
-	tmpl := notify.TmplText(kn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, kn.tmpl, data, &tmplErr)
 
 	bodyJSON := simplejson.New()
 	bodyJSON.Set("alert_state", state)
@@ -87,9 +130,17 @@ func (kn *KafkaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 		return false, err
 	}
 	bodyJSON.Set("incident_key", groupKey.Hash())
+	bodyJSON.Set("group_key", groupKey.String())
+	bodyJSON.Set("status", alerts.Status())
+	bodyJSON.Set("labels", data.CommonLabels)
+	bodyJSON.Set("annotations", data.CommonAnnotations)
+	bodyJSON.Set("firing", len(data.Alerts.Firing()))
 
 	valueJSON := simplejson.New()
 	valueJSON.Set("value", bodyJSON)
+	if kn.KeyStrategy == kafkaKeyGroupKey {
+		valueJSON.Set("key", groupKey.Hash())
+	}
 
 	recordJSON := simplejson.New()
 	recordJSON.Set("records", []interface{}{valueJSON})
@@ -107,6 +158,8 @@ func (kn *KafkaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 
 	cmd := &models.SendWebhookSync{
 		Url:        topicURL,
+		User:       kn.Username,
+		Password:   kn.Password,
 		Body:       string(body),
 		HttpMethod: "POST",
 		HttpHeader: map[string]string{
@@ -114,6 +167,9 @@ func (kn *KafkaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 			"Accept":       "application/vnd.kafka.v2+json",
 		},
 	}
+	if err := kn.compress.apply(cmd); err != nil {
+		return false, err
+	}
 
 	if err := bus.DispatchCtx(ctx, cmd); err != nil {
 		kn.log.Error("Failed to send notification to Kafka", "error", err, "body", string(body))
@@ -126,3 +182,13 @@ func (kn *KafkaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 func (kn *KafkaNotifier) SendResolved() bool {
 	return !kn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (kn *KafkaNotifier) Type() string {
+	return "kafka"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (kn *KafkaNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
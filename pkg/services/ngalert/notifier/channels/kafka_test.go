@@ -55,7 +55,12 @@ func TestKafkaNotifier(t *testing.T) {
 						"client_url": "http://localhost/alerting/list",
 						"description": "[FIRING:1]  (val1)",
 						"details": "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
-						"incident_key": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733"
+						"incident_key": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+						"group_key": "alertname",
+						"status": "firing",
+						"labels": {"alertname": "alert1", "lbl1": "val1"},
+						"annotations": {"ann1": "annv1"},
+						"firing": 1
 					  }
 					}
 				  ]
@@ -91,7 +96,50 @@ func TestKafkaNotifier(t *testing.T) {
 						"client_url": "http://localhost/alerting/list",
 						"description": "[FIRING:2]  ",
 						"details": "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n\n\n\n\n",
-						"incident_key": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733"
+						"incident_key": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+						"group_key": "alertname",
+						"status": "firing",
+						"labels": {"alertname": "alert1"},
+						"annotations": {},
+						"firing": 2
+					  }
+					}
+				  ]
+				}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Group key as record key",
+			settings: `{
+				"kafkaRestProxy": "http://localhost",
+				"kafkaTopic": "sometopic",
+				"key_strategy": "group_key"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expUrl: "http://localhost/topics/sometopic",
+			expMsg: `{
+				  "records": [
+					{
+					  "key": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+					  "value": {
+						"alert_state": "alerting",
+						"client": "Grafana",
+						"client_url": "http://localhost/alerting/list",
+						"description": "[FIRING:1]  (val1)",
+						"details": "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
+						"incident_key": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+						"group_key": "alertname",
+						"status": "firing",
+						"labels": {"alertname": "alert1", "lbl1": "val1"},
+						"annotations": {"ann1": "annv1"},
+						"firing": 1
 					  }
 					}
 				  ]
@@ -106,6 +154,18 @@ func TestKafkaNotifier(t *testing.T) {
 			name:         "Topic missing",
 			settings:     `{"kafkaRestProxy": "http://localhost"}`,
 			expInitError: alerting.ValidationError{Reason: "Could not find kafka topic property in settings"},
+		}, {
+			name:         "Invalid endpoint",
+			settings:     `{"kafkaRestProxy": "localhost:8082", "kafkaTopic": "sometopic"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Kafka REST proxy URL: Must be an absolute URL"},
+		}, {
+			name:         "Invalid topic",
+			settings:     `{"kafkaRestProxy": "http://localhost", "kafkaTopic": "some topic"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Kafka topic: Must be at most 249 letters, digits, '.', '_' or '-'"},
+		}, {
+			name:         "Invalid key strategy",
+			settings:     `{"kafkaRestProxy": "http://localhost", "kafkaTopic": "sometopic", "key_strategy": "random"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Kafka key strategy: Must be one of none, group_key"},
 		},
 	}
 
@@ -153,3 +213,35 @@ func TestKafkaNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestKafkaNotifierBasicAuth(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewKafkaNotifier(&NotificationChannelConfig{
+		Name: "kafka_testing",
+		Type: "kafka",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"kafkaRestProxy": "http://localhost",
+			"kafkaTopic":     "sometopic",
+			"username":       "grafana",
+			"password":       "secret",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	var cmd *models.SendWebhookSync
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		cmd = webhook
+		return nil
+	})
+
+	ok, err := pn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "grafana", cmd.User)
+	require.Equal(t, "secret", cmd.Password)
+}
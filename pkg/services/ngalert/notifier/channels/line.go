@@ -1,17 +1,26 @@
 package channels
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
 	"net/url"
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
-	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
@@ -20,15 +29,136 @@ import (
 
 const (
 	LineNotifyURL string = "https://notify-api.line.me/api/notify"
+
+	// lineNotifyStatusURL returns the status of an access token.
+	lineNotifyStatusURL = "https://notify-api.line.me/api/status"
+
+	// screenshotURLAnnotation is the annotation of an alert that holds the
+	// URL or path of a screenshot of its panel.
+	screenshotURLAnnotation = "__screenshotUrl__"
+
+	// screenshotPathAnnotation is the annotation of an alert that holds the
+	// local path of a screenshot of its panel, which Grafana rendered into
+	// its images directory.
+	screenshotPathAnnotation = "__screenshotPath__"
+
+	// lineMaxImageFileSize is the size in bytes of the largest screenshot
+	// that is uploaded, to bound the memory of a notification.
+	lineMaxImageFileSize = 10 << 20
 )
 
+// lineSeverityOrder is the order of the known severities in the summary of a
+// LINE message. Other severities follow in alphabetical order.
+var lineSeverityOrder = map[string]int{
+	"critical": 0,
+	"error":    1,
+	"warning":  2,
+	"info":     3,
+}
+
 // NewLineNotifier is the constructor for the LINE notifier
 func NewLineNotifier(model *NotificationChannelConfig, t *template.Template) (*LineNotifier, error) {
-	token := model.DecryptedValue("token", model.Settings.Get("token").MustString())
+	token, err := model.ResolvedSecret("token")
+	if err != nil {
+		return nil, err
+	}
 	if token == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find token in settings"}
 	}
 
+	annotationFields, err := parseAnnotationFields(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultTitle, defaultMessage, err := localizedTemplatesWithAnnotations(model.Settings, annotationFields)
+	if err != nil {
+		return nil, err
+	}
+	title := model.Settings.Get("title").MustString()
+	if title == "" {
+		title = defaultTitle
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = defaultMessage
+	}
+	templateMessage, err := parseTemplateName(model.Settings, t)
+	if err != nil {
+		return nil, err
+	}
+	if templateMessage != "" {
+		message = templateMessage
+	}
+	compact, err := parseCompact(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+	if compact != "" {
+		message = compact
+	}
+
+	// Resolved notifications fall back to the regular message template.
+	resolvedMessage := model.Settings.Get("resolved_message").MustString()
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.line")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	quietHours, err := parseQuietHours(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	linkPath, err := parseLinkPath(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	breaker, err := parseCircuitBreaker(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	maxValueLength, err := parseMaxValueLength(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAlerts, ok := intSetting(model.Settings, "max_alerts", 0)
+	if !ok || maxAlerts < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max alerts: Must not be negative"}
+	}
+
+	stickerPackageID := model.Settings.Get("sticker_package_id").MustString()
+	stickerID := model.Settings.Get("sticker_id").MustString()
+	if (stickerPackageID == "") != (stickerID == "") {
+		return nil, alerting.ValidationError{Reason: "Both sticker package ID and sticker ID must be set to attach a sticker"}
+	}
+
+	// Screenshots are served from the image URL, so only attach them if it
+	// is configured.
+	imageURL := model.Settings.Get("image").MustString()
+	if imageURL != "" {
+		u, err := url.Parse(imageURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, alerting.ValidationError{Reason: "Invalid image URL: Must be an absolute URL"}
+		}
+	}
+
+	uploadImage := model.Settings.Get("upload_image").MustBool(false)
+	if uploadImage && model.ImagesDir == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid upload image: Grafana has no images directory to upload screenshots from"}
+	}
+
 	return &LineNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
 			Uid:                   model.UID,
@@ -37,9 +167,30 @@ func NewLineNotifier(model *NotificationChannelConfig, t *template.Template) (*L
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		Token: token,
-		log:   log.New("alerting.notifier.line"),
-		tmpl:  t,
+		Token:            token,
+		Title:            title,
+		Message:          message,
+		ResolvedMessage:  resolvedMessage,
+		StickerPackageID: stickerPackageID,
+		StickerID:        stickerID,
+		ImageURL:         imageURL,
+		UploadImage:      uploadImage,
+		LinkPath:         linkPath,
+		AnnotationFields: annotationFields,
+		IncludeSummary:   model.Settings.Get("include_summary").MustBool(false),
+		SeverityLabel:    model.Settings.Get("severity_label").MustString("severity"),
+		MaxValueLength:   maxValueLength,
+		MaxAlerts:        maxAlerts,
+		quietHours:       quietHours,
+		breaker:          breaker,
+		retry:            newRetryOptions(maxRetries),
+		httpOptions:      httpOpts,
+		metrics:          model.Metrics,
+		debugHTTP:        parseDebugHTTP(model.Settings, logger, token),
+		statusURL:        lineNotifyStatusURL,
+		imagesDir:        model.ImagesDir,
+		log:              logger,
+		tmpl:             t,
 	}, nil
 }
 
@@ -47,52 +198,348 @@ func NewLineNotifier(model *NotificationChannelConfig, t *template.Template) (*L
 // alert notifications to LINE.
 type LineNotifier struct {
 	old_notifiers.NotifierBase
-	Token string
-	log   log.Logger
-	tmpl  *template.Template
+	Token            string
+	Title            string
+	Message          string
+	ResolvedMessage  string
+	StickerPackageID string
+	StickerID        string
+	ImageURL         string
+	UploadImage      bool
+	LinkPath         string
+	AnnotationFields []string
+	IncludeSummary   bool
+	SeverityLabel    string
+	MaxValueLength   int
+	MaxAlerts        int
+	quietHours       *quietHours
+	breaker          *circuitBreaker
+	retry            retryOptions
+	httpOptions      httpOptions
+	metrics          *DeliveryMetrics
+	debugHTTP        *httpDebugLogger
+	statusURL        string
+	imagesDir        string
+	log              log.Logger
+	tmpl             *template.Template
 }
 
 // Notify send an alert notification to LINE
 func (ln *LineNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	ln.log.Debug("Executing line notification", "notification", ln.Name)
+	if len(as) == 0 {
+		ln.log.Debug("Not sending a notification without alerts", "notification", ln.Name)
+		return true, nil
+	}
 
-	ruleURL := path.Join(ln.tmpl.ExternalURL.String(), "/alerting/list")
+	status := types.Alerts(as...).Status()
+	if status == model.AlertResolved && !ln.SendResolved() {
+		return true, nil
+	}
 
-	data := notify.GetTemplateData(ctx, ln.tmpl, as, gokit_log.NewNopLogger())
-	var tmplErr error
-	tmpl := notify.TmplText(ln.tmpl, data, &tmplErr)
+	logger := ln.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing line notification", "notification", ln.Name)
 
-	body := fmt.Sprintf(
-		"%s\n%s\n\n%s",
-		tmpl(`{{ template "default.title" . }}`),
-		ruleURL,
-		tmpl(`{{ template "default.message" . }}`),
-	)
-	if tmplErr != nil {
-		return false, fmt.Errorf("failed to template Line message: %w", tmplErr)
+	if ln.quietHours.suppresses(status) {
+		logger.Debug("Suppressing notification during quiet hours", "notification", ln.Name)
+		return true, nil
+	}
+
+	cmd, err := ln.buildCommand(ctx, as)
+	if err != nil {
+		return false, err
+	}
+
+	if err := ln.breaker.allow(LineNotifyURL); err != nil {
+		logger.Warn("Not sending notification to LINE", "error", err, "webhook", ln.Name)
+		return false, err
+	}
+
+	ln.debugHTTP.instrument(cmd)
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, ln.retry)
+	ln.breaker.done(LineNotifyURL, err)
+	ln.metrics.observe("line", status, start, err)
+	if err != nil {
+		logger.Error("Failed to send notification to LINE", "error", err, "body", cmd.Body)
+		return false, err
+	}
+
+	// LINE Notify identifies the recipient by the token only.
+	logDelivery(ctx, ln.log, "line", ln.Token, as, start)
+	return true, nil
+}
+
+// Preview renders the notification for as without sending it. It returns the
+// request body and headers that Notify would send.
+func (ln *LineNotifier) Preview(ctx context.Context, as ...*types.Alert) (string, map[string]string, error) {
+	cmd, err := ln.buildCommand(ctx, as)
+	if err != nil {
+		return "", nil, err
+	}
+	return cmd.Body, cmd.HttpHeader, nil
+}
+
+// buildCommand builds the request that sends the notification for as. LINE
+// Notify doesn't support idempotency keys, so a retry of a send that timed
+// out may deliver the notification twice.
+func (ln *LineNotifier) buildCommand(ctx context.Context, as []*types.Alert) (*models.SendWebhookSync, error) {
+	body, err := renderLineMessage(ctx, ln.tmpl, as, ln.Title, ln.Message, ln.ResolvedMessage, ln.LinkPath, ln.AnnotationFields, ln.MaxValueLength, ln.MaxAlerts)
+	if err != nil {
+		return nil, err
+	}
+	if ln.IncludeSummary {
+		if summary := lineSeveritySummary(as, ln.SeverityLabel); summary != "" {
+			body = summary + "\n" + body
+		}
 	}
 
 	form := url.Values{}
 	form.Add("message", body)
+	if ln.StickerPackageID != "" && ln.StickerID != "" {
+		form.Add("stickerPackageId", ln.StickerPackageID)
+		form.Add("stickerId", ln.StickerID)
+	}
+	// An uploaded screenshot takes precedence over the image URL.
+	var imageFile string
+	if ln.UploadImage {
+		imageFile = ln.screenshotFile(as)
+	}
+	if ln.ImageURL != "" && imageFile == "" {
+		image, err := screenshotURL(ln.ImageURL, as)
+		if err != nil {
+			return nil, err
+		}
+		if image != "" {
+			form.Add("imageThumbnail", image)
+			form.Add("imageFullsize", image)
+		}
+	}
+
+	contentType := "application/x-www-form-urlencoded;charset=UTF-8"
+	body = form.Encode()
+	if imageFile != "" {
+		var err error
+		body, contentType, err = multipartLineForm(form, imageFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	cmd := &models.SendWebhookSync{
 		Url:        LineNotifyURL,
 		HttpMethod: "POST",
 		HttpHeader: map[string]string{
 			"Authorization": fmt.Sprintf("Bearer %s", ln.Token),
-			"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+			"Content-Type":  contentType,
 		},
-		Body: form.Encode(),
+		Body: body,
 	}
-
-	if err := bus.DispatchCtx(ctx, cmd); err != nil {
-		ln.log.Error("Failed to send notification to LINE", "error", err, "body", body)
-		return false, err
+	if err := ln.httpOptions.apply(ctx, cmd, ln.tmpl, as); err != nil {
+		return nil, err
 	}
-
-	return true, nil
+	return cmd, nil
 }
 
 func (ln *LineNotifier) SendResolved() bool {
 	return !ln.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (ln *LineNotifier) Type() string {
+	return "line"
+}
+
+// Fingerprint implements the Fingerprinter interface.
+func (ln *LineNotifier) Fingerprint(ctx context.Context, as ...*types.Alert) string {
+	return notificationFingerprint(ctx, as)
+}
+
+// Ping checks the token by looking up its status.
+func (ln *LineNotifier) Ping(ctx context.Context) error {
+	return ping(ctx, ln.httpOptions, ln.statusURL, map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", ln.Token),
+	})
+}
+
+// renderLineMessage renders the text of a LINE message: the title, a link to
+// linkPath, e.g. the alert rules, and the message. Resolved notifications use
+// resolvedMessage if it is set, and test notifications are prefixed as such.
+// If annotationFields is not empty, the templates only see those annotations.
+// Label and annotation values are shortened to maxValueLength characters,
+// unless it is 0. If maxAlerts is set, the message only sees the first
+// maxAlerts alerts and is followed by the number of the others, whereas the
+// title sees all of them.
+func renderLineMessage(ctx context.Context, t *template.Template, as []*types.Alert, title, message, resolvedMessage, linkPath string, annotationFields []string, maxValueLength, maxAlerts int) (string, error) {
+	ruleURL := path.Join(t.ExternalURL.String(), linkPath)
+
+	templateData := func(as []*types.Alert) *template.Data {
+		data := notify.GetTemplateData(ctx, t, as, gokit_log.NewNopLogger())
+		truncateTemplateValues(data, maxValueLength)
+		if len(annotationFields) > 0 {
+			for i := range data.Alerts {
+				data.Alerts[i].Annotations = selectAnnotations(data.Alerts[i].Annotations, annotationFields)
+			}
+			data.CommonAnnotations = selectAnnotations(data.CommonAnnotations, annotationFields)
+		}
+		return data
+	}
+	var tmplErr error
+	tmpl := tmplText(ctx, t, templateData(as), &tmplErr)
+
+	shown, overflow := truncateAlerts(maxAlerts, as)
+	messageTmpl, overflowLine := tmpl, ""
+	if overflow > 0 {
+		messageTmpl = tmplText(ctx, t, templateData(shown), &tmplErr)
+		overflowLine = "\n" + moreAlertsLine(overflow)
+	}
+
+	if types.Alerts(as...).Status() == model.AlertResolved && resolvedMessage != "" {
+		message = resolvedMessage
+	}
+
+	prefix := ""
+	if isTestNotification(as) {
+		prefix = testNotificationPrefix
+	}
+
+	// The message can't tell which alerts of a group that has both firing
+	// and resolved alerts are in which state, so mark every alert.
+	summary, stateLines := "", ""
+	if isMixedGroup(as) {
+		summary = "\n" + alertStateSummary(as)
+		stateLines = alertStateLines(shown) + "\n"
+	}
+
+	text := fmt.Sprintf(
+		"%s%s%s\n%s\n\n%s%s%s",
+		prefix,
+		tmpl(title),
+		summary,
+		ruleURL,
+		stateLines,
+		messageTmpl(message),
+		overflowLine,
+	)
+	if tmplErr != nil {
+		return "", fmt.Errorf("failed to template Line message: %w", tmplErr)
+	}
+
+	return text, nil
+}
+
+// lineSeveritySummary returns a line that counts the alerts in as by the
+// value of their severity label, such as "🔥 3 critical, 2 warning". It
+// returns an empty string if none of the alerts has a severity.
+func lineSeveritySummary(as []*types.Alert, severityLabel string) string {
+	counts := map[string]int{}
+	for _, a := range as {
+		severity := strings.ToLower(string(a.Labels[model.LabelName(severityLabel)]))
+		if severity == "" {
+			continue
+		}
+		counts[severity]++
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+
+	severities := make([]string, 0, len(counts))
+	for severity := range counts {
+		severities = append(severities, severity)
+	}
+	sort.Slice(severities, func(i, j int) bool {
+		oi, iKnown := lineSeverityOrder[severities[i]]
+		oj, jKnown := lineSeverityOrder[severities[j]]
+		if iKnown != jKnown {
+			return iKnown
+		}
+		if iKnown && oi != oj {
+			return oi < oj
+		}
+		return severities[i] < severities[j]
+	})
+
+	parts := make([]string, 0, len(severities))
+	for _, severity := range severities {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+	}
+	return "\U0001F525 " + strings.Join(parts, ", ") // Fire
+}
+
+// screenshotFile returns the path of the screenshot of the first alert that
+// has one in the images directory, or an empty string if there is none.
+// Screenshots outside of the images directory are never uploaded, so that
+// annotations can't make the notifier send arbitrary files.
+func (ln *LineNotifier) screenshotFile(as []*types.Alert) string {
+	for _, a := range as {
+		screenshot := string(a.Annotations[screenshotPathAnnotation])
+		if screenshot == "" {
+			continue
+		}
+		if !pathInDir(ln.imagesDir, screenshot) {
+			ln.log.Warn("Not uploading a screenshot that isn't in the images directory", "notification", ln.Name, "path", screenshot)
+			return ""
+		}
+		return screenshot
+	}
+	return ""
+}
+
+// multipartLineForm returns the multipart/form-data body and content type of
+// a LINE Notify request with form and the image file at imagePath.
+func multipartLineForm(form url.Values, imagePath string) (string, string, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open screenshot: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	image, err := ioutil.ReadAll(io.LimitReader(f, lineMaxImageFileSize+1))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read screenshot: %w", err)
+	}
+	if len(image) > lineMaxImageFileSize {
+		return "", "", fmt.Errorf("screenshot is larger than %d bytes", lineMaxImageFileSize)
+	}
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := w.WriteField(name, form.Get(name)); err != nil {
+			return "", "", err
+		}
+	}
+	part, err := w.CreateFormFile("imageFile", filepath.Base(imagePath))
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := part.Write(image); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	return b.String(), w.FormDataContentType(), nil
+}
+
+// screenshotURL returns the URL of the screenshot of the first alert that
+// has one. Screenshot paths are relative to imageURL.
+func screenshotURL(imageURL string, as []*types.Alert) (string, error) {
+	for _, a := range as {
+		screenshot := string(a.Annotations[screenshotURLAnnotation])
+		if screenshot == "" {
+			continue
+		}
+		if u, err := url.Parse(screenshot); err == nil && u.IsAbs() {
+			return screenshot, nil
+		}
+		return joinUrlPath(imageURL, screenshot)
+	}
+	return "", nil
+}
@@ -0,0 +1,182 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+var (
+	LineMessagingPushURL = "https://api.line.me/v2/bot/message/push"
+)
+
+// LineMessagingNotifier is responsible for sending alert notifications
+// to a LINE user, group or room with the LINE Messaging API.
+type LineMessagingNotifier struct {
+	old_notifiers.NotifierBase
+	Token           string
+	To              string
+	Title           string
+	Message         string
+	ResolvedMessage string
+	retry           retryOptions
+	httpOptions     httpOptions
+	metrics         *DeliveryMetrics
+	log             log.Logger
+	tmpl            *template.Template
+}
+
+// NewLineMessagingNotifier is the constructor for the LINE Messaging API notifier
+func NewLineMessagingNotifier(model *NotificationChannelConfig, t *template.Template) (*LineMessagingNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	token, err := model.ResolvedSecret("token")
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find channel access token in settings"}
+	}
+
+	to := model.Settings.Get("to").MustString()
+	if to == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find recipient (to) in settings"}
+	}
+
+	title := model.Settings.Get("title").MustString()
+	if title == "" {
+		title = `{{ template "default.title" . }}`
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+	resolvedMessage := model.Settings.Get("resolved_message").MustString()
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.line-messaging")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &LineMessagingNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		Token:           token,
+		To:              to,
+		Title:           title,
+		Message:         message,
+		ResolvedMessage: resolvedMessage,
+		retry:           newRetryOptions(maxRetries),
+		httpOptions:     httpOpts,
+		metrics:         model.Metrics,
+		log:             logger,
+		tmpl:            t,
+	}, nil
+}
+
+type lineMessagingPush struct {
+	To       string                 `json:"to"`
+	Messages []lineMessagingMessage `json:"messages"`
+}
+
+type lineMessagingMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Notify sends an alert notification with the LINE Messaging API
+func (ln *LineMessagingNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	if len(as) == 0 {
+		ln.log.Debug("Not sending a notification without alerts", "notification", ln.Name)
+		return true, nil
+	}
+	ln.log.Debug("Executing line messaging notification", "notification", ln.Name)
+
+	text, err := renderLineMessage(ctx, ln.tmpl, as, ln.Title, ln.Message, ln.ResolvedMessage, defaultLinkPath, nil, 0, 0)
+	if err != nil {
+		return false, err
+	}
+
+	body, err := json.Marshal(lineMessagingPush{
+		To:       ln.To,
+		Messages: []lineMessagingMessage{{Type: "text", Text: text}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        LineMessagingPushURL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", ln.Token),
+			"Content-Type":  "application/json",
+		},
+		Body: string(body),
+	}
+	if err := ln.httpOptions.apply(ctx, cmd, ln.tmpl, as); err != nil {
+		return false, err
+	}
+	setIdempotencyKey(ctx, cmd, lineRetryKeyHeader)
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, ln.retry)
+	// A retry key that was accepted before is rejected as a conflict, as the
+	// message has been delivered already.
+	var statusErr models.WebhookStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict {
+		ln.log.Debug("LINE message was delivered by an earlier attempt", "to", ln.To)
+		err = nil
+	}
+	ln.metrics.observe("line-messaging", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		ln.log.Error("Failed to send notification with the LINE Messaging API", "error", err, "to", ln.To)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (ln *LineMessagingNotifier) SendResolved() bool {
+	return !ln.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (ln *LineMessagingNotifier) Type() string {
+	return "line-messaging"
+}
+
+// Fingerprint implements the Fingerprinter interface.
+func (ln *LineMessagingNotifier) Fingerprint(ctx context.Context, as ...*types.Alert) string {
+	return notificationFingerprint(ctx, as)
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (ln *LineMessagingNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
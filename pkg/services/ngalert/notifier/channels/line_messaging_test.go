@@ -0,0 +1,177 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestLineMessagingNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expHeaders   map[string]string
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"token": "sometoken", "to": "U1234"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/json",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       `{"to":"U1234","messages":[{"type":"text","text":"[FIRING:1]  (val1)\nhttp:/localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n"}]}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Multiple alerts",
+			settings: `{"token": "sometoken", "to": "U1234"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+						Annotations: model.LabelSet{"ann1": "annv2"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/json",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       `{"to":"U1234","messages":[{"type":"text","text":"[FIRING:2]  \nhttp:/localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n\n\n\n\n"}]}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Resolved alert with resolved message",
+			settings: `{"token": "sometoken", "to": "C5678", "resolved_message": "{{ .CommonLabels.alertname }} is back to normal"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/json",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       `{"to":"C5678","messages":[{"type":"text","text":"[RESOLVED]  (val1)\nhttp:/localhost/alerting/list\n\nalert1 is back to normal"}]}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Custom title template",
+			settings: `{"token": "sometoken", "to": "U1234", "title": "Alarm: {{ .CommonLabels.alertname }} ({{ len .Alerts.Firing }})"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/json",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       `{"to":"U1234","messages":[{"type":"text","text":"Alarm: alert1 (1)\nhttp:/localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n"}]}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Token missing",
+			settings:     `{"to": "U1234"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find channel access token in settings"},
+		}, {
+			name:         "Recipient missing",
+			settings:     `{"token": "sometoken"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find recipient (to) in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "line_messaging_testing",
+				Type:     "line-messaging",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewLineMessagingNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			body := ""
+			webhookURL := ""
+			var headers map[string]string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				webhookURL = webhook.Url
+				headers = webhook.HttpHeader
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, LineMessagingPushURL, webhookURL)
+			require.Equal(t, c.expHeaders, headers)
+			require.JSONEq(t, c.expMsg, body)
+		})
+	}
+}
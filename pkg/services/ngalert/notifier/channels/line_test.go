@@ -2,8 +2,14 @@ package channels
 
 import (
 	"context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -14,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestLineNotifier(t *testing.T) {
@@ -46,6 +53,7 @@ func TestLineNotifier(t *testing.T) {
 			expHeaders: map[string]string{
 				"Authorization": "Bearer sometoken",
 				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
 			},
 			expMsg:       "message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A",
 			expInitError: nil,
@@ -69,10 +77,234 @@ func TestLineNotifier(t *testing.T) {
 			expHeaders: map[string]string{
 				"Authorization": "Bearer sometoken",
 				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
 			},
 			expMsg:       "message=%5BFIRING%3A2%5D++%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val2%0AAnnotations%3A%0A+-+ann1+%3D+annv2%0ASource%3A+%0A%0A%0A%0A%0A",
 			expInitError: nil,
 			expMsgError:  nil,
+		}, {
+			name:     "Custom message template",
+			settings: `{"token": "sometoken", "message": "{{ .Alerts.Firing | len }} firing: {{ .CommonLabels.alertname }}"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A1+firing%3A+alert1",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Firing alert ignores resolved message",
+			settings: `{"token": "sometoken", "resolved_message": "{{ .CommonLabels.alertname }} is back to normal"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Resolved alert with resolved message",
+			settings: `{"token": "sometoken", "resolved_message": "{{ .CommonLabels.alertname }} is back to normal"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BRESOLVED%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0Aalert1+is+back+to+normal",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Resolved alert falls back to message",
+			settings: `{"token": "sometoken"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BRESOLVED%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A%0A%0A%2A%2AResolved%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Sticker",
+			settings: `{"token": "sometoken", "sticker_package_id": "446", "sticker_id": "1988"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A&stickerId=1988&stickerPackageId=446",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Screenshot path relative to the image URL",
+			settings: `{"token": "sometoken", "message": "{{ .CommonLabels.alertname }}", "image": "https://images.example.com/grafana"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"__screenshotUrl__": "screenshots/abc.png"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "imageFullsize=https%3A%2F%2Fimages.example.com%2Fgrafana%2Fscreenshots%2Fabc.png&imageThumbnail=https%3A%2F%2Fimages.example.com%2Fgrafana%2Fscreenshots%2Fabc.png&message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0Aalert1",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Absolute screenshot URL",
+			settings: `{"token": "sometoken", "message": "{{ .CommonLabels.alertname }}", "image": "https://images.example.com/grafana"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"__screenshotUrl__": "https://cdn.example.com/abc.png"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "imageFullsize=https%3A%2F%2Fcdn.example.com%2Fabc.png&imageThumbnail=https%3A%2F%2Fcdn.example.com%2Fabc.png&message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0Aalert1",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "No screenshot annotation",
+			settings: `{"token": "sometoken", "message": "{{ .CommonLabels.alertname }}", "image": "https://images.example.com/grafana"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0Aalert1",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Screenshot without image URL",
+			settings: `{"token": "sometoken", "message": "{{ .CommonLabels.alertname }}"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"__screenshotUrl__": "screenshots/abc.png"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=%5BFIRING%3A1%5D++%28val1%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0Aalert1",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Invalid image URL",
+			settings:     `{"token": "sometoken", "image": "images.example.com/grafana"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid image URL: Must be an absolute URL"},
+		}, {
+			name:     "Custom title template",
+			settings: `{"token": "sometoken", "title": "Alarm: {{ .CommonLabels.alertname }} ({{ len .Alerts.Firing }})"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=Alarm%3A+alert1+%281%29%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Custom title template with resolved message",
+			settings: `{"token": "sometoken", "title": "Entwarnung: {{ .CommonLabels.alertname }}", "resolved_message": "{{ .CommonLabels.alertname }} is back to normal"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expHeaders: map[string]string{
+				"Authorization": "Bearer sometoken",
+				"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
+				"User-Agent":    "Grafana/" + setting.BuildVersion,
+			},
+			expMsg:       "message=Entwarnung%3A+alert1%0Ahttp%3A%2Flocalhost%2Falerting%2Flist%0A%0Aalert1+is+back+to+normal",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Sticker ID without package ID",
+			settings:     `{"token": "sometoken", "sticker_id": "1988"}`,
+			expInitError: alerting.ValidationError{Reason: "Both sticker package ID and sticker ID must be set to attach a sticker"},
 		}, {
 			name:         "Token missing",
 			settings:     `{}`,
@@ -124,3 +356,307 @@ func TestLineNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestLineNotifierDisableResolveMessage(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewLineNotifier(&NotificationChannelConfig{
+		Name:                  "line_testing",
+		Type:                  "line",
+		Settings:              simplejson.NewFromAny(map[string]interface{}{"token": "sometoken"}),
+		DisableResolveMessage: true,
+	}, tmpl)
+	require.NoError(t, err)
+
+	sent := 0
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent++
+		return nil
+	})
+
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: time.Now().Add(-2 * time.Hour),
+			EndsAt:   time.Now().Add(-time.Hour),
+		},
+	}
+	ok, err := pn.Notify(notifyContext(), resolved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, sent)
+
+	ok, err = pn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, sent)
+}
+
+func TestLineNotifierSummary(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := func(name string, labels model.LabelSet) *types.Alert {
+		labels["alertname"] = model.LabelValue(name)
+		return &types.Alert{Alert: model.Alert{Labels: labels}}
+	}
+	alerts := []*types.Alert{
+		alert("a1", model.LabelSet{"severity": "warning", "level": "low"}),
+		alert("a2", model.LabelSet{"severity": "critical", "level": "high"}),
+		alert("a3", model.LabelSet{"severity": "Critical", "level": "high"}),
+		alert("a4", model.LabelSet{"severity": "page"}),
+		alert("a5", model.LabelSet{"severity": "critical"}),
+		alert("a6", model.LabelSet{"severity": "warning"}),
+		alert("a7", model.LabelSet{}),
+	}
+
+	cases := []struct {
+		name     string
+		settings map[string]interface{}
+		expMsg   string
+	}{
+		{
+			name:     "Disabled by default",
+			settings: map[string]interface{}{},
+			expMsg:   "Title\nhttp:/localhost/alerting/list\n\nMessage",
+		}, {
+			name:     "Counts by severity",
+			settings: map[string]interface{}{"include_summary": true},
+			expMsg:   "\U0001F525 3 critical, 2 warning, 1 page\nTitle\nhttp:/localhost/alerting/list\n\nMessage",
+		}, {
+			name:     "Custom severity label",
+			settings: map[string]interface{}{"include_summary": true, "severity_label": "level"},
+			expMsg:   "\U0001F525 2 high, 1 low\nTitle\nhttp:/localhost/alerting/list\n\nMessage",
+		}, {
+			name:     "No severities",
+			settings: map[string]interface{}{"include_summary": true, "severity_label": "missing"},
+			expMsg:   "Title\nhttp:/localhost/alerting/list\n\nMessage",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := map[string]interface{}{"token": "sometoken", "title": "Title", "message": "Message"}
+			for k, v := range c.settings {
+				settings[k] = v
+			}
+			pn, err := NewLineNotifier(&NotificationChannelConfig{
+				Name:     "line_testing",
+				Type:     "line",
+				Settings: simplejson.NewFromAny(settings),
+			}, tmpl)
+			require.NoError(t, err)
+
+			var body string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+
+			ok, err := pn.Notify(notifyContext(), alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			require.Equal(t, c.expMsg, values.Get("message"))
+		})
+	}
+}
+
+func TestLineNotifierAnnotationFields(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"description": "Disk is almost full", "runbook_url": "https://runbooks.example.org/disk", "summary": "Disk full"},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     map[string]interface{}
+		expMsg       string
+		expInitError error
+	}{
+		{
+			name:     "All annotations by default",
+			settings: map[string]interface{}{},
+			expMsg:   "[FIRING:1]  (val1)\nhttp:/localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - description = Disk is almost full\n - runbook_url = https://runbooks.example.org/disk\n - summary = Disk full\nSource: \n\n\n\n\n",
+		}, {
+			name:     "Selected annotations in order",
+			settings: map[string]interface{}{"annotation_fields": []interface{}{"summary", "description", "missing"}},
+			expMsg:   "[FIRING:1]  (val1)\nhttp:/localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - summary = Disk full\n - description = Disk is almost full\nSource: \n\n\n\n\n",
+		}, {
+			name:     "Selected annotations from a text area",
+			settings: map[string]interface{}{"annotation_fields": `["summary", "description"]`},
+			expMsg:   "[FIRING:1]  (val1)\nhttp:/localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - summary = Disk full\n - description = Disk is almost full\nSource: \n\n\n\n\n",
+		}, {
+			name:     "Selected annotations with a locale",
+			settings: map[string]interface{}{"locale": "de", "annotation_fields": []interface{}{"runbook_url"}},
+			expMsg:   "[AUSGELÖST:1]  (val1)\nhttp:/localhost/alerting/list\n\n\n**Ausgelöst**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotationen:\n - runbook_url = https://runbooks.example.org/disk\nQuelle: \n\n\n\n\n",
+		}, {
+			name: "Custom message only sees the selected annotations",
+			settings: map[string]interface{}{
+				"annotation_fields": []interface{}{"summary"},
+				"message":           `{{ range .CommonAnnotations.SortedPairs }}{{ .Name }}={{ .Value }};{{ end }}{{ range .Alerts }}{{ len .Annotations }}{{ end }}`,
+			},
+			expMsg: "[FIRING:1]  (val1)\nhttp:/localhost/alerting/list\n\nsummary=Disk full;1",
+		}, {
+			name:         "Not a list",
+			settings:     map[string]interface{}{"annotation_fields": "summary"},
+			expInitError: alerting.ValidationError{Reason: "Invalid annotation fields: Must be a list of annotation names"},
+		}, {
+			name:         "Invalid annotation name",
+			settings:     map[string]interface{}{"annotation_fields": []interface{}{"summary", "runbook-url"}},
+			expInitError: alerting.ValidationError{Reason: "Invalid annotation field \"runbook-url\": Must be a valid annotation name"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := map[string]interface{}{"token": "sometoken"}
+			for k, v := range c.settings {
+				settings[k] = v
+			}
+			pn, err := NewLineNotifier(&NotificationChannelConfig{
+				Name:     "line_testing",
+				Type:     "line",
+				Settings: simplejson.NewFromAny(settings),
+			}, tmpl)
+			if c.expInitError != nil {
+				require.Equal(t, c.expInitError, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var body string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+
+			ok, err := pn.Notify(notifyContext(), alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			require.Equal(t, c.expMsg, values.Get("message"))
+		})
+	}
+}
+
+func TestLineNotifierUploadImage(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	imagesDir := t.TempDir()
+	screenshot := filepath.Join(imagesDir, "panel.png")
+	require.NoError(t, ioutil.WriteFile(screenshot, []byte("\x89PNG fake image"), 0600))
+	outside := filepath.Join(t.TempDir(), "secret.png")
+	require.NoError(t, ioutil.WriteFile(outside, []byte("top secret content"), 0600))
+
+	newNotifier := func(t *testing.T, dir string) (*LineNotifier, error) {
+		return NewLineNotifier(&NotificationChannelConfig{
+			Name: "line_testing",
+			Type: "line",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"token":              "sometoken",
+				"upload_image":       true,
+				"image":              "http://localhost/public/img/attachments",
+				"sticker_package_id": "446",
+				"sticker_id":         "1988",
+			}),
+			ImagesDir: dir,
+		}, tmpl)
+	}
+	alertWithScreenshot := func(path string) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1"},
+				Annotations: model.LabelSet{screenshotPathAnnotation: model.LabelValue(path), screenshotURLAnnotation: "panel.png"},
+			},
+		}
+	}
+
+	t.Run("Uploads the screenshot as multipart form", func(t *testing.T) {
+		ln, err := newNotifier(t, imagesDir)
+		require.NoError(t, err)
+		cmd := sendAndCaptureAlerts(t, ln, alertWithScreenshot(screenshot))
+
+		mediaType, params, err := mime.ParseMediaType(cmd.HttpHeader["Content-Type"])
+		require.NoError(t, err)
+		require.Equal(t, "multipart/form-data", mediaType)
+		require.NotEmpty(t, params["boundary"])
+		require.True(t, strings.HasPrefix(cmd.Body, "--"+params["boundary"]+"\r\n"))
+		require.True(t, strings.HasSuffix(cmd.Body, "--"+params["boundary"]+"--\r\n"))
+
+		form, err := multipart.NewReader(strings.NewReader(cmd.Body), params["boundary"]).ReadForm(1 << 20)
+		require.NoError(t, err)
+		require.Contains(t, form.Value["message"][0], "alert1")
+		require.Equal(t, []string{"446"}, form.Value["stickerPackageId"])
+		require.Equal(t, []string{"1988"}, form.Value["stickerId"])
+		require.NotContains(t, form.Value, "imageThumbnail")
+
+		require.Len(t, form.File["imageFile"], 1)
+		require.Equal(t, "panel.png", form.File["imageFile"][0].Filename)
+		f, err := form.File["imageFile"][0].Open()
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		require.Equal(t, "\x89PNG fake image", string(content))
+	})
+
+	t.Run("Doesn't upload files outside of the images directory", func(t *testing.T) {
+		ln, err := newNotifier(t, imagesDir)
+		require.NoError(t, err)
+		cmd := sendAndCaptureAlerts(t, ln, alertWithScreenshot(outside))
+
+		require.Equal(t, "application/x-www-form-urlencoded;charset=UTF-8", cmd.HttpHeader["Content-Type"])
+		require.NotContains(t, cmd.Body, "top secret content")
+		values, err := url.ParseQuery(cmd.Body)
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost/public/img/attachments/panel.png", values.Get("imageFullsize"))
+
+		cmd = sendAndCaptureAlerts(t, ln, alertWithScreenshot(imagesDir+"/../"+filepath.Base(filepath.Dir(outside))+"/secret.png"))
+		require.NotContains(t, cmd.Body, "top secret content")
+	})
+
+	t.Run("Requires an images directory", func(t *testing.T) {
+		_, err := newNotifier(t, "")
+		require.EqualError(t, err, alerting.ValidationError{Reason: "Invalid upload image: Grafana has no images directory to upload screenshots from"}.Error())
+	})
+}
+
+// sendAndCaptureAlerts notifies n about as and returns the webhook command it
+// dispatched.
+func sendAndCaptureAlerts(t *testing.T, n *LineNotifier, as ...*types.Alert) *models.SendWebhookSync {
+	t.Helper()
+
+	var cmd *models.SendWebhookSync
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		cmd = webhook
+		return nil
+	})
+	ok, err := n.Notify(notifyContext(), as...)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, cmd)
+	return cmd
+}
@@ -0,0 +1,203 @@
+package channels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// notificationLocale holds the translations of the words of the default
+// notification templates.
+type notificationLocale struct {
+	Firing      string
+	Resolved    string
+	Labels      string
+	Annotations string
+	Source      string
+	Duration    string
+}
+
+// defaultLocale is the locale of the default notification templates.
+const defaultLocale = "en"
+
+// notificationLocales are the translations for the locale setting of
+// notifiers, by locale. English is englishLocale.
+var notificationLocales = map[string]notificationLocale{
+	"de": {
+		Firing:      "Ausgelöst",
+		Resolved:    "Behoben",
+		Labels:      "Labels",
+		Annotations: "Annotationen",
+		Source:      "Quelle",
+		Duration:    "Dauer",
+	},
+	"ja": {
+		Firing:      "発生中",
+		Resolved:    "解決済み",
+		Labels:      "ラベル",
+		Annotations: "アノテーション",
+		Source:      "ソース",
+		Duration:    "継続時間",
+	},
+}
+
+// englishLocale holds the words of the default notification templates.
+var englishLocale = notificationLocale{
+	Firing:      "Firing",
+	Resolved:    "Resolved",
+	Labels:      "Labels",
+	Annotations: "Annotations",
+	Source:      "Source",
+	Duration:    "Duration",
+}
+
+// localizedTemplates returns the default title and message templates for
+// the locale setting of a notification channel.
+func localizedTemplates(settings *simplejson.Json) (string, string, error) {
+	return localizedTemplatesWithAnnotations(settings, nil)
+}
+
+// localizedTemplatesWithAnnotations is like localizedTemplates, but if
+// annotationFields is not empty the default message only lists those
+// annotations of each alert, in that order.
+//
+// The default message links the source of each alert, its generator URL,
+// unless the include_source setting is false. It lists the labels of each
+// alert sorted by name, after the labels of the label_order setting. In
+// English, it is the "default.message" template unless one of these settings
+// changes it.
+func localizedTemplatesWithAnnotations(settings *simplejson.Json, annotationFields []string) (string, string, error) {
+	includeSource := settings.Get("include_source").MustBool(true)
+	labelOrder, err := parseLabelOrder(settings)
+	if err != nil {
+		return "", "", err
+	}
+	locale := settings.Get("locale").MustString(defaultLocale)
+	if locale == defaultLocale {
+		// The templates of the Alertmanager, which may be customized, are
+		// kept unless the message must differ from them.
+		if len(annotationFields) == 0 && len(labelOrder) == 0 && includeSource {
+			return `{{ template "default.title" . }}`, `{{ template "default.message" . }}`, nil
+		}
+		return `{{ template "default.title" . }}`, englishLocale.message(annotationFields, labelOrder, includeSource), nil
+	}
+
+	l, ok := notificationLocales[locale]
+	if !ok {
+		locales := []string{defaultLocale}
+		for name := range notificationLocales {
+			locales = append(locales, name)
+		}
+		sort.Strings(locales)
+		return "", "", alerting.ValidationError{Reason: "Invalid locale: Must be one of " + strings.Join(locales, ", ")}
+	}
+	return l.title(), l.message(annotationFields, labelOrder, includeSource), nil
+}
+
+// title returns the translation of the "default.title" template.
+func (l notificationLocale) title() string {
+	return fmt.Sprintf(`[{{ if eq .Status "firing" }}%s:{{ .Alerts.Firing | len }}{{ else }}%s{{ end }}] `+
+		`{{ .GroupLabels.SortedPairs.Values | join " " }} `+
+		`{{ if gt (len .CommonLabels) (len .GroupLabels) }}({{ with .CommonLabels.Remove .GroupLabels.Names }}{{ .Values | join " " }}{{ end }}){{ end }}`,
+		strings.ToUpper(l.Firing), strings.ToUpper(l.Resolved))
+}
+
+// message returns the translation of the "default.message" template, which
+// also tells for how long each alert has been firing. If annotationFields is
+// not empty, only those annotations are listed, in that order, instead of all
+// annotations sorted by name. The labels in labelOrder are listed first, in
+// that order, and the others sorted by name. includeSource adds the generator
+// URL of each alert.
+func (l notificationLocale) message(annotationFields, labelOrder []string, includeSource bool) string {
+	labelList := `{{ range .Labels.SortedPairs }} - {{ .Name }} = {{ .Value }}
+{{ end }}`
+	if len(labelOrder) > 0 {
+		var b strings.Builder
+		quoted := make([]string, 0, len(labelOrder))
+		for _, name := range labelOrder {
+			fmt.Fprintf(&b, "{{ with index .Labels %q }} - %s = {{ . }}\n{{ end }}", name, name)
+			quoted = append(quoted, fmt.Sprintf("%q", name))
+		}
+		fmt.Fprintf(&b, `{{ range (.Labels.Remove (stringSlice %s)).SortedPairs }} - {{ .Name }} = {{ .Value }}
+{{ end }}`, strings.Join(quoted, " "))
+		labelList = b.String()
+	}
+
+	annotationList := `{{ range .Annotations.SortedPairs }} - {{ .Name }} = {{ .Value }}
+{{ end }}`
+	if len(annotationFields) > 0 {
+		var b strings.Builder
+		for _, name := range annotationFields {
+			fmt.Fprintf(&b, "{{ with index .Annotations %q }} - %s = {{ . }}\n{{ end }}", name, name)
+		}
+		annotationList = b.String()
+	}
+
+	sourceLine := ""
+	if includeSource {
+		sourceLine = fmt.Sprintf("%s: {{ .GeneratorURL }}\n", l.Source)
+	}
+
+	alertList := fmt.Sprintf(`{{ range . }}%s:
+%s%s:
+%s{{ with alertDuration . }}%s: {{ humanizeDuration . }}
+{{ end }}%s{{ end }}`, l.Labels, labelList, l.Annotations, annotationList, l.Duration, sourceLine)
+
+	return fmt.Sprintf(`{{ if gt (len .Alerts.Firing) 0 }}
+**%s**
+{{ with .Alerts.Firing }}%s{{ end }}
+
+{{ end }}
+{{ if gt (len .Alerts.Resolved) 0 }}
+**%s**
+{{ with .Alerts.Resolved }}%s{{ end }}
+{{ end }}
+`, l.Firing, alertList, l.Resolved, alertList)
+}
+
+// parseAnnotationFields returns the annotation_fields setting of a
+// notification channel: the names of the annotations to render, in order.
+func parseAnnotationFields(settings *simplejson.Json) ([]string, error) {
+	fields, ok := stringListSetting(settings, "annotation_fields")
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid annotation fields: Must be a list of annotation names"}
+	}
+	for _, name := range fields {
+		if !model.LabelName(name).IsValid() {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid annotation field %q: Must be a valid annotation name", name)}
+		}
+	}
+	return fields, nil
+}
+
+// parseLabelOrder returns the label_order setting of a notification channel:
+// the names of the labels to list first, in order.
+func parseLabelOrder(settings *simplejson.Json) ([]string, error) {
+	names, ok := stringListSetting(settings, "label_order")
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid label order: Must be a list of label names"}
+	}
+	for _, name := range names {
+		if !model.LabelName(name).IsValid() {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid label %q in label order: Must be a valid label name", name)}
+		}
+	}
+	return names, nil
+}
+
+// selectAnnotations returns the annotations in kv whose names are in fields.
+func selectAnnotations(kv template.KV, fields []string) template.KV {
+	selected := make(template.KV, len(fields))
+	for _, name := range fields {
+		if v, ok := kv[name]; ok {
+			selected[name] = v
+		}
+	}
+	return selected
+}
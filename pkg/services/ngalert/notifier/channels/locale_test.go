@@ -0,0 +1,230 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestNotifiersLocale(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	// The field of the form that holds the message of each notifier.
+	messageFields := map[string]string{"threema": "text", "line": "message"}
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1"},
+		},
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			StartsAt: time.Now().Add(-2 * time.Hour),
+			EndsAt:   time.Now().Add(-time.Hour),
+		},
+	}
+
+	cases := []struct {
+		locale      string
+		alert       *types.Alert
+		expContains []string
+	}{
+		{
+			locale: "de",
+			alert:  alert,
+			expContains: []string{
+				"[AUSGELÖST:1]  (val1)",
+				"\n**Ausgelöst**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotationen:\n - ann1 = annv1\nQuelle: \n",
+			},
+		}, {
+			locale:      "de",
+			alert:       resolved,
+			expContains: []string{"[BEHOBEN]  (val1)", "\n**Behoben**\nLabels:\n", "Dauer: 1h\nQuelle: \n"},
+		}, {
+			locale: "ja",
+			alert:  alert,
+			expContains: []string{
+				"[発生中:1]  (val1)",
+				"\n**発生中**\nラベル:\n - alertname = alert1\n - lbl1 = val1\nアノテーション:\n - ann1 = annv1\nソース: \n",
+			},
+		}, {
+			locale: "en",
+			alert:  alert,
+			expContains: []string{
+				"[FIRING:1]  (val1)",
+				"\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		for name, n := range notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"locale": c.locale}) {
+			t.Run(c.locale+" "+name+" "+string(c.alert.Status()), func(t *testing.T) {
+				var values url.Values
+				bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+					var err error
+					values, err = url.ParseQuery(webhook.Body)
+					return err
+				})
+
+				ok, err := n.Notify(notifyContext(), c.alert)
+				require.NoError(t, err)
+				require.True(t, ok)
+
+				for _, s := range c.expContains {
+					require.Contains(t, values.Get(messageFields[name]), s)
+				}
+			})
+		}
+	}
+
+	t.Run("Invalid locale", func(t *testing.T) {
+		_, err := NewLineNotifier(&NotificationChannelConfig{
+			Name:     "line_testing",
+			Type:     "line",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"token": "sometoken", "locale": "xx"}),
+		}, tmpl)
+		require.EqualError(t, err, alerting.ValidationError{Reason: "Invalid locale: Must be one of de, en, ja"}.Error())
+	})
+}
+
+func TestNotifiersIncludeSource(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:       model.LabelSet{"alertname": "alert1", "instance": "db-1"},
+				GeneratorURL: "http://localhost/alerting/grafana/abc/view",
+			},
+		}, {
+			Alert: model.Alert{
+				Labels:       model.LabelSet{"alertname": "alert1", "instance": "db-2"},
+				GeneratorURL: "http://localhost/alerting/grafana/def/view",
+			},
+		},
+	}
+
+	render := func(t *testing.T, n notify.Notifier) string {
+		var body string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			body = webhook.Body
+			return nil
+		})
+		ok, err := n.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		values, err := url.ParseQuery(body)
+		require.NoError(t, err)
+		return values.Get("text") + values.Get("message")
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+		t.Run(name+" includes the source of each alert", func(t *testing.T) {
+			text := render(t, n)
+			require.Contains(t, text, " - instance = db-1\nAnnotations:\nSource: http://localhost/alerting/grafana/abc/view\n")
+			require.Contains(t, text, " - instance = db-2\nAnnotations:\nSource: http://localhost/alerting/grafana/def/view\n")
+		})
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"include_source": false}) {
+		t.Run(name+" without the source", func(t *testing.T) {
+			text := render(t, n)
+			require.Contains(t, text, " - instance = db-1\nAnnotations:\n")
+			require.NotContains(t, text, "Source:")
+			require.NotContains(t, text, "/alerting/grafana/")
+		})
+	}
+}
+
+func TestNotifiersLabelOrder(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"zone": "eu-1", "team": "db", "severity": "critical", "instance": "db-1", "alertname": "alert1"},
+			},
+		},
+	}
+
+	render := func(t *testing.T, n notify.Notifier) string {
+		var body string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			body = webhook.Body
+			return nil
+		})
+		ok, err := n.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		values, err := url.ParseQuery(body)
+		require.NoError(t, err)
+		return values.Get("text") + values.Get("message")
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+		t.Run(name+" sorts labels by name", func(t *testing.T) {
+			text := render(t, n)
+			require.Contains(t, text, "Labels:\n - alertname = alert1\n - instance = db-1\n - severity = critical\n - team = db\n - zone = eu-1\n")
+			for i := 0; i < 10; i++ {
+				require.Equal(t, text, render(t, n))
+			}
+		})
+	}
+
+	pinned := map[string]interface{}{"label_order": []interface{}{"severity", "team", "missing"}}
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, pinned) {
+		t.Run(name+" lists pinned labels first", func(t *testing.T) {
+			text := render(t, n)
+			require.Contains(t, text, "Labels:\n - severity = critical\n - team = db\n - alertname = alert1\n - instance = db-1\n - zone = eu-1\n")
+			require.NotContains(t, text, "missing")
+		})
+	}
+
+	fromTextArea := map[string]interface{}{"label_order": `["severity", "team"]`}
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, fromTextArea) {
+		t.Run(name+" reads the label order from a text area", func(t *testing.T) {
+			text := render(t, n)
+			require.Contains(t, text, "Labels:\n - severity = critical\n - team = db\n - alertname = alert1\n")
+		})
+	}
+
+	t.Run("Invalid label order", func(t *testing.T) {
+		for _, order := range []interface{}{"severity", []interface{}{"not a label"}} {
+			_, err := NewLineNotifier(&NotificationChannelConfig{
+				Name:     "line_testing",
+				Type:     "line",
+				Settings: simplejson.NewFromAny(map[string]interface{}{"token": "sometoken", "label_order": order}),
+			}, tmpl)
+			require.Error(t, err)
+			require.IsType(t, alerting.ValidationError{}, err)
+		}
+	})
+}
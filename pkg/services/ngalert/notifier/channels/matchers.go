@@ -0,0 +1,62 @@
+package channels
+
+import (
+	"fmt"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// parseDropMatchers reads the drop_matchers setting of a notification
+// channel: a list of label matchers such as severity="info". It returns nil
+// if no matchers are configured.
+func parseDropMatchers(settings *simplejson.Json) (labels.Matchers, error) {
+	ss, ok := stringListSetting(settings, "drop_matchers")
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid drop matchers: Must be a list of label matchers such as severity=\"info\""}
+	}
+	if len(ss) == 0 {
+		return nil, nil
+	}
+
+	matchers := make(labels.Matchers, 0, len(ss))
+	for _, s := range ss {
+		m, err := labels.ParseMatcher(s)
+		if err != nil {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid drop matcher %q: %s", s, err)}
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// dropAlerts returns the alerts in as whose labels don't match all of
+// matchers, in the same order.
+func dropAlerts(as []*types.Alert, matchers labels.Matchers) []*types.Alert {
+	if len(matchers) == 0 {
+		return as
+	}
+
+	kept := make([]*types.Alert, 0, len(as))
+	for _, a := range as {
+		if !matchesLabels(matchers, a.Labels) {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// matchesLabels reports whether lset matches all of matchers. Missing labels
+// have the empty value.
+func matchesLabels(matchers labels.Matchers, lset model.LabelSet) bool {
+	for _, m := range matchers {
+		if !m.Matches(string(lset[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// MatrixNotifier is responsible for sending
+// alert notifications to a Matrix room.
+type MatrixNotifier struct {
+	old_notifiers.NotifierBase
+	HomeserverURL    string
+	RoomID           string
+	AccessToken      string
+	Message          string
+	FormattedMessage string
+	retry            retryOptions
+	httpOptions      httpOptions
+	metrics          *DeliveryMetrics
+	log              log.Logger
+	tmpl             *template.Template
+}
+
+// NewMatrixNotifier is the constructor for the Matrix notifier
+func NewMatrixNotifier(model *NotificationChannelConfig, t *template.Template) (*MatrixNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	homeserverURL := model.Settings.Get("homeserver_url").MustString()
+	if homeserverURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Matrix homeserver URL in settings"}
+	}
+	if u, err := url.Parse(homeserverURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Matrix homeserver URL: Must be an absolute URL"}
+	}
+
+	roomID := model.Settings.Get("room_id").MustString()
+	if roomID == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Matrix room ID in settings"}
+	}
+
+	accessToken := model.DecryptedValue("access_token", model.Settings.Get("access_token").MustString())
+	if accessToken == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Matrix access token in settings"}
+	}
+
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+	// The formatted message is optional HTML shown by clients that support it.
+	formattedMessage := model.Settings.Get("formatted_message").MustString()
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.matrix")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &MatrixNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		HomeserverURL:    strings.TrimSuffix(homeserverURL, "/"),
+		RoomID:           roomID,
+		AccessToken:      accessToken,
+		Message:          message,
+		FormattedMessage: formattedMessage,
+		retry:            newRetryOptions(maxRetries),
+		httpOptions:      httpOpts,
+		metrics:          model.Metrics,
+		log:              logger,
+		tmpl:             t,
+	}, nil
+}
+
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// Notify sends an alert notification to Matrix
+func (mn *MatrixNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := mn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Matrix notification", "notification", mn.Name)
+
+	// The formatted message is rendered as HTML, so that label values can't
+	// inject markup into it.
+	rendered, err := renderTextAndHTML(ctx, mn.tmpl, as, `{{ template "default.title" . }}`+"\n"+mn.Message, mn.FormattedMessage)
+	if err != nil {
+		return false, fmt.Errorf("failed to template Matrix message: %w", err)
+	}
+
+	msg := matrixMessage{
+		MsgType: "m.text",
+		Body:    rendered.Text,
+	}
+	if rendered.HTML != "" {
+		msg.Format = "org.matrix.custom.html"
+		msg.FormattedBody = rendered.HTML
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	// The transaction ID makes retries of the same event idempotent, so
+	// every notification needs a new one.
+	txnID := util.GenerateShortUID()
+	cmd := &models.SendWebhookSync{
+		Url: fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message/%s",
+			mn.HomeserverURL, url.PathEscape(mn.RoomID), url.PathEscape(txnID)),
+		HttpMethod: "PUT",
+		HttpHeader: map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", mn.AccessToken),
+			"Content-Type":  "application/json",
+		},
+		Body: string(body),
+	}
+	if err := mn.httpOptions.apply(ctx, cmd, mn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, mn.retry)
+	mn.metrics.observe("matrix", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to send Matrix notification", "error", err, "webhook", mn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (mn *MatrixNotifier) SendResolved() bool {
+	return !mn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (mn *MatrixNotifier) Type() string {
+	return "matrix"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (mn *MatrixNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
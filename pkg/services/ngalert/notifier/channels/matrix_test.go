@@ -0,0 +1,164 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestMatrixNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURLPrefix string
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"homeserver_url": "http://localhost:8008/", "room_id": "!abc:example.org", "access_token": "sometoken"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expURLPrefix: "http://localhost:8008/_matrix/client/r0/rooms/%21abc:example.org/send/m.room.message/",
+			expMsg:       `{"msgtype":"m.text","body":"[FIRING:1]  (val1)\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Formatted message",
+			settings: `{
+				"homeserver_url": "https://matrix.example.org",
+				"room_id": "!abc:example.org",
+				"access_token": "sometoken",
+				"message": "{{ len .Alerts.Firing }} firing",
+				"formatted_message": "<b>{{ len .Alerts.Firing }}</b> firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+						Annotations: model.LabelSet{"ann1": "annv2"},
+					},
+				},
+			},
+			expURLPrefix: "https://matrix.example.org/_matrix/client/r0/rooms/%21abc:example.org/send/m.room.message/",
+			expMsg:       `{"msgtype":"m.text","body":"[FIRING:2]  \n2 firing","format":"org.matrix.custom.html","formatted_body":"<b>2</b> firing"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Formatted message escapes label values",
+			settings: `{
+				"homeserver_url": "https://matrix.example.org",
+				"room_id": "!abc:example.org",
+				"access_token": "sometoken",
+				"message": "{{ .CommonLabels.lbl1 }}",
+				"formatted_message": "<b>{{ .CommonLabels.lbl1 }}</b>"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "<script>alert(1)</script>"},
+					},
+				},
+			},
+			expURLPrefix: "https://matrix.example.org/_matrix/client/r0/rooms/%21abc:example.org/send/m.room.message/",
+			expMsg:       `{"msgtype":"m.text","body":"[FIRING:1]  (<script>alert(1)</script>)\n<script>alert(1)</script>","format":"org.matrix.custom.html","formatted_body":"<b>&lt;script&gt;alert(1)&lt;/script&gt;</b>"}`,
+		}, {
+			name:         "Homeserver URL missing",
+			settings:     `{"room_id": "!abc:example.org", "access_token": "sometoken"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Matrix homeserver URL in settings"},
+		}, {
+			name:         "Invalid homeserver URL",
+			settings:     `{"homeserver_url": "matrix.example.org", "room_id": "!abc:example.org", "access_token": "sometoken"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Matrix homeserver URL: Must be an absolute URL"},
+		}, {
+			name:         "Room ID missing",
+			settings:     `{"homeserver_url": "https://matrix.example.org", "access_token": "sometoken"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Matrix room ID in settings"},
+		}, {
+			name:         "Access token missing",
+			settings:     `{"homeserver_url": "https://matrix.example.org", "room_id": "!abc:example.org"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Matrix access token in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "matrix_testing",
+				Type:     "matrix",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewMatrixNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var sent []*models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				sent = append(sent, webhook)
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			for i := 0; i < 2; i++ {
+				ok, err := pn.Notify(ctx, c.alerts...)
+				if c.expMsgError != nil {
+					require.False(t, ok)
+					require.Error(t, err)
+					require.Equal(t, c.expMsgError.Error(), err.Error())
+					return
+				}
+				require.NoError(t, err)
+				require.True(t, ok)
+			}
+
+			require.Len(t, sent, 2)
+			for _, cmd := range sent {
+				require.Equal(t, "PUT", cmd.HttpMethod)
+				require.Equal(t, "Bearer sometoken", cmd.HttpHeader["Authorization"])
+				require.True(t, strings.HasPrefix(cmd.Url, c.expURLPrefix), cmd.Url)
+				require.NotEqual(t, c.expURLPrefix, cmd.Url, "missing transaction ID")
+				require.JSONEq(t, c.expMsg, cmd.Body)
+			}
+			require.NotEqual(t, sent[0].Url, sent[1].Url, "transaction IDs must be unique")
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+// MattermostNotifier is responsible for sending
+// alert notifications to Mattermost.
+type MattermostNotifier struct {
+	old_notifiers.NotifierBase
+	URL         string
+	Channel     string
+	Username    string
+	IconURL     string
+	Message     string
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewMattermostNotifier is the constructor for the Mattermost notifier
+func NewMattermostNotifier(model *NotificationChannelConfig, t *template.Template) (*MattermostNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	webhookURL := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if webhookURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+	if u, err := url.Parse(webhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Mattermost webhook URL: Must be an absolute URL"}
+	}
+
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.mattermost")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &MattermostNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:         webhookURL,
+		Channel:     model.Settings.Get("channel").MustString(),
+		Username:    model.Settings.Get("username").MustString(),
+		IconURL:     model.Settings.Get("icon_url").MustString(),
+		Message:     message,
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+type mattermostMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+	IconURL  string `json:"icon_url,omitempty"`
+}
+
+// Notify sends an alert notification to Mattermost
+func (mn *MattermostNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := mn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Mattermost notification", "notification", mn.Name)
+
+	data := notify.GetTemplateData(ctx, mn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, mn.tmpl, data, &tmplErr)
+
+	msg := mattermostMessage{
+		Text:     fmt.Sprintf("%s\n%s", tmpl(`{{ template "default.title" . }}`), tmpl(mn.Message)),
+		Channel:  mn.Channel,
+		Username: mn.Username,
+		IconURL:  mn.IconURL,
+	}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Mattermost message: %w", tmplErr)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        mn.URL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+	if err := mn.httpOptions.apply(ctx, cmd, mn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, mn.retry)
+	mn.metrics.observe("mattermost", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to send Mattermost notification", "error", err, "webhook", mn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (mn *MattermostNotifier) SendResolved() bool {
+	return !mn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (mn *MattermostNotifier) Type() string {
+	return "mattermost"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (mn *MattermostNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
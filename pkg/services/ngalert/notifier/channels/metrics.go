@@ -0,0 +1,57 @@
+package channels
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+// DeliveryMetrics instruments the delivery of notifications to integrations.
+type DeliveryMetrics struct {
+	sent     *prometheus.CounterVec
+	failed   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewDeliveryMetrics returns the delivery metrics registered with r. The
+// Alertmanager registers them once and hands them to its notifiers.
+func NewDeliveryMetrics(r prometheus.Registerer) *DeliveryMetrics {
+	return &DeliveryMetrics{
+		sent: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "integration_sent_total",
+			Help:      "The total number of notifications delivered successfully.",
+		}, []string{"integration", "status"}),
+		failed: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "integration_failed_total",
+			Help:      "The total number of notifications that failed to be delivered.",
+		}, []string{"integration", "status"}),
+		duration: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "integration_duration_seconds",
+			Help:      "Histogram of the time it takes to deliver a notification.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"integration"}),
+	}
+}
+
+// observe records the outcome of a delivery to integration that started at
+// start. The status is the status (firing or resolved) of the notified alerts.
+// Nothing is recorded if m is nil.
+func (m *DeliveryMetrics) observe(integration string, status model.AlertStatus, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.duration.WithLabelValues(integration).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.failed.WithLabelValues(integration, string(status)).Inc()
+		return
+	}
+	m.sent.WithLabelValues(integration, string(status)).Inc()
+}
@@ -0,0 +1,69 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDeliveryMetrics(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	threema := notifiersWithHTTPOptions(t, tmpl, nil)["threema"].(*ThreemaNotifier)
+	line := notifiersWithHTTPOptions(t, tmpl, nil)["line"].(*LineNotifier)
+
+	cases := []struct {
+		integration string
+		notifier    notify.Notifier
+		setMetrics  func(*DeliveryMetrics)
+	}{
+		{integration: "threema", notifier: threema, setMetrics: func(m *DeliveryMetrics) { threema.metrics = m }},
+		{integration: "line", notifier: line, setMetrics: func(m *DeliveryMetrics) { line.metrics = m }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.integration, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			m := NewDeliveryMetrics(reg)
+			c.setMetrics(m)
+
+			sendAndCapture(t, c.notifier)
+			require.Equal(t, 1.0, testutil.ToFloat64(m.sent.WithLabelValues(c.integration, "firing")))
+			require.Equal(t, 0.0, testutil.ToFloat64(m.failed.WithLabelValues(c.integration, "firing")))
+
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				return errors.New("gateway unavailable")
+			})
+			ok, err := c.notifier.Notify(notifyContext(), firingAlert())
+			require.Error(t, err)
+			require.False(t, ok)
+			require.Equal(t, 1.0, testutil.ToFloat64(m.sent.WithLabelValues(c.integration, "firing")))
+			require.Equal(t, 1.0, testutil.ToFloat64(m.failed.WithLabelValues(c.integration, "firing")))
+
+			families, err := reg.Gather()
+			require.NoError(t, err)
+			var observations uint64
+			for _, mf := range families {
+				if mf.GetName() == "grafana_alerting_integration_duration_seconds" {
+					for _, metric := range mf.GetMetric() {
+						observations += metric.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+			require.Equal(t, uint64(2), observations)
+		})
+	}
+}
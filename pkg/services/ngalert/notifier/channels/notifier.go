@@ -0,0 +1,96 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Notifier is implemented by the notifiers of the notification channels.
+type Notifier interface {
+	// Notify sends a notification for the alerts and reports whether it
+	// should be retried after a failure.
+	Notify(ctx context.Context, as ...*types.Alert) (bool, error)
+	// SendResolved reports whether notifications for resolved alerts are sent.
+	SendResolved() bool
+	// Type returns the kind of the notification channel, e.g. "threema".
+	Type() string
+	// Ping checks whether the credentials and endpoint of the notification
+	// channel are valid without sending a notification. It returns
+	// ErrPingUnsupported if the provider has no way to do that.
+	Ping(ctx context.Context) error
+}
+
+// BuildNotifier builds the notifier for the type of the notification channel.
+func BuildNotifier(model *NotificationChannelConfig, t *template.Template) (Notifier, error) {
+	switch model.Type {
+	case "email":
+		return NewEmailNotifier(model, t) // Email notifier already has a default template.
+	case "pagerduty":
+		return NewPagerdutyNotifier(model, t)
+	case "pushover":
+		return NewPushoverNotifier(model, t)
+	case "slack":
+		return NewSlackNotifier(model, t)
+	case "telegram":
+		return NewTelegramNotifier(model, t)
+	case "victorops":
+		return NewVictoropsNotifier(model, t)
+	case "teams":
+		return NewTeamsNotifier(model, t)
+	case "dingding":
+		return NewDingDingNotifier(model, t)
+	case "kafka":
+		return NewKafkaNotifier(model, t)
+	case "webhook":
+		return NewWebHookNotifier(model, t)
+	case "sensugo":
+		return NewSensuGoNotifier(model, t)
+	case "discord":
+		return NewDiscordNotifier(model, t)
+	case "alertmanager":
+		return NewAlertmanagerNotifier(model, t)
+	case "alertmanager-webhook":
+		return NewAlertmanagerWebhookNotifier(model, t)
+	case "googlechat":
+		return NewGoogleChatNotifier(model, t)
+	case "line":
+		return NewLineNotifier(model, t)
+	case "line-messaging":
+		return NewLineMessagingNotifier(model, t)
+	case "threema":
+		return NewThreemaNotifier(model, t)
+	case "opsgenie":
+		return NewOpsgenieNotifier(model, t)
+	case "wecom":
+		return NewWecomNotifier(model, t)
+	case "matrix":
+		return NewMatrixNotifier(model, t)
+	case "mattermost":
+		return NewMattermostNotifier(model, t)
+	case "signal":
+		return NewSignalNotifier(model, t)
+	case "servicenow":
+		return NewServiceNowNotifier(model, t)
+	case "zulip":
+		return NewZulipNotifier(model, t)
+	case "rocketchat":
+		return NewRocketChatNotifier(model, t)
+	case "gotify":
+		return NewGotifyNotifier(model, t)
+	case "twilio":
+		return NewTwilioSMSNotifier(model, t)
+	case "sns":
+		return NewSNSNotifier(model, t)
+	case "file":
+		return NewFileNotifier(model, t)
+	case "webex":
+		return NewWebexNotifier(model, t)
+	case "jira":
+		return NewJiraNotifier(model, t)
+	default:
+		return nil, fmt.Errorf("notifier %s is not supported", model.Type)
+	}
+}
@@ -0,0 +1,113 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestBuildNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	cases := []struct {
+		notifierType string
+		settings     string
+		expNotifier  Notifier
+	}{
+		{notifierType: "email", settings: `{"addresses": "someops@example.com"}`, expNotifier: &EmailNotifier{}},
+		{notifierType: "pagerduty", settings: `{"integrationKey": "abcdefgh0123456789"}`, expNotifier: &PagerdutyNotifier{}},
+		{notifierType: "pushover", settings: `{"userKey": "<userKey>", "apiToken": "<apiToken>"}`, expNotifier: &PushoverNotifier{}},
+		{notifierType: "slack", settings: `{"url": "https://hooks.slack.com/services/1"}`, expNotifier: &SlackNotifier{}},
+		{notifierType: "telegram", settings: `{"bottoken": "123456:abcdefgh0123456789", "chatid": "-1001234567890"}`, expNotifier: &TelegramNotifier{}},
+		{notifierType: "victorops", settings: `{"url": "http://localhost"}`, expNotifier: &VictoropsNotifier{}},
+		{notifierType: "teams", settings: `{"url": "http://localhost"}`, expNotifier: &TeamsNotifier{}},
+		{notifierType: "dingding", settings: `{"url": "http://localhost"}`, expNotifier: &DingDingNotifier{}},
+		{notifierType: "kafka", settings: `{"kafkaRestProxy": "http://localhost", "kafkaTopic": "sometopic"}`, expNotifier: &KafkaNotifier{}},
+		{notifierType: "webhook", settings: `{"url": "http://localhost"}`, expNotifier: &WebhookNotifier{}},
+		{notifierType: "sensugo", settings: `{"url": "http://localhost", "apikey": "abcdefgh0123456789"}`, expNotifier: &SensuGoNotifier{}},
+		{notifierType: "discord", settings: `{"url": "http://localhost"}`, expNotifier: &DiscordNotifier{}},
+		{notifierType: "alertmanager", settings: `{"url": "http://localhost"}`, expNotifier: &AlertmanagerNotifier{}},
+		{notifierType: "alertmanager-webhook", settings: `{"url": "http://localhost"}`, expNotifier: &AlertmanagerWebhookNotifier{}},
+		{notifierType: "googlechat", settings: `{"url": "http://localhost"}`, expNotifier: &GoogleChatNotifier{}},
+		{notifierType: "line", settings: `{"token": "sometoken"}`, expNotifier: &LineNotifier{}},
+		{notifierType: "line-messaging", settings: `{"token": "sometoken", "to": "U1234"}`, expNotifier: &LineMessagingNotifier{}},
+		{
+			notifierType: "threema",
+			settings:     `{"gateway_id": "*1234567", "recipient_id": "87654321", "api_secret": "supersecret12345"}`,
+			expNotifier:  &ThreemaNotifier{},
+		},
+		{notifierType: "opsgenie", settings: `{"apiKey": "abcdefgh0123456789"}`, expNotifier: &OpsgenieNotifier{}},
+		{notifierType: "wecom", settings: `{"key": "somekey"}`, expNotifier: &WecomNotifier{}},
+		{
+			notifierType: "matrix",
+			settings:     `{"homeserver_url": "http://localhost", "room_id": "!abc:example.org", "access_token": "sometoken"}`,
+			expNotifier:  &MatrixNotifier{},
+		},
+		{notifierType: "mattermost", settings: `{"url": "http://localhost/hooks/xxx"}`, expNotifier: &MattermostNotifier{}},
+		{
+			notifierType: "signal",
+			settings:     `{"signal_cli_url": "http://localhost:8080", "number": "+4912345", "recipients": "+4967890"}`,
+			expNotifier:  &SignalNotifier{},
+		},
+		{
+			notifierType: "servicenow",
+			settings:     `{"instance_url": "https://example.service-now.com", "username": "grafana", "password": "secret"}`,
+			expNotifier:  &ServiceNowNotifier{},
+		},
+		{
+			notifierType: "zulip",
+			settings:     `{"realm_url": "https://example.zulipchat.com", "bot_email": "bot@example.org", "api_key": "somekey", "stream": "alerts"}`,
+			expNotifier:  &ZulipNotifier{},
+		},
+		{notifierType: "rocketchat", settings: `{"url": "http://localhost/hooks/xxx"}`, expNotifier: &RocketChatNotifier{}},
+		{notifierType: "gotify", settings: `{"server_url": "http://localhost", "app_token": "sometoken"}`, expNotifier: &GotifyNotifier{}},
+		{
+			notifierType: "twilio",
+			settings:     `{"account_sid": "AC123", "auth_token": "sometoken", "from": "+15005550006", "to": "+4912345"}`,
+			expNotifier:  &TwilioSMSNotifier{},
+		},
+		{
+			notifierType: "sns",
+			settings:     `{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts"}`,
+			expNotifier:  &SNSNotifier{},
+		},
+		{
+			notifierType: "file",
+			settings:     `{"path": "notifications.log"}`,
+			expNotifier:  &FileNotifier{},
+		},
+		{
+			notifierType: "jira",
+			settings:     `{"url": "https://example.atlassian.net", "api_token": "sometoken", "project": "OPS", "issue_type": "Bug"}`,
+			expNotifier:  &JiraNotifier{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.notifierType, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			n, err := BuildNotifier(&NotificationChannelConfig{
+				Name:     c.notifierType + "_testing",
+				Type:     c.notifierType,
+				Settings: settingsJSON,
+				FileDir:  "/var/log/grafana",
+			}, tmpl)
+			require.NoError(t, err)
+			require.IsType(t, c.expNotifier, n)
+			require.Equal(t, c.notifierType, n.Type())
+		})
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := BuildNotifier(&NotificationChannelConfig{
+			Name:     "bogus_testing",
+			Type:     "bogus",
+			Settings: simplejson.New(),
+		}, tmpl)
+		require.EqualError(t, err, "notifier bogus is not supported")
+	})
+}
@@ -0,0 +1,54 @@
+package channels
+
+import (
+	"context"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// DetailedNotifier is implemented by notifiers that send a notification to
+// several targets, such as the recipients of a message, and can tell the
+// outcome for each of them. Their Notify only tells whether all targets
+// were notified.
+type DetailedNotifier interface {
+	NotifyDetailed(ctx context.Context, as ...*types.Alert) (NotifyResult, error)
+}
+
+// NotifyResult is the outcome of a notification for each of its targets. It
+// has no targets if nothing was sent, e.g. during quiet hours.
+type NotifyResult struct {
+	Targets []TargetResult
+}
+
+// TargetResult is the outcome of a notification for a single target. Err is
+// nil if the notification was delivered to the target.
+type TargetResult struct {
+	Target string
+	Err    error
+}
+
+// newNotifyResult returns the result of sending to targets, where errs holds
+// the error of every target in the same order, as returned by fanout.
+func newNotifyResult(targets []string, errs []error) NotifyResult {
+	r := NotifyResult{Targets: make([]TargetResult, len(targets))}
+	for i, target := range targets {
+		r.Targets[i] = TargetResult{Target: target, Err: errs[i]}
+	}
+	return r
+}
+
+// Delivered returns the number of targets the notification was delivered to.
+func (r NotifyResult) Delivered() int {
+	return len(r.Targets) - len(r.Failed())
+}
+
+// Failed returns the targets the notification wasn't delivered to.
+func (r NotifyResult) Failed() []TargetResult {
+	var failed []TargetResult
+	for _, t := range r.Targets {
+		if t.Err != nil {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,86 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	_ DetailedNotifier = &ThreemaNotifier{}
+	_ DetailedNotifier = &TwilioSMSNotifier{}
+)
+
+func TestNotifyDetailed(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	threema, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321,ABCDEFGH,12345678",
+			"api_secret":   "supersecret12345",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	twilio, err := NewTwilioSMSNotifier(&NotificationChannelConfig{
+		Name: "twilio_testing",
+		Type: "twilio",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"account_sid": "AC123",
+			"auth_token":  "sometoken",
+			"from":        "+15005550006",
+			"to":          "+4912345,ABCDEFGH,+4967890",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	// Sending to ABCDEFGH fails, the other recipients succeed.
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		if err != nil {
+			return err
+		}
+		if values.Get("to") == "ABCDEFGH" || values.Get("To") == "ABCDEFGH" {
+			return errors.New("unknown recipient")
+		}
+		return nil
+	})
+
+	for name, n := range map[string]DetailedNotifier{"threema": threema, "twilio": twilio} {
+		t.Run(name, func(t *testing.T) {
+			result, err := n.NotifyDetailed(notifyContext(), firingAlert())
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "1 of 3 recipients")
+
+			require.Len(t, result.Targets, 3)
+			require.Equal(t, 2, result.Delivered())
+			require.NoError(t, result.Targets[0].Err)
+			require.NoError(t, result.Targets[2].Err)
+
+			failed := result.Failed()
+			require.Len(t, failed, 1)
+			require.Equal(t, "ABCDEFGH", failed[0].Target)
+			require.Contains(t, failed[0].Err.Error(), "unknown recipient")
+		})
+	}
+
+	t.Run("Notify collapses the result", func(t *testing.T) {
+		ok, err := threema.Notify(notifyContext(), firingAlert())
+		require.False(t, ok)
+		require.EqualError(t, err, "failed to send Threema notification to 1 of 3 recipients: ABCDEFGH: unknown recipient")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
@@ -25,6 +26,9 @@ const (
 	OpsgenieSendTags    = "tags"
 	OpsgenieSendDetails = "details"
 	OpsgenieSendBoth    = "both"
+
+	// opsgenieEUAlertURL is the alert API of the EU region of Opsgenie.
+	opsgenieEUAlertURL = "https://api.eu.opsgenie.com/v2/alerts"
 )
 
 var (
@@ -32,6 +36,24 @@ var (
 	ValidPriorities  = map[string]bool{"P1": true, "P2": true, "P3": true, "P4": true, "P5": true}
 )
 
+// opsgenieSeverityPriorities maps the values of the severity label to
+// Opsgenie priorities. Alerts with other severities get the default priority
+// of Opsgenie.
+var opsgenieSeverityPriorities = map[string]alertPriority{
+	"critical": priorityP1,
+	"error":    priorityP2,
+	"high":     priorityP2,
+	"warning":  priorityP3,
+	"info":     priorityP4,
+	"low":      priorityP5,
+}
+
+// opsgenieResponder is a responder of an Opsgenie alert.
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
 // OpsgenieNotifier is responsible for sending alert notifications to Opsgenie.
 type OpsgenieNotifier struct {
 	old_notifiers.NotifierBase
@@ -40,8 +62,11 @@ type OpsgenieNotifier struct {
 	AutoClose        bool
 	OverridePriority bool
 	SendTagsAs       string
-	tmpl             *template.Template
-	log              log.Logger
+	SeverityLabel    string
+	// ResponderTeams are the names of the teams the alerts are assigned to.
+	ResponderTeams []string
+	tmpl           *template.Template
+	log            log.Logger
 }
 
 // NewOpsgenieNotifier is the constructor for the Opsgenie notifier
@@ -54,7 +79,21 @@ func NewOpsgenieNotifier(model *NotificationChannelConfig, t *template.Template)
 		return nil, alerting.ValidationError{Reason: "Could not find api key property in settings"}
 	}
 	if apiURL == "" {
-		apiURL = OpsgenieAlertURL
+		switch region := model.Settings.Get("region").MustString("us"); region {
+		case "us":
+			apiURL = OpsgenieAlertURL
+		case "eu":
+			apiURL = opsgenieEUAlertURL
+		default:
+			return nil, alerting.ValidationError{Reason: "Invalid Opsgenie region: Must be us or eu"}
+		}
+	}
+
+	var responderTeams []string
+	for _, team := range strings.Split(model.Settings.Get("responder_teams").MustString(), ",") {
+		if team = strings.TrimSpace(team); team != "" {
+			responderTeams = append(responderTeams, team)
+		}
 	}
 
 	sendTagsAs := model.Settings.Get("sendTagsAs").MustString(OpsgenieSendTags)
@@ -77,6 +116,8 @@ func NewOpsgenieNotifier(model *NotificationChannelConfig, t *template.Template)
 		AutoClose:        autoClose,
 		OverridePriority: overridePriority,
 		SendTagsAs:       sendTagsAs,
+		SeverityLabel:    model.Settings.Get("severity_label").MustString("severity"),
+		ResponderTeams:   responderTeams,
 		tmpl:             t,
 		log:              log.New("alerting.notifier." + model.Name),
 	}, nil
@@ -156,7 +197,7 @@ func (on *OpsgenieNotifier) buildOpsgenieMessage(ctx context.Context, alerts mod
 
 	data := notify.GetTemplateData(ctx, on.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(on.log)))
 	var tmplErr error
-	tmpl := notify.TmplText(on.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, on.tmpl, data, &tmplErr)
 
 	title := tmpl(`{{ template "default.title" . }}`)
 	description := fmt.Sprintf(
@@ -199,10 +240,22 @@ func (on *OpsgenieNotifier) buildOpsgenieMessage(ctx context.Context, alerts mod
 		}
 	}
 
-	if priority != "" && on.OverridePriority {
+	// The og_priority annotation takes precedence over the severity.
+	if priority == "" || !on.OverridePriority {
+		priority = opsgenieSeverityPriority(as, on.SeverityLabel).String()
+	}
+	if priority != "" {
 		bodyJSON.Set("priority", priority)
 	}
 
+	if len(on.ResponderTeams) > 0 {
+		responders := make([]opsgenieResponder, 0, len(on.ResponderTeams))
+		for _, team := range on.ResponderTeams {
+			responders = append(responders, opsgenieResponder{Name: team, Type: "team"})
+		}
+		bodyJSON.Set("responders", responders)
+	}
+
 	bodyJSON.Set("tags", tags)
 	bodyJSON.Set("details", details)
 	apiURL = on.APIUrl
@@ -218,6 +271,33 @@ func (on *OpsgenieNotifier) SendResolved() bool {
 	return !on.GetDisableResolveMessage()
 }
 
+// Type returns the kind of the notification channel.
+func (on *OpsgenieNotifier) Type() string {
+	return "opsgenie"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (on *OpsgenieNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
+
+// opsgenieSeverityPriority returns the highest Opsgenie priority of the
+// firing alerts in as according to the value of their severity label, or
+// priorityNone if none of them has a known severity.
+func opsgenieSeverityPriority(as []*types.Alert, severityLabel string) alertPriority {
+	priority := priorityNone
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(severityLabel)]))
+		if p, ok := opsgenieSeverityPriorities[severity]; ok && (priority == priorityNone || p < priority) {
+			priority = p
+		}
+	}
+	return priority
+}
+
 func (on *OpsgenieNotifier) sendDetails() bool {
 	return on.SendTagsAs == OpsgenieSendDetails || on.SendTagsAs == OpsgenieSendBoth
 }
@@ -29,6 +29,7 @@ func TestOpsgenieNotifier(t *testing.T) {
 		settings     string
 		alerts       []*types.Alert
 		expMsg       string
+		expURL       string
 		expInitError error
 		expMsgError  error
 	}{
@@ -138,6 +139,77 @@ func TestOpsgenieNotifier(t *testing.T) {
 			expInitError: nil,
 			expMsgError:  nil,
 		},
+		{
+			name: "Severity, responder teams and EU region",
+			settings: `{
+				"apiKey": "abcdefgh0123456789",
+				"region": "eu",
+				"responder_teams": "db, platform"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "warning"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical"},
+					},
+				},
+			},
+			expURL: "https://api.eu.opsgenie.com/v2/alerts",
+			expMsg: `{
+				"alias": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				"description": "[FIRING:2]  \nhttp://localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - severity = warning\nAnnotations:\nSource: \nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\nSource: \n\n\n\n\n",
+				"details": {
+					"url": "http://localhost/alerting/list"
+				},
+				"message": "[FIRING:2]  ",
+				"priority": "P1",
+				"responders": [{"name": "db", "type": "team"}, {"name": "platform", "type": "team"}],
+				"source": "Grafana",
+				"tags": []
+			}`,
+		},
+		{
+			name:     "Priority annotation overrides the severity",
+			settings: `{"apiKey": "abcdefgh0123456789"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "severity": "critical"},
+						Annotations: model.LabelSet{"og_priority": "P4"},
+					},
+				},
+			},
+			expURL: "https://api.opsgenie.com/v2/alerts",
+			expMsg: `{
+				"alias": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				"description": "[FIRING:1]  (critical)\nhttp://localhost/alerting/list\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\n - og_priority = P4\nSource: \n\n\n\n\n",
+				"details": {
+					"url": "http://localhost/alerting/list"
+				},
+				"message": "[FIRING:1]  (critical)",
+				"priority": "P4",
+				"source": "Grafana",
+				"tags": ["og_priority:P4"]
+			}`,
+		},
+		{
+			name:     "Resolved closes the alert by its alias",
+			settings: `{"apiKey": "abcdefgh0123456789"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						StartsAt: time.Now().Add(-time.Hour),
+						EndsAt:   time.Now().Add(-time.Minute),
+					},
+				},
+			},
+			expURL: "https://api.opsgenie.com/v2/alerts/6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733/close?identifierType=alias",
+			expMsg: `{"source": "Grafana"}`,
+		},
 		{
 			name:     "Resolved is not sent when auto close is false",
 			settings: `{"apiKey": "abcdefgh0123456789", "autoClose": false}`,
@@ -156,6 +228,11 @@ func TestOpsgenieNotifier(t *testing.T) {
 			settings:     `{}`,
 			expInitError: alerting.ValidationError{Reason: "Could not find api key property in settings"},
 		},
+		{
+			name:         "Error when invalid region",
+			settings:     `{"apiKey": "abcdefgh0123456789", "region": "ap"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Opsgenie region: Must be us or eu"},
+		},
 	}
 
 	for _, c := range cases {
@@ -177,9 +254,9 @@ func TestOpsgenieNotifier(t *testing.T) {
 			}
 			require.NoError(t, err)
 
-			body := "<not-sent>"
+			body, apiURL := "<not-sent>", ""
 			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
-				body = webhook.Body
+				body, apiURL = webhook.Body, webhook.Url
 				return nil
 			})
 
@@ -201,6 +278,41 @@ func TestOpsgenieNotifier(t *testing.T) {
 			} else {
 				require.JSONEq(t, c.expMsg, body)
 			}
+			if c.expURL != "" {
+				require.Equal(t, c.expURL, apiURL)
+			}
+		})
+	}
+}
+
+func TestOpsgenieSeverityPriority(t *testing.T) {
+	alert := func(severity string, resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "level": model.LabelValue(severity)}}}
+		if resolved {
+			a.StartsAt = time.Now().Add(-time.Hour)
+			a.EndsAt = time.Now().Add(-time.Minute)
+		}
+		return a
+	}
+
+	cases := []struct {
+		name   string
+		alerts []*types.Alert
+		exp    string
+	}{
+		{name: "critical", alerts: []*types.Alert{alert("critical", false)}, exp: "P1"},
+		{name: "error", alerts: []*types.Alert{alert("Error", false)}, exp: "P2"},
+		{name: "warning", alerts: []*types.Alert{alert("warning", false)}, exp: "P3"},
+		{name: "info", alerts: []*types.Alert{alert("info", false)}, exp: "P4"},
+		{name: "low", alerts: []*types.Alert{alert("low", false)}, exp: "P5"},
+		{name: "unknown severity", alerts: []*types.Alert{alert("major", false)}, exp: ""},
+		{name: "highest priority wins", alerts: []*types.Alert{alert("info", false), alert("error", false), alert("unknown", false)}, exp: "P2"},
+		{name: "resolved alerts are ignored", alerts: []*types.Alert{alert("critical", true), alert("warning", false)}, exp: "P3"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, opsgenieSeverityPriority(c.alerts, "level").String())
 		})
 	}
 }
@@ -40,6 +40,7 @@ type PagerdutyNotifier struct {
 	Component     string
 	Group         string
 	Summary       string
+	Source        string
 	tmpl          *template.Template
 	log           log.Logger
 }
@@ -51,6 +52,9 @@ func NewPagerdutyNotifier(model *NotificationChannelConfig, t *template.Template
 	}
 
 	key := model.DecryptedValue("integrationKey", model.Settings.Get("integrationKey").MustString())
+	if key == "" {
+		key = model.DecryptedValue("routing_key", model.Settings.Get("routing_key").MustString())
+	}
 	if key == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find integration key property in settings"}
 	}
@@ -75,6 +79,7 @@ func NewPagerdutyNotifier(model *NotificationChannelConfig, t *template.Template
 		Component: model.Settings.Get("component").MustString("Grafana"),
 		Group:     model.Settings.Get("group").MustString("default"),
 		Summary:   model.Settings.Get("summary").MustString(`{{ template "default.title" . }}`),
+		Source:    model.Settings.Get("source").MustString(),
 		tmpl:      t,
 		log:       log.New("alerting.notifier." + model.Name),
 	}, nil
@@ -127,11 +132,17 @@ func (pn *PagerdutyNotifier) buildPagerdutyMessage(ctx context.Context, alerts m
 
 	data := notify.GetTemplateData(ctx, pn.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(pn.log)))
 	var tmplErr error
-	tmpl := notify.TmplText(pn.tmpl, data, &tmplErr)
-
-	details := make(map[string]string, len(pn.CustomDetails))
+	tmpl := tmplText(ctx, pn.tmpl, data, &tmplErr)
+
+	// The common labels of the alerts are added to the custom details, so
+	// that they can be used in PagerDuty event rules. The details of the
+	// notifier take precedence.
+	details := make(map[string]string, len(pn.CustomDetails)+len(data.CommonLabels))
+	for k, v := range removePrivateItems(data.CommonLabels) {
+		details[k] = v
+	}
 	for k, v := range pn.CustomDetails {
-		detail, err := pn.tmpl.ExecuteTextString(v, data)
+		detail, err := executeTextString(ctx, pn.tmpl, v, data)
 		if err != nil {
 			return nil, "", fmt.Errorf("%q: failed to template %q: %w", k, v, err)
 		}
@@ -164,8 +175,9 @@ func (pn *PagerdutyNotifier) buildPagerdutyMessage(ctx context.Context, alerts m
 		msg.Payload.Summary = msg.Payload.Summary[:1021] + "..."
 	}
 
-	if hostname, err := os.Hostname(); err == nil {
-		// TODO: should this be configured like in Prometheus AM?
+	if pn.Source != "" {
+		msg.Payload.Source = tmpl(pn.Source)
+	} else if hostname, err := os.Hostname(); err == nil {
 		msg.Payload.Source = hostname
 	}
 
@@ -180,6 +192,16 @@ func (pn *PagerdutyNotifier) SendResolved() bool {
 	return !pn.GetDisableResolveMessage()
 }
 
+// Type returns the kind of the notification channel.
+func (pn *PagerdutyNotifier) Type() string {
+	return "pagerduty"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (pn *PagerdutyNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
+
 type pagerDutyMessage struct {
 	RoutingKey  string            `json:"routing_key,omitempty"`
 	ServiceKey  string            `json:"service_key,omitempty"`
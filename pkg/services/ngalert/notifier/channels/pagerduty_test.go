@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -61,6 +62,8 @@ func TestPagerdutyNotifier(t *testing.T) {
 					Component: "Grafana",
 					Group:     "default",
 					CustomDetails: map[string]string{
+						"alertname":    "alert1",
+						"lbl1":         "val1",
 						"firing":       "Labels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n",
 						"num_firing":   "1",
 						"num_resolved": "0",
@@ -108,6 +111,7 @@ func TestPagerdutyNotifier(t *testing.T) {
 					Component: "My Grafana",
 					Group:     "my_group",
 					CustomDetails: map[string]string{
+						"alertname":    "alert1",
 						"firing":       "Labels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n",
 						"num_firing":   "2",
 						"num_resolved": "0",
@@ -120,6 +124,81 @@ func TestPagerdutyNotifier(t *testing.T) {
 			},
 			expInitError: nil,
 			expMsgError:  nil,
+		}, {
+			name: "Resolved alert with routing key and source",
+			settings: `{
+				"routing_key": "abcdefgh0123456789",
+				"source": "{{ .CommonLabels.lbl1 }}.example.org"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"__alert_rule_uid__": "rule uid", "alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: &pagerDutyMessage{
+				RoutingKey:  "abcdefgh0123456789",
+				DedupKey:    "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				Description: "[RESOLVED]  (rule uid val1)",
+				EventAction: "resolve",
+				Payload: &pagerDutyPayload{
+					Summary:   "[RESOLVED]  (rule uid val1)",
+					Source:    "val1.example.org",
+					Severity:  "critical",
+					Class:     "default",
+					Component: "Grafana",
+					Group:     "default",
+					CustomDetails: map[string]string{
+						"alertname":    "alert1",
+						"lbl1":         "val1",
+						"firing":       "",
+						"num_firing":   "0",
+						"num_resolved": "1",
+						"resolved":     "Labels:\n - alertname = alert1\n - __alert_rule_uid__ = rule uid\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n",
+					},
+				},
+				Client:    "Grafana",
+				ClientURL: "http://localhost",
+				Links:     []pagerDutyLink{{HRef: "http://localhost", Text: "External URL"}},
+			},
+		}, {
+			name:     "Notifier details take precedence over labels",
+			settings: `{"integrationKey": "abcdefgh0123456789", "source": "grafana"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "num_firing": "many"},
+					},
+				},
+			},
+			expMsg: &pagerDutyMessage{
+				RoutingKey:  "abcdefgh0123456789",
+				DedupKey:    "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				Description: "[FIRING:1]  (many)",
+				EventAction: "trigger",
+				Payload: &pagerDutyPayload{
+					Summary:   "[FIRING:1]  (many)",
+					Source:    "grafana",
+					Severity:  "critical",
+					Class:     "default",
+					Component: "Grafana",
+					Group:     "default",
+					CustomDetails: map[string]string{
+						"alertname":    "alert1",
+						"firing":       "Labels:\n - alertname = alert1\n - num_firing = many\nAnnotations:\nSource: \n",
+						"num_firing":   "1",
+						"num_resolved": "0",
+						"resolved":     "",
+					},
+				},
+				Client:    "Grafana",
+				ClientURL: "http://localhost",
+				Links:     []pagerDutyLink{{HRef: "http://localhost", Text: "External URL"}},
+			},
 		}, {
 			name:         "Error in initing",
 			settings:     `{}`,
@@ -0,0 +1,54 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// ErrPingUnsupported is returned by the Ping method of notifiers that can't
+// check their configuration without sending a notification.
+var ErrPingUnsupported = errors.New("ping is not supported by this notifier")
+
+// ping makes a GET request to u, which must be a cheap call of the provider
+// that requires authentication, and fails unless it succeeds. Failed
+// requests return a models.WebhookStatusError.
+func ping(ctx context.Context, opts httpOptions, u string, headers map[string]string) error {
+	client, shared, err := opts.client()
+	if err != nil {
+		return err
+	}
+	if !shared {
+		defer client.CloseIdleConnections()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ctxhttp.Do(ctx, client, req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	// Drain the body, so that the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode/100 != 2 {
+		return models.WebhookStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestThreemaNotifierPing(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "*1234567", r.URL.Query().Get("from"))
+		if r.URL.Query().Get("secret") != "supersecret12345" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("100"))
+	}))
+	t.Cleanup(server.Close)
+
+	ping := func(apiSecret string) error {
+		tn, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":   "*1234567",
+				"recipient_id": "87654321",
+				"api_secret":   apiSecret,
+			}),
+		}, tmpl)
+		require.NoError(t, err)
+		tn.creditsURL = server.URL + "/credits"
+		return tn.Ping(context.Background())
+	}
+
+	require.NoError(t, ping("supersecret12345"))
+	require.Equal(t, models.WebhookStatusError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}, ping("wrongsecret12345"))
+}
+
+func TestLineNotifierPing(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		if r.Header.Get("Authorization") != "Bearer sometoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":401,"message":"Invalid access token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":200,"message":"ok","targetType":"USER","target":"grafana"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ping := func(token string) error {
+		ln, err := NewLineNotifier(&NotificationChannelConfig{
+			Name:     "line_testing",
+			Type:     "line",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"token": token}),
+		}, tmpl)
+		require.NoError(t, err)
+		ln.statusURL = server.URL + "/api/status"
+		return ln.Ping(context.Background())
+	}
+
+	require.NoError(t, ping("sometoken"))
+	require.Equal(t, models.WebhookStatusError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}, ping("othertoken"))
+}
+
+func TestPingUnsupported(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	n, err := BuildNotifier(&NotificationChannelConfig{
+		Name:     "googlechat_testing",
+		Type:     "googlechat",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost"}),
+	}, tmpl)
+	require.NoError(t, err)
+	require.ErrorIs(t, n.Ping(context.Background()), ErrPingUnsupported)
+}
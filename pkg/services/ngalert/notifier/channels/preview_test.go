@@ -0,0 +1,45 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestNotifiersPreviewMatchesNotify(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	type previewer interface {
+		Preview(context.Context, ...*types.Alert) (string, map[string]string, error)
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+		t.Run(name, func(t *testing.T) {
+			p, ok := n.(previewer)
+			require.True(t, ok)
+
+			dispatched := 0
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				dispatched++
+				return nil
+			})
+			body, headers, err := p.Preview(notifyContext(), firingAlert())
+			require.NoError(t, err)
+			require.Equal(t, 0, dispatched, "Preview must not send the notification")
+
+			cmd := sendAndCapture(t, n)
+			require.Equal(t, cmd.Body, body)
+			require.Equal(t, cmd.HttpHeader, headers)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package channels
+
+import (
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+// alertPriority is the priority of alerts in the style of Opsgenie, from P1
+// (the highest) to P5 (the lowest).
+type alertPriority int
+
+const (
+	// priorityNone means the alerts don't have a valid priority.
+	priorityNone alertPriority = iota
+	priorityP1
+	priorityP2
+	priorityP3
+	priorityP4
+	priorityP5
+)
+
+// String returns the priority in the form P1 to P5.
+func (p alertPriority) String() string {
+	if p == priorityNone {
+		return ""
+	}
+	return "P" + string(rune('0'+p))
+}
+
+// derivePriority returns the priority in the label of labels that is named
+// by priorityLabel. Both "P1" and "1" are accepted, ignoring case and
+// surrounding whitespace. It returns priorityNone if the label is missing or
+// not a priority from P1 to P5.
+func derivePriority(labels template.KV, priorityLabel string) alertPriority {
+	value := strings.ToUpper(strings.TrimSpace(labels[priorityLabel]))
+	value = strings.TrimPrefix(value, "P")
+	if len(value) != 1 || value[0] < '1' || value[0] > '5' {
+		return priorityNone
+	}
+	return alertPriority(value[0] - '0')
+}
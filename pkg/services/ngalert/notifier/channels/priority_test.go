@@ -0,0 +1,39 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivePriority(t *testing.T) {
+	cases := []struct {
+		name        string
+		labels      template.KV
+		label       string
+		expPriority alertPriority
+	}{
+		{name: "P1", labels: template.KV{"priority": "P1"}, label: "priority", expPriority: priorityP1},
+		{name: "P5", labels: template.KV{"priority": "P5"}, label: "priority", expPriority: priorityP5},
+		{name: "Lowercase", labels: template.KV{"priority": "p2"}, label: "priority", expPriority: priorityP2},
+		{name: "Number only", labels: template.KV{"priority": "3"}, label: "priority", expPriority: priorityP3},
+		{name: "Surrounding whitespace", labels: template.KV{"priority": " P4 "}, label: "priority", expPriority: priorityP4},
+		{name: "Custom label", labels: template.KV{"prio": "P1", "priority": "P5"}, label: "prio", expPriority: priorityP1},
+		{name: "Missing label", labels: template.KV{"severity": "critical"}, label: "priority", expPriority: priorityNone},
+		{name: "Out of range", labels: template.KV{"priority": "P6"}, label: "priority", expPriority: priorityNone},
+		{name: "Zero", labels: template.KV{"priority": "P0"}, label: "priority", expPriority: priorityNone},
+		{name: "Not a priority", labels: template.KV{"priority": "high"}, label: "priority", expPriority: priorityNone},
+		{name: "Empty", labels: template.KV{"priority": ""}, label: "priority", expPriority: priorityNone},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expPriority, derivePriority(c.labels, c.label))
+		})
+	}
+
+	require.Equal(t, "P1", priorityP1.String())
+	require.Equal(t, "P5", priorityP5.String())
+	require.Equal(t, "", priorityNone.String())
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"mime/multipart"
 	"strconv"
+	"strings"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/grafana/grafana/pkg/bus"
@@ -22,8 +23,27 @@ import (
 
 const (
 	PUSHOVERENDPOINT = "https://api.pushover.net/1/messages.json"
+
+	// pushoverEmergencyPriority is the Pushover priority that is repeated
+	// until it is acknowledged and requires the retry and expire parameters.
+	pushoverEmergencyPriority = 2
+	// pushoverDefaultRetry and pushoverDefaultExpire are used for emergency
+	// notifications when retry and expire are not configured, in seconds.
+	pushoverDefaultRetry  = 60
+	pushoverDefaultExpire = 3600
+	// pushoverMinRetry is the shortest retry interval accepted by Pushover
+	// in seconds.
+	pushoverMinRetry = 30
 )
 
+// pushoverSeverityPriorities maps the values of the severity label to
+// Pushover priorities. Other severities are sent with the configured
+// priority.
+var pushoverSeverityPriorities = map[string]int{
+	"critical": pushoverEmergencyPriority,
+	"warning":  1,
+}
+
 // getBoundary is used for overriding the behaviour for tests
 // and set a boundary
 var getBoundary = func() string {
@@ -38,6 +58,10 @@ type PushoverNotifier struct {
 	APIToken         string
 	AlertingPriority int
 	OKPriority       int
+	// SeverityPriority derives the priority of firing notifications from
+	// SeverityLabel. Alerts without a known severity use AlertingPriority.
+	SeverityPriority bool
+	SeverityLabel    string
 	Retry            int
 	Expire           int
 	Device           string
@@ -51,6 +75,10 @@ type PushoverNotifier struct {
 
 // NewSlackNotifier is the constructor for the Slack notifier
 func NewPushoverNotifier(model *NotificationChannelConfig, t *template.Template) (*PushoverNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
 	userKey := model.DecryptedValue("userKey", model.Settings.Get("userKey").MustString())
 	APIToken := model.DecryptedValue("apiToken", model.Settings.Get("apiToken").MustString())
 	device := model.Settings.Get("device").MustString()
@@ -62,8 +90,26 @@ func NewPushoverNotifier(model *NotificationChannelConfig, t *template.Template)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert OK priority to integer: %w", err)
 	}
+	severityPriority := model.Settings.Get("severity_priority").MustBool(false)
+
+	// Retry and expire are only sent, and so only validated, with the
+	// emergency priority.
 	retry, _ := strconv.Atoi(model.Settings.Get("retry").MustString())
+	if retry == 0 {
+		retry = pushoverDefaultRetry
+	}
 	expire, _ := strconv.Atoi(model.Settings.Get("expire").MustString())
+	if expire == 0 {
+		expire = pushoverDefaultExpire
+	}
+	if alertingPriority == pushoverEmergencyPriority || okPriority == pushoverEmergencyPriority || severityPriority {
+		if retry < pushoverMinRetry {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid retry: Must be at least %d seconds", pushoverMinRetry)}
+		}
+		if expire < 0 {
+			return nil, alerting.ValidationError{Reason: "Invalid expire: Must not be negative"}
+		}
+	}
 	alertingSound := model.Settings.Get("sound").MustString()
 	okSound := model.Settings.Get("okSound").MustString()
 	uploadImage := model.Settings.Get("uploadImage").MustBool(true)
@@ -87,6 +133,8 @@ func NewPushoverNotifier(model *NotificationChannelConfig, t *template.Template)
 		APIToken:         APIToken,
 		AlertingPriority: alertingPriority,
 		OKPriority:       okPriority,
+		SeverityPriority: severityPriority,
+		SeverityLabel:    model.Settings.Get("severity_label").MustString("severity"),
 		Retry:            retry,
 		Expire:           expire,
 		Device:           device,
@@ -125,6 +173,16 @@ func (pn *PushoverNotifier) SendResolved() bool {
 	return !pn.GetDisableResolveMessage()
 }
 
+// Type returns the kind of the notification channel.
+func (pn *PushoverNotifier) Type() string {
+	return "pushover"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (pn *PushoverNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
+
 func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (map[string]string, bytes.Buffer, error) {
 	var b bytes.Buffer
 
@@ -137,7 +195,7 @@ func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Al
 
 	var tmplErr error
 	data := notify.GetTemplateData(ctx, pn.tmpl, as, gokit_log.NewNopLogger())
-	tmpl := notify.TmplText(pn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, pn.tmpl, data, &tmplErr)
 
 	w := multipart.NewWriter(&b)
 	boundary := getBoundary()
@@ -164,13 +222,17 @@ func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Al
 	priority := pn.AlertingPriority
 	if alerts.Status() == model.AlertResolved {
 		priority = pn.OKPriority
+	} else if pn.SeverityPriority {
+		if p, ok := pushoverSeverityPriority(as, pn.SeverityLabel); ok {
+			priority = p
+		}
 	}
 	err = w.WriteField("priority", strconv.Itoa(priority))
 	if err != nil {
 		return nil, b, err
 	}
 
-	if priority == 2 {
+	if priority == pushoverEmergencyPriority {
 		err = w.WriteField("retry", strconv.Itoa(pn.Retry))
 		if err != nil {
 			return nil, b, err
@@ -244,3 +306,20 @@ func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Al
 
 	return headers, b, nil
 }
+
+// pushoverSeverityPriority returns the highest Pushover priority of the
+// firing alerts in as according to the value of their severity label. It
+// returns false if none of them has a known severity.
+func pushoverSeverityPriority(as []*types.Alert, severityLabel string) (int, bool) {
+	priority, found := 0, false
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(severityLabel)]))
+		if p, ok := pushoverSeverityPriorities[severity]; ok && (!found || p > priority) {
+			priority, found = p, true
+		}
+	}
+	return priority, found
+}
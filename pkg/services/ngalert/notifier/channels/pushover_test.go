@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -109,6 +110,199 @@ func TestPushoverNotifier(t *testing.T) {
 			expInitError: nil,
 			expMsgError:  nil,
 		},
+		{
+			name: "Critical severity is sent as emergency",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"severity_priority": true,
+				"sound": "siren",
+				"message": "{{ .CommonLabels.severity }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1", "severity": "critical"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":      "<userKey>",
+				"token":     "<apiToken>",
+				"priority":  "2",
+				"retry":     "60",
+				"expire":    "3600",
+				"sound":     "siren",
+				"title":     "[FIRING:1]  (val1 critical)",
+				"url":       "http://localhost/alerting/list",
+				"url_title": "Show alert rule",
+				"message":   "critical",
+				"html":      "1",
+			},
+		}, {
+			name: "Highest severity of the firing alerts is used",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"severity_priority": true,
+				"severity_label": "level",
+				"message": "{{ len .Alerts }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "level": "Warning"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "level": "info"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":      "<userKey>",
+				"token":     "<apiToken>",
+				"priority":  "1",
+				"sound":     "",
+				"title":     "[FIRING:3]  ",
+				"url":       "http://localhost/alerting/list",
+				"url_title": "Show alert rule",
+				"message":   "3",
+				"html":      "1",
+			},
+		}, {
+			name: "Priority is only derived from severity if enabled",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"message": "{{ .CommonLabels.severity }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1", "severity": "critical"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":      "<userKey>",
+				"token":     "<apiToken>",
+				"priority":  "0",
+				"sound":     "",
+				"title":     "[FIRING:1]  (val1 critical)",
+				"url":       "http://localhost/alerting/list",
+				"url_title": "Show alert rule",
+				"message":   "critical",
+				"html":      "1",
+			},
+		}, {
+			name: "Resolved critical alert uses the OK priority and sound",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"severity_priority": true,
+				"sound": "siren",
+				"okSound": "magic",
+				"message": "{{ .CommonLabels.severity }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1", "severity": "critical"},
+						StartsAt: time.Now().Add(-2 * time.Hour),
+						EndsAt:   time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":      "<userKey>",
+				"token":     "<apiToken>",
+				"priority":  "0",
+				"sound":     "magic",
+				"title":     "[RESOLVED]  (val1 critical)",
+				"url":       "http://localhost/alerting/list",
+				"url_title": "Show alert rule",
+				"message":   "critical",
+				"html":      "1",
+			},
+		}, {
+			name: "Configured priority is used without a known severity",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"severity_priority": true,
+				"priority": "-1",
+				"message": "{{ len .Alerts }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "unknown"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":      "<userKey>",
+				"token":     "<apiToken>",
+				"priority":  "-1",
+				"sound":     "",
+				"title":     "[FIRING:1]  (unknown)",
+				"url":       "http://localhost/alerting/list",
+				"url_title": "Show alert rule",
+				"message":   "1",
+				"html":      "1",
+			},
+		}, {
+			name: "Retry is ignored without the emergency priority",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"priority": "1",
+				"retry": "10",
+				"expire": "-1",
+				"message": "{{ len .Alerts }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":      "<userKey>",
+				"token":     "<apiToken>",
+				"priority":  "1",
+				"sound":     "",
+				"title":     "[FIRING:1]  ",
+				"url":       "http://localhost/alerting/list",
+				"url_title": "Show alert rule",
+				"message":   "1",
+				"html":      "1",
+			},
+		}, {
+			name: "Retry below the Pushover minimum",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"priority": "2",
+				"retry": "10"
+			}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid retry: Must be at least 30 seconds"},
+		}, {
+			name: "Negative expire with priority from severity",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>",
+				"severity_priority": true,
+				"expire": "-1"
+			}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid expire: Must not be negative"},
+		},
 		{
 			name: "Missing user key",
 			settings: `{
@@ -0,0 +1,89 @@
+package channels
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// quietHours is a daily time window in which firing notifications are not
+// sent, e.g. at night. A nil quietHours never suppresses anything.
+type quietHours struct {
+	// start and end are the times of day the window starts and ends. The
+	// window spans midnight if end is before start.
+	start, end time.Duration
+	location   *time.Location
+	// sendResolved controls whether resolved notifications are sent during
+	// the window.
+	sendResolved bool
+	clock        Clock
+}
+
+// parseQuietHours reads the quiet_hours settings of a notification channel.
+// It returns nil if they aren't configured.
+func parseQuietHours(settings *simplejson.Json) (*quietHours, error) {
+	qs, ok := jsonSetting(settings, "quiet_hours")
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid quiet hours: Must be an object with a start and an end"}
+	}
+	if qs.Interface() == nil {
+		return nil, nil
+	}
+	if _, err := qs.Map(); err != nil {
+		return nil, alerting.ValidationError{Reason: "Invalid quiet hours: Must be an object with a start and an end"}
+	}
+
+	start, err := parseTimeOfDay(qs.Get("start").MustString())
+	if err != nil {
+		return nil, alerting.ValidationError{Reason: "Invalid quiet hours start: Must be a time such as 22:00"}
+	}
+	end, err := parseTimeOfDay(qs.Get("end").MustString())
+	if err != nil {
+		return nil, alerting.ValidationError{Reason: "Invalid quiet hours end: Must be a time such as 07:00"}
+	}
+	if start == end {
+		return nil, alerting.ValidationError{Reason: "Invalid quiet hours: Start and end must differ"}
+	}
+
+	location, err := time.LoadLocation(qs.Get("timezone").MustString("UTC"))
+	if err != nil {
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid quiet hours time zone: %s", err)}
+	}
+
+	return &quietHours{
+		start:        start,
+		end:          end,
+		location:     location,
+		sendResolved: qs.Get("send_resolved").MustBool(true),
+		clock:        realClock,
+	}, nil
+}
+
+// parseTimeOfDay parses a time of day such as 22:00 into the time since
+// midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// suppresses reports whether a notification of alerts with status must not
+// be sent right now.
+func (q *quietHours) suppresses(status model.AlertStatus) bool {
+	if q == nil || (status == model.AlertResolved && q.sendResolved) {
+		return false
+	}
+
+	now := q.clock.Now().In(q.location)
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if q.start < q.end {
+		return timeOfDay >= q.start && timeOfDay < q.end
+	}
+	return timeOfDay >= q.start || timeOfDay < q.end
+}
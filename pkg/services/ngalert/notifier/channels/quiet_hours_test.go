@@ -0,0 +1,189 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestQuietHours(t *testing.T) {
+	cases := []struct {
+		name          string
+		settings      map[string]interface{}
+		now           time.Time
+		status        model.AlertStatus
+		expSuppressed bool
+	}{
+		{
+			name:          "In a window spanning midnight",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00"},
+			now:           time.Date(2021, 6, 1, 23, 30, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: true,
+		}, {
+			name:          "In a window spanning midnight after midnight",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00"},
+			now:           time.Date(2021, 6, 1, 6, 59, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: true,
+		}, {
+			name:          "After a window spanning midnight",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00"},
+			now:           time.Date(2021, 6, 1, 7, 0, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: false,
+		}, {
+			name:          "In a window during the day",
+			settings:      map[string]interface{}{"start": "12:00", "end": "13:00"},
+			now:           time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: true,
+		}, {
+			name:          "Before a window during the day",
+			settings:      map[string]interface{}{"start": "12:00", "end": "13:00"},
+			now:           time.Date(2021, 6, 1, 11, 59, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: false,
+		}, {
+			name:          "In the window of the time zone",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00", "timezone": "Europe/Berlin"},
+			now:           time.Date(2021, 6, 1, 21, 30, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: true,
+		}, {
+			name:          "Outside of the window of the time zone",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00", "timezone": "Europe/Berlin"},
+			now:           time.Date(2021, 6, 1, 5, 30, 0, 0, time.UTC),
+			status:        model.AlertFiring,
+			expSuppressed: false,
+		}, {
+			name:          "Resolved notifications are sent by default",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00"},
+			now:           time.Date(2021, 6, 1, 23, 30, 0, 0, time.UTC),
+			status:        model.AlertResolved,
+			expSuppressed: false,
+		}, {
+			name:          "Resolved notifications can be suppressed",
+			settings:      map[string]interface{}{"start": "22:00", "end": "07:00", "send_resolved": false},
+			now:           time.Date(2021, 6, 1, 23, 30, 0, 0, time.UTC),
+			status:        model.AlertResolved,
+			expSuppressed: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			q, err := parseQuietHours(simplejson.NewFromAny(map[string]interface{}{"quiet_hours": c.settings}))
+			require.NoError(t, err)
+			mock := clock.NewMock()
+			mock.Set(c.now)
+			q.clock = mock
+
+			require.Equal(t, c.expSuppressed, q.suppresses(c.status))
+		})
+	}
+
+	t.Run("Not configured", func(t *testing.T) {
+		q, err := parseQuietHours(simplejson.New())
+		require.NoError(t, err)
+		require.Nil(t, q)
+		require.False(t, q.suppresses(model.AlertFiring))
+	})
+
+	t.Run("From a text area", func(t *testing.T) {
+		q, err := parseQuietHours(simplejson.NewFromAny(map[string]interface{}{"quiet_hours": `{"start": "22:00", "end": "07:00"}`}))
+		require.NoError(t, err)
+		mock := clock.NewMock()
+		mock.Set(time.Date(2021, 6, 1, 23, 30, 0, 0, time.UTC))
+		q.clock = mock
+		require.True(t, q.suppresses(model.AlertFiring))
+
+		for _, text := range []string{"22:00-07:00", `["22:00", "07:00"]`} {
+			_, err = parseQuietHours(simplejson.NewFromAny(map[string]interface{}{"quiet_hours": text}))
+			require.EqualError(t, err, alerting.ValidationError{Reason: "Invalid quiet hours: Must be an object with a start and an end"}.Error(), text)
+		}
+	})
+
+	invalidCases := []struct {
+		name     string
+		settings map[string]interface{}
+		expErr   error
+	}{
+		{
+			name:     "Invalid start",
+			settings: map[string]interface{}{"start": "10pm", "end": "07:00"},
+			expErr:   alerting.ValidationError{Reason: "Invalid quiet hours start: Must be a time such as 22:00"},
+		}, {
+			name:     "Missing end",
+			settings: map[string]interface{}{"start": "22:00"},
+			expErr:   alerting.ValidationError{Reason: "Invalid quiet hours end: Must be a time such as 07:00"},
+		}, {
+			name:     "Empty window",
+			settings: map[string]interface{}{"start": "22:00", "end": "22:00"},
+			expErr:   alerting.ValidationError{Reason: "Invalid quiet hours: Start and end must differ"},
+		}, {
+			name:     "Invalid time zone",
+			settings: map[string]interface{}{"start": "22:00", "end": "07:00", "timezone": "Mars/Olympus_Mons"},
+			expErr:   alerting.ValidationError{Reason: "Invalid quiet hours time zone: unknown time zone Mars/Olympus_Mons"},
+		},
+	}
+	for _, c := range invalidCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseQuietHours(simplejson.NewFromAny(map[string]interface{}{"quiet_hours": c.settings}))
+			require.Equal(t, c.expErr.Error(), err.Error())
+		})
+	}
+}
+
+func TestThreemaNotifierQuietHours(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	tn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321",
+			"api_secret":   "supersecret12345",
+			"quiet_hours": map[string]interface{}{
+				"start": "22:00",
+				"end":   "07:00",
+			},
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	mock := clock.NewMock()
+	mock.Set(time.Date(2021, 6, 1, 23, 0, 0, 0, time.UTC))
+	tn.quietHours.clock = mock
+
+	sent := 0
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent++
+		return nil
+	})
+
+	ok, err := tn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, sent)
+
+	mock.Add(8 * time.Hour)
+	ok, err = tn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, sent)
+}
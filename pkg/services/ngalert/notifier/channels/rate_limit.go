@@ -0,0 +1,92 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// errRateLimitDeadline is returned by rateLimiter.wait if the limiter doesn't
+// allow another message before the context is done.
+var errRateLimitDeadline = errors.New("waiting for the rate limit would exceed the context deadline")
+
+// sharedRateLimiters hands out token bucket rate limiters that are shared by
+// all notifiers using the same key, e.g. the same gateway account.
+type sharedRateLimiters struct {
+	mtx      sync.Mutex
+	limiters map[string]*rateLimiter
+	// clock tells the time of the limiters.
+	clock Clock
+}
+
+func newSharedRateLimiters(clock Clock) *sharedRateLimiters {
+	return &sharedRateLimiters{limiters: map[string]*rateLimiter{}, clock: clock}
+}
+
+// get returns the limiter for key that allows perMinute messages per minute.
+// The bucket holds a minute's worth of messages so that bursts of
+// notifications aren't delayed until the quota is used up. Notifiers that
+// use the same key with different limits share the strictest one, so that
+// none of them exceeds its own limit.
+func (s *sharedRateLimiters) get(key string, perMinute int) *rateLimiter {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	limit := rate.Every(time.Minute / time.Duration(perMinute))
+	l, ok := s.limiters[key]
+	if !ok {
+		l = &rateLimiter{
+			limiter:   rate.NewLimiter(limit, perMinute),
+			perMinute: perMinute,
+			clock:     s.clock,
+		}
+		s.limiters[key] = l
+		return l
+	}
+	if perMinute < l.perMinute {
+		now := s.clock.Now()
+		l.limiter.SetLimitAt(now, limit)
+		l.limiter.SetBurstAt(now, perMinute)
+		l.perMinute = perMinute
+	}
+	return l
+}
+
+// rateLimiter is a token bucket rate limiter that tells the time with a
+// Clock, so that tests can control it.
+type rateLimiter struct {
+	limiter *rate.Limiter
+	// perMinute is the number of messages per minute the limiter allows. It
+	// is guarded by the mutex of the sharedRateLimiters it belongs to.
+	perMinute int
+	clock     Clock
+}
+
+// wait blocks until the limiter allows another message. It fails right away
+// if that isn't possible before ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	now := l.clock.Now()
+	r := l.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return errRateLimitDeadline
+	}
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.CancelAt(now)
+		return errRateLimitDeadline
+	}
+
+	select {
+	case <-ctx.Done():
+		r.CancelAt(l.clock.Now())
+		return ctx.Err()
+	case <-l.clock.After(delay):
+		return nil
+	}
+}
@@ -0,0 +1,133 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestThreemaNotifierRateLimit(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(t *testing.T, gatewayID string, rateLimit int) *ThreemaNotifier {
+		t.Helper()
+		tn, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":   gatewayID,
+				"recipient_id": "87654321",
+				"api_secret":   "supersecret12345",
+				"rate_limit":   rateLimit,
+			}),
+		}, tmpl)
+		require.NoError(t, err)
+		return tn
+	}
+
+	sent := map[string]int{}
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		sent[values.Get("from")]++
+		return nil
+	})
+
+	t.Run("Notifiers of the same gateway share the limit", func(t *testing.T) {
+		first := newNotifier(t, "*RATE001", 2)
+		second := newNotifier(t, "*RATE001", 2)
+		other := newNotifier(t, "*RATE002", 2)
+
+		for i := 0; i < 2; i++ {
+			ok, err := first.Notify(notifyContext(), firingAlert())
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		ctx, cancel := context.WithTimeout(notifyContext(), 100*time.Millisecond)
+		defer cancel()
+		ok, err := second.Notify(ctx, firingAlert())
+		require.False(t, ok)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "rate limit of gateway *RATE001 exceeded")
+		require.Equal(t, 2, sent["*RATE001"])
+
+		ok, err = other.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 1, sent["*RATE002"])
+	})
+
+	t.Run("Waits for a token until the context is done", func(t *testing.T) {
+		// 1200 messages per minute is one message every 50ms.
+		tn := newNotifier(t, "*RATE003", 1200)
+		require.True(t, tn.credentials[0].rateLimiter.limiter.AllowN(time.Now(), 1200))
+
+		ctx, cancel := context.WithTimeout(notifyContext(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		ok, err := tn.Notify(ctx, firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.GreaterOrEqual(t, int64(time.Since(start)), int64(25*time.Millisecond))
+		require.Equal(t, 1, sent["*RATE003"])
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		tn := newNotifier(t, "*RATE004", 0)
+		require.Nil(t, tn.credentials[0].rateLimiter)
+	})
+
+	t.Run("Negative rate limit", func(t *testing.T) {
+		_, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":   "*RATE005",
+				"recipient_id": "87654321",
+				"api_secret":   "supersecret12345",
+				"rate_limit":   -1,
+			}),
+		}, tmpl)
+		require.Equal(t, alerting.ValidationError{Reason: "Invalid Threema rate limit: Must not be negative"}.Error(), err.Error())
+	})
+}
+
+func TestSharedRateLimiters(t *testing.T) {
+	mockClock := clock.NewMock()
+	limiters := newSharedRateLimiters(mockClock)
+
+	t.Run("Shares the strictest limit", func(t *testing.T) {
+		l := limiters.get("a", 2)
+		require.Same(t, l, limiters.get("a", 1))
+		require.Equal(t, 1, l.perMinute)
+		require.Same(t, l, limiters.get("a", 5))
+		require.Equal(t, 1, l.perMinute)
+		require.NotSame(t, l, limiters.get("b", 1))
+	})
+
+	t.Run("Tells the time with the clock", func(t *testing.T) {
+		l := limiters.get("c", 1)
+		require.NoError(t, l.wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.ErrorIs(t, l.wait(ctx), errRateLimitDeadline)
+
+		mockClock.Add(time.Minute)
+		require.NoError(t, l.wait(ctx))
+	})
+}
@@ -0,0 +1,40 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// textAndHTML is a notification body as plain text and as HTML, e.g. for
+// email or the formatted body of a Matrix message.
+type textAndHTML struct {
+	Text string
+	HTML string
+}
+
+// renderTextAndHTML renders textTmpl with text/template and htmlTmpl with
+// html/template, both from the template data of as. The HTML template
+// escapes what it renders according to the context, so that label values
+// and annotations can't inject markup. The HTML is empty if htmlTmpl is.
+func renderTextAndHTML(ctx context.Context, t *template.Template, as []*types.Alert, textTmpl, htmlTmpl string) (textAndHTML, error) {
+	data := notify.GetTemplateData(ctx, t, as, gokit_log.NewNopLogger())
+
+	var tmplErr error
+	var body textAndHTML
+	body.Text = tmplText(ctx, t, data, &tmplErr)(textTmpl)
+	if tmplErr != nil {
+		return textAndHTML{}, fmt.Errorf("failed to template text body: %w", tmplErr)
+	}
+	if htmlTmpl != "" {
+		body.HTML = notify.TmplHTML(t, data, &tmplErr)(htmlTmpl)
+		if tmplErr != nil {
+			return textAndHTML{}, fmt.Errorf("failed to template HTML body: %w", tmplErr)
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,84 @@
+package channels
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTextAndHTML(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "host": "<script>alert(1)</script>"},
+			Annotations: model.LabelSet{"summary": `"quoted" & <b>bold</b>`},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		textTmpl string
+		htmlTmpl string
+		exp      textAndHTML
+		expErr   string
+	}{
+		{
+			name:     "label values are escaped in HTML only",
+			textTmpl: "{{ .CommonLabels.host }}",
+			htmlTmpl: "<p>{{ .CommonLabels.host }}</p>",
+			exp: textAndHTML{
+				Text: "<script>alert(1)</script>",
+				HTML: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>",
+			},
+		}, {
+			name:     "escaping depends on the context",
+			textTmpl: "{{ .CommonAnnotations.summary }}",
+			htmlTmpl: `<a title="{{ .CommonAnnotations.summary }}" href="/alerts?host={{ .CommonLabels.host }}">{{ .CommonLabels.alertname }}</a>`,
+			exp: textAndHTML{
+				Text: `"quoted" & <b>bold</b>`,
+				HTML: `<a title="&#34;quoted&#34; &amp; &lt;b&gt;bold&lt;/b&gt;" href="/alerts?host=%3cscript%3ealert%281%29%3c%2fscript%3e">alert1</a>`,
+			},
+		}, {
+			name:     "named templates are shared",
+			textTmpl: `{{ template "default.title" . }}`,
+			htmlTmpl: `<h1>{{ template "default.title" . }}</h1>`,
+			exp: textAndHTML{
+				Text: "[FIRING:1]  (<script>alert(1)</script>)",
+				HTML: "<h1>[FIRING:1]  (&lt;script&gt;alert(1)&lt;/script&gt;)</h1>",
+			},
+		}, {
+			name:     "no HTML template",
+			textTmpl: "{{ .CommonLabels.alertname }}",
+			exp:      textAndHTML{Text: "alert1"},
+		}, {
+			name:     "invalid text template",
+			textTmpl: "{{ .CommonLabels.alertname }",
+			expErr:   "failed to template text body: template: :1: unexpected \"}\" in operand",
+		}, {
+			name:     "invalid HTML template",
+			textTmpl: "{{ .CommonLabels.alertname }}",
+			htmlTmpl: "<p>{{ .CommonLabels.alertname }</p>",
+			expErr:   "failed to template HTML body: template: :1: unexpected \"}\" in operand",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body, err := renderTextAndHTML(notifyContext(), tmpl, []*types.Alert{alert}, c.textTmpl, c.htmlTmpl)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, body)
+		})
+	}
+}
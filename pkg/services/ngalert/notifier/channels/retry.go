@@ -0,0 +1,95 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// retryOptions controls how sendWithRetry retries a failed webhook.
+type retryOptions struct {
+	// maxAttempts is the total number of attempts, including the first one.
+	maxAttempts int
+	// initialBackoff is the upper bound of the wait before the first retry.
+	// It doubles with every attempt up to maxBackoff.
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// clock times the backoff.
+	clock Clock
+	// retryable reports whether a send that failed with an error is retried.
+	// It is isRetryable if nil.
+	retryable func(error) bool
+}
+
+// newRetryOptions returns the retry options for a notifier that is
+// configured to retry a failed send up to maxRetries times.
+func newRetryOptions(maxRetries int) retryOptions {
+	return retryOptions{
+		maxAttempts:    maxRetries + 1,
+		initialBackoff: 500 * time.Millisecond,
+		maxBackoff:     10 * time.Second,
+		clock:          realClock,
+	}
+}
+
+// sendWithRetry dispatches cmd and retries it with exponential backoff and
+// jitter as long as the error is retryable, the attempts are not exhausted and
+// ctx is not done. Nothing is sent if ctx is already done. The webhook is sent
+// with ctx, so that cancelling it aborts the request in flight.
+func sendWithRetry(ctx context.Context, cmd *models.SendWebhookSync, opts retryOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	retryable := opts.retryable
+	if retryable == nil {
+		retryable = isRetryable
+	}
+
+	backoff := opts.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := bus.DispatchCtx(ctx, cmd)
+		if err == nil || attempt >= opts.maxAttempts || !retryable(err) {
+			return err
+		}
+
+		// Full jitter, so that notifiers failing at the same time don't
+		// retry in lockstep.
+		wait := backoff
+		if wait > 0 {
+			// nolint:gosec
+			wait = time.Duration(rand.Int63n(int64(wait))) + 1
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-opts.clock.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > opts.maxBackoff {
+			backoff = opts.maxBackoff
+		}
+	}
+}
+
+// isRetryable reports whether sending a webhook that failed with err
+// may succeed when tried again.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr models.WebhookStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode/100 == 5 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// Anything else is most likely a network error.
+	return true
+}
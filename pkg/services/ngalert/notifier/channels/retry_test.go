@@ -0,0 +1,184 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestSendWithRetry(t *testing.T) {
+	opts := retryOptions{
+		maxAttempts:    3,
+		initialBackoff: time.Millisecond,
+		maxBackoff:     5 * time.Millisecond,
+		clock:          realClock,
+	}
+	serverErr := models.WebhookStatusError{StatusCode: 503, Status: "503 Service Unavailable"}
+	clientErr := models.WebhookStatusError{StatusCode: 401, Status: "401 Unauthorized"}
+	networkErr := errors.New("connection reset by peer")
+
+	cases := []struct {
+		name        string
+		errs        []error
+		expAttempts int
+		expErr      error
+	}{
+		{
+			name:        "succeeds after two transient failures",
+			errs:        []error{serverErr, networkErr, nil},
+			expAttempts: 3,
+		}, {
+			name:        "gives up when attempts are exhausted",
+			errs:        []error{serverErr, serverErr, serverErr, nil},
+			expAttempts: 3,
+			expErr:      serverErr,
+		}, {
+			name:        "does not retry client errors",
+			errs:        []error{clientErr, nil},
+			expAttempts: 1,
+			expErr:      clientErr,
+		}, {
+			name:        "retries rate limiting",
+			errs:        []error{models.WebhookStatusError{StatusCode: 429, Status: "429 Too Many Requests"}, nil},
+			expAttempts: 2,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			attempts := 0
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				err := c.errs[attempts]
+				attempts++
+				return err
+			})
+
+			err := sendWithRetry(context.Background(), &models.SendWebhookSync{Url: "http://localhost"}, opts)
+			require.Equal(t, c.expErr, err)
+			require.Equal(t, c.expAttempts, attempts)
+		})
+	}
+
+	t.Run("stops retrying when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		bus.AddHandlerCtx("test", func(_ context.Context, webhook *models.SendWebhookSync) error {
+			attempts++
+			cancel()
+			return networkErr
+		})
+
+		opts := retryOptions{maxAttempts: 5, initialBackoff: time.Hour, maxBackoff: time.Hour, clock: clock.NewMock()}
+		err := sendWithRetry(ctx, &models.SendWebhookSync{Url: "http://localhost"}, opts)
+		require.Equal(t, networkErr, err)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("does not send when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts := 0
+		bus.AddHandlerCtx("test", func(_ context.Context, webhook *models.SendWebhookSync) error {
+			attempts++
+			return nil
+		})
+
+		err := sendWithRetry(ctx, &models.SendWebhookSync{Url: "http://localhost"}, opts)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 0, attempts)
+	})
+
+	t.Run("waits for the backoff on the clock", func(t *testing.T) {
+		attempts := 0
+		bus.AddHandlerCtx("test", func(_ context.Context, webhook *models.SendWebhookSync) error {
+			attempts++
+			if attempts == 1 {
+				return networkErr
+			}
+			return nil
+		})
+
+		mock := clock.NewMock()
+		opts := retryOptions{maxAttempts: 2, initialBackoff: time.Hour, maxBackoff: time.Hour, clock: mock}
+		done := make(chan error)
+		go func() {
+			done <- sendWithRetry(context.Background(), &models.SendWebhookSync{Url: "http://localhost"}, opts)
+		}()
+
+		// The retry happens as soon as the clock passes the backoff,
+		// without waiting for an hour.
+		for {
+			select {
+			case err := <-done:
+				require.NoError(t, err)
+				require.Equal(t, 2, attempts)
+				return
+			default:
+				mock.Add(time.Hour)
+			}
+		}
+	})
+}
+
+func TestNotifiersRetryFailedSends(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	threemaSettings, err := simplejson.NewJson([]byte(`{
+		"gateway_id": "*1234567",
+		"recipient_id": "87654321",
+		"api_secret": "supersecret12345",
+		"max_retries": 2
+	}`))
+	require.NoError(t, err)
+	threema, err := NewThreemaNotifier(&NotificationChannelConfig{Name: "threema_testing", Type: "threema", Settings: threemaSettings}, tmpl)
+	require.NoError(t, err)
+	threema.retry.initialBackoff = time.Millisecond
+
+	lineSettings, err := simplejson.NewJson([]byte(`{"token": "sometoken", "max_retries": 2}`))
+	require.NoError(t, err)
+	line, err := NewLineNotifier(&NotificationChannelConfig{Name: "line_testing", Type: "line", Settings: lineSettings}, tmpl)
+	require.NoError(t, err)
+	line.retry.initialBackoff = time.Millisecond
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1"},
+		},
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	for name, n := range map[string]notify.Notifier{"threema": threema, "line": line} {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				attempts++
+				if attempts <= 2 {
+					return models.WebhookStatusError{StatusCode: 502, Status: "502 Bad Gateway"}
+				}
+				return nil
+			})
+
+			ok, err := n.Notify(ctx, alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, 3, attempts)
+		})
+	}
+}
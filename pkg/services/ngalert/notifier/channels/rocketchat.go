@@ -0,0 +1,180 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+// RocketChatNotifier is responsible for sending
+// alert notifications to Rocket.Chat.
+type RocketChatNotifier struct {
+	old_notifiers.NotifierBase
+	URL         string
+	Channel     string
+	Username    string
+	Message     string
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewRocketChatNotifier is the constructor for the Rocket.Chat notifier
+func NewRocketChatNotifier(model *NotificationChannelConfig, t *template.Template) (*RocketChatNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	webhookURL := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if webhookURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
+	}
+	if u, err := url.Parse(webhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Rocket.Chat webhook URL: Must be an absolute URL"}
+	}
+
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.rocketchat")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &RocketChatNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:         webhookURL,
+		Channel:     model.Settings.Get("channel").MustString(),
+		Username:    model.Settings.Get("username").MustString(),
+		Message:     message,
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+type rocketChatMessage struct {
+	Text        string                 `json:"text"`
+	Channel     string                 `json:"channel,omitempty"`
+	Username    string                 `json:"username,omitempty"`
+	Attachments []rocketChatAttachment `json:"attachments"`
+}
+
+type rocketChatAttachment struct {
+	Color     string            `json:"color"`
+	Title     string            `json:"title"`
+	TitleLink string            `json:"title_link"`
+	Fields    []rocketChatField `json:"fields,omitempty"`
+}
+
+type rocketChatField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Notify sends an alert notification to Rocket.Chat
+func (rn *RocketChatNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := rn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Rocket.Chat notification", "notification", rn.Name)
+
+	data := notify.GetTemplateData(ctx, rn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, rn.tmpl, data, &tmplErr)
+
+	var fields []rocketChatField
+	for _, pair := range data.CommonLabels.SortedPairs() {
+		fields = append(fields, rocketChatField{Title: pair.Name, Value: pair.Value, Short: true})
+	}
+
+	status := types.Alerts(as...).Status()
+	msg := rocketChatMessage{
+		Text:     tmpl(rn.Message),
+		Channel:  rn.Channel,
+		Username: rn.Username,
+		Attachments: []rocketChatAttachment{
+			{
+				Color:     getAlertStatusColor(status),
+				Title:     tmpl(`{{ template "default.title" . }}`),
+				TitleLink: path.Join(rn.tmpl.ExternalURL.String(), "/alerting/list"),
+				Fields:    fields,
+			},
+		},
+	}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Rocket.Chat message: %w", tmplErr)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        rn.URL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+	if err := rn.httpOptions.apply(ctx, cmd, rn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, rn.retry)
+	rn.metrics.observe("rocketchat", status, start, err)
+	if err != nil {
+		logger.Error("Failed to send Rocket.Chat notification", "error", err, "webhook", rn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (rn *RocketChatNotifier) SendResolved() bool {
+	return !rn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (rn *RocketChatNotifier) Type() string {
+	return "rocketchat"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (rn *RocketChatNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,139 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestRocketChatNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One firing alert",
+			settings: `{"url": "http://localhost/hooks/xxx"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg: `{
+				"text": "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
+				"attachments": [{
+					"color": "#D63232",
+					"title": "[FIRING:1]  (val1)",
+					"title_link": "http:/localhost/alerting/list",
+					"fields": [
+						{"title": "alertname", "value": "alert1", "short": true},
+						{"title": "lbl1", "value": "val1", "short": true}
+					]
+				}]
+			}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Resolved alert with custom channel, username and message",
+			settings: `{"url": "http://localhost/hooks/xxx", "channel": "#alerts", "username": "grafana", "message": "{{ len .Alerts.Resolved }} resolved"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						StartsAt: time.Now().Add(-2 * time.Hour),
+						EndsAt:   time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: `{
+				"text": "1 resolved",
+				"channel": "#alerts",
+				"username": "grafana",
+				"attachments": [{
+					"color": "#36a64f",
+					"title": "[RESOLVED]  (val1)",
+					"title_link": "http:/localhost/alerting/list",
+					"fields": [
+						{"title": "alertname", "value": "alert1", "short": true},
+						{"title": "lbl1", "value": "val1", "short": true}
+					]
+				}]
+			}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "URL missing",
+			settings:     `{}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find url property in settings"},
+		}, {
+			name:         "Invalid URL",
+			settings:     `{"url": "localhost/hooks/xxx"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Rocket.Chat webhook URL: Must be an absolute URL"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "rocketchat_testing",
+				Type:     "rocketchat",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewRocketChatNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			body := ""
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.JSONEq(t, c.expMsg, body)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package channels
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// secretRefRegexp matches a reference to a secret in an environment variable
+// or a file, with the same syntax as in the Grafana configuration.
+var secretRefRegexp = regexp.MustCompile(`^\$__(env|file)\{([^}]+)\}$`)
+
+// SecretRefOptions are the server settings that control which secrets the
+// settings of a notification channel may reference. Anyone who can edit a
+// channel can read the secrets it references, by pointing the channel at a
+// server they control, so references are disabled unless an administrator
+// enables them.
+type SecretRefOptions struct {
+	// Enabled allows references to secrets at all.
+	Enabled bool
+	// Dir is the directory that referenced files must be in. Files can't be
+	// referenced if it is empty.
+	Dir string
+	// EnvPrefix is the prefix that referenced environment variables must
+	// have. Environment variables can't be referenced if it is empty.
+	EnvPrefix string
+}
+
+// ResolvedSecret returns the value of the secret field, like DecryptedValue,
+// and resolves references like $__env{NAME} and $__file{/path/to/secret} to
+// the value of the environment variable or the content of the file.
+func (an *NotificationChannelConfig) ResolvedSecret(field string) (string, error) {
+	return resolveSecret(field, an.DecryptedValue(field, an.Settings.Get(field).MustString()), an.SecretRefs)
+}
+
+// resolveSecret resolves value if it is a reference to a secret that opts
+// allow, and returns it as is otherwise.
+func resolveSecret(field, value string, opts SecretRefOptions) (string, error) {
+	match := secretRefRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return value, nil
+	}
+	if !opts.Enabled {
+		return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: References to secrets are disabled in the Grafana configuration", field)}
+	}
+
+	switch source, ref := match[1], match[2]; source {
+	case "env":
+		if opts.EnvPrefix == "" || !strings.HasPrefix(ref, opts.EnvPrefix) {
+			return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: Environment variable %s is not allowed", field, ref)}
+		}
+		secret, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: Environment variable %s is not set", field, ref)}
+		}
+		return secret, nil
+	default:
+		// Relative paths are relative to the secrets directory.
+		path := ref
+		if opts.Dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(opts.Dir, path)
+		}
+		// Files outside of the directory and missing files get the same
+		// error, so that it can't be used to tell which files exist.
+		errRead := alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: Could not read file %s from the secrets directory", field, ref)}
+		if !pathInDir(opts.Dir, path) {
+			return "", errRead
+		}
+		secret, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return "", errRead
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+}
@@ -0,0 +1,169 @@
+package channels
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestResolveSecret(t *testing.T) {
+	require.NoError(t, os.Setenv("ALERTING_SECRET_TEST_THREEMA", "supersecret12345"))
+	require.NoError(t, os.Setenv("GF_TEST_THREEMA_SECRET", "supersecret12345"))
+	t.Cleanup(func() {
+		require.NoError(t, os.Unsetenv("ALERTING_SECRET_TEST_THREEMA"))
+		require.NoError(t, os.Unsetenv("GF_TEST_THREEMA_SECRET"))
+	})
+
+	secretsDir := t.TempDir()
+	secretFile := filepath.Join(secretsDir, "threema")
+	require.NoError(t, ioutil.WriteFile(secretFile, []byte("filesecret123456\n"), 0600))
+	missingFile := filepath.Join(secretsDir, "missing")
+	outsideFile := filepath.Join(t.TempDir(), "outside")
+	require.NoError(t, ioutil.WriteFile(outsideFile, []byte("outsidesecret123"), 0600))
+	symlink := filepath.Join(secretsDir, "link")
+	require.NoError(t, os.Symlink(outsideFile, symlink))
+
+	opts := SecretRefOptions{Enabled: true, Dir: secretsDir, EnvPrefix: "ALERTING_SECRET_"}
+
+	cases := []struct {
+		name   string
+		value  string
+		opts   *SecretRefOptions
+		exp    string
+		expErr error
+	}{
+		{name: "literal value", value: "supersecret12345", exp: "supersecret12345"},
+		{name: "empty value", value: "", exp: ""},
+		{name: "literal value that looks like a reference", value: "$__env{A}B", exp: "$__env{A}B"},
+		{name: "environment variable", value: "$__env{ALERTING_SECRET_TEST_THREEMA}", exp: "supersecret12345"},
+		{name: "file", value: "$__file{" + secretFile + "}", exp: "filesecret123456"},
+		{name: "file relative to the secrets directory", value: "$__file{threema}", exp: "filesecret123456"},
+		{
+			name:   "references disabled",
+			value:  "$__env{ALERTING_SECRET_TEST_THREEMA}",
+			opts:   &SecretRefOptions{Dir: secretsDir, EnvPrefix: "ALERTING_SECRET_"},
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: References to secrets are disabled in the Grafana configuration"},
+		}, {
+			name:   "environment variable without the prefix",
+			value:  "$__env{GF_TEST_THREEMA_SECRET}",
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Environment variable GF_TEST_THREEMA_SECRET is not allowed"},
+		}, {
+			name:   "environment variables without a prefix",
+			value:  "$__env{ALERTING_SECRET_TEST_THREEMA}",
+			opts:   &SecretRefOptions{Enabled: true, Dir: secretsDir},
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Environment variable ALERTING_SECRET_TEST_THREEMA is not allowed"},
+		}, {
+			name:   "missing environment variable",
+			value:  "$__env{ALERTING_SECRET_MISSING}",
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Environment variable ALERTING_SECRET_MISSING is not set"},
+		}, {
+			name:   "missing file",
+			value:  "$__file{" + missingFile + "}",
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Could not read file " + missingFile + " from the secrets directory"},
+		}, {
+			name:   "file outside of the secrets directory",
+			value:  "$__file{" + outsideFile + "}",
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Could not read file " + outsideFile + " from the secrets directory"},
+		}, {
+			name:   "relative path out of the secrets directory",
+			value:  "$__file{../outside}",
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Could not read file ../outside from the secrets directory"},
+		}, {
+			name:   "symbolic link out of the secrets directory",
+			value:  "$__file{link}",
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Could not read file link from the secrets directory"},
+		}, {
+			name:   "files without a secrets directory",
+			value:  "$__file{" + secretFile + "}",
+			opts:   &SecretRefOptions{Enabled: true, EnvPrefix: "ALERTING_SECRET_"},
+			expErr: alerting.ValidationError{Reason: "Invalid api_secret: Could not read file " + secretFile + " from the secrets directory"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := opts
+			if c.opts != nil {
+				o = *c.opts
+			}
+			secret, err := resolveSecret("api_secret", c.value, o)
+			if c.expErr != nil {
+				require.Equal(t, c.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, secret)
+		})
+	}
+}
+
+func TestNotifiersResolveSecrets(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	require.NoError(t, os.Setenv("ALERTING_SECRET_TEST_LINE_TOKEN", "envtoken"))
+	t.Cleanup(func() {
+		require.NoError(t, os.Unsetenv("ALERTING_SECRET_TEST_LINE_TOKEN"))
+	})
+	secretsDir := t.TempDir()
+	secretFile := filepath.Join(secretsDir, "threema")
+	require.NoError(t, ioutil.WriteFile(secretFile, []byte("filesecret123456"), 0600))
+	secretRefs := SecretRefOptions{Enabled: true, Dir: secretsDir, EnvPrefix: "ALERTING_SECRET_"}
+
+	threema, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321",
+			"api_secret":   "$__file{" + secretFile + "}",
+		}),
+		SecretRefs: secretRefs,
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, "filesecret123456", threema.APISecret)
+
+	line, err := NewLineNotifier(&NotificationChannelConfig{
+		Name:       "line_testing",
+		Type:       "line",
+		Settings:   simplejson.NewFromAny(map[string]interface{}{"token": "$__env{ALERTING_SECRET_TEST_LINE_TOKEN}"}),
+		SecretRefs: secretRefs,
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, "envtoken", line.Token)
+
+	twilio, err := NewTwilioSMSNotifier(&NotificationChannelConfig{
+		Name: "twilio_testing",
+		Type: "twilio",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"account_sid": "AC123",
+			"auth_token":  "$__env{ALERTING_SECRET_TEST_LINE_TOKEN}",
+			"from":        "+15005550006",
+			"to":          "+4912345",
+		}),
+		SecretRefs: secretRefs,
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, "envtoken", twilio.AuthToken)
+
+	_, err = NewLineNotifier(&NotificationChannelConfig{
+		Name:       "line_testing",
+		Type:       "line",
+		Settings:   simplejson.NewFromAny(map[string]interface{}{"token": "$__env{ALERTING_SECRET_TEST_MISSING_TOKEN}"}),
+		SecretRefs: secretRefs,
+	}, tmpl)
+	require.Equal(t, alerting.ValidationError{Reason: "Invalid token: Environment variable ALERTING_SECRET_TEST_MISSING_TOKEN is not set"}.Error(), err.Error())
+
+	// References are resolved only if the server enables them.
+	_, err = NewLineNotifier(&NotificationChannelConfig{
+		Name:     "line_testing",
+		Type:     "line",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"token": "$__env{ALERTING_SECRET_TEST_LINE_TOKEN}"}),
+	}, tmpl)
+	require.Equal(t, alerting.ValidationError{Reason: "Invalid token: References to secrets are disabled in the Grafana configuration"}.Error(), err.Error())
+}
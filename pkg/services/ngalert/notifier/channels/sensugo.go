@@ -76,7 +76,7 @@ func (sn *SensuGoNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 
 	data := notify.GetTemplateData(ctx, sn.tmpl, as, gokit_log.NewNopLogger())
 	var tmplErr error
-	tmpl := notify.TmplText(sn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, sn.tmpl, data, &tmplErr)
 
 	// Sensu Go alerts require an entity and a check. We set it to the user-specified
 	// value (optional), else we fallback and use the grafana rule anme  and ruleID.
@@ -163,3 +163,13 @@ func (sn *SensuGoNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 func (sn *SensuGoNotifier) SendResolved() bool {
 	return !sn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (sn *SensuGoNotifier) Type() string {
+	return "sensugo"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (sn *SensuGoNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,208 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+const (
+	// serviceNowDefaultLevel is the urgency and impact of incidents whose
+	// severity is unknown, which is 3 (low).
+	serviceNowDefaultLevel = "3"
+)
+
+var (
+	// serviceNowLevels maps the value of the severity label of alerts to the
+	// urgency and impact of the incident, from 1 (high) to 3 (low).
+	serviceNowLevels = map[string]string{
+		"critical": "1",
+		"error":    "2",
+		"warning":  "2",
+		"info":     "3",
+	}
+)
+
+// ServiceNowNotifier is responsible for opening incidents in ServiceNow.
+type ServiceNowNotifier struct {
+	old_notifiers.NotifierBase
+	URL              string
+	User             string
+	Password         string
+	ShortDescription string
+	Description      string
+	SeverityLabel    string
+	retry            retryOptions
+	httpOptions      httpOptions
+	metrics          *DeliveryMetrics
+	log              log.Logger
+	tmpl             *template.Template
+}
+
+// NewServiceNowNotifier is the constructor for the ServiceNow notifier
+func NewServiceNowNotifier(model *NotificationChannelConfig, t *template.Template) (*ServiceNowNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	instanceURL := model.Settings.Get("instance_url").MustString()
+	if instanceURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find ServiceNow instance URL in settings"}
+	}
+	if u, err := url.Parse(instanceURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid ServiceNow instance URL: Must be an absolute URL"}
+	}
+	incidentURL, err := joinUrlPath(instanceURL, "/api/now/table/incident")
+	if err != nil {
+		return nil, err
+	}
+
+	username := model.Settings.Get("username").MustString()
+	if username == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find ServiceNow username in settings"}
+	}
+	password := model.DecryptedValue("password", model.Settings.Get("password").MustString())
+	if password == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find ServiceNow password in settings"}
+	}
+
+	shortDescription := model.Settings.Get("short_description").MustString()
+	if shortDescription == "" {
+		shortDescription = `{{ template "default.title" . }}`
+	}
+	description := model.Settings.Get("description").MustString()
+	if description == "" {
+		description = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.servicenow")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &ServiceNowNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:              incidentURL,
+		User:             username,
+		Password:         password,
+		ShortDescription: shortDescription,
+		Description:      description,
+		SeverityLabel:    model.Settings.Get("severity_label").MustString("severity"),
+		retry:            newRetryOptions(maxRetries),
+		httpOptions:      httpOpts,
+		metrics:          model.Metrics,
+		log:              logger,
+		tmpl:             t,
+	}, nil
+}
+
+type serviceNowIncident struct {
+	ShortDescription string `json:"short_description"`
+	Description      string `json:"description"`
+	Urgency          string `json:"urgency"`
+	Impact           string `json:"impact"`
+}
+
+// Notify opens an incident in ServiceNow
+func (sn *ServiceNowNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := sn.log.New(notificationLogContext(ctx, as)...)
+
+	// Incidents are only opened for firing alerts. Updating the incident when
+	// the alerts are resolved requires to keep track of it, which isn't
+	// supported yet.
+	status := types.Alerts(as...).Status()
+	if status == model.AlertResolved {
+		logger.Debug("Not opening a ServiceNow incident for resolved alerts", "notification", sn.Name)
+		return true, nil
+	}
+	logger.Debug("Executing ServiceNow notification", "notification", sn.Name)
+
+	data := notify.GetTemplateData(ctx, sn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, sn.tmpl, data, &tmplErr)
+
+	level, ok := serviceNowLevels[strings.ToLower(data.CommonLabels[sn.SeverityLabel])]
+	if !ok {
+		level = serviceNowDefaultLevel
+	}
+	incident := serviceNowIncident{
+		ShortDescription: tmpl(sn.ShortDescription),
+		Description:      tmpl(sn.Description),
+		Urgency:          level,
+		Impact:           level,
+	}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template ServiceNow incident: %w", tmplErr)
+	}
+
+	body, err := json.Marshal(incident)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        sn.URL,
+		User:       sn.User,
+		Password:   sn.Password,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Accept":       "application/json",
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+	if err := sn.httpOptions.apply(ctx, cmd, sn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, sn.retry)
+	sn.metrics.observe("servicenow", status, start, err)
+	if err != nil {
+		logger.Error("Failed to open ServiceNow incident", "error", err, "webhook", sn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (sn *ServiceNowNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (sn *ServiceNowNotifier) Type() string {
+	return "servicenow"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (sn *ServiceNowNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
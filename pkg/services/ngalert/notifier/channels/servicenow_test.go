@@ -0,0 +1,187 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestServiceNowNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"instance_url": "https://example.service-now.com", "username": "grafana", "password": "secret"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg:       `{"short_description":"[FIRING:1]  (val1)","description":"\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n","urgency":"3","impact":"3"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Critical severity and custom descriptions",
+			settings: `{
+				"instance_url": "https://example.service-now.com",
+				"username": "grafana",
+				"password": "secret",
+				"short_description": "{{ .CommonLabels.alertname }} is firing",
+				"description": "{{ len .Alerts.Firing }} firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical", "lbl1": "val1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical", "lbl1": "val2"},
+					},
+				},
+			},
+			expMsg:       `{"short_description":"alert1 is firing","description":"2 firing","urgency":"1","impact":"1"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Custom severity label",
+			settings: `{
+				"instance_url": "https://example.service-now.com",
+				"username": "grafana",
+				"password": "secret",
+				"severity_label": "level",
+				"description": "firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "level": "Warning"},
+					},
+				},
+			},
+			expMsg:       `{"short_description":"[FIRING:1]  (Warning)","description":"firing","urgency":"2","impact":"2"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Instance URL missing",
+			settings:     `{"username": "grafana", "password": "secret"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find ServiceNow instance URL in settings"},
+		}, {
+			name:         "Invalid instance URL",
+			settings:     `{"instance_url": "example.service-now.com", "username": "grafana", "password": "secret"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid ServiceNow instance URL: Must be an absolute URL"},
+		}, {
+			name:         "Username missing",
+			settings:     `{"instance_url": "https://example.service-now.com", "password": "secret"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find ServiceNow username in settings"},
+		}, {
+			name:         "Password missing",
+			settings:     `{"instance_url": "https://example.service-now.com", "username": "grafana"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find ServiceNow password in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "servicenow_testing",
+				Type:     "servicenow",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewServiceNowNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var sent *models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				sent = webhook
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.NotNil(t, sent)
+			require.Equal(t, "https://example.service-now.com/api/now/table/incident", sent.Url)
+			require.Equal(t, "POST", sent.HttpMethod)
+			require.Equal(t, "grafana", sent.User)
+			require.Equal(t, "secret", sent.Password)
+			require.JSONEq(t, c.expMsg, sent.Body)
+		})
+	}
+}
+
+func TestServiceNowNotifierResolved(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	pn, err := NewServiceNowNotifier(&NotificationChannelConfig{
+		Name: "servicenow_testing",
+		Type: "servicenow",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"instance_url": "https://example.service-now.com",
+			"username":     "grafana",
+			"password":     "secret",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	sent := 0
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent++
+		return nil
+	})
+
+	ok, err := pn.Notify(notifyContext(), &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: time.Now().Add(-2 * time.Hour),
+			EndsAt:   time.Now().Add(-time.Hour),
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, sent)
+}
@@ -0,0 +1,164 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+// SignalNotifier is responsible for sending alert notifications to Signal
+// through the REST API of signal-cli.
+type SignalNotifier struct {
+	old_notifiers.NotifierBase
+	URL         string
+	Number      string
+	Recipients  []string
+	Message     string
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewSignalNotifier is the constructor for the Signal notifier
+func NewSignalNotifier(model *NotificationChannelConfig, t *template.Template) (*SignalNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	apiURL := model.Settings.Get("signal_cli_url").MustString()
+	if apiURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find signal-cli URL in settings"}
+	}
+	if u, err := url.Parse(apiURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid signal-cli URL: Must be an absolute URL"}
+	}
+	sendURL, err := joinUrlPath(apiURL, "/v2/send")
+	if err != nil {
+		return nil, err
+	}
+
+	number := model.Settings.Get("number").MustString()
+	if number == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Signal sender number in settings"}
+	}
+	recipients := splitRecipientIDs(model.Settings.Get("recipients").MustString())
+	if len(recipients) == 0 {
+		return nil, alerting.ValidationError{Reason: "Could not find Signal recipients in settings"}
+	}
+
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.signal")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &SignalNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:         sendURL,
+		Number:      number,
+		Recipients:  recipients,
+		Message:     message,
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+type signalMessage struct {
+	Number     string   `json:"number"`
+	Recipients []string `json:"recipients"`
+	Message    string   `json:"message"`
+}
+
+// Notify sends an alert notification to Signal
+func (sn *SignalNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := sn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Signal notification", "notification", sn.Name)
+
+	data := notify.GetTemplateData(ctx, sn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, sn.tmpl, data, &tmplErr)
+
+	msg := signalMessage{
+		Number:     sn.Number,
+		Recipients: sn.Recipients,
+		Message:    fmt.Sprintf("%s\n%s", tmpl(`{{ template "default.title" . }}`), tmpl(sn.Message)),
+	}
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Signal message: %w", tmplErr)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        sn.URL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+	if err := sn.httpOptions.apply(ctx, cmd, sn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, sn.retry)
+	sn.metrics.observe("signal", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to send Signal notification", "error", err, "webhook", sn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (sn *SignalNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (sn *SignalNotifier) Type() string {
+	return "signal"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (sn *SignalNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
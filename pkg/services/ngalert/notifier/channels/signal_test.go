@@ -0,0 +1,137 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestSignalNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"signal_cli_url": "http://localhost:8080", "number": "+4912345", "recipients": "+4967890"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expURL:       "http://localhost:8080/v2/send",
+			expMsg:       `{"number":"+4912345","recipients":["+4967890"],"message":"[FIRING:1]  (val1)\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Multiple recipients and custom message",
+			settings: `{
+				"signal_cli_url": "https://signal.example.org/api/",
+				"number": "+4912345",
+				"recipients": "+4967890, group.abcdef==",
+				"message": "{{ len .Alerts.Firing }} firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+						Annotations: model.LabelSet{"ann1": "annv2"},
+					},
+				},
+			},
+			expURL:       "https://signal.example.org/api/v2/send",
+			expMsg:       `{"number":"+4912345","recipients":["+4967890","group.abcdef=="],"message":"[FIRING:2]  \n2 firing"}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "URL missing",
+			settings:     `{"number": "+4912345", "recipients": "+4967890"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find signal-cli URL in settings"},
+		}, {
+			name:         "Invalid URL",
+			settings:     `{"signal_cli_url": "localhost:8080", "number": "+4912345", "recipients": "+4967890"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid signal-cli URL: Must be an absolute URL"},
+		}, {
+			name:         "Number missing",
+			settings:     `{"signal_cli_url": "http://localhost:8080", "recipients": "+4967890"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Signal sender number in settings"},
+		}, {
+			name:         "Recipients missing",
+			settings:     `{"signal_cli_url": "http://localhost:8080", "number": "+4912345", "recipients": " , "}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Signal recipients in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "signal_testing",
+				Type:     "signal",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewSignalNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			body := ""
+			webhookURL := ""
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				webhookURL = webhook.Url
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookURL)
+			require.JSONEq(t, c.expMsg, body)
+		})
+	}
+}
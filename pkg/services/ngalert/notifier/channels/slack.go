@@ -248,7 +248,7 @@ func (sn *SlackNotifier) buildSlackMessage(ctx context.Context, as []*types.Aler
 	data := notify.GetTemplateData(ctx, sn.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(sn.log)))
 	alerts := types.Alerts(as...)
 	var tmplErr error
-	tmpl := notify.TmplText(sn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, sn.tmpl, data, &tmplErr)
 
 	ruleURL, err := joinUrlPath(sn.tmpl.ExternalURL.String(), "/alerting/list")
 	if err != nil {
@@ -319,3 +319,13 @@ func (sn *SlackNotifier) buildSlackMessage(ctx context.Context, as []*types.Aler
 func (sn *SlackNotifier) SendResolved() bool {
 	return !sn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (sn *SlackNotifier) Type() string {
+	return "slack"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (sn *SlackNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
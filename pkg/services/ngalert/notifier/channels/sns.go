@@ -0,0 +1,314 @@
+package channels
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+const (
+	// snsMaxSubjectLength is the maximum length of the subject of an SNS
+	// message in characters.
+	snsMaxSubjectLength = 100
+	// snsMaxMessageAttributes is the maximum number of attributes of an SNS
+	// message.
+	snsMaxMessageAttributes = 10
+	// snsMaxMessageSize is the maximum size of an SNS message in bytes,
+	// which includes its subject and attributes.
+	snsMaxMessageSize = 256 * 1024
+
+	snsFormatText = "text"
+	snsFormatJSON = "json"
+)
+
+// snsTopicARNRegexp matches the ARN of an SNS topic and captures its region.
+var snsTopicARNRegexp = regexp.MustCompile(`^arn:aws[a-z-]*:sns:([a-z0-9-]+):[0-9]{12}:[A-Za-z0-9_-]{1,256}(\.fifo)?$`)
+
+// snsPublisher is the part of the SNS client that the SNS notifier uses.
+type snsPublisher interface {
+	PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error)
+}
+
+// SNSNotifier is responsible for publishing
+// alert notifications to an Amazon SNS topic.
+type SNSNotifier struct {
+	old_notifiers.NotifierBase
+	TopicARN          string
+	Region            string
+	Subject           string
+	Message           string
+	MessageFormat     string
+	MessageAttributes map[string]string
+	// fifo is set for FIFO topics, which require a message group ID and a
+	// deduplication ID.
+	fifo    bool
+	client  snsPublisher
+	metrics *DeliveryMetrics
+	log     log.Logger
+	tmpl    *template.Template
+}
+
+// snsJSONMessage is the message published when the message format is JSON.
+type snsJSONMessage struct {
+	Subject           string      `json:"subject"`
+	Message           string      `json:"message"`
+	Status            string      `json:"status"`
+	GroupKey          string      `json:"groupKey"`
+	CommonLabels      template.KV `json:"commonLabels"`
+	CommonAnnotations template.KV `json:"commonAnnotations"`
+	ExternalURL       string      `json:"externalURL"`
+}
+
+// NewSNSNotifier is the constructor for the Amazon SNS notifier
+func NewSNSNotifier(model *NotificationChannelConfig, t *template.Template) (*SNSNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	topicARN := model.Settings.Get("topic_arn").MustString()
+	if topicARN == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find SNS topic ARN in settings"}
+	}
+	match := snsTopicARNRegexp.FindStringSubmatch(topicARN)
+	if match == nil {
+		return nil, alerting.ValidationError{Reason: "Invalid SNS topic ARN: Must be an ARN such as arn:aws:sns:us-east-1:123456789012:alerts"}
+	}
+	// The topic can only be reached in its own region, so it is the default.
+	region := model.Settings.Get("region").MustString(match[1])
+
+	subject := model.Settings.Get("subject").MustString()
+	if subject == "" {
+		subject = `{{ template "default.title" . }}`
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+	messageFormat := model.Settings.Get("message_format").MustString(snsFormatText)
+	if messageFormat != snsFormatText && messageFormat != snsFormatJSON {
+		return nil, alerting.ValidationError{Reason: "Invalid SNS message format: Must be one of text, json"}
+	}
+
+	// Message attributes map the name of an attribute to the label it is
+	// taken from, so that subscribers can filter on labels.
+	settingAttributes, ok := jsonSetting(model.Settings, "message_attributes")
+	if !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid SNS message attributes: Must be an object of attribute names and labels"}
+	}
+	messageAttributes := map[string]string{}
+	for name := range settingAttributes.MustMap() {
+		label, err := settingAttributes.Get(name).String()
+		if err != nil || label == "" {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid SNS message attribute %s: Must be the name of a label", name)}
+		}
+		messageAttributes[name] = label
+	}
+	if len(messageAttributes) > snsMaxMessageAttributes {
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid SNS message attributes: Must be at most %d", snsMaxMessageAttributes)}
+	}
+
+	// Without an access key the credentials are taken from the default
+	// chain, e.g. the environment or the instance role.
+	cfg := &aws.Config{Region: aws.String(region)}
+	accessKey := model.Settings.Get("access_key").MustString()
+	secretKey := model.DecryptedValue("secret_key", model.Settings.Get("secret_key").MustString())
+	if (accessKey == "") != (secretKey == "") {
+		return nil, alerting.ValidationError{Reason: "Both SNS access key and secret key must be set to use static credentials"}
+	}
+	if accessKey != "" {
+		cfg.Credentials = credentials.NewStaticCredentials(accessKey, secretKey, "")
+	}
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	return &SNSNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		TopicARN:          topicARN,
+		Region:            region,
+		Subject:           subject,
+		Message:           message,
+		MessageFormat:     messageFormat,
+		MessageAttributes: messageAttributes,
+		fifo:              strings.HasSuffix(topicARN, ".fifo"),
+		client:            sns.New(sess),
+		metrics:           model.Metrics,
+		log:               log.New("alerting.notifier.sns"),
+		tmpl:              t,
+	}, nil
+}
+
+// Notify publishes the alert notification to the SNS topic
+func (sn *SNSNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := sn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing SNS notification", "notification", sn.Name, "topic", sn.TopicARN)
+
+	input, err := sn.buildInput(ctx, as)
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	_, err = sn.client.PublishWithContext(ctx, input)
+	sn.metrics.observe("sns", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to publish SNS notification", "error", err, "notification", sn.Name)
+		return false, fmt.Errorf("failed to publish SNS notification: %w", err)
+	}
+
+	return true, nil
+}
+
+// buildInput builds the request that publishes the notification for as.
+func (sn *SNSNotifier) buildInput(ctx context.Context, as []*types.Alert) (*sns.PublishInput, error) {
+	data := notify.GetTemplateData(ctx, sn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, sn.tmpl, data, &tmplErr)
+
+	subject := snsSubject(tmpl(sn.Subject))
+	message := tmpl(sn.Message)
+	if tmplErr != nil {
+		return nil, fmt.Errorf("failed to template SNS message: %w", tmplErr)
+	}
+
+	// Attributes must not be empty, so labels that are not common to all
+	// alerts are left out.
+	var attributes map[string]*sns.MessageAttributeValue
+	for name, label := range sn.MessageAttributes {
+		value := data.CommonLabels[label]
+		if value == "" {
+			continue
+		}
+		if attributes == nil {
+			attributes = map[string]*sns.MessageAttributeValue{}
+		}
+		attributes[name] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	// The subject and attributes count towards the size limit of the
+	// message, so the message is shortened to what they leave.
+	maxSize := snsMaxMessageSize - len(subject)
+	for name, attr := range attributes {
+		maxSize -= len(name) + len(aws.StringValue(attr.DataType)) + len(aws.StringValue(attr.StringValue))
+	}
+
+	if sn.MessageFormat == snsFormatJSON {
+		groupKey, err := notify.ExtractGroupKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msg := snsJSONMessage{
+			Subject:           subject,
+			Message:           message,
+			Status:            data.Status,
+			GroupKey:          groupKey.String(),
+			CommonLabels:      data.CommonLabels,
+			CommonAnnotations: data.CommonAnnotations,
+			ExternalURL:       data.ExternalURL,
+		}
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		// Escaping never makes the JSON shorter than the message, so
+		// shortening the message by the excess makes the JSON fit.
+		if excess := len(b) - maxSize; excess > 0 {
+			msg.Message = truncateUTF8WithEllipsis(msg.Message, len(msg.Message)-excess)
+			if b, err = json.Marshal(msg); err != nil {
+				return nil, err
+			}
+		}
+		message = string(b)
+	} else {
+		message = truncateUTF8WithEllipsis(message, maxSize)
+	}
+	if len(message) > maxSize {
+		return nil, fmt.Errorf("SNS message doesn't fit into the size limit of %d bytes", snsMaxMessageSize)
+	}
+
+	input := &sns.PublishInput{
+		TopicArn:          aws.String(sn.TopicARN),
+		Message:           aws.String(message),
+		MessageAttributes: attributes,
+	}
+	if subject != "" {
+		input.Subject = aws.String(subject)
+	}
+
+	// FIFO topics deliver the messages of a group in order, so each alert
+	// group is a message group. The deduplication ID drops duplicates of the
+	// same notification that are published within five minutes.
+	if sn.fifo {
+		// Test notifications have no group key, but still need a group.
+		var groupKey string
+		if key, err := notify.ExtractGroupKey(ctx); err == nil {
+			groupKey = key.String()
+		}
+		sum := sha256.Sum256([]byte(groupKey))
+		input.MessageGroupId = aws.String(fmt.Sprintf("%x", sum))
+		input.MessageDeduplicationId = aws.String(idempotencyKey(groupKey, subject+"\x00"+message))
+	}
+
+	return input, nil
+}
+
+// snsSubject makes s a valid SNS subject: a single line of printable ASCII
+// characters, at most snsMaxSubjectLength characters long. Other whitespace
+// becomes spaces and other characters are left out.
+func snsSubject(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= ' ' && r <= '~':
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return -1
+		}
+	}, s)
+	return truncateRunes(strings.Join(strings.Fields(s), " "), snsMaxSubjectLength)
+}
+
+func (sn *SNSNotifier) SendResolved() bool {
+	return !sn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (sn *SNSNotifier) Type() string {
+	return "sns"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (sn *SNSNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,294 @@
+package channels
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+type mockSNSClient struct {
+	inputs []*sns.PublishInput
+	err    error
+}
+
+func (c *mockSNSClient) PublishWithContext(_ aws.Context, input *sns.PublishInput, _ ...request.Option) (*sns.PublishOutput, error) {
+	c.inputs = append(c.inputs, input)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &sns.PublishOutput{MessageId: aws.String("1")}, nil
+}
+
+func TestSNSNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name          string
+		settings      string
+		alerts        []*types.Alert
+		expSubject    *string
+		expMessage    string
+		expAttributes map[string]*sns.MessageAttributeValue
+		expInitError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expSubject: aws.String("[FIRING:1] (val1)"),
+			expMessage: "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n",
+		}, {
+			name: "Message attributes from labels",
+			settings: `{
+				"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts",
+				"subject": "{{ .CommonLabels.alertname }}\non {{ .CommonLabels.instance }}",
+				"message": "{{ len .Alerts.Firing }} firing",
+				"message_attributes": {"severity": "severity", "team": "team", "instance": "instance"}
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical", "team": "db", "instance": "db-1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical", "team": "db", "instance": "db-2"},
+					},
+				},
+			},
+			expSubject: aws.String("alert1 on"),
+			expMessage: "2 firing",
+			expAttributes: map[string]*sns.MessageAttributeValue{
+				"severity": {DataType: aws.String("String"), StringValue: aws.String("critical")},
+				"team":     {DataType: aws.String("String"), StringValue: aws.String("db")},
+			},
+		}, {
+			name: "Subject of printable ASCII characters",
+			settings: `{
+				"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts",
+				"subject": "🔥 Disk full\ton\u00a0db-1 — {{ .CommonLabels.alertname }}\u0007",
+				"message": "Disk full"
+			}`,
+			alerts:     []*types.Alert{firingAlert()},
+			expSubject: aws.String("Disk full on db-1 alert1"),
+			expMessage: "Disk full",
+		}, {
+			name: "JSON message",
+			settings: `{
+				"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts",
+				"message": "{{ .CommonLabels.alertname }} is firing",
+				"message_format": "json"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expSubject: aws.String("[FIRING:1] (val1)"),
+			expMessage: `{"subject":"[FIRING:1] (val1)","message":"alert1 is firing","status":"firing","groupKey":"alertname","commonLabels":{"alertname":"alert1","lbl1":"val1"},"commonAnnotations":{"ann1":"annv1"},"externalURL":"http://localhost"}`,
+		}, {
+			name:         "Topic ARN missing",
+			settings:     `{}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find SNS topic ARN in settings"},
+		}, {
+			name:         "Invalid topic ARN",
+			settings:     `{"topic_arn": "arn:aws:sqs:eu-central-1:123456789012:alerts"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid SNS topic ARN: Must be an ARN such as arn:aws:sns:us-east-1:123456789012:alerts"},
+		}, {
+			name:         "Invalid message format",
+			settings:     `{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts", "message_format": "xml"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid SNS message format: Must be one of text, json"},
+		}, {
+			name:         "Invalid message attribute",
+			settings:     `{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts", "message_attributes": {"severity": 1}}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid SNS message attribute severity: Must be the name of a label"},
+		}, {
+			name:         "Message attributes that aren't JSON",
+			settings:     `{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts", "message_attributes": "severity=severity"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid SNS message attributes: Must be an object of attribute names and labels"},
+		}, {
+			name:         "Secret key missing",
+			settings:     `{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts", "access_key": "AKIAEXAMPLE"}`,
+			expInitError: alerting.ValidationError{Reason: "Both SNS access key and secret key must be set to use static credentials"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "sns_testing",
+				Type:     "sns",
+				Settings: settingsJSON,
+			}
+
+			sn, err := NewSNSNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "eu-central-1", sn.Region)
+
+			client := &mockSNSClient{}
+			sn.client = client
+
+			ok, err := sn.Notify(notifyContext(), c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Len(t, client.inputs, 1)
+			input := client.inputs[0]
+			require.Equal(t, "arn:aws:sns:eu-central-1:123456789012:alerts", aws.StringValue(input.TopicArn))
+			require.Equal(t, c.expSubject, input.Subject)
+			require.Equal(t, c.expMessage, aws.StringValue(input.Message))
+			require.Equal(t, c.expAttributes, input.MessageAttributes)
+		})
+	}
+}
+
+func TestSNSNotifierPublishError(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sn, err := NewSNSNotifier(&NotificationChannelConfig{
+		Name: "sns_testing",
+		Type: "sns",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts",
+			"region":    "us-east-1",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, "us-east-1", sn.Region)
+	sn.client = &mockSNSClient{err: errors.New("AuthorizationError")}
+
+	ok, err := sn.Notify(notifyContext(), firingAlert())
+	require.False(t, ok)
+	require.EqualError(t, err, "failed to publish SNS notification: AuthorizationError")
+}
+
+func TestSNSNotifierFIFOTopic(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sn, err := NewSNSNotifier(&NotificationChannelConfig{
+		Name:     "sns_testing",
+		Type:     "sns",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts.fifo"}),
+	}, tmpl)
+	require.NoError(t, err)
+	client := &mockSNSClient{}
+	sn.client = client
+
+	other := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}}
+	for _, alert := range []*types.Alert{firingAlert(), firingAlert(), other} {
+		_, err := sn.Notify(notifyContext(), alert)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, client.inputs, 3)
+	for _, input := range client.inputs {
+		require.Len(t, aws.StringValue(input.MessageGroupId), 64)
+		require.NotEmpty(t, aws.StringValue(input.MessageDeduplicationId))
+	}
+	// Notifications of the same group are in the same message group, and
+	// the same notification has the same deduplication ID.
+	require.Equal(t, client.inputs[0].MessageGroupId, client.inputs[2].MessageGroupId)
+	require.Equal(t, client.inputs[0].MessageDeduplicationId, client.inputs[1].MessageDeduplicationId)
+	require.NotEqual(t, client.inputs[0].MessageDeduplicationId, client.inputs[2].MessageDeduplicationId)
+
+	t.Run("Standard topics have neither", func(t *testing.T) {
+		sn, err := NewSNSNotifier(&NotificationChannelConfig{
+			Name:     "sns_testing",
+			Type:     "sns",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"topic_arn": "arn:aws:sns:eu-central-1:123456789012:alerts"}),
+		}, tmpl)
+		require.NoError(t, err)
+		client := &mockSNSClient{}
+		sn.client = client
+
+		_, err = sn.Notify(notifyContext(), firingAlert())
+		require.NoError(t, err)
+		require.Nil(t, client.inputs[0].MessageGroupId)
+		require.Nil(t, client.inputs[0].MessageDeduplicationId)
+	})
+}
+
+func TestSNSNotifierMessageSize(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "db"}}}
+
+	for _, format := range []string{snsFormatText, snsFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			sn, err := NewSNSNotifier(&NotificationChannelConfig{
+				Name: "sns_testing",
+				Type: "sns",
+				Settings: simplejson.NewFromAny(map[string]interface{}{
+					"topic_arn":          "arn:aws:sns:eu-central-1:123456789012:alerts",
+					"message":            strings.Repeat("x", 300*1024),
+					"message_format":     format,
+					"message_attributes": map[string]interface{}{"team": "team"},
+				}),
+			}, tmpl)
+			require.NoError(t, err)
+			client := &mockSNSClient{}
+			sn.client = client
+
+			_, err = sn.Notify(notifyContext(), alert)
+			require.NoError(t, err)
+
+			input := client.inputs[0]
+			size := len(aws.StringValue(input.Subject)) + len(aws.StringValue(input.Message)) + len("team") + len("String") + len("db")
+			require.LessOrEqual(t, size, snsMaxMessageSize)
+			require.Greater(t, size, snsMaxMessageSize-8)
+			if format == snsFormatJSON {
+				var msg snsJSONMessage
+				require.NoError(t, json.Unmarshal([]byte(aws.StringValue(input.Message)), &msg))
+				require.True(t, strings.HasSuffix(msg.Message, "x…"))
+			} else {
+				require.True(t, strings.HasSuffix(aws.StringValue(input.Message), "x…"))
+			}
+		})
+	}
+}
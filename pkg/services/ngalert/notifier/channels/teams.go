@@ -3,12 +3,14 @@ package channels
 import (
 	"context"
 	"encoding/json"
+	"net/url"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -18,14 +20,22 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/logging"
 )
 
+const (
+	// teamsMessageCard is the legacy card format of Teams connectors.
+	teamsMessageCard = "message_card"
+	// teamsAdaptiveCard is the card format that replaces message cards.
+	teamsAdaptiveCard = "adaptive_card"
+)
+
 // TeamsNotifier is responsible for sending
 // alert notifications to Microsoft teams.
 type TeamsNotifier struct {
 	old_notifiers.NotifierBase
-	URL     string
-	Message string
-	tmpl    *template.Template
-	log     log.Logger
+	URL        string
+	Message    string
+	CardFormat string
+	tmpl       *template.Template
+	log        log.Logger
 }
 
 // NewTeamsNotifier is the constructor for Teams notifier.
@@ -38,6 +48,14 @@ func NewTeamsNotifier(model *NotificationChannelConfig, t *template.Template) (*
 	if u == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
 	}
+	if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Teams webhook URL: Must be an absolute URL"}
+	}
+
+	cardFormat := model.Settings.Get("card_format").MustString(teamsMessageCard)
+	if cardFormat != teamsMessageCard && cardFormat != teamsAdaptiveCard {
+		return nil, alerting.ValidationError{Reason: "Invalid Teams card format: Must be one of message_card, adaptive_card"}
+	}
 
 	return &TeamsNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
@@ -47,10 +65,11 @@ func NewTeamsNotifier(model *NotificationChannelConfig, t *template.Template) (*
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		URL:     u,
-		Message: model.Settings.Get("message").MustString(`{{ template "default.message" .}}`),
-		log:     log.New("alerting.notifier.teams"),
-		tmpl:    t,
+		URL:        u,
+		Message:    model.Settings.Get("message").MustString(`{{ template "default.message" .}}`),
+		CardFormat: cardFormat,
+		log:        log.New("alerting.notifier.teams"),
+		tmpl:       t,
 	}, nil
 }
 
@@ -58,7 +77,7 @@ func NewTeamsNotifier(model *NotificationChannelConfig, t *template.Template) (*
 func (tn *TeamsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	data := notify.GetTemplateData(ctx, tn.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(tn.log)))
 	var tmplErr error
-	tmpl := notify.TmplText(tn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, tn.tmpl, data, &tmplErr)
 
 	ruleURL, err := joinUrlPath(tn.tmpl.ExternalURL.String(), "/alerting/list")
 	if err != nil {
@@ -66,18 +85,47 @@ func (tn *TeamsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	}
 
 	title := tmpl(`{{ template "default.title" . }}`)
-	body := map[string]interface{}{
+	message := tmpl(tn.Message)
+	status := types.Alerts(as...).Status()
+
+	var body map[string]interface{}
+	if tn.CardFormat == teamsAdaptiveCard {
+		body = teamsAdaptiveCardBody(title, message, status, data.CommonLabels, ruleURL)
+	} else {
+		body = teamsMessageCardBody(title, message, status, ruleURL)
+	}
+
+	if tmplErr != nil {
+		return false, errors.Wrap(tmplErr, "failed to template Teams message")
+	}
+
+	b, err := json.Marshal(&body)
+	if err != nil {
+		return false, errors.Wrap(err, "marshal json")
+	}
+	cmd := &models.SendWebhookSync{Url: tn.URL, Body: string(b)}
+
+	if err := bus.DispatchCtx(ctx, cmd); err != nil {
+		return false, errors.Wrap(err, "send notification to Teams")
+	}
+
+	return true, nil
+}
+
+// teamsMessageCardBody returns the body of a legacy message card.
+func teamsMessageCardBody(title, message string, status model.AlertStatus, ruleURL string) map[string]interface{} {
+	return map[string]interface{}{
 		"@type":    "MessageCard",
 		"@context": "http://schema.org/extensions",
 		// summary MUST not be empty or the webhook request fails
 		// summary SHOULD contain some meaningful information, since it is used for mobile notifications
 		"summary":    title,
 		"title":      title,
-		"themeColor": getAlertStatusColor(types.Alerts(as...).Status()),
+		"themeColor": getAlertStatusColor(status),
 		"sections": []map[string]interface{}{
 			{
 				"title": "Details",
-				"text":  tmpl(tn.Message),
+				"text":  message,
 			},
 		},
 		"potentialAction": []map[string]interface{}{
@@ -94,24 +142,87 @@ func (tn *TeamsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 			},
 		},
 	}
+}
 
-	if tmplErr != nil {
-		return false, errors.Wrap(tmplErr, "failed to template Teams message")
+// teamsAdaptiveCardBody returns the body of a message with an Adaptive Card
+// that lists the common labels of the alerts as facts.
+func teamsAdaptiveCardBody(title, message string, status model.AlertStatus, labels template.KV, ruleURL string) map[string]interface{} {
+	// Adaptive Cards have no theme color, so the title is highlighted.
+	style, color := "attention", "Attention"
+	if status == model.AlertResolved {
+		style, color = "good", "Good"
 	}
 
-	b, err := json.Marshal(&body)
-	if err != nil {
-		return false, errors.Wrap(err, "marshal json")
+	items := []map[string]interface{}{
+		{
+			"type":  "Container",
+			"style": style,
+			"bleed": true,
+			"items": []map[string]interface{}{
+				{
+					"type":   "TextBlock",
+					"text":   title,
+					"size":   "Large",
+					"weight": "Bolder",
+					"color":  color,
+					"wrap":   true,
+				},
+			},
+		},
+		{
+			"type": "TextBlock",
+			"text": message,
+			"wrap": true,
+		},
 	}
-	cmd := &models.SendWebhookSync{Url: tn.URL, Body: string(b)}
-
-	if err := bus.DispatchCtx(ctx, cmd); err != nil {
-		return false, errors.Wrap(err, "send notification to Teams")
+	if len(labels) > 0 {
+		facts := make([]map[string]interface{}, 0, len(labels))
+		for _, pair := range labels.SortedPairs() {
+			facts = append(facts, map[string]interface{}{"title": pair.Name, "value": pair.Value})
+		}
+		items = append(items, map[string]interface{}{
+			"type":  "FactSet",
+			"facts": facts,
+		})
 	}
 
-	return true, nil
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					// Use the full width of the conversation.
+					"msteams": map[string]interface{}{"width": "Full"},
+					// summary is used for notifications on mobile devices.
+					"summary": title,
+					"body":    items,
+					"actions": []map[string]interface{}{
+						{
+							"type":  "Action.OpenUrl",
+							"title": "Open in Grafana",
+							"url":   ruleURL,
+						},
+					},
+				},
+			},
+		},
+	}
 }
 
 func (tn *TeamsNotifier) SendResolved() bool {
 	return !tn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (tn *TeamsNotifier) Type() string {
+	return "teams"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (tn *TeamsNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
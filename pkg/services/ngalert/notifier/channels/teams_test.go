@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -109,6 +110,122 @@ func TestTeamsNotifier(t *testing.T) {
 			},
 			expInitError: nil,
 			expMsgError:  nil,
+		}, {
+			name:     "Adaptive card with one alert",
+			settings: `{"url": "http://localhost", "card_format": "adaptive_card"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"type": "message",
+				"attachments": []map[string]interface{}{
+					{
+						"contentType": "application/vnd.microsoft.card.adaptive",
+						"content": map[string]interface{}{
+							"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+							"type":    "AdaptiveCard",
+							"version": "1.4",
+							"msteams": map[string]interface{}{"width": "Full"},
+							"summary": "[FIRING:1]  (val1)",
+							"body": []map[string]interface{}{
+								{
+									"type":  "Container",
+									"style": "attention",
+									"bleed": true,
+									"items": []map[string]interface{}{
+										{"type": "TextBlock", "text": "[FIRING:1]  (val1)", "size": "Large", "weight": "Bolder", "color": "Attention", "wrap": true},
+									},
+								},
+								{"type": "TextBlock", "text": "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n", "wrap": true},
+								{
+									"type": "FactSet",
+									"facts": []map[string]interface{}{
+										{"title": "alertname", "value": "alert1"},
+										{"title": "lbl1", "value": "val1"},
+									},
+								},
+							},
+							"actions": []map[string]interface{}{
+								{"type": "Action.OpenUrl", "title": "Open in Grafana", "url": "http://localhost/alerting/list"},
+							},
+						},
+					},
+				},
+			},
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Adaptive card with resolved alerts",
+			settings: `{
+				"url": "http://localhost",
+				"card_format": "adaptive_card",
+				"message": "{{ len .Alerts.Resolved }} alerts are resolved"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						StartsAt: time.Now().Add(-2 * time.Hour),
+						EndsAt:   time.Now().Add(-time.Hour),
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+						StartsAt: time.Now().Add(-2 * time.Hour),
+						EndsAt:   time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"type": "message",
+				"attachments": []map[string]interface{}{
+					{
+						"contentType": "application/vnd.microsoft.card.adaptive",
+						"content": map[string]interface{}{
+							"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+							"type":    "AdaptiveCard",
+							"version": "1.4",
+							"msteams": map[string]interface{}{"width": "Full"},
+							"summary": "[RESOLVED]  ",
+							"body": []map[string]interface{}{
+								{
+									"type":  "Container",
+									"style": "good",
+									"bleed": true,
+									"items": []map[string]interface{}{
+										{"type": "TextBlock", "text": "[RESOLVED]  ", "size": "Large", "weight": "Bolder", "color": "Good", "wrap": true},
+									},
+								},
+								{"type": "TextBlock", "text": "2 alerts are resolved", "wrap": true},
+								{
+									"type": "FactSet",
+									"facts": []map[string]interface{}{
+										{"title": "alertname", "value": "alert1"},
+									},
+								},
+							},
+							"actions": []map[string]interface{}{
+								{"type": "Action.OpenUrl", "title": "Open in Grafana", "url": "http://localhost/alerting/list"},
+							},
+						},
+					},
+				},
+			},
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Invalid URL",
+			settings:     `{"url": "localhost/webhook"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Teams webhook URL: Must be an absolute URL"},
+		}, {
+			name:         "Invalid card format",
+			settings:     `{"url": "http://localhost", "card_format": "hero_card"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Teams card format: Must be one of message_card, adaptive_card"},
 		}, {
 			name:         "Error in initing",
 			settings:     `{}`,
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"html"
 	"mime/multipart"
+	"regexp"
+	"strings"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
@@ -21,17 +24,45 @@ import (
 
 var (
 	TelegramAPIURL = "https://api.telegram.org/bot%s/sendMessage"
+
+	// telegramBotTokenRegexp matches the tokens of Telegram bots, which are
+	// the ID of the bot followed by a secret.
+	telegramBotTokenRegexp = regexp.MustCompile(`^[0-9]+:[A-Za-z0-9_-]+$`)
+
+	// telegramChatIDRegexp matches the numeric IDs of chats, which are
+	// negative for groups and channels, and the usernames of channels.
+	telegramChatIDRegexp = regexp.MustCompile(`^(-?[0-9]+|@[A-Za-z0-9_]{5,})$`)
+
+	// telegramMarkdownV2Escaper escapes the characters that are reserved in
+	// MarkdownV2.
+	telegramMarkdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+)
+
+const (
+	// telegramParseModeHTML and telegramParseModeMarkdownV2 are the formats
+	// Telegram parses messages in.
+	telegramParseModeHTML       = "HTML"
+	telegramParseModeMarkdownV2 = "MarkdownV2"
+
+	telegramDefaultMessage = `{{ template "default.message" . }}`
 )
 
 // TelegramNotifier is responsible for sending
 // alert notifications to Telegram.
 type TelegramNotifier struct {
 	old_notifiers.NotifierBase
-	BotToken string
-	ChatID   string
-	Message  string
-	log      log.Logger
-	tmpl     *template.Template
+	BotToken              string
+	ChatID                string
+	Message               string
+	ParseMode             string
+	DisableWebPagePreview bool
+	Silent                bool
+	log                   log.Logger
+	tmpl                  *template.Template
 }
 
 // NewTelegramNotifier is the constructor for the Telegram notifier
@@ -42,15 +73,31 @@ func NewTelegramNotifier(model *NotificationChannelConfig, t *template.Template)
 
 	botToken := model.DecryptedValue("bottoken", model.Settings.Get("bottoken").MustString())
 	chatID := model.Settings.Get("chatid").MustString()
-	message := model.Settings.Get("message").MustString(`{{ template "default.message" . }}`)
+	message := model.Settings.Get("message").MustString(telegramDefaultMessage)
 
 	if botToken == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find Bot Token in settings"}
 	}
+	if !telegramBotTokenRegexp.MatchString(botToken) {
+		return nil, alerting.ValidationError{Reason: "Invalid Bot Token: Must be the ID of the bot and a secret separated by a colon"}
+	}
 
 	if chatID == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find Chat Id in settings"}
 	}
+	if !telegramChatIDRegexp.MatchString(chatID) {
+		return nil, alerting.ValidationError{Reason: "Invalid Chat Id: Must be a number or the @username of a channel"}
+	}
+
+	parseMode := model.Settings.Get("parse_mode").MustString(telegramParseModeHTML)
+	switch {
+	case strings.EqualFold(parseMode, telegramParseModeHTML):
+		parseMode = telegramParseModeHTML
+	case strings.EqualFold(parseMode, telegramParseModeMarkdownV2):
+		parseMode = telegramParseModeMarkdownV2
+	default:
+		return nil, alerting.ValidationError{Reason: "Invalid parse mode: Must be one of HTML, MarkdownV2"}
+	}
 
 	return &TelegramNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
@@ -60,11 +107,14 @@ func NewTelegramNotifier(model *NotificationChannelConfig, t *template.Template)
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		BotToken: botToken,
-		ChatID:   chatID,
-		Message:  message,
-		tmpl:     t,
-		log:      log.New("alerting.notifier.telegram"),
+		BotToken:              botToken,
+		ChatID:                chatID,
+		Message:               message,
+		ParseMode:             parseMode,
+		DisableWebPagePreview: model.Settings.Get("disable_web_page_preview").MustBool(false),
+		Silent:                parseSilent(model.Settings),
+		tmpl:                  t,
+		log:                   log.New("alerting.notifier.telegram"),
 	}, nil
 }
 
@@ -116,16 +166,36 @@ func (tn *TelegramNotifier) Notify(ctx context.Context, as ...*types.Alert) (boo
 func (tn *TelegramNotifier) buildTelegramMessage(ctx context.Context, as []*types.Alert) (map[string]string, error) {
 	msg := map[string]string{}
 	msg["chat_id"] = tn.ChatID
-	msg["parse_mode"] = "html"
+	msg["parse_mode"] = tn.ParseMode
+	if tn.DisableWebPagePreview {
+		msg["disable_web_page_preview"] = "true"
+	}
+	if tn.Silent {
+		msg["disable_notification"] = "true"
+	}
 
 	data := notify.GetTemplateData(ctx, &template.Template{ExternalURL: tn.tmpl.ExternalURL}, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(tn.log)))
 	var tmplErr error
-	tmpl := notify.TmplText(tn.tmpl, data, &tmplErr)
-
+	tmpl := tmplText(ctx, tn.tmpl, data, &tmplErr)
+
+	// Telegram rejects messages that don't parse, so values must not contain
+	// markup. Custom templates are written in the parse mode, so only the
+	// values they include are escaped, whereas the default message is plain
+	// text as a whole.
+	escape := html.EscapeString
+	if tn.ParseMode == telegramParseModeMarkdownV2 {
+		escape = telegramMarkdownV2Escaper.Replace
+	}
+	if tn.Message != telegramDefaultMessage {
+		mapTemplateValues(data, escape)
+	}
 	message := tmpl(tn.Message)
 	if tmplErr != nil {
 		return nil, tmplErr
 	}
+	if tn.Message == telegramDefaultMessage {
+		message = escape(message)
+	}
 
 	msg["text"] = message
 
@@ -146,3 +216,13 @@ func writeField(w *multipart.Writer, name, value string) error {
 func (tn *TelegramNotifier) SendResolved() bool {
 	return !tn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (tn *TelegramNotifier) Type() string {
+	return "telegram"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (tn *TelegramNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
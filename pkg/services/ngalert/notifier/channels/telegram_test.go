@@ -33,8 +33,8 @@ func TestTelegramNotifier(t *testing.T) {
 		{
 			name: "Default template with one alert",
 			settings: `{
-				"bottoken": "abcdefgh0123456789",
-				"chatid": "someid"
+				"bottoken": "123456:abcdefgh0123456789",
+				"chatid": "-1001234567890"
 			}`,
 			alerts: []*types.Alert{
 				{
@@ -46,8 +46,8 @@ func TestTelegramNotifier(t *testing.T) {
 				},
 			},
 			expMsg: map[string]string{
-				"chat_id":    "someid",
-				"parse_mode": "html",
+				"chat_id":    "-1001234567890",
+				"parse_mode": "HTML",
 				"text":       "\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: a URL\n\n\n\n\n",
 			},
 			expInitError: nil,
@@ -55,8 +55,8 @@ func TestTelegramNotifier(t *testing.T) {
 		}, {
 			name: "Custom template with multiple alerts",
 			settings: `{
-				"bottoken": "abcdefgh0123456789",
-				"chatid": "someid",
+				"bottoken": "123456:abcdefgh0123456789",
+				"chatid": "-1001234567890",
 				"message": "__Custom Firing__\n{{len .Alerts.Firing}} Firing\n{{ template \"__text_alert_list\" .Alerts.Firing }}"
 			}`,
 			alerts: []*types.Alert{
@@ -74,12 +74,77 @@ func TestTelegramNotifier(t *testing.T) {
 				},
 			},
 			expMsg: map[string]string{
-				"chat_id":    "someid",
-				"parse_mode": "html",
+				"chat_id":    "-1001234567890",
+				"parse_mode": "HTML",
 				"text":       "__Custom Firing__\n2 Firing\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: a URL\nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n",
 			},
 			expInitError: nil,
 			expMsgError:  nil,
+		}, {
+			name: "Custom HTML template escapes values, silently",
+			settings: `{
+				"bottoken": "123456:abcdefgh0123456789",
+				"chatid": "@alerting",
+				"message": "<b>{{ .CommonLabels.alertname }}</b> {{ .CommonAnnotations.summary }}",
+				"disable_web_page_preview": true,
+				"silent": true
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "<alert1>"},
+						Annotations: model.LabelSet{"summary": "a & b"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"chat_id":                  "@alerting",
+				"parse_mode":               "HTML",
+				"disable_web_page_preview": "true",
+				"disable_notification":     "true",
+				"text":                     "<b>&lt;alert1&gt;</b> a &amp; b",
+			},
+		}, {
+			name: "Custom MarkdownV2 template escapes values",
+			settings: `{
+				"bottoken": "123456:abcdefgh0123456789",
+				"chatid": "-1001234567890",
+				"parse_mode": "markdownv2",
+				"message": "*{{ .CommonLabels.alertname }}* {{ .CommonAnnotations.summary }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "disk_usage"},
+						Annotations: model.LabelSet{"summary": "[db-1] (95.5%) > 90! `df` ~#+=|{}\\"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"chat_id":    "-1001234567890",
+				"parse_mode": "MarkdownV2",
+				"text":       "*disk\\_usage* \\[db\\-1\\] \\(95\\.5%\\) \\> 90\\! \\`df\\` \\~\\#\\+\\=\\|\\{\\}\\\\",
+			},
+		}, {
+			name: "Default template in MarkdownV2 is escaped as a whole",
+			settings: `{
+				"bottoken": "123456:abcdefgh0123456789",
+				"chatid": "-1001234567890",
+				"parse_mode": "MarkdownV2"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:       model.LabelSet{"alertname": "alert1"},
+						GeneratorURL: "a URL",
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"chat_id":    "-1001234567890",
+				"parse_mode": "MarkdownV2",
+				"text":       "\n\\*\\*Firing\\*\\*\nLabels:\n \\- alertname \\= alert1\nAnnotations:\nSource: a URL\n\n\n\n\n",
+			},
 		}, {
 			name:         "Error in initing",
 			settings:     `{}`,
@@ -87,12 +152,25 @@ func TestTelegramNotifier(t *testing.T) {
 		}, {
 			name: "Error in building message",
 			settings: `{
-				"bottoken": "abcdefgh0123456789",
-				"chatid": "someid",
+				"bottoken": "123456:abcdefgh0123456789",
+				"chatid": "-1001234567890",
 				"message": "{{ .BrokenTemplate }"
 			}`,
 			expMsgError: errors.New("template: :1: unexpected \"}\" in operand"),
 		},
+		{
+			name:         "Invalid bot token",
+			settings:     `{"bottoken": "abcdefgh0123456789", "chatid": "-1001234567890"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Bot Token: Must be the ID of the bot and a secret separated by a colon"},
+		}, {
+			name:         "Invalid chat ID",
+			settings:     `{"bottoken": "123456:abcdefgh0123456789", "chatid": "someid"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Chat Id: Must be a number or the @username of a channel"},
+		}, {
+			name:         "Invalid parse mode",
+			settings:     `{"bottoken": "123456:abcdefgh0123456789", "chatid": "-1001234567890", "parse_mode": "Markdown"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid parse mode: Must be one of HTML, MarkdownV2"},
+		},
 	}
 
 	for _, c := range cases {
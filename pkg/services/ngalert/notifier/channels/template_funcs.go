@@ -0,0 +1,167 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	tmplhtml "html/template"
+	"path/filepath"
+	"reflect"
+	"strings"
+	tmpltext "text/template"
+	"time"
+	"unsafe"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+)
+
+// templateClock is the clock the template functions tell the time with.
+var templateClock = realClock
+
+// templateFuncs are the functions that notification templates can use in
+// addition to the ones of the Alertmanager.
+var templateFuncs = template.FuncMap{
+	"alertDuration":    alertDuration,
+	"humanizeDuration": humanizeDuration,
+}
+
+// TemplateOption configures the notification templates built by NewTemplate.
+type TemplateOption func(*templateOptions)
+
+type templateOptions struct {
+	funcs template.FuncMap
+}
+
+// WithTemplateFuncs makes funcs available to the templates in addition to the
+// default functions. A function of funcs replaces a default function of the
+// same name.
+func WithTemplateFuncs(funcs template.FuncMap) TemplateOption {
+	return func(o *templateOptions) {
+		if o.funcs == nil {
+			o.funcs = template.FuncMap{}
+		}
+		for name, fn := range funcs {
+			o.funcs[name] = fn
+		}
+	}
+}
+
+// NewTemplate builds the notification templates from the template files
+// matching paths, like template.FromGlobs. The notifiers constructed with it
+// can use templateFuncs and the functions of opts in their templates, so
+// their templates must be built with it.
+func NewTemplate(paths []string, opts ...TemplateOption) (*template.Template, error) {
+	var o templateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	funcs := make(template.FuncMap, len(templateFuncs)+len(o.funcs))
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	for name, fn := range o.funcs {
+		funcs[name] = fn
+	}
+
+	// The default templates of the Alertmanager don't use the functions, so
+	// they are added to the templates after the defaults are parsed and
+	// before the template files are.
+	t, err := template.FromGlobs()
+	if err != nil {
+		return nil, err
+	}
+	text, html, err := templateTrees(t)
+	if err != nil {
+		return nil, err
+	}
+	text.Funcs(tmpltext.FuncMap(funcs))
+	html.Funcs(tmplhtml.FuncMap(funcs))
+
+	for _, tp := range paths {
+		// Like FromGlobs, allow globs that don't match any files yet.
+		p, err := filepath.Glob(tp)
+		if err != nil {
+			return nil, err
+		}
+		if len(p) == 0 {
+			continue
+		}
+		if _, err := text.ParseGlob(tp); err != nil {
+			return nil, err
+		}
+		if _, err := html.ParseGlob(tp); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// templateTrees returns the text and HTML templates that t bundles. The
+// Alertmanager doesn't export them, and doesn't let the functions of a
+// single Template be extended otherwise.
+func templateTrees(t *template.Template) (*tmpltext.Template, *tmplhtml.Template, error) {
+	v := reflect.ValueOf(t).Elem()
+	textField, htmlField := v.FieldByName("text"), v.FieldByName("html")
+	if !textField.IsValid() || !htmlField.IsValid() {
+		return nil, nil, errors.New("unsupported Alertmanager template")
+	}
+
+	// nolint:gosec
+	text, ok := reflect.NewAt(textField.Type(), unsafe.Pointer(textField.UnsafeAddr())).Elem().Interface().(*tmpltext.Template)
+	if !ok || text == nil {
+		return nil, nil, errors.New("unsupported Alertmanager text template")
+	}
+	// nolint:gosec
+	html, ok := reflect.NewAt(htmlField.Type(), unsafe.Pointer(htmlField.UnsafeAddr())).Elem().Interface().(*tmplhtml.Template)
+	if !ok || html == nil {
+		return nil, nil, errors.New("unsupported Alertmanager HTML template")
+	}
+	return text, html, nil
+}
+
+// alertDuration returns how long a has been firing, or how long it fired
+// before it was resolved. It returns 0 if a hasn't started yet.
+func alertDuration(a template.Alert) time.Duration {
+	if a.StartsAt.IsZero() {
+		return 0
+	}
+
+	end := templateClock.Now()
+	if a.Status == string(model.AlertResolved) && !a.EndsAt.IsZero() && a.EndsAt.Before(end) {
+		end = a.EndsAt
+	}
+	if d := end.Sub(a.StartsAt); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// humanizeDuration formats d with its two most significant units, e.g.
+// "12m", "1h 5m" or "2d 3h". Durations below a second are dropped.
+func humanizeDuration(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+
+	for i, u := range units {
+		if d < u.size {
+			continue
+		}
+		parts := []string{fmt.Sprintf("%d%s", d/u.size, u.suffix)}
+		if i+1 < len(units) {
+			next := units[i+1]
+			if n := d % u.size / next.size; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d%s", n, next.suffix))
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+	return "0s"
+}
@@ -0,0 +1,209 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	cases := []struct {
+		in  time.Duration
+		exp string
+	}{
+		{in: -time.Minute, exp: "0s"},
+		{in: 0, exp: "0s"},
+		{in: 500 * time.Millisecond, exp: "0s"},
+		{in: 45 * time.Second, exp: "45s"},
+		{in: 12 * time.Minute, exp: "12m"},
+		{in: 12*time.Minute + 30*time.Second + 400*time.Millisecond, exp: "12m 30s"},
+		{in: time.Hour + 5*time.Minute + 59*time.Second, exp: "1h 5m"},
+		{in: time.Hour + 59*time.Second, exp: "1h"},
+		{in: 51 * time.Hour, exp: "2d 3h"},
+		{in: 72*time.Hour + 30*time.Minute, exp: "3d"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.in.String(), func(t *testing.T) {
+			require.Equal(t, c.exp, humanizeDuration(c.in))
+		})
+	}
+}
+
+func TestAlertDuration(t *testing.T) {
+	mockClock := clock.NewMock()
+	origClock := templateClock
+	templateClock = mockClock
+	t.Cleanup(func() {
+		templateClock = origClock
+	})
+	now := mockClock.Now()
+
+	cases := []struct {
+		name  string
+		alert template.Alert
+		exp   time.Duration
+	}{
+		{
+			name:  "firing",
+			alert: template.Alert{Status: "firing", StartsAt: now.Add(-12 * time.Minute)},
+			exp:   12 * time.Minute,
+		}, {
+			name:  "resolved",
+			alert: template.Alert{Status: "resolved", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+			exp:   time.Hour,
+		}, {
+			name:  "firing until a future end",
+			alert: template.Alert{Status: "firing", StartsAt: now.Add(-12 * time.Minute), EndsAt: now.Add(time.Hour)},
+			exp:   12 * time.Minute,
+		}, {
+			name:  "not started yet",
+			alert: template.Alert{Status: "firing", StartsAt: now.Add(time.Minute)},
+			exp:   0,
+		}, {
+			name:  "no start",
+			alert: template.Alert{Status: "firing"},
+			exp:   0,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, alertDuration(c.alert))
+		})
+	}
+}
+
+func TestNotifiersAlertDuration(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Now())
+	origClock := templateClock
+	templateClock = mockClock
+	t.Cleanup(func() {
+		templateClock = origClock
+	})
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: mockClock.Now().Add(-12 * time.Minute),
+		},
+	}
+
+	// The default English message is the one of the Alertmanager, which
+	// doesn't tell the duration.
+	messageFields := map[string]string{"threema": "text", "line": "message"}
+	for _, c := range []struct {
+		settings map[string]interface{}
+		exp      string
+	}{
+		{settings: map[string]interface{}{"locale": "de"}, exp: "Annotationen:\nDauer: 12m\nQuelle: \n"},
+		{settings: map[string]interface{}{"label_order": []interface{}{"alertname"}}, exp: "Annotations:\nDuration: 12m\nSource: \n"},
+		{settings: nil, exp: "Annotations:\nSource: \n"},
+	} {
+		for name, n := range notifiersWithHTTPOptions(t, tmpl, c.settings) {
+			t.Run(fmt.Sprintf("%s %v", name, c.settings), func(t *testing.T) {
+				var values url.Values
+				bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+					var err error
+					values, err = url.ParseQuery(webhook.Body)
+					return err
+				})
+
+				_, err := n.Notify(notifyContext(), alert)
+				require.NoError(t, err)
+				require.Contains(t, values.Get(messageFields[name]), c.exp)
+			})
+		}
+	}
+}
+
+func TestNewTemplateWithFuncs(t *testing.T) {
+	tmpl := templateForTests(t, WithTemplateFuncs(template.FuncMap{
+		"regexReplace": func(pattern, replacement, text string) string {
+			return regexp.MustCompile(pattern).ReplaceAllString(text, replacement)
+		},
+		// Replaces the default function.
+		"toUpper": func(s string) string {
+			return "UPPER:" + strings.ToUpper(s)
+		},
+	}))
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1", "instance": "db-1.example.org:9100"},
+		},
+	}
+	message := `{{ .CommonLabels.instance | regexReplace "\\.example\\.org:[0-9]+$" "" | toUpper }}`
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"message": message}) {
+		t.Run(name, func(t *testing.T) {
+			var body string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+			ok, err := n.Notify(notifyContext(), alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			require.Contains(t, values.Get("text")+values.Get("message"), "UPPER:DB-1")
+		})
+	}
+
+	t.Run("Other templates are not affected", func(t *testing.T) {
+		for _, name := range []string{"regexReplace", "alertDuration", "humanizeDuration"} {
+			_, ok := template.DefaultFuncs[name]
+			require.False(t, ok, name)
+		}
+
+		_, err := templateForTests(t).ExecuteTextString(message, template.Data{})
+		require.Error(t, err)
+		s, err := templateForTests(t).ExecuteTextString(`{{ toUpper "db-1" }}`, template.Data{})
+		require.NoError(t, err)
+		require.Equal(t, "DB-1", s)
+	})
+
+	t.Run("Templates with different functions are built concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tmpl, err := NewTemplate(nil, WithTemplateFuncs(template.FuncMap{
+					"id": func() int { return i },
+				}))
+				require.NoError(t, err)
+				s, err := tmpl.ExecuteTextString(`{{ id }}`, template.Data{})
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprint(i), s)
+			}()
+		}
+		wg.Wait()
+	})
+}
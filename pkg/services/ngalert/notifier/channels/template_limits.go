@@ -0,0 +1,59 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+var (
+	// templateTimeout is how long a single template may take to execute.
+	templateTimeout = 10 * time.Second
+
+	// maxTemplateOutputSize is the maximum size in bytes of the output of a
+	// single template.
+	maxTemplateOutputSize = 1 << 20
+)
+
+// executeTextString executes text with data like t.ExecuteTextString, but
+// aborts with an error if it takes longer than templateTimeout, ctx is done
+// before, or the output exceeds maxTemplateOutputSize. This keeps a runaway
+// user-supplied template from blocking the notification. Go templates can't
+// be interrupted, so an aborted template is abandoned rather than stopped.
+func executeTextString(ctx context.Context, t *template.Template, text string, data interface{}) (string, error) {
+	type result struct {
+		s   string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		s, err := t.ExecuteTextString(text, data)
+		done <- result{s: s, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err == nil && len(r.s) > maxTemplateOutputSize {
+			return "", fmt.Errorf("template execution aborted: output exceeds %d bytes", maxTemplateOutputSize)
+		}
+		return r.s, r.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("template execution aborted: %w", ctx.Err())
+	case <-templateClock.After(templateTimeout):
+		return "", fmt.Errorf("template execution aborted: exceeded the deadline of %s", templateTimeout)
+	}
+}
+
+// tmplText is like notify.TmplText, but limits the execution of the
+// templates like executeTextString.
+func tmplText(ctx context.Context, t *template.Template, data *template.Data, err *error) func(string) string {
+	return func(text string) (s string) {
+		if *err != nil {
+			return
+		}
+		s, *err = executeTextString(ctx, t, text, data)
+		return s
+	}
+}
@@ -0,0 +1,99 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingData blocks templates that call Wait until release is closed.
+type blockingData struct {
+	release chan struct{}
+}
+
+func (d blockingData) Wait() string {
+	<-d.release
+	return "released"
+}
+
+func TestExecuteTextString(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	t.Run("Output within the limit", func(t *testing.T) {
+		s, err := executeTextString(context.Background(), tmpl, `{{ .Status }}`, &template.Data{Status: "firing"})
+		require.NoError(t, err)
+		require.Equal(t, "firing", s)
+	})
+
+	t.Run("Output exceeding the limit", func(t *testing.T) {
+		orig := maxTemplateOutputSize
+		t.Cleanup(func() { maxTemplateOutputSize = orig })
+		maxTemplateOutputSize = 100
+
+		s, err := executeTextString(context.Background(), tmpl, strings.Repeat("x", 101), nil)
+		require.EqualError(t, err, "template execution aborted: output exceeds 100 bytes")
+		require.Empty(t, s)
+
+		s, err = executeTextString(context.Background(), tmpl, strings.Repeat("x", 100), nil)
+		require.NoError(t, err)
+		require.Len(t, s, 100)
+	})
+
+	t.Run("Exceeding the deadline", func(t *testing.T) {
+		mock := clock.NewMock()
+		orig := templateClock
+		t.Cleanup(func() { templateClock = orig })
+		templateClock = mock
+
+		data := blockingData{release: make(chan struct{})}
+		defer close(data.release)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := executeTextString(context.Background(), tmpl, `{{ .Wait }}`, data)
+			done <- err
+		}()
+		for {
+			mock.Add(templateTimeout)
+			select {
+			case err := <-done:
+				require.EqualError(t, err, "template execution aborted: exceeded the deadline of 10s")
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		data := blockingData{release: make(chan struct{})}
+		defer close(data.release)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		_, err := executeTextString(ctx, tmpl, `{{ .Wait }}`, data)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Notifiers fail on templates exceeding the limit", func(t *testing.T) {
+		orig := maxTemplateOutputSize
+		t.Cleanup(func() { maxTemplateOutputSize = orig })
+		maxTemplateOutputSize = 100
+
+		for name, n := range notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"message": `{{ "` + strings.Repeat("x", 200) + `" }}`}) {
+			ok, err := n.Notify(notifyContext(), firingAlert())
+			require.False(t, ok, name)
+			require.Error(t, err, name)
+			require.Contains(t, err.Error(), "template execution aborted: output exceeds 100 bytes", name)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package channels
+
+import (
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// testAlertLabel marks the alerts of test notifications.
+	testAlertLabel = "grafana_test"
+
+	// testNotificationPrefix prefixes the messages of test notifications.
+	testNotificationPrefix = "[TEST] "
+)
+
+// TestAlert returns a sample alert for testing a notification channel. It
+// carries representative labels and annotations, and is marked so that
+// notifiers can tell it apart from real alerts.
+func TestAlert() *types.Alert {
+	now := time.Now()
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{
+				model.AlertNameLabel: "TestAlert",
+				"severity":           "critical",
+				testAlertLabel:       "true",
+			},
+			Annotations: model.LabelSet{
+				"summary":            "Notification test",
+				runbookURLAnnotation: "https://grafana.com/docs/grafana/latest/alerting/",
+			},
+			StartsAt: now,
+		},
+		UpdatedAt: now,
+	}
+}
+
+// isTestNotification reports whether any of as is marked as a test alert.
+func isTestNotification(as []*types.Alert) bool {
+	for _, a := range as {
+		if a.Labels[testAlertLabel] == "true" {
+			return true
+		}
+	}
+	return false
+}
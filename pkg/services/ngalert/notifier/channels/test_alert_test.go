@@ -0,0 +1,49 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestNotifiersMarkTestNotifications(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	// The field of the form that holds the message of each notifier.
+	messageFields := map[string]string{"threema": "text", "line": "message"}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, nil) {
+		t.Run(name, func(t *testing.T) {
+			var values url.Values
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				var err error
+				values, err = url.ParseQuery(webhook.Body)
+				return err
+			})
+
+			ok, err := n.Notify(notifyContext(), TestAlert())
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			message := values.Get(messageFields[name])
+			require.Contains(t, message, "[TEST] [FIRING:1]")
+			require.Contains(t, message, "alertname = TestAlert")
+			require.Contains(t, message, "summary = Notification test")
+			require.NotContains(t, message, "<no value>")
+
+			ok, err = n.Notify(notifyContext(), firingAlert())
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.NotContains(t, values.Get(messageFields[name]), "[TEST]")
+		})
+	}
+}
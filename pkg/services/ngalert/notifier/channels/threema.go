@@ -2,18 +2,24 @@ package channels
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
 	"path"
+	"regexp"
 	"strings"
+	"time"
 
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
 
-	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
@@ -24,15 +30,105 @@ var (
 	ThreemaGwBaseURL = "https://msgapi.threema.ch/send_simple"
 )
 
+// threemaGwCreditsURL returns the remaining credits of a gateway ID.
+const threemaGwCreditsURL = "https://msgapi.threema.ch/credits"
+
+var (
+	// threemaAPISecretPattern matches the API secrets issued by the Threema
+	// Gateway, which are 16 alphanumeric characters.
+	threemaAPISecretPattern = regexp.MustCompile(`^[A-Za-z0-9]{16}$`)
+
+	// threemaRateLimiters are shared by all Threema notifiers that send with
+	// the same gateway ID, as the quota belongs to the gateway account.
+	threemaRateLimiters = newSharedRateLimiters(realClock)
+)
+
+const (
+	// threemaMaxMessageSize is the default maximum size of a message in bytes.
+	threemaMaxMessageSize = 3500
+	// threemaMinMessageSize is the smallest maximum size of a message that
+	// leaves room for a body next to the title and links.
+	threemaMinMessageSize = 256
+
+	// runbookURLAnnotation is the annotation of an alert that holds the URL
+	// of its runbook.
+	runbookURLAnnotation = "runbook_url"
+
+	// threemaFormatMarkdown and threemaFormatText are the formats of the
+	// messages sent to Threema. Markdown highlights the headings in bold.
+	threemaFormatMarkdown = "markdown"
+	threemaFormatText     = "text"
+
+	// threemaMaxSplitDepth is how often a message that the gateway rejects
+	// as too long is split in half, so it is sent in up to 16 parts.
+	threemaMaxSplitDepth = 4
+)
+
+var (
+	// threemaSeverityEmojis maps the value of the severity label of firing
+	// alerts to the emoji that prefixes the message.
+	threemaSeverityEmojis = map[string]string{
+		"critical": "\U0001F534 ",   // Red circle
+		"error":    "\U0001F7E0 ",   // Orange circle
+		"warning":  "\u26A0\uFE0F ", // Warning sign
+		"info":     "\u2139\uFE0F ", // Information
+	}
+
+	// threemaPriorityEmojis maps the priority of firing alerts to the emoji
+	// that prefixes the message. It takes precedence over the severity.
+	threemaPriorityEmojis = map[alertPriority]string{
+		priorityP1: "\U0001F534 ",   // Red circle
+		priorityP2: "\U0001F7E0 ",   // Orange circle
+		priorityP3: "\u26A0\uFE0F ", // Warning sign
+		priorityP4: "\u2139\uFE0F ", // Information
+		priorityP5: "\u2139\uFE0F ", // Information
+	}
+)
+
 // ThreemaNotifier is responsible for sending
 // alert notifications to Threema.
 type ThreemaNotifier struct {
 	old_notifiers.NotifierBase
-	GatewayID   string
-	RecipientID string
-	APISecret   string
-	log         log.Logger
-	tmpl        *template.Template
+	GatewayID           string
+	RecipientIDs        []string
+	APISecret           string
+	GatewayURL          string
+	Title               string
+	Message             string
+	MaxMessageSize      int
+	MaxAlertsPerMessage int
+	MaxAlerts           int
+	MaxValueLength      int
+	SeverityLabel       string
+	PriorityLabel       string
+	IncludeURL          bool
+	IncludeRunbook      bool
+	LinkPath            string
+	Format              string
+	FiringEmoji         string
+	ResolvedEmoji       string
+	// Silent is accepted for consistency with the other chat notifiers, but
+	// has no effect: the Threema Gateway can't send messages silently.
+	Silent          bool
+	emojiExpression *emojiExpression
+	e2e             *threemaE2E
+	// credentials are tried in order until one of them can send a message.
+	// The first are GatewayID and APISecret.
+	credentials  []threemaCredentials
+	dedup        *deduplicator
+	dropMatchers labels.Matchers
+	routes       []threemaRoute
+	quietHours   *quietHours
+	breaker      *circuitBreaker
+	backoff      *recipientBackoff
+	fanout       fanoutOptions
+	retry        retryOptions
+	httpOptions  httpOptions
+	metrics      *DeliveryMetrics
+	debugHTTP    *httpDebugLogger
+	creditsURL   string
+	log          log.Logger
+	tmpl         *template.Template
 }
 
 // NewThreemaNotifier is the constructor for the Threema notifier
@@ -41,28 +137,181 @@ func NewThreemaNotifier(model *NotificationChannelConfig, t *template.Template)
 		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
 	}
 
-	gatewayID := model.Settings.Get("gateway_id").MustString()
-	recipientID := model.Settings.Get("recipient_id").MustString()
-	apiSecret := model.DecryptedValue("api_secret", model.Settings.Get("api_secret").MustString())
+	recipientIDs := splitRecipientIDs(model.Settings.Get("recipient_id").MustString())
+	credentials, err := parseThreemaCredentials(model)
+	if err != nil {
+		return nil, err
+	}
+	gatewayID, apiSecret := credentials[0].gatewayID, credentials[0].apiSecret
+	defaultTitle, defaultMessage, err := localizedTemplates(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+	title := model.Settings.Get("title").MustString()
+	if title == "" {
+		title = defaultTitle
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = defaultMessage
+	}
+	templateMessage, err := parseTemplateName(model.Settings, t)
+	if err != nil {
+		return nil, err
+	}
+	if templateMessage != "" {
+		message = templateMessage
+	}
+	compact, err := parseCompact(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+	if compact != "" {
+		message = compact
+	}
 
 	// Validation
-	if gatewayID == "" {
-		return nil, alerting.ValidationError{Reason: "Could not find Threema Gateway ID in settings"}
+	if len(recipientIDs) == 0 {
+		return nil, alerting.ValidationError{Reason: "Could not find Threema Recipient ID in settings"}
 	}
-	if !strings.HasPrefix(gatewayID, "*") {
-		return nil, alerting.ValidationError{Reason: "Invalid Threema Gateway ID: Must start with a *"}
+	for _, recipientID := range recipientIDs {
+		if len(recipientID) != 8 {
+			return nil, alerting.ValidationError{Reason: "Invalid Threema Recipient ID: Must be 8 characters long"}
+		}
 	}
-	if len(gatewayID) != 8 {
-		return nil, alerting.ValidationError{Reason: "Invalid Threema Gateway ID: Must be 8 characters long"}
+
+	encryption := model.Settings.Get("encryption").MustString(threemaEncryptionSimple)
+	if encryption != threemaEncryptionSimple && encryption != threemaEncryptionE2E {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema encryption: Must be simple or e2e"}
 	}
-	if recipientID == "" {
-		return nil, alerting.ValidationError{Reason: "Could not find Threema Recipient ID in settings"}
+	// Messages are encrypted with the private key of a single gateway ID.
+	if encryption == threemaEncryptionE2E && len(credentials) > 1 {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema credentials: End-to-end encryption supports a single gateway ID"}
 	}
-	if len(recipientID) != 8 {
-		return nil, alerting.ValidationError{Reason: "Invalid Threema Recipient ID: Must be 8 characters long"}
+
+	gatewayURL := model.Settings.Get("gateway_url").MustString()
+	if gatewayURL == "" {
+		gatewayURL = ThreemaGwBaseURL
+		if encryption == threemaEncryptionE2E {
+			gatewayURL = ThreemaGwE2EURL
+		}
+	} else if gatewayURL, err = validateURL("Threema Gateway URL", gatewayURL, urlValidationOptions{requireHost: true}); err != nil {
+		return nil, err
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.threema")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	quietHours, err := parseQuietHours(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var e2e *threemaE2E
+	if encryption == threemaEncryptionE2E {
+		privateKey := model.DecryptedValue("private_key", model.Settings.Get("private_key").MustString())
+		e2e, err = newThreemaE2E(model.Settings, privateKey, gatewayID, apiSecret, httpOpts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	maxMessageSize, ok := intSetting(model.Settings, "max_message_size", threemaMaxMessageSize)
+	if !ok || maxMessageSize < threemaMinMessageSize {
+		return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid Threema max message size: Must be a number of at least %d", threemaMinMessageSize)}
+	}
+
+	maxAlertsPerMessage, ok := intSetting(model.Settings, "max_alerts_per_message", 0)
+	if !ok || maxAlertsPerMessage < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema max alerts per message: Must not be negative"}
 	}
-	if apiSecret == "" {
-		return nil, alerting.ValidationError{Reason: "Could not find Threema API secret in settings"}
+
+	maxAlerts, ok := intSetting(model.Settings, "max_alerts", 0)
+	if !ok || maxAlerts < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max alerts: Must not be negative"}
+	}
+
+	maxValueLength, err := parseMaxValueLength(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit, ok := intSetting(model.Settings, "rate_limit", 0)
+	if !ok || rateLimit < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema rate limit: Must not be negative"}
+	} else if rateLimit > 0 {
+		for i := range credentials {
+			credentials[i].rateLimiter = threemaRateLimiters.get(credentials[i].gatewayID, rateLimit)
+		}
+	}
+
+	var dedup *deduplicator
+	if interval := model.Settings.Get("dedup_interval").MustString(); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			return nil, alerting.ValidationError{Reason: "Invalid Threema dedup interval: Must be a positive duration such as 5m"}
+		}
+		dedup = newDeduplicator(d, realClock)
+	}
+
+	fanoutOpts, err := parseFanoutOptions(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	breaker, err := parseCircuitBreaker(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff, err := parseRecipientBackoff(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	dropMatchers, err := parseDropMatchers(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := parseThreemaRoutes(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	severityLabel := model.Settings.Get("severity_label").MustString("severity")
+	priorityLabel := model.Settings.Get("priority_label").MustString("priority")
+	includeURL := model.Settings.Get("include_url").MustBool(true)
+	includeRunbook := model.Settings.Get("include_runbook").MustBool(true)
+	linkPath, err := parseLinkPath(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+	format := model.Settings.Get("format").MustString(threemaFormatMarkdown)
+	if format != threemaFormatMarkdown && format != threemaFormatText {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema format: Must be one of markdown, text"}
+	}
+
+	var emojiExpr *emojiExpression
+	if expr := model.Settings.Get("emoji_expression").MustString(); expr != "" {
+		emojiExpr, err = parseEmojiExpression(expr)
+		if err != nil {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid emoji expression: %s", err)}
+		}
+	}
+
+	secrets := make([]string, 0, len(credentials))
+	for _, creds := range credentials {
+		secrets = append(secrets, creds.apiSecret)
 	}
 
 	return &ThreemaNotifier{
@@ -73,64 +322,804 @@ func NewThreemaNotifier(model *NotificationChannelConfig, t *template.Template)
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		GatewayID:   gatewayID,
-		RecipientID: recipientID,
-		APISecret:   apiSecret,
-		log:         log.New("alerting.notifier.threema"),
-		tmpl:        t,
+		GatewayID:           gatewayID,
+		RecipientIDs:        recipientIDs,
+		APISecret:           apiSecret,
+		GatewayURL:          gatewayURL,
+		Title:               title,
+		Message:             message,
+		MaxMessageSize:      maxMessageSize,
+		MaxAlertsPerMessage: maxAlertsPerMessage,
+		MaxAlerts:           maxAlerts,
+		MaxValueLength:      maxValueLength,
+		SeverityLabel:       severityLabel,
+		PriorityLabel:       priorityLabel,
+		IncludeURL:          includeURL,
+		IncludeRunbook:      includeRunbook,
+		LinkPath:            linkPath,
+		Format:              format,
+		FiringEmoji:         model.Settings.Get("firing_emoji").MustString(),
+		ResolvedEmoji:       model.Settings.Get("resolved_emoji").MustString(),
+		Silent:              parseSilent(model.Settings),
+		emojiExpression:     emojiExpr,
+		e2e:                 e2e,
+		credentials:         credentials,
+		dedup:               dedup,
+		dropMatchers:        dropMatchers,
+		routes:              routes,
+		quietHours:          quietHours,
+		breaker:             breaker,
+		backoff:             backoff,
+		fanout:              fanoutOpts,
+		retry:               newRetryOptions(maxRetries),
+		httpOptions:         httpOpts,
+		metrics:             model.Metrics,
+		debugHTTP:           parseDebugHTTP(model.Settings, logger, secrets...),
+		creditsURL:          threemaGwCreditsURL,
+		log:                 logger,
+		tmpl:                t,
 	}, nil
 }
 
-// Notify send an alert notification to Threema
+// Notify send an alert notification to Threema. It fails if sending to any
+// recipient failed, see NotifyDetailed. Recipients that are backing off are
+// skipped without failing it.
 func (tn *ThreemaNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	tn.log.Debug("Sending threema alert notification", "from", tn.GatewayID, "to", tn.RecipientID)
+	_, err := tn.NotifyDetailed(ctx, as...)
+	return err == nil, err
+}
+
+// NotifyDetailed sends an alert notification to every Threema recipient and
+// returns the outcome for each recipient.
+func (tn *ThreemaNotifier) NotifyDetailed(ctx context.Context, as ...*types.Alert) (NotifyResult, error) {
+	if len(as) == 0 {
+		tn.log.Debug("Not sending a notification without alerts", "notification", tn.Name)
+		return NotifyResult{}, nil
+	}
+
+	// Alerts matching the drop matchers are never forwarded to Threema, and
+	// don't count towards the status of the notification.
+	if kept := dropAlerts(as, tn.dropMatchers); len(kept) < len(as) {
+		tn.log.Debug("Dropping alerts matching the drop matchers", "notification", tn.Name, "dropped", len(as)-len(kept))
+		if len(kept) == 0 {
+			return NotifyResult{}, nil
+		}
+		as = kept
+	}
+
+	status := types.Alerts(as...).Status()
+	if status == model.AlertResolved && !tn.SendResolved() {
+		return NotifyResult{}, nil
+	}
+
+	logger := tn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Sending threema alert notification", "from", tn.GatewayID, "to", strings.Join(tn.RecipientIDs, ","))
+
+	if tn.quietHours.suppresses(status) {
+		logger.Debug("Suppressing notification during quiet hours", "notification", tn.Name)
+		return NotifyResult{}, nil
+	}
+
+	// Don't bother rendering and sending if the notification was cancelled,
+	// e.g. because Grafana is shutting down.
+	if err := ctx.Err(); err != nil {
+		return NotifyResult{}, err
+	}
+
+	// The alerts of every route are sent to its recipients as a
+	// notification of their own.
+	var (
+		recipientIDs []string
+		errs         []error
+	)
+	for _, group := range tn.routeAlerts(as) {
+		groupStatus := types.Alerts(group.alerts...).Status()
+		if groupStatus == model.AlertResolved && !tn.SendResolved() {
+			continue
+		}
+		pages, err := tn.renderMessages(ctx, group.alerts)
+		if err != nil {
+			return NotifyResult{}, err
+		}
+		recipientIDs = append(recipientIDs, group.recipientIDs...)
+		errs = append(errs, tn.notifyRecipients(ctx, logger, group.recipientIDs, group.alerts, pages, groupStatus)...)
+	}
+
+	// Skipped recipients are reported in the result, but only recipients
+	// that failed to be sent to fail the notification.
+	result := newNotifyResult(recipientIDs, errs)
+	hardErrs := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if !errors.Is(err, errRecipientBackoff) {
+			hardErrs = append(hardErrs, err)
+		}
+	}
+	if failedRecipients, sendErrs := fanoutErrors(hardErrs); failedRecipients > 0 {
+		if err := ctx.Err(); err != nil {
+			logger.Warn("Threema notification cancelled", "error", err, "webhook", tn.Name)
+			return result, err
+		}
+		return result, fmt.Errorf("failed to send Threema notification to %d of %d recipients: %s",
+			failedRecipients, len(recipientIDs), sendErrs)
+	}
+
+	return result, nil
+}
+
+// notifyRecipients sends the pages of a notification about as to every
+// recipient and returns the error of each recipient, as fanout does.
+func (tn *ThreemaNotifier) notifyRecipients(ctx context.Context, logger log.Logger, recipientIDs []string, as []*types.Alert, pages []threemaPage, status model.AlertStatus) []error {
+	// Send the messages to every recipient and keep going on failures, so
+	// that a single unreachable recipient or failed page doesn't prevent
+	// delivery of the others. The pages of a recipient are sent in order.
+	return fanout(ctx, recipientIDs, tn.fanout, func(ctx context.Context, recipientID string) error {
+		start := time.Now()
+		if err := tn.backoff.wait(recipientID); err != nil {
+			logger.Warn("Skipping threema recipient", "error", err, "webhook", tn.Name, "to", recipientID)
+			return fmt.Errorf("%s: %w", recipientID, err)
+		}
+
+		var (
+			sendErrs []string
+			sent     int
+		)
+		for i, page := range pages {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// Every page is deduplicated on its own, so that a page doesn't
+			// replace the previous page as the last message sent.
+			dedupID := fmt.Sprintf("%s\x00%d", recipientID, i)
+			if tn.dedup.isDuplicate(dedupID, page.dedupKey(as)) {
+				logger.Debug("Skipping duplicate threema notification", "to", recipientID)
+				continue
+			}
+
+			if err := tn.sendPage(ctx, logger, as, recipientID, page, status, 0); err != nil {
+				logger.Error("Failed to send threema notification", "error", err, "webhook", tn.Name, "to", recipientID)
+				if len(pages) > 1 {
+					sendErrs = append(sendErrs, fmt.Sprintf("%s (page %d/%d): %s", recipientID, i+1, len(pages), err))
+				} else {
+					sendErrs = append(sendErrs, fmt.Sprintf("%s: %s", recipientID, err))
+				}
+				continue
+			}
+			tn.dedup.sent(dedupID, page.dedupKey(as))
+			sent++
+		}
+		if len(sendErrs) > 0 {
+			// Cancelled sends don't tell anything about the recipient.
+			if ctx.Err() == nil {
+				tn.backoff.failed(recipientID)
+			}
+			return errors.New(strings.Join(sendErrs, "; "))
+		}
+		tn.backoff.succeeded(recipientID)
+		// Recipients whose pages were all duplicates weren't delivered to.
+		if sent > 0 {
+			logDelivery(ctx, tn.log, "threema", recipientID, as, start)
+		}
+		return nil
+	})
+}
+
+// sendPage sends page to recipientID. If the gateway rejects it as too long,
+// the alerts of the page are split in half and each half is sent as a message
+// of its own, up to threemaMaxSplitDepth times.
+func (tn *ThreemaNotifier) sendPage(ctx context.Context, logger log.Logger, as []*types.Alert, recipientID string, page threemaPage, status model.AlertStatus, depth int) error {
+	err := tn.sendMessage(ctx, as, recipientID, page.text, status)
+	if !isThreemaMessageTooLong(err) || len(page.alerts) < 2 || depth >= threemaMaxSplitDepth {
+		return err
+	}
+
+	logger.Debug("Splitting threema message that is too long", "to", recipientID, "alerts", len(page.alerts))
+	half := (len(page.alerts) + 1) / 2
+	var errs []string
+	for i, alerts := range [][]*types.Alert{page.alerts[:half], page.alerts[half:]} {
+		part := threemaPage{alerts: alerts, header: fmt.Sprintf("%s (part %d/2)", page.header, i+1)}
+		// The alerts that are left out follow the second part.
+		if i == 1 {
+			part.overflow = page.overflow
+		}
+		part.text, err = tn.renderMessage(ctx, as, part)
+		if err == nil {
+			err = tn.sendPage(ctx, logger, as, recipientID, part, status, depth+1)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// sendMessage sends message to recipientID. If the gateway rejects the
+// credentials or can't be reached, the message is sent with the next
+// credentials.
+func (tn *ThreemaNotifier) sendMessage(ctx context.Context, as []*types.Alert, recipientID, message string, status model.AlertStatus) error {
+	start := time.Now()
+	err := tn.breaker.allow(recipientID)
+	if err != nil {
+		return err
+	}
+	var sent bool
+	var sendErr error
+	for i, creds := range tn.credentials {
+		if i > 0 {
+			tn.log.Warn("Failing over to the next Threema gateway", "error", err, "webhook", tn.Name, "from", creds.gatewayID)
+		}
+		err = tn.waitForRateLimit(ctx, creds)
+		var cmd *models.SendWebhookSync
+		if err == nil {
+			cmd, err = tn.buildCommand(ctx, as, creds, recipientID, message)
+		}
+		if err == nil {
+			tn.debugHTTP.instrument(cmd)
+			err = sendWithRetry(ctx, cmd, tn.retry)
+			sent, sendErr = true, err
+		}
+		if !isThreemaFailoverError(ctx, err) {
+			break
+		}
+	}
+	// Only the gateway's answer to the last request tells whether it is
+	// healthy, not failures to wait for the rate limit or build a request.
+	if sent {
+		tn.breaker.done(recipientID, sendErr)
+	} else {
+		tn.breaker.release(recipientID)
+	}
+	tn.metrics.observe("threema", status, start, err)
+	return err
+}
+
+// isThreemaFailoverError reports whether a message that failed to be sent
+// with err can be sent with other credentials: the gateway rejected the
+// credentials, has no credits left for them, or couldn't be reached.
+func isThreemaFailoverError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var statusErr models.WebhookStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusPaymentRequired
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isThreemaMessageTooLong reports whether the gateway rejected a message
+// because it is too long.
+func isThreemaMessageTooLong(err error) bool {
+	var statusErr models.WebhookStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestEntityTooLarge
+}
 
+// waitForRateLimit blocks until the rate limit of the gateway of creds
+// allows to send another message. It fails right away if that isn't possible
+// before ctx is done.
+func (tn *ThreemaNotifier) waitForRateLimit(ctx context.Context, creds threemaCredentials) error {
+	if creds.rateLimiter == nil {
+		return nil
+	}
+	if err := creds.rateLimiter.wait(ctx); err != nil {
+		return fmt.Errorf("rate limit of gateway %s exceeded: %w", creds.gatewayID, err)
+	}
+	return nil
+}
+
+// Preview renders the notification for as without sending it. It returns the
+// request body and headers that Notify would send to the first recipient,
+// which is the first page if the alerts are split into several messages.
+func (tn *ThreemaNotifier) Preview(ctx context.Context, as ...*types.Alert) (string, map[string]string, error) {
+	pages, err := tn.renderMessages(ctx, as)
+	if err != nil {
+		return "", nil, err
+	}
+	cmd, err := tn.buildCommand(ctx, as, tn.credentials[0], tn.RecipientIDs[0], pages[0].text)
+	if err != nil {
+		return "", nil, err
+	}
+	return cmd.Body, cmd.HttpHeader, nil
+}
+
+// threemaPage is a single Threema message of a notification.
+type threemaPage struct {
+	// alerts are the alerts listed in the message.
+	alerts []*types.Alert
+	// header is appended to the title of the message.
+	header string
+	// overflow is the number of alerts that are left out of the message
+	// after the alerts of the page, see MaxAlerts.
+	overflow int
+	text     string
+}
+
+// dedupKey identifies the content of the page for deduplication. It is built
+// from the alerts of the notification, as, rather than from the text, which
+// changes with time as it tells for how long the alerts have been firing.
+func (p threemaPage) dedupKey(as []*types.Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\x00%d", p.header, p.overflow)
+	for _, alerts := range [][]*types.Alert{p.alerts, as} {
+		fmt.Fprintf(&b, "\x00%d", len(alerts))
+		for _, a := range alerts {
+			fmt.Fprintf(&b, "\x00%s\x00%s\x00%s\x00%d", a.Labels, a.Annotations, a.GeneratorURL, a.StartsAt.UnixNano())
+			if a.Resolved() {
+				fmt.Fprintf(&b, "\x00%d", a.EndsAt.UnixNano())
+			}
+		}
+	}
+	return b.String()
+}
+
+// renderMessages renders the Threema messages for as. Only the first
+// MaxAlerts alerts are listed, if set, followed by the number of the others.
+// There is a single message unless more than MaxAlertsPerMessage alerts are
+// listed, which are then split into pages of that many alerts.
+func (tn *ThreemaNotifier) renderMessages(ctx context.Context, as []*types.Alert) ([]threemaPage, error) {
+	shown, overflow := truncateAlerts(tn.MaxAlerts, as)
+	if tn.MaxAlertsPerMessage == 0 || len(shown) <= tn.MaxAlertsPerMessage {
+		page := threemaPage{alerts: shown, overflow: overflow}
+		var err error
+		page.text, err = tn.renderMessage(ctx, as, page)
+		if err != nil {
+			return nil, err
+		}
+		return []threemaPage{page}, nil
+	}
+
+	count := (len(shown) + tn.MaxAlertsPerMessage - 1) / tn.MaxAlertsPerMessage
+	pages := make([]threemaPage, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * tn.MaxAlertsPerMessage
+		if end > len(shown) {
+			end = len(shown)
+		}
+		page := threemaPage{alerts: shown[i*tn.MaxAlertsPerMessage : end], header: fmt.Sprintf(" (page %d/%d)", i+1, count)}
+		if i == count-1 {
+			page.overflow = overflow
+		}
+		var err error
+		page.text, err = tn.renderMessage(ctx, as, page)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// renderMessage renders the text of the Threema message for the alerts of
+// page, which are part of as. The title and emoji are those of all of as,
+// and the header of the page is appended to the title.
+func (tn *ThreemaNotifier) renderMessage(ctx context.Context, as []*types.Alert, p threemaPage) (string, error) {
+	page, pageHeader := p.alerts, p.header
 	tmplData := notify.GetTemplateData(ctx, tn.tmpl, as, gokit_log.NewNopLogger())
+	truncateTemplateValues(tmplData, tn.MaxValueLength)
 	var tmplErr error
-	tmpl := notify.TmplText(tn.tmpl, tmplData, &tmplErr)
+	tmpl := tmplText(ctx, tn.tmpl, tmplData, &tmplErr)
 
-	// Set up basic API request data
-	data := url.Values{}
-	data.Set("from", tn.GatewayID)
-	data.Set("to", tn.RecipientID)
-	data.Set("secret", tn.APISecret)
-
-	// Determine emoji
+	// Determine emoji. Configured emojis take precedence over the ones of
+	// the priority and severity.
 	stateEmoji := "\u26A0\uFE0F " // Warning sign
 	alerts := types.Alerts(as...)
 	if alerts.Status() == model.AlertResolved {
 		stateEmoji = "\u2705 " // Check Mark Button
+		if tn.ResolvedEmoji != "" {
+			stateEmoji = tn.ResolvedEmoji + " "
+		}
+	} else if tn.FiringEmoji != "" {
+		stateEmoji = tn.FiringEmoji + " "
+	} else if emoji, ok := threemaPriorityEmojis[derivePriority(tmplData.CommonLabels, tn.PriorityLabel)]; ok {
+		stateEmoji = emoji
+	} else if emoji, ok := threemaSeverityEmojis[strings.ToLower(tmplData.CommonLabels[tn.SeverityLabel])]; ok {
+		stateEmoji = emoji
+	}
+	// The emoji expression overrides everything else unless it fails or
+	// doesn't select an emoji.
+	if tn.emojiExpression != nil {
+		emoji, err := tn.emojiExpression.eval(tmplData.Status, tmplData.CommonLabels)
+		if err != nil {
+			tn.log.Warn("Failed to evaluate emoji expression", "error", err, "notification", tn.Name)
+		} else if emoji != "" {
+			stateEmoji = emoji + " "
+		}
+	}
+
+	title := tmpl(tn.Title) + pageHeader
+	if isTestNotification(as) {
+		title = testNotificationPrefix + title
+	}
+	body := tmpl(tn.Message)
+	if len(page) != len(as) {
+		pageData := notify.GetTemplateData(ctx, tn.tmpl, page, gokit_log.NewNopLogger())
+		truncateTemplateValues(pageData, tn.MaxValueLength)
+		body = tmplText(ctx, tn.tmpl, pageData, &tmplErr)(tn.Message)
+	}
+	if tmplErr != nil {
+		return "", fmt.Errorf("failed to template Theema message: %w", tmplErr)
+	}
+
+	// A silence link is only unambiguous when there is a single firing alert.
+	silenceLine := ""
+	if firing := tmplData.Alerts.Firing(); len(firing) == 1 {
+		extended, err := extendAlert(firing[0], tmplData.ExternalURL)
+		if err != nil {
+			return "", err
+		}
+		if extended.SilenceURL != "" {
+			silenceLine = fmt.Sprintf("%s %s\n", threemaHeading(tn.Format, "Silence:"), extended.SilenceURL)
+		}
+	}
+
+	runbookLines := ""
+	if tn.IncludeRunbook {
+		runbookLines = threemaRunbookLines(page, tn.Format)
+	}
+
+	// The emoji and the body can't tell which alerts of a group that has both
+	// firing and resolved alerts are in which state, so mark every alert.
+	stateLines := ""
+	if isMixedGroup(as) {
+		title += "\n" + alertStateSummary(as)
+		stateLines = fmt.Sprintf("%s\n%s\n", threemaHeading(tn.Format, "Alerts:"), alertStateLines(page))
+	}
+
+	urlLine := ""
+	if tn.IncludeURL {
+		urlLine = fmt.Sprintf("%s %s\n", threemaHeading(tn.Format, "URL:"), path.Join(tn.tmpl.ExternalURL.String(), tn.LinkPath))
+	}
+
+	overflowLine := ""
+	if p.overflow > 0 {
+		overflowLine = "\n" + moreAlertsLine(p.overflow)
 	}
 
 	// Build message
-	message := fmt.Sprintf("%s%s\n\n*Message:*\n%s\n*URL:* %s\n",
-		stateEmoji,
-		tmpl(`{{ template "default.title" . }}`),
-		tmpl(`{{ template "default.message" . }}`),
-		path.Join(tn.tmpl.ExternalURL.String(), "/alerting/list"),
-	)
-	data.Set("text", message)
+	buildMessage := func(body string) string {
+		return fmt.Sprintf("%s%s\n\n%s%s\n%s%s\n%s%s%s",
+			stateEmoji,
+			title,
+			stateLines,
+			threemaHeading(tn.Format, "Message:"),
+			body,
+			overflowLine,
+			runbookLines,
+			urlLine,
+			silenceLine,
+		)
+	}
+	message := buildMessage(body)
+	if len(message) > tn.MaxMessageSize {
+		budget := tn.MaxMessageSize - (len(message) - len(body))
+		truncated, err := tn.truncateBody(ctx, page, budget)
+		if err != nil {
+			return "", fmt.Errorf("failed to template Theema message: %w", err)
+		}
+		// A long title can leave no room for the body, in which case the
+		// message is cut as a whole.
+		message = truncateUTF8WithEllipsis(buildMessage(truncated), tn.MaxMessageSize)
+	}
+	return message, nil
+}
 
-	if tmplErr != nil {
-		return false, fmt.Errorf("failed to template Theema message: %w", tmplErr)
+// threemaRunbookLines returns the runbook links of as. A single alert gets a
+// plain runbook line, whereas multiple alerts get one line per alert with a
+// runbook, identified by its labels.
+func threemaRunbookLines(as []*types.Alert, format string) string {
+	if len(as) == 1 {
+		if runbookURL := as[0].Annotations[runbookURLAnnotation]; runbookURL != "" {
+			return fmt.Sprintf("%s %s\n", threemaHeading(format, "Runbook:"), runbookURL)
+		}
+		return ""
+	}
+
+	var lines strings.Builder
+	for _, alert := range as {
+		if runbookURL := alert.Annotations[runbookURLAnnotation]; runbookURL != "" {
+			fmt.Fprintf(&lines, "%s %s\n", threemaHeading(format, fmt.Sprintf("Runbook %s:", alert.Labels)), runbookURL)
+		}
+	}
+	return lines.String()
+}
+
+// threemaHeading returns heading in bold, unless the message is sent as
+// plain text.
+func threemaHeading(format, heading string) string {
+	if format == threemaFormatText {
+		return heading
+	}
+	return "*" + heading + "*"
+}
+
+// buildCommand builds the request that sends message to recipientID with
+// creds. With end-to-end encryption the message is encrypted for the
+// recipient. The gateway doesn't support idempotency keys, so a retry of a
+// send that timed out may deliver the message twice.
+func (tn *ThreemaNotifier) buildCommand(ctx context.Context, as []*types.Alert, creds threemaCredentials, recipientID, message string) (*models.SendWebhookSync, error) {
+	data := url.Values{}
+	data.Set("from", creds.gatewayID)
+	data.Set("secret", creds.apiSecret)
+	data.Set("to", recipientID)
+	if tn.e2e != nil {
+		nonce, box, err := tn.e2e.encrypt(ctx, recipientID, message)
+		if err != nil {
+			return nil, err
+		}
+		data.Set("nonce", nonce)
+		data.Set("box", box)
+	} else {
+		data.Set("text", message)
 	}
 
 	cmd := &models.SendWebhookSync{
-		Url:        ThreemaGwBaseURL,
+		Url:        tn.GatewayURL,
 		Body:       data.Encode(),
 		HttpMethod: "POST",
 		HttpHeader: map[string]string{
 			"Content-Type": "application/x-www-form-urlencoded",
 		},
 	}
-	if err := bus.DispatchCtx(ctx, cmd); err != nil {
-		tn.log.Error("Failed to send threema notification", "error", err, "webhook", tn.Name)
-		return false, err
+	if err := tn.httpOptions.apply(ctx, cmd, tn.tmpl, as); err != nil {
+		return nil, err
 	}
+	return cmd, nil
+}
 
-	return true, nil
+// truncateBody renders the message template for as many alerts as fit into
+// budget bytes and appends a note about how many alerts were left out. If not
+// even a single alert fits, its rendered text is cut at the budget instead.
+// If the budget is too small for anything but the note, only the note is
+// returned.
+func (tn *ThreemaNotifier) truncateBody(ctx context.Context, as []*types.Alert, budget int) (string, error) {
+	render := func(n int) (string, error) {
+		data := notify.GetTemplateData(ctx, tn.tmpl, as[:n], gokit_log.NewNopLogger())
+		truncateTemplateValues(data, tn.MaxValueLength)
+		return executeTextString(ctx, tn.tmpl, tn.Message, data)
+	}
+	note := func(n int) string {
+		if n == len(as) {
+			return "…"
+		}
+		return fmt.Sprintf("…\n(%d alerts truncated)", len(as)-n)
+	}
+
+	if budget <= len(note(1)) {
+		return note(1), nil
+	}
+
+	// The rendered size grows with the number of alerts, so search for the
+	// largest prefix of alerts that still fits.
+	best, bestN := "", 0
+	lo, hi := 1, len(as)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		body, err := render(mid)
+		if err != nil {
+			return "", err
+		}
+		if len(body)+len(note(mid)) <= budget {
+			best, bestN = body, mid
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	if bestN > 0 {
+		return best + note(bestN), nil
+	}
+
+	body, err := render(1)
+	if err != nil {
+		return "", err
+	}
+	return truncateUTF8(body, budget-len(note(1))) + note(1), nil
+}
+
+// threemaRoute sends the alerts matching all of its matchers to its
+// recipients instead of the default ones.
+type threemaRoute struct {
+	matchers     labels.Matchers
+	recipientIDs []string
+}
+
+// threemaCredentials are a gateway ID and its API secret.
+type threemaCredentials struct {
+	gatewayID   string
+	apiSecret   string
+	rateLimiter *rateLimiter
+}
+
+// parseThreemaCredentials returns the credentials of a notification channel
+// in the order they are tried: those of the credentials setting, which lists
+// a primary and backup gateway IDs with their API secrets, or else the
+// gateway ID and API secret.
+func parseThreemaCredentials(model *NotificationChannelConfig) ([]threemaCredentials, error) {
+	// The credentials hold API secrets, so the form stores them encrypted.
+	settings := model.Settings
+	if secure := model.DecryptedValue("credentials", ""); secure != "" {
+		settings = simplejson.NewFromAny(map[string]interface{}{"credentials": secure})
+	}
+	errInvalid := alerting.ValidationError{Reason: "Invalid Threema credentials: Must be a list of gateway IDs and API secrets"}
+	list, ok := jsonSetting(settings, "credentials")
+	if !ok {
+		return nil, errInvalid
+	}
+	if list.Interface() == nil {
+		apiSecret, err := model.ResolvedSecret("api_secret")
+		if err != nil {
+			return nil, err
+		}
+		creds := threemaCredentials{gatewayID: model.Settings.Get("gateway_id").MustString(), apiSecret: apiSecret}
+		if err := creds.validate(); err != nil {
+			return nil, err
+		}
+		return []threemaCredentials{creds}, nil
+	}
+
+	if model.Settings.Get("gateway_id").MustString() != "" || model.DecryptedValue("api_secret", model.Settings.Get("api_secret").MustString()) != "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema credentials: Must not be set together with a gateway ID and API secret"}
+	}
+	items, err := list.Array()
+	if err != nil || len(items) == 0 {
+		return nil, errInvalid
+	}
+	credentials := make([]threemaCredentials, 0, len(items))
+	for i := range items {
+		item := list.GetIndex(i)
+		apiSecret, err := resolveSecret("api_secret", item.Get("api_secret").MustString(), model.SecretRefs)
+		if err != nil {
+			return nil, err
+		}
+		creds := threemaCredentials{gatewayID: item.Get("gateway_id").MustString(), apiSecret: apiSecret}
+		if err := creds.validate(); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, creds)
+	}
+	return credentials, nil
+}
+
+func (c threemaCredentials) validate() error {
+	if c.gatewayID == "" {
+		return alerting.ValidationError{Reason: "Could not find Threema Gateway ID in settings"}
+	}
+	if !strings.HasPrefix(c.gatewayID, "*") {
+		return alerting.ValidationError{Reason: "Invalid Threema Gateway ID: Must start with a *"}
+	}
+	if len(c.gatewayID) != 8 {
+		return alerting.ValidationError{Reason: "Invalid Threema Gateway ID: Must be 8 characters long"}
+	}
+	if c.apiSecret == "" {
+		return alerting.ValidationError{Reason: "Could not find Threema API secret in settings"}
+	}
+	if !threemaAPISecretPattern.MatchString(c.apiSecret) {
+		return alerting.ValidationError{Reason: "Invalid Threema API secret: Must be 16 alphanumeric characters"}
+	}
+	return nil
+}
+
+// parseThreemaRoutes reads the routes setting of a Threema notification
+// channel: a list of objects with a list of label matchers and the
+// recipient_id to send the matching alerts to, e.g.
+//
+//	[{"matchers": ["team=\"db\""], "recipient_id": "ABCDEFGH"}]
+func parseThreemaRoutes(settings *simplejson.Json) ([]threemaRoute, error) {
+	errInvalid := alerting.ValidationError{Reason: "Invalid Threema routes: Must be a list of routes with matchers and a recipient_id"}
+	value, ok := jsonSetting(settings, "routes")
+	if !ok {
+		return nil, errInvalid
+	}
+	if value.Interface() == nil {
+		return nil, nil
+	}
+	items, err := value.Array()
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	routes := make([]threemaRoute, 0, len(items))
+	for i := range items {
+		item := value.GetIndex(i)
+		ss, err := item.Get("matchers").StringArray()
+		if err != nil || len(ss) == 0 {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid Threema route %d: Must have a list of label matchers such as team=\"db\"", i+1)}
+		}
+		route := threemaRoute{recipientIDs: splitRecipientIDs(item.Get("recipient_id").MustString())}
+		for _, s := range ss {
+			m, err := labels.ParseMatcher(s)
+			if err != nil {
+				return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid Threema route matcher %q: %s", s, err)}
+			}
+			route.matchers = append(route.matchers, m)
+		}
+		if len(route.recipientIDs) == 0 {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid Threema route %d: Must have a recipient_id", i+1)}
+		}
+		for _, recipientID := range route.recipientIDs {
+			if len(recipientID) != 8 {
+				return nil, alerting.ValidationError{Reason: "Invalid Threema Recipient ID: Must be 8 characters long"}
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// threemaAlertGroup are alerts that are sent to the same recipients.
+type threemaAlertGroup struct {
+	recipientIDs []string
+	alerts       []*types.Alert
+}
+
+// routeAlerts groups as by the first route they match, in the order of the
+// routes. Alerts that don't match any route are sent to the default
+// recipients, after the routes.
+func (tn *ThreemaNotifier) routeAlerts(as []*types.Alert) []threemaAlertGroup {
+	if len(tn.routes) == 0 {
+		return []threemaAlertGroup{{recipientIDs: tn.RecipientIDs, alerts: as}}
+	}
+
+	routed := make([][]*types.Alert, len(tn.routes)+1)
+	for _, a := range as {
+		i := len(tn.routes)
+		for j, route := range tn.routes {
+			if matchesLabels(route.matchers, a.Labels) {
+				i = j
+				break
+			}
+		}
+		routed[i] = append(routed[i], a)
+	}
+
+	groups := make([]threemaAlertGroup, 0, len(routed))
+	for i, alerts := range routed {
+		if len(alerts) == 0 {
+			continue
+		}
+		group := threemaAlertGroup{recipientIDs: tn.RecipientIDs, alerts: alerts}
+		if i < len(tn.routes) {
+			group.recipientIDs = tn.routes[i].recipientIDs
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// splitRecipientIDs parses a comma-separated list of Threema IDs,
+// ignoring surrounding whitespace and empty entries.
+func splitRecipientIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 func (tn *ThreemaNotifier) SendResolved() bool {
 	return !tn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (tn *ThreemaNotifier) Type() string {
+	return "threema"
+}
+
+// Fingerprint implements the Fingerprinter interface.
+func (tn *ThreemaNotifier) Fingerprint(ctx context.Context, as ...*types.Alert) string {
+	return notificationFingerprint(ctx, as)
+}
+
+// Ping checks the gateway ID and API secret by looking up the remaining
+// credits of the gateway.
+func (tn *ThreemaNotifier) Ping(ctx context.Context) error {
+	query := url.Values{}
+	query.Set("from", tn.GatewayID)
+	query.Set("secret", tn.APISecret)
+	return ping(ctx, tn.httpOptions, tn.creditsURL+"?"+query.Encode(), nil)
+}
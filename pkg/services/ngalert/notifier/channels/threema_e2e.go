@@ -0,0 +1,201 @@
+package channels
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+var (
+	ThreemaGwE2EURL     = "https://msgapi.threema.ch/send_e2e"
+	ThreemaGwPubKeysURL = "https://msgapi.threema.ch/pubkeys/"
+)
+
+const (
+	threemaEncryptionSimple = "simple"
+	threemaEncryptionE2E    = "e2e"
+
+	// threemaTextMessageType is the type byte of an end-to-end encrypted
+	// text message.
+	threemaTextMessageType = 0x01
+	// threemaMinPaddedSize is the minimum size of a padded message, so that
+	// short messages can't be told apart by the size of the box.
+	threemaMinPaddedSize = 32
+)
+
+// threemaE2E encrypts Threema messages end-to-end for the recipients.
+type threemaE2E struct {
+	privateKey [32]byte
+
+	// lookupPublicKey returns the public key of a recipient that is not
+	// configured in publicKeys. It is replaced in tests.
+	lookupPublicKey func(ctx context.Context, recipientID string) (*[32]byte, error)
+
+	mtx        sync.Mutex
+	publicKeys map[string]*[32]byte
+}
+
+// newThreemaE2E reads the end-to-end encryption settings. Public keys of
+// recipients that aren't configured in public_keys are fetched from the
+// gateway on first use and cached.
+func newThreemaE2E(settings *simplejson.Json, privateKey, gatewayID, apiSecret string, httpOpts httpOptions) (*threemaE2E, error) {
+	e2e := &threemaE2E{publicKeys: map[string]*[32]byte{}}
+
+	key, err := parseThreemaKey(privateKey)
+	if err != nil {
+		return nil, alerting.ValidationError{Reason: "Invalid Threema private key: Must be 64 hexadecimal characters"}
+	}
+	e2e.privateKey = *key
+
+	errInvalid := alerting.ValidationError{Reason: "Invalid Threema public keys: Must be an object of Threema IDs and their public keys"}
+	publicKeys, ok := jsonSetting(settings, "public_keys")
+	if !ok {
+		return nil, errInvalid
+	}
+	keys := map[string]interface{}{}
+	if publicKeys.Interface() != nil {
+		if keys, err = publicKeys.Map(); err != nil {
+			return nil, errInvalid
+		}
+	}
+	for recipientID, v := range keys {
+		s, _ := v.(string)
+		key, err := parseThreemaKey(s)
+		if err != nil {
+			return nil, alerting.ValidationError{Reason: fmt.Sprintf("Invalid Threema public key for %s: Must be 64 hexadecimal characters", recipientID)}
+		}
+		e2e.publicKeys[recipientID] = key
+	}
+
+	e2e.lookupPublicKey = func(ctx context.Context, recipientID string) (*[32]byte, error) {
+		return fetchThreemaPublicKey(ctx, httpOpts, gatewayID, apiSecret, recipientID)
+	}
+
+	return e2e, nil
+}
+
+// encrypt encrypts text for recipientID and returns the hex encoded nonce
+// and box.
+func (e *threemaE2E) encrypt(ctx context.Context, recipientID, text string) (string, string, error) {
+	publicKey, err := e.publicKey(ctx, recipientID)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, boxed, err := encryptThreemaText(text, publicKey, &e.privateKey, rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(nonce[:]), hex.EncodeToString(boxed), nil
+}
+
+func (e *threemaE2E) publicKey(ctx context.Context, recipientID string) (*[32]byte, error) {
+	e.mtx.Lock()
+	key, ok := e.publicKeys[recipientID]
+	e.mtx.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	key, err := e.lookupPublicKey(ctx, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the public key of %s: %w", recipientID, err)
+	}
+
+	e.mtx.Lock()
+	e.publicKeys[recipientID] = key
+	e.mtx.Unlock()
+	return key, nil
+}
+
+// encryptThreemaText pads text as a Threema text message and seals it in a
+// NaCl box for the recipient's public key. The nonce and the padding are read
+// from random.
+func encryptThreemaText(text string, publicKey, privateKey *[32]byte, random io.Reader) ([24]byte, []byte, error) {
+	var nonce [24]byte
+
+	// Messages are padded PKCS#7 style with 1 to 255 bytes.
+	var padding [1]byte
+	if _, err := io.ReadFull(random, padding[:]); err != nil {
+		return nonce, nil, err
+	}
+	padLen := int(padding[0])
+	if padLen == 0 {
+		padLen = 1
+	}
+	if size := 1 + len(text); size+padLen < threemaMinPaddedSize {
+		padLen = threemaMinPaddedSize - size
+	}
+
+	msg := make([]byte, 0, 1+len(text)+padLen)
+	msg = append(msg, threemaTextMessageType)
+	msg = append(msg, text...)
+	for i := 0; i < padLen; i++ {
+		msg = append(msg, byte(padLen))
+	}
+
+	if _, err := io.ReadFull(random, nonce[:]); err != nil {
+		return nonce, nil, err
+	}
+
+	return nonce, box.Seal(nil, msg, &nonce, publicKey, privateKey), nil
+}
+
+func parseThreemaKey(s string) (*[32]byte, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes long, got %d", len(b))
+	}
+	var key [32]byte
+	copy(key[:], b)
+	return &key, nil
+}
+
+// fetchThreemaPublicKey looks up the public key of recipientID at the gateway.
+func fetchThreemaPublicKey(ctx context.Context, httpOpts httpOptions, gatewayID, apiSecret, recipientID string) (*[32]byte, error) {
+	query := url.Values{}
+	query.Set("from", gatewayID)
+	query.Set("secret", apiSecret)
+	u := ThreemaGwPubKeysURL + url.PathEscape(recipientID) + "?" + query.Encode()
+
+	client, shared, err := httpOpts.client()
+	if err != nil {
+		return nil, err
+	}
+	if !shared {
+		defer client.CloseIdleConnections()
+	}
+
+	resp, err := ctxhttp.Get(ctx, client, u)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return parseThreemaKey(string(body))
+}
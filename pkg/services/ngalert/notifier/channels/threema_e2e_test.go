@@ -0,0 +1,258 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+// threemaTestKeys returns a fixed key pair derived from seed.
+func threemaTestKeys(t *testing.T, seed byte) (*[32]byte, *[32]byte) {
+	t.Helper()
+	publicKey, privateKey, err := box.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{seed}, 32)))
+	require.NoError(t, err)
+	return publicKey, privateKey
+}
+
+func TestEncryptThreemaText(t *testing.T) {
+	senderPub, senderPriv := threemaTestKeys(t, 1)
+	recipientPub, recipientPriv := threemaTestKeys(t, 2)
+	nonce := bytes.Repeat([]byte{7}, 24)
+
+	cases := []struct {
+		name       string
+		text       string
+		padding    byte
+		expPadding int
+	}{
+		{
+			name:       "Padding from the random source",
+			text:       strings.Repeat("a", 40),
+			padding:    5,
+			expPadding: 5,
+		}, {
+			name:       "At least one byte of padding",
+			text:       strings.Repeat("a", 40),
+			padding:    0,
+			expPadding: 1,
+		}, {
+			name:       "Short messages are padded to the minimum size",
+			text:       "hi",
+			padding:    5,
+			expPadding: 29,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			random := bytes.NewReader(append([]byte{c.padding}, nonce...))
+			gotNonce, boxed, err := encryptThreemaText(c.text, recipientPub, senderPriv, random)
+			require.NoError(t, err)
+			require.Equal(t, nonce, gotNonce[:])
+
+			msg, ok := box.Open(nil, boxed, &gotNonce, senderPub, recipientPriv)
+			require.True(t, ok)
+
+			exp := append([]byte{threemaTextMessageType}, c.text...)
+			exp = append(exp, bytes.Repeat([]byte{byte(c.expPadding)}, c.expPadding)...)
+			require.Equal(t, exp, msg)
+		})
+	}
+
+	t.Run("Fails when the random source is exhausted", func(t *testing.T) {
+		_, _, err := encryptThreemaText("hi", recipientPub, senderPriv, bytes.NewReader([]byte{5}))
+		require.Error(t, err)
+	})
+}
+
+func TestThreemaNotifierE2E(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	senderPub, senderPriv := threemaTestKeys(t, 1)
+	configuredPub, configuredPriv := threemaTestKeys(t, 2)
+	fetchedPub, fetchedPriv := threemaTestKeys(t, 3)
+
+	newNotifier := func(t *testing.T, encryption string) *ThreemaNotifier {
+		settings := simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321,ABCDEFGH",
+			"api_secret":   "supersecret12345",
+			"encryption":   encryption,
+			"private_key":  hex.EncodeToString(senderPriv[:]),
+			"public_keys": map[string]interface{}{
+				"87654321": hex.EncodeToString(configuredPub[:]),
+			},
+		})
+		tn, err := NewThreemaNotifier(&NotificationChannelConfig{Name: "threema_testing", Type: "threema", Settings: settings}, tmpl)
+		require.NoError(t, err)
+		return tn
+	}
+
+	// The text of the simple message is what gets encrypted.
+	simple := newNotifier(t, "simple")
+	var expText string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		expText = values.Get("text")
+		return nil
+	})
+	_, err = simple.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.NotEmpty(t, expText)
+
+	t.Run("Encrypts the message for every recipient", func(t *testing.T) {
+		tn := newNotifier(t, "e2e")
+		lookups := 0
+		tn.e2e.lookupPublicKey = func(ctx context.Context, recipientID string) (*[32]byte, error) {
+			require.Equal(t, "ABCDEFGH", recipientID)
+			lookups++
+			return fetchedPub, nil
+		}
+
+		recipientKeys := map[string]*[32]byte{"87654321": configuredPriv, "ABCDEFGH": fetchedPriv}
+		var mtx sync.Mutex
+		sent := map[string]bool{}
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			require.Equal(t, ThreemaGwE2EURL, webhook.Url)
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			require.Empty(t, values.Get("text"))
+			require.Equal(t, "supersecret12345", values.Get("secret"))
+
+			to := values.Get("to")
+			nonce, err := hex.DecodeString(values.Get("nonce"))
+			require.NoError(t, err)
+			var n [24]byte
+			copy(n[:], nonce)
+			boxed, err := hex.DecodeString(values.Get("box"))
+			require.NoError(t, err)
+
+			msg, ok := box.Open(nil, boxed, &n, senderPub, recipientKeys[to])
+			require.True(t, ok)
+			padLen := int(msg[len(msg)-1])
+			require.Equal(t, byte(threemaTextMessageType), msg[0])
+			require.Equal(t, expText, string(msg[1:len(msg)-padLen]))
+			mtx.Lock()
+			sent[to] = true
+			mtx.Unlock()
+			return nil
+		})
+
+		for i := 0; i < 2; i++ {
+			ok, err := tn.Notify(notifyContext(), firingAlert())
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.Equal(t, map[string]bool{"87654321": true, "ABCDEFGH": true}, sent)
+		require.Equal(t, 1, lookups, "fetched public keys should be cached")
+	})
+
+	t.Run("Fails for recipients without a public key", func(t *testing.T) {
+		tn := newNotifier(t, "e2e")
+		tn.e2e.lookupPublicKey = func(ctx context.Context, recipientID string) (*[32]byte, error) {
+			return nil, errors.New("unexpected response status 404 Not Found")
+		}
+
+		var sentTo []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			sentTo = append(sentTo, values.Get("to"))
+			return nil
+		})
+
+		ok, err := tn.Notify(notifyContext(), firingAlert())
+		require.False(t, ok)
+		require.EqualError(t, err, "failed to send Threema notification to 1 of 2 recipients: ABCDEFGH: failed to look up the public key of ABCDEFGH: unexpected response status 404 Not Found")
+		require.Equal(t, []string{"87654321"}, sentTo)
+	})
+
+	invalidCases := []struct {
+		name     string
+		settings map[string]interface{}
+		expErr   error
+	}{
+		{
+			name:     "Unknown encryption",
+			settings: map[string]interface{}{"encryption": "pgp"},
+			expErr:   alerting.ValidationError{Reason: "Invalid Threema encryption: Must be simple or e2e"},
+		}, {
+			name:     "Missing private key",
+			settings: map[string]interface{}{"encryption": "e2e"},
+			expErr:   alerting.ValidationError{Reason: "Invalid Threema private key: Must be 64 hexadecimal characters"},
+		}, {
+			name:     "Short private key",
+			settings: map[string]interface{}{"encryption": "e2e", "private_key": "abcdef"},
+			expErr:   alerting.ValidationError{Reason: "Invalid Threema private key: Must be 64 hexadecimal characters"},
+		}, {
+			name: "Invalid public key",
+			settings: map[string]interface{}{
+				"encryption":  "e2e",
+				"private_key": hex.EncodeToString(senderPriv[:]),
+				"public_keys": map[string]interface{}{"87654321": "not hex"},
+			},
+			expErr: alerting.ValidationError{Reason: "Invalid Threema public key for 87654321: Must be 64 hexadecimal characters"},
+		}, {
+			name: "Invalid public key from a text area",
+			settings: map[string]interface{}{
+				"encryption":  "e2e",
+				"private_key": hex.EncodeToString(senderPriv[:]),
+				"public_keys": `{"87654321": "not hex"}`,
+			},
+			expErr: alerting.ValidationError{Reason: "Invalid Threema public key for 87654321: Must be 64 hexadecimal characters"},
+		}, {
+			name: "Public keys that aren't JSON",
+			settings: map[string]interface{}{
+				"encryption":  "e2e",
+				"private_key": hex.EncodeToString(senderPriv[:]),
+				"public_keys": "87654321=abcdef",
+			},
+			expErr: alerting.ValidationError{Reason: "Invalid Threema public keys: Must be an object of Threema IDs and their public keys"},
+		}, {
+			name: "Public keys that aren't an object",
+			settings: map[string]interface{}{
+				"encryption":  "e2e",
+				"private_key": hex.EncodeToString(senderPriv[:]),
+				"public_keys": `["87654321"]`,
+			},
+			expErr: alerting.ValidationError{Reason: "Invalid Threema public keys: Must be an object of Threema IDs and their public keys"},
+		},
+	}
+	for _, c := range invalidCases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := map[string]interface{}{
+				"gateway_id":   "*1234567",
+				"recipient_id": "87654321",
+				"api_secret":   "supersecret12345",
+			}
+			for k, v := range c.settings {
+				settings[k] = v
+			}
+			_, err := NewThreemaNotifier(&NotificationChannelConfig{
+				Name:     "threema_testing",
+				Type:     "threema",
+				Settings: simplejson.NewFromAny(settings),
+			}, tmpl)
+			require.Error(t, err)
+			require.Equal(t, c.expErr.Error(), err.Error())
+		})
+	}
+}
@@ -2,8 +2,14 @@ package channels
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -11,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
@@ -27,6 +34,7 @@ func TestThreemaNotifier(t *testing.T) {
 		name         string
 		settings     string
 		alerts       []*types.Alert
+		expURL       string
 		expMsg       string
 		expInitError error
 		expMsgError  error
@@ -36,7 +44,7 @@ func TestThreemaNotifier(t *testing.T) {
 			settings: `{
 				"gateway_id": "*1234567",
 				"recipient_id": "87654321",
-				"api_secret": "supersecret"
+				"api_secret": "supersecret12345"
 			}`,
 			alerts: []*types.Alert{
 				{
@@ -46,7 +54,7 @@ func TestThreemaNotifier(t *testing.T) {
 					},
 				},
 			},
-			expMsg:       "from=%2A1234567&secret=supersecret&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A1%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A&to=87654321",
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A1%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A%2ASilence%3A%2A+http%3A%2F%2Flocalhost%2Falerting%2Fsilence%2Fnew%3Falertmanager%3Dgrafana%26matchers%3Dalertname%253Dalert1%252Clbl1%253Dval1%0A&to=87654321",
 			expInitError: nil,
 			expMsgError:  nil,
 		}, {
@@ -54,7 +62,7 @@ func TestThreemaNotifier(t *testing.T) {
 			settings: `{
 				"gateway_id": "*1234567",
 				"recipient_id": "87654321",
-				"api_secret": "supersecret"
+				"api_secret": "supersecret12345"
 			}`,
 			alerts: []*types.Alert{
 				{
@@ -69,15 +77,165 @@ func TestThreemaNotifier(t *testing.T) {
 					},
 				},
 			},
-			expMsg:       "from=%2A1234567&secret=supersecret&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A2%5D++%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val2%0AAnnotations%3A%0A+-+ann1+%3D+annv2%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A&to=87654321",
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A2%5D++%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val2%0AAnnotations%3A%0A+-+ann1+%3D+annv2%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Resolved alert has no silence link",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9C%85+%5BRESOLVED%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A%0A%0A%2A%2AResolved%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Custom message template",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"message": "{{ .Alerts.Firing | len }} firing: {{ .CommonLabels.alertname }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A1%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A1+firing%3A+alert1%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A%2ASilence%3A%2A+http%3A%2F%2Flocalhost%2Falerting%2Fsilence%2Fnew%3Falertmanager%3Dgrafana%26matchers%3Dalertname%253Dalert1%252Clbl1%253Dval1%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "URL line disabled",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"message": "{{ .Alerts.Firing | len }} firing: {{ .CommonLabels.alertname }}",
+				"include_url": false
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A1%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A1+firing%3A+alert1%0A%2ASilence%3A%2A+http%3A%2F%2Flocalhost%2Falerting%2Fsilence%2Fnew%3Falertmanager%3Dgrafana%26matchers%3Dalertname%253Dalert1%252Clbl1%253Dval1%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "URL line disabled without silence link",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"message": "{{ .Alerts.Firing | len }} firing: {{ .CommonLabels.alertname }}",
+				"include_url": false
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+					},
+				},
+			},
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A2%5D++%0A%0A%2AMessage%3A%2A%0A2+firing%3A+alert1%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Custom title template",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"title": "{{ .CommonLabels.alertname }} is {{ .Status }}"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+alert1+is+firing%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A%2ASilence%3A%2A+http%3A%2F%2Flocalhost%2Falerting%2Fsilence%2Fnew%3Falertmanager%3Dgrafana%26matchers%3Dalertname%253Dalert1%252Clbl1%253Dval1%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Empty message and title fall back to the defaults",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"title": "",
+				"message": ""
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A1%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A%2ASilence%3A%2A+http%3A%2F%2Flocalhost%2Falerting%2Fsilence%2Fnew%3Falertmanager%3Dgrafana%26matchers%3Dalertname%253Dalert1%252Clbl1%253Dval1%0A&to=87654321",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Custom gateway URL",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"gateway_url": "https://threema-proxy.internal/send_simple"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expURL:       "https://threema-proxy.internal/send_simple",
+			expMsg:       "from=%2A1234567&secret=supersecret12345&text=%E2%9A%A0%EF%B8%8F+%5BFIRING%3A1%5D++%28val1%29%0A%0A%2AMessage%3A%2A%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A%0A%2AURL%3A%2A+http%3A%2Flocalhost%2Falerting%2Flist%0A%2ASilence%3A%2A+http%3A%2F%2Flocalhost%2Falerting%2Fsilence%2Fnew%3Falertmanager%3Dgrafana%26matchers%3Dalertname%253Dalert1%252Clbl1%253Dval1%0A&to=87654321",
 			expInitError: nil,
 			expMsgError:  nil,
+		}, {
+			name: "Invalid gateway URL",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345",
+				"gateway_url": "threema-proxy.internal/send_simple"
+			}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Threema Gateway URL: Must be an absolute URL"},
 		}, {
 			name: "Invalid gateway id",
 			settings: `{
 				"gateway_id": "12345678",
 				"recipient_id": "87654321",
-				"api_secret": "supersecret"
+				"api_secret": "supersecret12345"
 			}`,
 			expInitError: alerting.ValidationError{Reason: "Invalid Threema Gateway ID: Must start with a *"},
 		}, {
@@ -85,7 +243,7 @@ func TestThreemaNotifier(t *testing.T) {
 			settings: `{
 				"gateway_id": "*1234567",
 				"recipient_id": "8765432",
-				"api_secret": "supersecret"
+				"api_secret": "supersecret12345"
 			}`,
 			expInitError: alerting.ValidationError{Reason: "Invalid Threema Recipient ID: Must be 8 characters long"},
 		}, {
@@ -95,6 +253,22 @@ func TestThreemaNotifier(t *testing.T) {
 				"recipient_id": "87654321"
 			}`,
 			expInitError: alerting.ValidationError{Reason: "Could not find Threema API secret in settings"},
+		}, {
+			name: "Truncated API secret",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret"
+			}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Threema API secret: Must be 16 alphanumeric characters"},
+		}, {
+			name: "API secret with illegal characters",
+			settings: `{
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret-1234"
+			}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Threema API secret: Must be 16 alphanumeric characters"},
 		},
 	}
 
@@ -118,8 +292,10 @@ func TestThreemaNotifier(t *testing.T) {
 			require.NoError(t, err)
 
 			body := ""
+			webhookURL := ""
 			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
 				body = webhook.Body
+				webhookURL = webhook.Url
 				return nil
 			})
 
@@ -135,7 +311,1244 @@ func TestThreemaNotifier(t *testing.T) {
 			require.NoError(t, err)
 			require.True(t, ok)
 
+			expURL := c.expURL
+			if expURL == "" {
+				expURL = ThreemaGwBaseURL
+			}
+			require.Equal(t, expURL, webhookURL)
 			require.Equal(t, c.expMsg, body)
 		})
 	}
 }
+
+func TestThreemaNotifierMultipleRecipients(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{
+		"gateway_id": "*1234567",
+		"recipient_id": "87654321, ABCDEFGH,12345678",
+		"api_secret": "supersecret12345"
+	}`))
+	require.NoError(t, err)
+
+	m := &NotificationChannelConfig{
+		Name:     "threema_testing",
+		Type:     "threema",
+		Settings: settingsJSON,
+	}
+
+	pn, err := NewThreemaNotifier(m, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, []string{"87654321", "ABCDEFGH", "12345678"}, pn.RecipientIDs)
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1"},
+		},
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	t.Run("sends one message per recipient", func(t *testing.T) {
+		var mtx sync.Mutex
+		var recipients []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			mtx.Lock()
+			recipients = append(recipients, values.Get("to"))
+			mtx.Unlock()
+			return nil
+		})
+
+		ok, err := pn.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.ElementsMatch(t, []string{"87654321", "ABCDEFGH", "12345678"}, recipients)
+	})
+
+	t.Run("partial failure is reported after trying every recipient", func(t *testing.T) {
+		var mtx sync.Mutex
+		var recipients []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			mtx.Lock()
+			recipients = append(recipients, values.Get("to"))
+			mtx.Unlock()
+			if values.Get("to") == "ABCDEFGH" {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		})
+
+		ok, err := pn.Notify(ctx, alert)
+		require.False(t, ok)
+		require.EqualError(t, err, "failed to send Threema notification to 1 of 3 recipients: ABCDEFGH: gateway unavailable")
+		require.ElementsMatch(t, []string{"87654321", "ABCDEFGH", "12345678"}, recipients)
+	})
+}
+
+func TestThreemaNotifierTruncation(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{
+		"gateway_id": "*1234567",
+		"recipient_id": "87654321",
+		"api_secret": "supersecret12345",
+		"max_message_size": 1000
+	}`))
+	require.NoError(t, err)
+
+	m := &NotificationChannelConfig{
+		Name:     "threema_testing",
+		Type:     "threema",
+		Settings: settingsJSON,
+	}
+
+	pn, err := NewThreemaNotifier(m, tmpl)
+	require.NoError(t, err)
+
+	var text string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		text = values.Get("text")
+		return nil
+	})
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	t.Run("drops whole alerts from large groups", func(t *testing.T) {
+		alerts := make([]*types.Alert, 0, 40)
+		for i := 0; i < 40; i++ {
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "alert1", "instance": model.LabelValue(fmt.Sprintf("host-%02d", i))},
+					Annotations: model.LabelSet{"description": model.LabelValue(strings.Repeat("Größenänderung ", 10))},
+				},
+			})
+		}
+
+		ok, err := pn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.LessOrEqual(t, len(text), 1000)
+		require.True(t, utf8.ValidString(text))
+		require.Regexp(t, `…\n\(\d+ alerts truncated\)\n\*URL:\* http:/localhost/alerting/list\n$`, text)
+		require.Contains(t, text, "instance = host-00")
+		require.NotContains(t, text, "instance = host-39")
+	})
+
+	t.Run("cuts a single oversized alert on a rune boundary", func(t *testing.T) {
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1"},
+				Annotations: model.LabelSet{"description": model.LabelValue(strings.Repeat("€", 2000))},
+			},
+		}
+
+		ok, err := pn.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.LessOrEqual(t, len(text), 1000)
+		require.True(t, utf8.ValidString(text))
+		require.Contains(t, text, "€…\n*URL:* http:/localhost/alerting/list\n")
+	})
+
+	t.Run("cuts the whole message if the title leaves no room for the body", func(t *testing.T) {
+		pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":       "*1234567",
+				"recipient_id":     "87654321",
+				"api_secret":       "supersecret12345",
+				"max_message_size": "256",
+				"title":            strings.Repeat("Größe ", 60),
+			}),
+		}, tmpl)
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(ctx, firingAlert())
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.LessOrEqual(t, len(text), 256)
+		require.True(t, utf8.ValidString(text))
+		require.True(t, strings.HasSuffix(text, "…"))
+	})
+
+	t.Run("too small max message size", func(t *testing.T) {
+		_, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":       "*1234567",
+				"recipient_id":     "87654321",
+				"api_secret":       "supersecret12345",
+				"max_message_size": 10,
+			}),
+		}, tmpl)
+		require.Equal(t, alerting.ValidationError{Reason: "Invalid Threema max message size: Must be a number of at least 256"}, err)
+	})
+}
+
+func TestThreemaNotifierSeverity(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name     string
+		settings string
+		labels   []model.LabelSet
+		resolved bool
+		expEmoji string
+	}{
+		{
+			name:     "Critical",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "critical"}},
+			expEmoji: "\U0001F534 ",
+		}, {
+			name:     "Error",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "error"}},
+			expEmoji: "\U0001F7E0 ",
+		}, {
+			name:     "Warning",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "warning"}},
+			expEmoji: "⚠️ ",
+		}, {
+			name:     "Info",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "info"}},
+			expEmoji: "ℹ️ ",
+		}, {
+			name:     "Severity is case insensitive",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "CRITICAL"}},
+			expEmoji: "\U0001F534 ",
+		}, {
+			name:     "Unknown severity falls back to warning",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "page"}},
+			expEmoji: "⚠️ ",
+		}, {
+			name:     "No severity label falls back to warning",
+			labels:   []model.LabelSet{{"alertname": "alert1"}},
+			expEmoji: "⚠️ ",
+		}, {
+			name: "Severity that is not common to all alerts is ignored",
+			labels: []model.LabelSet{
+				{"alertname": "alert1", "severity": "critical"},
+				{"alertname": "alert1", "severity": "info"},
+			},
+			expEmoji: "⚠️ ",
+		}, {
+			name:     "Custom severity label",
+			settings: `"severity_label": "priority",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "info", "priority": "critical"}},
+			expEmoji: "\U0001F534 ",
+		}, {
+			name:     "Priority P1",
+			labels:   []model.LabelSet{{"alertname": "alert1", "priority": "P1"}},
+			expEmoji: "\U0001F534 ",
+		}, {
+			name:     "Priority P4",
+			labels:   []model.LabelSet{{"alertname": "alert1", "priority": "p4"}},
+			expEmoji: "ℹ️ ",
+		}, {
+			name:     "Priority takes precedence over severity",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "info", "priority": "P2"}},
+			expEmoji: "\U0001F7E0 ",
+		}, {
+			name:     "Invalid priority falls back to severity",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "info", "priority": "urgent"}},
+			expEmoji: "ℹ️ ",
+		}, {
+			name:     "Custom priority label",
+			settings: `"priority_label": "prio",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "priority": "P5", "prio": "P1"}},
+			expEmoji: "\U0001F534 ",
+		}, {
+			name:     "Resolved alerts keep the check mark",
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "critical"}},
+			resolved: true,
+			expEmoji: "✅ ",
+		}, {
+			name:     "Custom firing emoji",
+			settings: `"firing_emoji": "🔴", "resolved_emoji": "🟢",`,
+			labels:   []model.LabelSet{{"alertname": "alert1"}},
+			expEmoji: "🔴 ",
+		}, {
+			name:     "Custom firing emoji takes precedence over severity",
+			settings: `"firing_emoji": "🔴",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "info"}},
+			expEmoji: "🔴 ",
+		}, {
+			name:     "Custom resolved emoji",
+			settings: `"firing_emoji": "🔴", "resolved_emoji": "🟢",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "critical"}},
+			resolved: true,
+			expEmoji: "🟢 ",
+		}, {
+			name:     "Emoji expression",
+			settings: `"emoji_expression": "labels.severity == \"critical\" && labels.team == \"db\" ? \"🗄️\" : \"\"", "firing_emoji": "🔴",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "critical", "team": "db"}},
+			expEmoji: "🗄️ ",
+		}, {
+			name:     "Emoji expression for resolved alerts",
+			settings: `"emoji_expression": "status == \"resolved\" ? \"🟢\" : \"🔴\"",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "critical"}},
+			resolved: true,
+			expEmoji: "🟢 ",
+		}, {
+			name:     "Empty result of the emoji expression falls back to the default",
+			settings: `"emoji_expression": "labels.team == \"db\" ? \"🗄️\" : \"\"",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "critical", "team": "web"}},
+			expEmoji: "\U0001F534 ",
+		}, {
+			name:     "Failing emoji expression falls back to the default",
+			settings: `"emoji_expression": "labels.team ? \"🗄️\" : \"\"",`,
+			labels:   []model.LabelSet{{"alertname": "alert1", "severity": "info", "team": "db"}},
+			expEmoji: "ℹ️ ",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(`{` + c.settings + `
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345"
+			}`))
+			require.NoError(t, err)
+
+			pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+				Name:     "threema_testing",
+				Type:     "threema",
+				Settings: settingsJSON,
+			}, tmpl)
+			require.NoError(t, err)
+
+			var alerts []*types.Alert
+			for _, lbls := range c.labels {
+				alert := &types.Alert{Alert: model.Alert{Labels: lbls}}
+				if c.resolved {
+					alert.StartsAt = time.Now().Add(-2 * time.Hour)
+					alert.EndsAt = time.Now().Add(-time.Hour)
+				}
+				alerts = append(alerts, alert)
+			}
+
+			body := ""
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+
+			ok, err := pn.Notify(notifyContext(), alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(values.Get("text"), c.expEmoji+"["), values.Get("text"))
+		})
+	}
+
+	t.Run("Invalid regular expression in the emoji expression", func(t *testing.T) {
+		_, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":       "*1234567",
+				"recipient_id":     "87654321",
+				"api_secret":       "supersecret12345",
+				"emoji_expression": `labels.team =~ "(" ? "🗄️" : ""`,
+			}),
+		}, tmpl)
+		require.IsType(t, alerting.ValidationError{}, err)
+		require.Contains(t, err.Error(), `Invalid emoji expression: invalid regular expression "(" at position 15`)
+	})
+}
+
+func TestThreemaNotifierDisableResolveMessage(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321",
+			"api_secret":   "supersecret12345",
+		}),
+		DisableResolveMessage: true,
+	}, tmpl)
+	require.NoError(t, err)
+
+	sent := 0
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent++
+		return nil
+	})
+
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: time.Now().Add(-2 * time.Hour),
+			EndsAt:   time.Now().Add(-time.Hour),
+		},
+	}
+	ok, err := pn.Notify(notifyContext(), resolved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 0, sent)
+
+	ok, err = pn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, sent)
+}
+
+func TestThreemaNotifierRunbook(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name        string
+		settings    string
+		alerts      []*types.Alert
+		expLines    []string
+		notExpected string
+	}{
+		{
+			name: "Single alert with runbook",
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1"},
+						Annotations: model.LabelSet{"runbook_url": "https://wiki.example.org/runbooks/alert1"},
+					},
+				},
+			},
+			expLines: []string{"\n*Runbook:* https://wiki.example.org/runbooks/alert1\n*URL:* "},
+		}, {
+			name: "Single alert without runbook",
+			alerts: []*types.Alert{
+				{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}},
+			},
+			notExpected: "*Runbook",
+		}, {
+			name: "Multiple alerts",
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"runbook_url": "https://wiki.example.org/runbooks/1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val3"},
+						Annotations: model.LabelSet{"runbook_url": "https://wiki.example.org/runbooks/3"},
+					},
+				},
+			},
+			expLines: []string{
+				"\n*Runbook {alertname=\"alert1\", lbl1=\"val1\"}:* https://wiki.example.org/runbooks/1\n" +
+					"*Runbook {alertname=\"alert1\", lbl1=\"val3\"}:* https://wiki.example.org/runbooks/3\n*URL:* ",
+			},
+			notExpected: "val2\"}:*",
+		}, {
+			name:     "Disabled",
+			settings: `"include_runbook": false,`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1"},
+						Annotations: model.LabelSet{"runbook_url": "https://wiki.example.org/runbooks/alert1"},
+					},
+				},
+			},
+			notExpected: "*Runbook",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(`{` + c.settings + `
+				"gateway_id": "*1234567",
+				"recipient_id": "87654321",
+				"api_secret": "supersecret12345"
+			}`))
+			require.NoError(t, err)
+
+			pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+				Name:     "threema_testing",
+				Type:     "threema",
+				Settings: settingsJSON,
+			}, tmpl)
+			require.NoError(t, err)
+
+			body := ""
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+
+			ok, err := pn.Notify(notifyContext(), c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			text := values.Get("text")
+			for _, line := range c.expLines {
+				require.Contains(t, text, line)
+			}
+			if c.notExpected != "" {
+				require.NotContains(t, text, c.notExpected)
+			}
+		})
+	}
+}
+
+func TestThreemaNotifierPages(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":             "*1234567",
+			"recipient_id":           "87654321",
+			"api_secret":             "supersecret12345",
+			"max_alerts_per_message": 10,
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	var alerts []*types.Alert
+	for i := 0; i < 25; i++ {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1", "idx": model.LabelValue(fmt.Sprintf("%02d", i))},
+			},
+		})
+	}
+
+	t.Run("Splits the alerts into pages", func(t *testing.T) {
+		var texts []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			texts = append(texts, values.Get("text"))
+			return nil
+		})
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, texts, 3)
+		for i, text := range texts {
+			require.True(t, strings.HasPrefix(text, fmt.Sprintf("⚠️ [FIRING:25]   (page %d/3)\n", i+1)), text)
+			require.Contains(t, text, "*URL:* http:/localhost/alerting/list\n")
+		}
+		for i := 0; i < 25; i++ {
+			idx := fmt.Sprintf(" - idx = %02d\n", i)
+			for page, text := range texts {
+				if page == i/10 {
+					require.Contains(t, text, idx)
+				} else {
+					require.NotContains(t, text, idx)
+				}
+			}
+		}
+	})
+
+	t.Run("Small groups are not split", func(t *testing.T) {
+		var texts []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			texts = append(texts, values.Get("text"))
+			return nil
+		})
+
+		ok, err := pn.Notify(notifyContext(), alerts[:10]...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, texts, 1)
+		require.NotContains(t, texts[0], "(page")
+	})
+
+	t.Run("Failed pages are reported after sending all pages", func(t *testing.T) {
+		sent := 0
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			sent++
+			if sent == 2 {
+				return errors.New("gateway unavailable")
+			}
+			return nil
+		})
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.False(t, ok)
+		require.EqualError(t, err, "failed to send Threema notification to 1 of 1 recipients: 87654321 (page 2/3): gateway unavailable")
+		require.Equal(t, 3, sent)
+	})
+
+	t.Run("Invalid setting", func(t *testing.T) {
+		_, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":             "*1234567",
+				"recipient_id":           "87654321",
+				"api_secret":             "supersecret12345",
+				"max_alerts_per_message": -1,
+			}),
+		}, tmpl)
+		require.EqualError(t, err, alerting.ValidationError{Reason: "Invalid Threema max alerts per message: Must not be negative"}.Error())
+	})
+}
+
+func TestThreemaNotifierCancelled(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321,ABCDEFGH",
+			"api_secret":   "supersecret12345",
+			// Send to one recipient at a time, so that cancelling while
+			// sending stops before the next one.
+			"concurrency": 1,
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	t.Run("before sending", func(t *testing.T) {
+		sent := 0
+		bus.AddHandlerCtx("test", func(_ context.Context, webhook *models.SendWebhookSync) error {
+			sent++
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(notifyContext())
+		cancel()
+		ok, err := pn.Notify(ctx, firingAlert())
+		require.False(t, ok)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 0, sent)
+	})
+
+	t.Run("while sending", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(notifyContext())
+		defer cancel()
+		var sentTo []string
+		bus.AddHandlerCtx("test", func(_ context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			sentTo = append(sentTo, values.Get("to"))
+			cancel()
+			return nil
+		})
+
+		ok, err := pn.Notify(ctx, firingAlert())
+		require.False(t, ok)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, []string{"87654321"}, sentTo)
+	})
+}
+
+func TestThreemaNotifierFormat(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(format string) (*ThreemaNotifier, error) {
+		settings := map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321",
+			"api_secret":   "supersecret12345",
+		}
+		if format != "" {
+			settings["format"] = format
+		}
+		return NewThreemaNotifier(&NotificationChannelConfig{
+			Name:     "threema_testing",
+			Type:     "threema",
+			Settings: simplejson.NewFromAny(settings),
+		}, tmpl)
+	}
+
+	var text string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		text = values.Get("text")
+		return nil
+	})
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"runbook_url": "https://wiki.example.org/runbooks/alert1"},
+		},
+	}
+	render := func(format string) string {
+		tn, err := newNotifier(format)
+		require.NoError(t, err)
+		ok, err := tn.Notify(notifyContext(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return text
+	}
+
+	markdown := render("")
+	require.Equal(t, markdown, render("markdown"))
+	require.Contains(t, markdown, "\n*Message:*\n")
+	require.Contains(t, markdown, "\n*Runbook:* https://wiki.example.org/runbooks/alert1\n*URL:* http:/localhost/alerting/list\n*Silence:* ")
+
+	// The plain text message only differs in the headings, and keeps the
+	// emoji.
+	plain := render("text")
+	require.True(t, strings.HasPrefix(plain, "\u26A0\uFE0F [FIRING:1]"))
+	require.Equal(t, strings.NewReplacer(
+		"*Message:*", "Message:",
+		"*Runbook:*", "Runbook:",
+		"*URL:*", "URL:",
+		"*Silence:*", "Silence:",
+	).Replace(markdown), plain)
+
+	_, err = newNotifier("html")
+	require.Equal(t, alerting.ValidationError{Reason: "Invalid Threema format: Must be one of markdown, text"}.Error(), err.Error())
+}
+
+func TestThreemaNotifierDropMatchers(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(dropMatchers interface{}) (*ThreemaNotifier, error) {
+		return NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":    "*1234567",
+				"recipient_id":  "87654321",
+				"api_secret":    "supersecret12345",
+				"message":       "{{ range .Alerts }}{{ .Labels.alertname }};{{ end }}",
+				"drop_matchers": dropMatchers,
+			}),
+		}, tmpl)
+	}
+	alert := func(name string, labels model.LabelSet) *types.Alert {
+		labels["alertname"] = model.LabelValue(name)
+		return &types.Alert{Alert: model.Alert{Labels: labels}}
+	}
+	alerts := []*types.Alert{
+		alert("a1", model.LabelSet{"severity": "info", "team": "noisy-frontend"}),
+		alert("a2", model.LabelSet{"severity": "info", "team": "core"}),
+		alert("a3", model.LabelSet{"severity": "critical", "team": "noisy-backend"}),
+		alert("a4", model.LabelSet{"severity": "info", "team": "noisy-backend"}),
+	}
+
+	var bodies []string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		bodies = append(bodies, webhook.Body)
+		return nil
+	})
+
+	t.Run("Alerts matching all matchers are dropped", func(t *testing.T) {
+		bodies = nil
+		pn, err := newNotifier([]interface{}{`severity="info"`, `team=~"noisy-.*"`})
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, bodies, 1)
+
+		values, err := url.ParseQuery(bodies[0])
+		require.NoError(t, err)
+		require.Contains(t, values.Get("text"), "a2;a3;")
+		require.NotContains(t, values.Get("text"), "a1;")
+		require.NotContains(t, values.Get("text"), "a4;")
+	})
+
+	t.Run("Matchers from a text area", func(t *testing.T) {
+		bodies = nil
+		pn, err := newNotifier(`["severity=\"info\"", "team=~\"noisy-.*\""]`)
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, bodies, 1)
+
+		values, err := url.ParseQuery(bodies[0])
+		require.NoError(t, err)
+		require.Contains(t, values.Get("text"), "a2;a3;")
+	})
+
+	t.Run("Nothing is sent if all alerts are dropped", func(t *testing.T) {
+		bodies = nil
+		pn, err := newNotifier([]interface{}{`alertname=~"a[0-9]+"`})
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Empty(t, bodies)
+	})
+
+	t.Run("Invalid matcher", func(t *testing.T) {
+		_, err := newNotifier([]interface{}{`severity=~"(info"`})
+		require.Error(t, err)
+		require.IsType(t, alerting.ValidationError{}, err)
+		require.Contains(t, err.Error(), "Invalid drop matcher \"severity=~\\\"(info\\\"\"")
+	})
+
+	t.Run("Not a list", func(t *testing.T) {
+		_, err := newNotifier(`severity="info"`)
+		require.Equal(t, alerting.ValidationError{Reason: "Invalid drop matchers: Must be a list of label matchers such as severity=\"info\""}, err)
+	})
+}
+
+func TestThreemaNotifierMessageTooLong(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"gateway_id":   "*1234567",
+			"recipient_id": "87654321",
+			"api_secret":   "supersecret12345",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	newAlerts := func(n int) []*types.Alert {
+		var alerts []*types.Alert
+		for i := 0; i < n; i++ {
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{
+					Labels: model.LabelSet{"alertname": "alert1", "idx": model.LabelValue(fmt.Sprintf("%02d", i))},
+				},
+			})
+		}
+		return alerts
+	}
+	tooLong := models.WebhookStatusError{StatusCode: 413, Status: "413 Request Entity Too Large"}
+
+	t.Run("Splits the alerts until the messages are accepted", func(t *testing.T) {
+		var texts []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			text := values.Get("text")
+			if strings.Count(text, " - idx = ") > 2 {
+				return tooLong
+			}
+			texts = append(texts, text)
+			return nil
+		})
+
+		ok, err := pn.Notify(notifyContext(), newAlerts(7)...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		headers := []string{" (part 1/2) (part 1/2)", " (part 1/2) (part 2/2)", " (part 2/2) (part 1/2)", " (part 2/2) (part 2/2)"}
+		require.Len(t, texts, len(headers))
+		for i, header := range headers {
+			require.True(t, strings.HasPrefix(texts[i], "⚠️ [FIRING:7]  "+header+"\n"), texts[i])
+		}
+		for i := 0; i < 7; i++ {
+			idx := fmt.Sprintf(" - idx = %02d\n", i)
+			require.Equal(t, 1, strings.Count(strings.Join(texts, ""), idx), idx)
+		}
+	})
+
+	t.Run("Splitting is bounded", func(t *testing.T) {
+		sent := 0
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			sent++
+			return tooLong
+		})
+
+		ok, err := pn.Notify(notifyContext(), newAlerts(64)...)
+		require.False(t, ok)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tooLong.Error())
+		// The message itself, then 2, 4, 8 and 16 parts.
+		require.Equal(t, 31, sent)
+	})
+
+	t.Run("Single alerts are not split", func(t *testing.T) {
+		sent := 0
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			sent++
+			return tooLong
+		})
+
+		ok, err := pn.Notify(notifyContext(), newAlerts(1)...)
+		require.False(t, ok)
+		require.EqualError(t, err, "failed to send Threema notification to 1 of 1 recipients: 87654321: "+tooLong.Error())
+		require.Equal(t, 1, sent)
+	})
+
+	t.Run("Other errors are not split", func(t *testing.T) {
+		sent := 0
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			sent++
+			return models.WebhookStatusError{StatusCode: 400, Status: "400 Bad Request"}
+		})
+
+		ok, err := pn.Notify(notifyContext(), newAlerts(4)...)
+		require.False(t, ok)
+		require.Error(t, err)
+		require.Equal(t, 1, sent)
+	})
+}
+
+func TestThreemaNotifierCredentialsFailover(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+		Name: "threema_testing",
+		Type: "threema",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"recipient_id": "87654321",
+			"credentials": []interface{}{
+				map[string]interface{}{"gateway_id": "*PRIMARY", "api_secret": "primarysecret123"},
+				map[string]interface{}{"gateway_id": "*BACKUP1", "api_secret": "backupsecret1234"},
+			},
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, "*PRIMARY", pn.GatewayID)
+
+	cases := []struct {
+		name       string
+		primaryErr error
+		expSenders []string
+		expErr     string
+	}{
+		{
+			name:       "Primary succeeds",
+			expSenders: []string{"*PRIMARY"},
+		}, {
+			name:       "Rejected credentials fail over",
+			primaryErr: models.WebhookStatusError{StatusCode: 401, Status: "401 Unauthorized"},
+			expSenders: []string{"*PRIMARY", "*BACKUP1"},
+		}, {
+			name:       "Missing credits fail over",
+			primaryErr: models.WebhookStatusError{StatusCode: 402, Status: "402 Payment Required"},
+			expSenders: []string{"*PRIMARY", "*BACKUP1"},
+		}, {
+			name:       "Network errors fail over",
+			primaryErr: &url.Error{Op: "Post", URL: ThreemaGwBaseURL, Err: errors.New("connection refused")},
+			expSenders: []string{"*PRIMARY", "*BACKUP1"},
+		}, {
+			name:       "Other errors don't fail over",
+			primaryErr: models.WebhookStatusError{StatusCode: 400, Status: "400 Bad Request"},
+			expSenders: []string{"*PRIMARY"},
+			expErr:     "failed to send Threema notification to 1 of 1 recipients: 87654321: Webhook response status 400 Bad Request",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var senders []string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				values, err := url.ParseQuery(webhook.Body)
+				require.NoError(t, err)
+				senders = append(senders, values.Get("from"))
+				if values.Get("from") == "*PRIMARY" {
+					require.Equal(t, "primarysecret123", values.Get("secret"))
+					return c.primaryErr
+				}
+				require.Equal(t, "backupsecret1234", values.Get("secret"))
+				return nil
+			})
+
+			ok, err := pn.Notify(notifyContext(), firingAlert())
+			require.Equal(t, c.expSenders, senders)
+			if c.expErr != "" {
+				require.False(t, ok)
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+
+	t.Run("All credentials failing", func(t *testing.T) {
+		sent := 0
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			sent++
+			return models.WebhookStatusError{StatusCode: 401, Status: "401 Unauthorized"}
+		})
+
+		ok, err := pn.Notify(notifyContext(), firingAlert())
+		require.False(t, ok)
+		require.EqualError(t, err, "failed to send Threema notification to 1 of 1 recipients: 87654321: Webhook response status 401 Unauthorized")
+		require.Equal(t, 2, sent)
+	})
+
+	t.Run("Credentials from the secure settings", func(t *testing.T) {
+		pn, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name:     "threema_testing",
+			Type:     "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"recipient_id": "87654321"}),
+			SecureSettings: securejsondata.GetEncryptedJsonData(map[string]string{
+				"credentials": `[{"gateway_id": "*PRIMARY", "api_secret": "primarysecret123"}, {"gateway_id": "*BACKUP1", "api_secret": "backupsecret1234"}]`,
+			}),
+		}, tmpl)
+		require.NoError(t, err)
+		require.Len(t, pn.credentials, 2)
+		require.Equal(t, "*BACKUP1", pn.credentials[1].gatewayID)
+		require.Equal(t, "backupsecret1234", pn.credentials[1].apiSecret)
+	})
+
+	invalid := []struct {
+		name     string
+		settings map[string]interface{}
+		expErr   string
+	}{
+		{
+			name:     "Not a list",
+			settings: map[string]interface{}{"credentials": "*PRIMARY"},
+			expErr:   "Invalid Threema credentials: Must be a list of gateway IDs and API secrets",
+		}, {
+			name:     "Empty list",
+			settings: map[string]interface{}{"credentials": []interface{}{}},
+			expErr:   "Invalid Threema credentials: Must be a list of gateway IDs and API secrets",
+		}, {
+			name: "Together with a gateway ID",
+			settings: map[string]interface{}{
+				"gateway_id":  "*1234567",
+				"credentials": []interface{}{map[string]interface{}{"gateway_id": "*PRIMARY", "api_secret": "primarysecret123"}},
+			},
+			expErr: "Invalid Threema credentials: Must not be set together with a gateway ID and API secret",
+		}, {
+			name: "Invalid backup gateway ID",
+			settings: map[string]interface{}{
+				"credentials": []interface{}{
+					map[string]interface{}{"gateway_id": "*PRIMARY", "api_secret": "primarysecret123"},
+					map[string]interface{}{"gateway_id": "*BACKUP", "api_secret": "backupsecret1234"},
+				},
+			},
+			expErr: "Invalid Threema Gateway ID: Must be 8 characters long",
+		}, {
+			name: "Invalid backup API secret",
+			settings: map[string]interface{}{
+				"credentials": []interface{}{
+					map[string]interface{}{"gateway_id": "*PRIMARY", "api_secret": "primarysecret123"},
+					map[string]interface{}{"gateway_id": "*BACKUP1", "api_secret": "backup"},
+				},
+			},
+			expErr: "Invalid Threema API secret: Must be 16 alphanumeric characters",
+		}, {
+			name: "End-to-end encryption",
+			settings: map[string]interface{}{
+				"encryption":  "e2e",
+				"private_key": strings.Repeat("ab", 32),
+				"credentials": []interface{}{
+					map[string]interface{}{"gateway_id": "*PRIMARY", "api_secret": "primarysecret123"},
+					map[string]interface{}{"gateway_id": "*BACKUP1", "api_secret": "backupsecret1234"},
+				},
+			},
+			expErr: "Invalid Threema credentials: End-to-end encryption supports a single gateway ID",
+		},
+	}
+	for _, c := range invalid {
+		t.Run(c.name, func(t *testing.T) {
+			c.settings["recipient_id"] = "87654321"
+			_, err := NewThreemaNotifier(&NotificationChannelConfig{
+				Name:     "threema_testing",
+				Type:     "threema",
+				Settings: simplejson.NewFromAny(c.settings),
+			}, tmpl)
+			require.Equal(t, alerting.ValidationError{Reason: c.expErr}, err)
+		})
+	}
+}
+
+func TestThreemaNotifierRoutes(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(routes interface{}) (*ThreemaNotifier, error) {
+		return NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":   "*1234567",
+				"recipient_id": "87654321",
+				"api_secret":   "supersecret12345",
+				"routes":       routes,
+				"message":      `{{ range .Alerts }}{{ .Labels.alertname }};{{ end }}`,
+			}),
+		}, tmpl)
+	}
+	alert := func(name, team string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name), "team": model.LabelValue(team)}}}
+	}
+
+	var mtx sync.Mutex
+	texts := map[string][]string{}
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		mtx.Lock()
+		defer mtx.Unlock()
+		texts[values.Get("to")] = append(texts[values.Get("to")], values.Get("text"))
+		return nil
+	})
+
+	t.Run("Routes a mixed alert set to the recipients of the matching routes", func(t *testing.T) {
+		texts = map[string][]string{}
+		pn, err := newNotifier([]interface{}{
+			map[string]interface{}{"matchers": []interface{}{`team="a"`}, "recipient_id": "AAAAAAAA"},
+			map[string]interface{}{"matchers": []interface{}{`team=~"b|c"`}, "recipient_id": "BBBBBBBB, CCCCCCCC"},
+			map[string]interface{}{"matchers": []interface{}{`alertname="a2"`}, "recipient_id": "DDDDDDDD"},
+		})
+		require.NoError(t, err)
+
+		result, err := pn.NotifyDetailed(notifyContext(), alert("a1", "a"), alert("a2", "a"), alert("a3", "c"), alert("a4", "d"), alert("a5", ""))
+		require.NoError(t, err)
+		require.Equal(t, 4, result.Delivered())
+
+		require.Len(t, texts, 4)
+		// The first matching route wins.
+		require.Equal(t, []string{"a1;a2;"}, messageBodies(texts["AAAAAAAA"]))
+		require.Equal(t, []string{"a3;"}, messageBodies(texts["BBBBBBBB"]))
+		require.Equal(t, []string{"a3;"}, messageBodies(texts["CCCCCCCC"]))
+		require.Equal(t, []string{"a4;a5;"}, messageBodies(texts["87654321"]))
+	})
+
+	t.Run("Routes from a text area", func(t *testing.T) {
+		texts = map[string][]string{}
+		pn, err := newNotifier(`[{"matchers": ["team=\"a\""], "recipient_id": "AAAAAAAA"}]`)
+		require.NoError(t, err)
+
+		_, err = pn.NotifyDetailed(notifyContext(), alert("a1", "a"), alert("a2", "b"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"a1;"}, messageBodies(texts["AAAAAAAA"]))
+		require.Equal(t, []string{"a2;"}, messageBodies(texts["87654321"]))
+	})
+
+	t.Run("Routes without alerts are not notified", func(t *testing.T) {
+		texts = map[string][]string{}
+		pn, err := newNotifier([]interface{}{
+			map[string]interface{}{"matchers": []interface{}{`team="a"`}, "recipient_id": "AAAAAAAA"},
+		})
+		require.NoError(t, err)
+
+		result, err := pn.NotifyDetailed(notifyContext(), alert("a1", "a"))
+		require.NoError(t, err)
+		require.Equal(t, []TargetResult{{Target: "AAAAAAAA"}}, result.Targets)
+		require.Len(t, texts, 1)
+	})
+
+	for _, c := range []struct {
+		name   string
+		routes interface{}
+		expErr string
+	}{
+		{
+			name:   "Not a list",
+			routes: "team=a",
+			expErr: "Invalid Threema routes: Must be a list of routes with matchers and a recipient_id",
+		}, {
+			name:   "Missing matchers",
+			routes: []interface{}{map[string]interface{}{"recipient_id": "AAAAAAAA"}},
+			expErr: `Invalid Threema route 1: Must have a list of label matchers such as team="db"`,
+		}, {
+			name:   "Invalid matcher",
+			routes: []interface{}{map[string]interface{}{"matchers": []interface{}{`team=~"(a"`}, "recipient_id": "AAAAAAAA"}},
+			expErr: `Invalid Threema route matcher "team=~\"(a\""`,
+		}, {
+			name:   "Missing recipient",
+			routes: []interface{}{map[string]interface{}{"matchers": []interface{}{`team="a"`}}},
+			expErr: "Invalid Threema route 1: Must have a recipient_id",
+		}, {
+			name:   "Invalid recipient",
+			routes: []interface{}{map[string]interface{}{"matchers": []interface{}{`team="a"`}, "recipient_id": "AAAA"}},
+			expErr: "Invalid Threema Recipient ID: Must be 8 characters long",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := newNotifier(c.routes)
+			require.Error(t, err)
+			require.IsType(t, alerting.ValidationError{}, err)
+			require.Contains(t, err.Error(), c.expErr)
+		})
+	}
+}
+
+// messageBodies returns the bodies of Threema messages without their title
+// and links.
+func messageBodies(texts []string) []string {
+	bodies := make([]string, 0, len(texts))
+	for _, text := range texts {
+		body := text[strings.Index(text, "*Message:*\n")+len("*Message:*\n"):]
+		bodies = append(bodies, body[:strings.Index(body, "\n")])
+	}
+	return bodies
+}
@@ -0,0 +1,238 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+const (
+	// twilioAPIURL is the URL of the Twilio API that sends SMS. The account
+	// SID is filled in for the verb.
+	twilioAPIURL = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+
+	// twilioMaxBodyLength is the maximum length of an SMS sent with Twilio
+	// in characters.
+	twilioMaxBodyLength = 1600
+)
+
+// TwilioSMSNotifier is responsible for sending
+// alert notifications as SMS with Twilio.
+type TwilioSMSNotifier struct {
+	old_notifiers.NotifierBase
+	AccountSID  string
+	AuthToken   string
+	From        string
+	To          []string
+	Message     string
+	fanout      fanoutOptions
+	quietHours  *quietHours
+	breaker     *circuitBreaker
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+	// apiURL is the URL of the Twilio API, see twilioAPIURL.
+	apiURL string
+}
+
+// NewTwilioSMSNotifier is the constructor for the Twilio SMS notifier
+func NewTwilioSMSNotifier(model *NotificationChannelConfig, t *template.Template) (*TwilioSMSNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	accountSID := model.Settings.Get("account_sid").MustString()
+	if accountSID == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Twilio account SID in settings"}
+	}
+	authToken, err := model.ResolvedSecret("auth_token")
+	if err != nil {
+		return nil, err
+	}
+	if authToken == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Twilio auth token in settings"}
+	}
+	from := model.Settings.Get("from").MustString()
+	if from == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Twilio sender number in settings"}
+	}
+	to := splitRecipientIDs(model.Settings.Get("to").MustString())
+	if len(to) == 0 {
+		return nil, alerting.ValidationError{Reason: "Could not find Twilio recipient numbers in settings"}
+	}
+
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.title" . }}
+{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+	retry := newRetryOptions(maxRetries)
+	retry.retryable = isTwilioRetryable
+
+	logger := log.New("alerting.notifier.twilio")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	quietHours, err := parseQuietHours(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	fanoutOpts, err := parseFanoutOptions(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	breaker, err := parseCircuitBreaker(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwilioSMSNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		AccountSID:  accountSID,
+		AuthToken:   authToken,
+		From:        from,
+		To:          to,
+		Message:     message,
+		fanout:      fanoutOpts,
+		quietHours:  quietHours,
+		breaker:     breaker,
+		retry:       retry,
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+		apiURL:      twilioAPIURL,
+	}, nil
+}
+
+// Notify sends an SMS to every recipient with Twilio. It fails if any of
+// them could not be sent, see NotifyDetailed.
+func (tn *TwilioSMSNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	_, err := tn.NotifyDetailed(ctx, as...)
+	return err == nil, err
+}
+
+// NotifyDetailed sends an SMS to every recipient with Twilio and returns the
+// outcome for each recipient.
+func (tn *TwilioSMSNotifier) NotifyDetailed(ctx context.Context, as ...*types.Alert) (NotifyResult, error) {
+	logger := tn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Twilio SMS notification", "notification", tn.Name, "to", strings.Join(tn.To, ","))
+
+	status := types.Alerts(as...).Status()
+	if tn.quietHours.suppresses(status) {
+		logger.Debug("Suppressing notification during quiet hours", "notification", tn.Name)
+		return NotifyResult{}, nil
+	}
+
+	data := notify.GetTemplateData(ctx, tn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	body := truncateWithEllipsis(tmplText(ctx, tn.tmpl, data, &tmplErr)(tn.Message), twilioMaxBodyLength)
+	if tmplErr != nil {
+		return NotifyResult{}, fmt.Errorf("failed to template Twilio SMS: %w", tmplErr)
+	}
+
+	// Send one SMS per recipient and keep going on failures, so that a
+	// single unreachable recipient doesn't prevent delivery to the others.
+	errs := fanout(ctx, tn.To, tn.fanout, func(ctx context.Context, to string) error {
+		form := url.Values{}
+		form.Set("From", tn.From)
+		form.Set("To", to)
+		form.Set("Body", body)
+
+		cmd := &models.SendWebhookSync{
+			Url:        fmt.Sprintf(tn.apiURL, url.PathEscape(tn.AccountSID)),
+			User:       tn.AccountSID,
+			Password:   tn.AuthToken,
+			HttpMethod: "POST",
+			HttpHeader: map[string]string{
+				"Content-Type": "application/x-www-form-urlencoded",
+			},
+			Body: form.Encode(),
+		}
+
+		if err := tn.httpOptions.apply(ctx, cmd, tn.tmpl, as); err != nil {
+			logger.Error("Failed to build Twilio SMS", "error", err, "webhook", tn.Name, "to", to)
+			return fmt.Errorf("%s: %w", to, err)
+		}
+
+		if err := tn.breaker.allow(to); err != nil {
+			logger.Warn("Not sending Twilio SMS", "error", err, "webhook", tn.Name, "to", to)
+			return fmt.Errorf("%s: %w", to, err)
+		}
+
+		start := time.Now()
+		err := sendWithRetry(ctx, cmd, tn.retry)
+		tn.breaker.done(to, err)
+		tn.metrics.observe("twilio", status, start, err)
+		if err != nil {
+			logger.Error("Failed to send Twilio SMS", "error", err, "webhook", tn.Name, "to", to)
+			return fmt.Errorf("%s: %w", to, err)
+		}
+		return nil
+	})
+
+	result := newNotifyResult(tn.To, errs)
+	if failed, sendErrs := fanoutErrors(errs); failed > 0 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		return result, fmt.Errorf("failed to send Twilio SMS to %d of %d recipients: %s", failed, len(tn.To), sendErrs)
+	}
+
+	return result, nil
+}
+
+func (tn *TwilioSMSNotifier) SendResolved() bool {
+	return !tn.GetDisableResolveMessage()
+}
+
+// isTwilioRetryable reports whether an SMS that failed with err can be sent
+// again. Twilio doesn't deduplicate requests, so only requests that it
+// rejected because of its rate limit are retried. Other failed requests may
+// have sent the SMS already.
+func isTwilioRetryable(err error) bool {
+	var statusErr models.WebhookStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests
+}
+
+// Type returns the kind of the notification channel.
+func (tn *TwilioSMSNotifier) Type() string {
+	return "twilio"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (tn *TwilioSMSNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,313 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestTwilioSMSNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsgs      []string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"account_sid": "AC123", "auth_token": "sometoken", "from": "+15005550006", "to": "+4912345"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsgs: []string{
+				"Body=%5BFIRING%3A1%5D++%28val1%29%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A&From=%2B15005550006&To=%2B4912345",
+			},
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Multiple recipients",
+			settings: `{"account_sid": "AC123", "auth_token": "sometoken", "from": "+15005550006", "to": "+4912345, +4967890", "message": "{{ len .Alerts.Firing }} firing"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+					},
+				},
+			},
+			expMsgs: []string{
+				"Body=1+firing&From=%2B15005550006&To=%2B4912345",
+				"Body=1+firing&From=%2B15005550006&To=%2B4967890",
+			},
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Account SID missing",
+			settings:     `{"auth_token": "sometoken", "from": "+15005550006", "to": "+4912345"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Twilio account SID in settings"},
+		}, {
+			name:         "Auth token missing",
+			settings:     `{"account_sid": "AC123", "from": "+15005550006", "to": "+4912345"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Twilio auth token in settings"},
+		}, {
+			name:         "Sender missing",
+			settings:     `{"account_sid": "AC123", "auth_token": "sometoken", "to": "+4912345"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Twilio sender number in settings"},
+		}, {
+			name:         "Recipients missing",
+			settings:     `{"account_sid": "AC123", "auth_token": "sometoken", "from": "+15005550006"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Twilio recipient numbers in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "twilio_testing",
+				Type:     "twilio",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewTwilioSMSNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var mtx sync.Mutex
+			var sent []*models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				mtx.Lock()
+				defer mtx.Unlock()
+				sent = append(sent, webhook)
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			bodies := make([]string, 0, len(sent))
+			for _, cmd := range sent {
+				require.Equal(t, "https://api.twilio.com/2010-04-01/Accounts/AC123/Messages.json", cmd.Url)
+				require.Equal(t, "AC123", cmd.User)
+				require.Equal(t, "sometoken", cmd.Password)
+				bodies = append(bodies, cmd.Body)
+			}
+			require.ElementsMatch(t, c.expMsgs, bodies)
+		})
+	}
+}
+
+func TestTwilioSMSNotifierMultipleRecipients(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewTwilioSMSNotifier(&NotificationChannelConfig{
+		Name: "twilio_testing",
+		Type: "twilio",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"account_sid": "AC123",
+			"auth_token":  "sometoken",
+			"from":        "+15005550006",
+			"to":          "+4911111,+4922222,+4933333",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	var mtx sync.Mutex
+	var recipients []string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		mtx.Lock()
+		recipients = append(recipients, values.Get("To"))
+		mtx.Unlock()
+		if values.Get("To") == "+4922222" {
+			return errors.New("unreachable")
+		}
+		return nil
+	})
+
+	ok, err := pn.Notify(notifyContext(), firingAlert())
+	require.False(t, ok)
+	require.EqualError(t, err, "failed to send Twilio SMS to 1 of 3 recipients: +4922222: unreachable")
+	require.ElementsMatch(t, []string{"+4911111", "+4922222", "+4933333"}, recipients)
+}
+
+func TestTwilioSMSNotifierTruncation(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewTwilioSMSNotifier(&NotificationChannelConfig{
+		Name: "twilio_testing",
+		Type: "twilio",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"account_sid": "AC123",
+			"auth_token":  "sometoken",
+			"from":        "+15005550006",
+			"to":          "+4912345",
+			"message":     "{{ .CommonAnnotations.description }}",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	var body string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		values, err := url.ParseQuery(webhook.Body)
+		require.NoError(t, err)
+		body = values.Get("Body")
+		return nil
+	})
+
+	// Multi-byte characters must not be split.
+	description := strings.Repeat("ä", 2000)
+	ok, err := pn.Notify(notifyContext(), &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"description": model.LabelValue(description)},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.True(t, utf8.ValidString(body))
+	require.Equal(t, 1600, utf8.RuneCountInString(body))
+	require.Equal(t, strings.Repeat("ä", 1599)+"…", body)
+}
+
+func TestTwilioSMSNotifierRetry(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewTwilioSMSNotifier(&NotificationChannelConfig{
+		Name: "twilio_testing",
+		Type: "twilio",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"account_sid": "AC123",
+			"auth_token":  "sometoken",
+			"from":        "+15005550006",
+			"to":          "+4912345",
+			"max_retries": 2,
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	pn.retry.initialBackoff = time.Millisecond
+
+	cases := []struct {
+		name        string
+		err         error
+		expAttempts int
+	}{
+		{
+			name:        "Retries requests rejected by the rate limit",
+			err:         models.WebhookStatusError{StatusCode: 429, Status: "429 Too Many Requests"},
+			expAttempts: 3,
+		}, {
+			name:        "Doesn't retry server errors, which may have sent the SMS",
+			err:         models.WebhookStatusError{StatusCode: 502, Status: "502 Bad Gateway"},
+			expAttempts: 1,
+		}, {
+			name:        "Doesn't retry network errors, which may have sent the SMS",
+			err:         errors.New("connection reset by peer"),
+			expAttempts: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			attempts := 0
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				attempts++
+				return c.err
+			})
+
+			ok, err := pn.Notify(notifyContext(), firingAlert())
+			require.False(t, ok)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.err.Error())
+			require.Equal(t, c.expAttempts, attempts)
+		})
+	}
+}
+
+func TestTwilioSMSNotifierAPIURL(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn, err := NewTwilioSMSNotifier(&NotificationChannelConfig{
+		Name: "twilio_testing",
+		Type: "twilio",
+		Settings: simplejson.NewFromAny(map[string]interface{}{
+			"account_sid": "AC/123",
+			"auth_token":  "sometoken",
+			"from":        "+15005550006",
+			"to":          "+4912345",
+		}),
+	}, tmpl)
+	require.NoError(t, err)
+	pn.apiURL = "http://localhost:1234/Accounts/%s/Messages.json"
+
+	var sentURL string
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sentURL = webhook.Url
+		return nil
+	})
+
+	ok, err := pn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost:1234/Accounts/AC%2F123/Messages.json", sentURL)
+}
@@ -3,21 +3,32 @@ package channels
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/base32"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/util"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/alerting"
 )
 
 const (
@@ -40,6 +51,23 @@ type NotificationChannelConfig struct {
 	DisableResolveMessage bool                          `json:"disableResolveMessage"`
 	Settings              *simplejson.Json              `json:"settings"`
 	SecureSettings        securejsondata.SecureJsonData `json:"secureSettings"`
+
+	// ImagesDir is the directory Grafana renders screenshots into. It is
+	// set by the server, not by the settings of the channel.
+	ImagesDir string `json:"-"`
+
+	// SecretRefs controls which secrets the settings may reference. It is
+	// set by the server, not by the settings of the channel.
+	SecretRefs SecretRefOptions `json:"-"`
+
+	// FileDir is the directory the file notifier may write to. It is set by
+	// the server, not by the settings of the channel.
+	FileDir string `json:"-"`
+
+	// Metrics records the deliveries of the notifier. It is set by the
+	// server, not by the settings of the channel. Deliveries aren't recorded
+	// if it is nil.
+	Metrics *DeliveryMetrics `json:"-"`
 }
 
 // DecryptedValue returns decrypted value from secureSettings
@@ -122,3 +150,304 @@ func joinUrlPath(base, additionalPath string) (string, error) {
 
 	return u.String(), nil
 }
+
+// defaultLinkPath is the path of the page notifications link to, relative to
+// the external URL of Grafana.
+const defaultLinkPath = "/alerting/list"
+
+// parseLinkPath reads the link_path setting of a notification channel, which
+// must be a path relative to the external URL of Grafana.
+func parseLinkPath(settings *simplejson.Json) (string, error) {
+	linkPath := settings.Get("link_path").MustString()
+	if linkPath == "" {
+		return defaultLinkPath, nil
+	}
+	if u, err := url.Parse(linkPath); err != nil || u.Scheme != "" || u.Host != "" {
+		return "", alerting.ValidationError{Reason: "Invalid link path: Must be a path relative to the Grafana URL such as /alerting/list"}
+	}
+	return linkPath, nil
+}
+
+// urlValidationOptions configures validateURL.
+type urlValidationOptions struct {
+	// requireHost rejects URLs without a host, such as http:/path.
+	requireHost bool
+}
+
+// validateURL checks that raw, the value of the URL setting called name, is
+// an absolute http or https URL, and returns it.
+func validateURL(name, raw string, opts urlValidationOptions) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: Must be an absolute URL", name)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: Must be an http or https URL", name)}
+	}
+	if opts.requireHost && u.Hostname() == "" {
+		return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid %s: Must have a host", name)}
+	}
+	return raw, nil
+}
+
+// parseTemplateName reads the template setting of a notification channel,
+// which names a template of t to render the message with instead of the
+// default one. It returns the message that executes the template, or an
+// empty string if the setting isn't set.
+func parseTemplateName(settings *simplejson.Json, t *template.Template) (string, error) {
+	name := settings.Get("template").MustString()
+	if name == "" {
+		return "", nil
+	}
+	if settings.Get("message").MustString() != "" {
+		return "", alerting.ValidationError{Reason: "Invalid template: Must not be set together with a message"}
+	}
+
+	// Templates are only looked up when they are executed, so execute it
+	// with empty data to find out whether it is defined. Other errors depend
+	// on the data and are reported when notifying.
+	message := fmt.Sprintf("{{ template %q . }}", name)
+	if _, err := t.ExecuteTextString(message, &template.Data{}); err != nil && strings.Contains(err.Error(), fmt.Sprintf("template %q not defined", name)) {
+		return "", alerting.ValidationError{Reason: fmt.Sprintf("Invalid template: Template %q is not defined", name)}
+	}
+	return message, nil
+}
+
+// compactMessage renders a single line per alert, such as
+// "[FIRING] HighLatency{severity=warning} — Latency is above 1s".
+const compactMessage = `{{ range .Alerts }}[{{ .Status | toUpper }}] {{ .Labels.alertname }}` +
+	`{{ with (.Labels.Remove (stringSlice "alertname")).SortedPairs }}{{ "{" }}{{ range $i, $p := . }}{{ if $i }},{{ end }}{{ $p.Name }}={{ $p.Value }}{{ end }}{{ "}" }}{{ end }}` +
+	`{{ with .Annotations.summary }} — {{ . }}{{ end }}
+{{ end }}`
+
+// parseCompact reads the compact setting of a notification channel. It
+// returns compactMessage if compact mode is enabled, or an empty string
+// otherwise.
+func parseCompact(settings *simplejson.Json) (string, error) {
+	if !settings.Get("compact").MustBool(false) {
+		return "", nil
+	}
+	if settings.Get("message").MustString() != "" || settings.Get("template").MustString() != "" {
+		return "", alerting.ValidationError{Reason: "Invalid compact mode: Must not be set together with a message or template"}
+	}
+	return compactMessage, nil
+}
+
+// truncateUTF8 shortens s to at most maxBytes bytes without splitting a
+// multi-byte character.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	if maxBytes <= 0 {
+		return ""
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
+// truncateUTF8WithEllipsis shortens s to at most maxBytes bytes like
+// truncateUTF8, but ends it with an ellipsis if it was shortened.
+func truncateUTF8WithEllipsis(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	if maxBytes < len("…") {
+		return ""
+	}
+	return truncateUTF8(s, maxBytes-len("…")) + "…"
+}
+
+// truncateRunes shortens s to at most maxRunes characters without splitting
+// a multi-byte character.
+func truncateRunes(s string, maxRunes int) string {
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	if maxRunes <= 0 {
+		return ""
+	}
+	return string([]rune(s)[:maxRunes])
+}
+
+// truncateWithEllipsis shortens s to at most maxRunes characters like
+// truncateRunes, but ends it with an ellipsis if it was shortened.
+func truncateWithEllipsis(s string, maxRunes int) string {
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	if maxRunes <= 0 {
+		return ""
+	}
+	return truncateRunes(s, maxRunes-1) + "…"
+}
+
+// moreAlertsLine returns the line that tells that n more alerts are left
+// out of a message.
+func moreAlertsLine(n int) string {
+	if n == 1 {
+		return "… and 1 more alert"
+	}
+	return fmt.Sprintf("… and %d more alerts", n)
+}
+
+// intSetting reads the integer setting key of a notification channel, or def
+// if it is not set. The frontend submits numbers entered in forms as strings,
+// so strings are parsed too. It returns false if the setting isn't an integer.
+func intSetting(settings *simplejson.Json, key string, def int) (int, bool) {
+	value := settings.Get(key)
+	if s, err := value.String(); err == nil {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return def, true
+		}
+		i, err := strconv.Atoi(s)
+		return i, err == nil
+	}
+	if value.Interface() == nil {
+		return def, true
+	}
+	i, err := value.Int()
+	return i, err == nil
+}
+
+// jsonSetting reads the structured setting key of a notification channel,
+// such as a list or an object. The frontend submits the text of text areas as
+// strings, so strings are parsed as JSON, and an empty string is the same as a
+// missing setting. It returns false if the setting is a string that isn't JSON.
+func jsonSetting(settings *simplejson.Json, key string) (*simplejson.Json, bool) {
+	value := settings.Get(key)
+	s, err := value.String()
+	if err != nil {
+		return value, true
+	}
+	if strings.TrimSpace(s) == "" {
+		return simplejson.NewFromAny(nil), true
+	}
+	// The decoder of simplejson stops after the first value, so text such
+	// as 22:00-07:00 would be read as the number 22.
+	if !json.Valid([]byte(s)) {
+		return nil, false
+	}
+	parsed, err := simplejson.NewJson([]byte(s))
+	if err != nil {
+		return nil, false
+	}
+	return parsed, true
+}
+
+// stringListSetting reads the list setting key of a notification channel,
+// which may be the JSON text of a list as well, see jsonSetting. It returns
+// nil if the setting isn't set, and false if it isn't a list of strings.
+func stringListSetting(settings *simplejson.Json, key string) ([]string, bool) {
+	value, ok := jsonSetting(settings, key)
+	if !ok {
+		return nil, false
+	}
+	if value.Interface() == nil {
+		return nil, true
+	}
+	list, err := value.StringArray()
+	return list, err == nil
+}
+
+// parseMaxValueLength reads the max_value_length setting of a notification
+// channel, the maximum number of characters of label and annotation values in
+// messages. 0, the default, doesn't limit them.
+func parseMaxValueLength(settings *simplejson.Json) (int, error) {
+	maxLength, ok := intSetting(settings, "max_value_length", 0)
+	if !ok || maxLength < 0 {
+		return 0, alerting.ValidationError{Reason: "Invalid max value length: Must not be negative"}
+	}
+	return maxLength, nil
+}
+
+// parseSilent reads the silent setting of a notification channel, which
+// delivers notifications without a sound or vibration on the devices of the
+// recipients where the service supports it.
+func parseSilent(settings *simplejson.Json) bool {
+	return settings.Get("silent").MustBool(false)
+}
+
+// truncateTemplateValues shortens the label and annotation values of data to
+// at most maxLength characters, ending shortened values with an ellipsis. It
+// leaves data alone if maxLength is 0.
+func truncateTemplateValues(data *template.Data, maxLength int) {
+	if maxLength == 0 {
+		return
+	}
+	mapTemplateValues(data, func(v string) string {
+		return truncateWithEllipsis(v, maxLength)
+	})
+}
+
+// mapTemplateValues replaces the label and annotation values of data with
+// the result of fn.
+func mapTemplateValues(data *template.Data, fn func(string) string) {
+	replace := func(kv template.KV) {
+		for k, v := range kv {
+			kv[k] = fn(v)
+		}
+	}
+	for _, a := range data.Alerts {
+		replace(a.Labels)
+		replace(a.Annotations)
+	}
+	replace(data.GroupLabels)
+	replace(data.CommonLabels)
+	replace(data.CommonAnnotations)
+}
+
+// pathInDir reports whether the path p is in the directory dir or one of its
+// subdirectories, after resolving symbolic links. Both must be absolute.
+func pathInDir(dir, p string) bool {
+	if dir == "" || !filepath.IsAbs(dir) || !filepath.IsAbs(p) {
+		return false
+	}
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(resolvedDir, resolved)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// notificationLogContext returns the log context that correlates a
+// notification with the alert group it was sent for, and with its
+// fingerprint, which downstream systems can deduplicate it by.
+func notificationLogContext(ctx context.Context, as []*types.Alert) []interface{} {
+	// The group key is missing only when notifying outside of the
+	// Alertmanager, so log it as empty rather than failing.
+	groupKey, _ := notify.ExtractGroupKey(ctx)
+	return []interface{}{
+		"groupKey", groupKey.String(),
+		"alerts", len(as),
+		"status", types.Alerts(as...).Status(),
+		"fingerprint", notificationFingerprint(ctx, as),
+	}
+}
+
+// threadKey returns a key that identifies the thread of the alert group of
+// ctx. It is derived from the group key, so all notifications of the same
+// group get the same key, and notifiers can use it to post follow-up
+// notifications as replies into one thread. The key is the hash of the group
+// key, encoded as 26 lowercase alphanumeric characters, so it doesn't leak
+// label values to the receiver.
+func threadKey(ctx context.Context) (string, error) {
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(groupKey.String()))
+	key := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:])
+	return strings.ToLower(key[:26]), nil
+}
@@ -0,0 +1,700 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/inconshreveable/log15"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestTruncateUTF8(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		maxBytes int
+		exp      string
+	}{
+		{name: "short string is untouched", in: "hello", maxBytes: 10, exp: "hello"},
+		{name: "exact length is untouched", in: "hello", maxBytes: 5, exp: "hello"},
+		{name: "ascii is cut at the limit", in: "hello world", maxBytes: 5, exp: "hello"},
+		{name: "multi-byte rune is not split", in: "a€b", maxBytes: 3, exp: "a"},
+		{name: "multi-byte rune that fits is kept", in: "a€b", maxBytes: 4, exp: "a€"},
+		{name: "non-positive limit", in: "hello", maxBytes: 0, exp: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, truncateUTF8(c.in, c.maxBytes))
+		})
+	}
+}
+
+func TestTruncateUTF8WithEllipsis(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		maxBytes int
+		exp      string
+	}{
+		{name: "short string is untouched", in: "hello", maxBytes: 10, exp: "hello"},
+		{name: "ellipsis counts towards the limit", in: "hello world", maxBytes: 7, exp: "hell…"},
+		{name: "multi-byte rune is not split", in: "a€b€c", maxBytes: 7, exp: "a€…"},
+		{name: "limit shorter than the ellipsis", in: "hello", maxBytes: 2, exp: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, truncateUTF8WithEllipsis(c.in, c.maxBytes))
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		maxRunes int
+		exp      string
+	}{
+		{name: "short string is untouched", in: "hello", maxRunes: 10, exp: "hello"},
+		{name: "exact length is untouched", in: "日本語", maxRunes: 3, exp: "日本語"},
+		{name: "runes are counted, not bytes", in: "日本語のテキスト", maxRunes: 3, exp: "日本語"},
+		{name: "non-positive limit", in: "hello", maxRunes: 0, exp: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, truncateRunes(c.in, c.maxRunes))
+		})
+	}
+}
+
+func TestTruncateWithEllipsis(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		maxRunes int
+		exp      string
+	}{
+		{name: "short string is untouched", in: "hello", maxRunes: 10, exp: "hello"},
+		{name: "exact length is untouched", in: "hello", maxRunes: 5, exp: "hello"},
+		{name: "ellipsis counts towards the limit", in: "hello world", maxRunes: 5, exp: "hell…"},
+		{name: "runes are counted, not bytes", in: "日本語のテキスト", maxRunes: 4, exp: "日本語…"},
+		{name: "non-positive limit", in: "hello", maxRunes: 0, exp: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, truncateWithEllipsis(c.in, c.maxRunes))
+		})
+	}
+}
+
+func TestIntSetting(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		exp   int
+		expOK bool
+	}{
+		{name: "missing setting is the default", value: nil, exp: 42, expOK: true},
+		{name: "empty string is the default", value: "", exp: 42, expOK: true},
+		{name: "number", value: 10, exp: 10, expOK: true},
+		{name: "numeric string from a form", value: " 10 ", exp: 10, expOK: true},
+		{name: "negative numbers are left to the caller", value: "-1", exp: -1, expOK: true},
+		{name: "text", value: "ten", expOK: false},
+		{name: "fraction", value: "1.5", expOK: false},
+		{name: "other types", value: true, expOK: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := simplejson.New()
+			if c.value != nil {
+				settings.Set("key", c.value)
+			}
+			i, ok := intSetting(settings, "key", 42)
+			require.Equal(t, c.expOK, ok)
+			if c.expOK {
+				require.Equal(t, c.exp, i)
+			}
+		})
+	}
+}
+
+func TestJSONSetting(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		exp   interface{}
+		expOK bool
+	}{
+		{name: "missing setting", value: nil, exp: nil, expOK: true},
+		{name: "empty string is a missing setting", value: " ", exp: nil, expOK: true},
+		{name: "object", value: map[string]interface{}{"a": "b"}, exp: map[string]interface{}{"a": "b"}, expOK: true},
+		{name: "object from a text area", value: `{"a": "b"}`, exp: map[string]interface{}{"a": "b"}, expOK: true},
+		{name: "list from a text area", value: `["a", "b"]`, exp: []interface{}{"a", "b"}, expOK: true},
+		{name: "text that isn't JSON", value: "a=b", expOK: false},
+		{name: "text that starts with JSON", value: "22:00-07:00", expOK: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := simplejson.New()
+			if c.value != nil {
+				settings.Set("key", c.value)
+			}
+			value, ok := jsonSetting(settings, "key")
+			require.Equal(t, c.expOK, ok)
+			if c.expOK {
+				require.Equal(t, c.exp, value.Interface())
+			}
+		})
+	}
+}
+
+func TestStringListSetting(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		exp   []string
+		expOK bool
+	}{
+		{name: "missing setting", value: nil, exp: nil, expOK: true},
+		{name: "list", value: []interface{}{"a", "b"}, exp: []string{"a", "b"}, expOK: true},
+		{name: "list from a text area", value: `["a", "b"]`, exp: []string{"a", "b"}, expOK: true},
+		{name: "not a list", value: `{"a": "b"}`, expOK: false},
+		{name: "text that isn't JSON", value: "a", expOK: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := simplejson.New()
+			if c.value != nil {
+				settings.Set("key", c.value)
+			}
+			list, ok := stringListSetting(settings, "key")
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.exp, list)
+		})
+	}
+}
+
+func TestNotifiersLogGroupContext(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	notifiers := notifiersWithHTTPOptions(t, tmpl, nil)
+	loggers := map[string]log.Logger{
+		"threema": notifiers["threema"].(*ThreemaNotifier).log,
+		"line":    notifiers["line"].(*LineNotifier).log,
+	}
+
+	for name, n := range notifiers {
+		t.Run(name, func(t *testing.T) {
+			records := map[log15.Lvl]*log15.Record{}
+			loggers[name].SetHandler(log15.FuncHandler(func(r *log15.Record) error {
+				records[r.Lvl] = r
+				return nil
+			}))
+
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				return errors.New("gateway unavailable")
+			})
+			_, err := n.Notify(notifyContext(), firingAlert())
+			require.Error(t, err)
+
+			expKey, err := notify.ExtractGroupKey(notifyContext())
+			require.NoError(t, err)
+			for _, lvl := range []log15.Lvl{log15.LvlDebug, log15.LvlError} {
+				r, ok := records[lvl]
+				require.True(t, ok, "missing %s log line", lvl)
+				fields := map[string]interface{}{}
+				for i := 0; i+1 < len(r.Ctx); i += 2 {
+					fields[r.Ctx[i].(string)] = r.Ctx[i+1]
+				}
+				require.Equal(t, expKey.String(), fields["groupKey"])
+				require.Equal(t, 1, fields["alerts"])
+				require.Equal(t, "firing", fmt.Sprint(fields["status"]))
+				require.Equal(t, n.(Fingerprinter).Fingerprint(notifyContext(), firingAlert()), fields["fingerprint"])
+			}
+		})
+	}
+}
+
+func TestThreadKey(t *testing.T) {
+	key := func(groupKey string) string {
+		k, err := threadKey(notify.WithGroupKey(context.Background(), groupKey))
+		require.NoError(t, err)
+		return k
+	}
+
+	require.Equal(t, key("{}:{alertname=\"alert1\"}"), key("{}:{alertname=\"alert1\"}"))
+	require.NotEqual(t, key("{}:{alertname=\"alert1\"}"), key("{}:{alertname=\"alert2\"}"))
+	require.Regexp(t, "^[a-z2-7]{26}$", key("{}:{alertname=\"alert1\"}"))
+
+	_, err := threadKey(context.Background())
+	require.Error(t, err)
+}
+
+func TestParseLinkPath(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings map[string]interface{}
+		exp      string
+		expErr   error
+	}{
+		{name: "default", settings: map[string]interface{}{}, exp: "/alerting/list"},
+		{name: "custom path", settings: map[string]interface{}{"link_path": "/d/abc123/overview"}, exp: "/d/abc123/overview"},
+		{
+			name:     "absolute URL",
+			settings: map[string]interface{}{"link_path": "https://grafana.example.org/alerting/list"},
+			expErr:   alerting.ValidationError{Reason: "Invalid link path: Must be a path relative to the Grafana URL such as /alerting/list"},
+		}, {
+			name:     "host without scheme",
+			settings: map[string]interface{}{"link_path": "//grafana.example.org/alerting/list"},
+			expErr:   alerting.ValidationError{Reason: "Invalid link path: Must be a path relative to the Grafana URL such as /alerting/list"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			linkPath, err := parseLinkPath(simplejson.NewFromAny(c.settings))
+			if c.expErr != nil {
+				require.Equal(t, c.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, linkPath)
+		})
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	cases := []struct {
+		name   string
+		raw    string
+		opts   urlValidationOptions
+		exp    string
+		expErr error
+	}{
+		{name: "https URL", raw: "https://threema.example.org/send_simple", opts: urlValidationOptions{requireHost: true}, exp: "https://threema.example.org/send_simple"},
+		{name: "http URL with port", raw: "http://10.0.0.1:8080", opts: urlValidationOptions{requireHost: true}, exp: "http://10.0.0.1:8080"},
+		{name: "URL without host if not required", raw: "http:///send", exp: "http:///send"},
+		{
+			name:   "missing scheme",
+			raw:    "threema.example.org/send_simple",
+			expErr: alerting.ValidationError{Reason: "Invalid Test URL: Must be an absolute URL"},
+		}, {
+			name:   "unparsable",
+			raw:    "https://threema example.org",
+			expErr: alerting.ValidationError{Reason: "Invalid Test URL: Must be an absolute URL"},
+		}, {
+			name:   "other scheme",
+			raw:    "ftp://threema.example.org",
+			expErr: alerting.ValidationError{Reason: "Invalid Test URL: Must be an http or https URL"},
+		}, {
+			name:   "missing host",
+			raw:    "https:///send_simple",
+			opts:   urlValidationOptions{requireHost: true},
+			expErr: alerting.ValidationError{Reason: "Invalid Test URL: Must have a host"},
+		}, {
+			name:   "port without host",
+			raw:    "https://:8080/send_simple",
+			opts:   urlValidationOptions{requireHost: true},
+			expErr: alerting.ValidationError{Reason: "Invalid Test URL: Must have a host"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, err := validateURL("Test URL", c.raw, c.opts)
+			if c.expErr != nil {
+				require.Equal(t, c.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, u)
+		})
+	}
+}
+
+func TestNotifiersLinkPath(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost/grafana")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	notifiers := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"link_path": "/alerting/grafana/abc123/view"})
+	expLink := "http:/localhost/grafana/alerting/grafana/abc123/view"
+
+	threemaBody, err := url.ParseQuery(sendAndCapture(t, notifiers["threema"]).Body)
+	require.NoError(t, err)
+	require.Contains(t, threemaBody.Get("text"), "*URL:* "+expLink+"\n")
+
+	lineBody, err := url.ParseQuery(sendAndCapture(t, notifiers["line"]).Body)
+	require.NoError(t, err)
+	require.Contains(t, lineBody.Get("message"), "\n"+expLink+"\n")
+}
+
+func TestParseTemplateName(t *testing.T) {
+	tmpl := templateWithCustomMessage(t)
+
+	cases := []struct {
+		name     string
+		settings map[string]interface{}
+		exp      string
+		expErr   error
+	}{
+		{name: "unset", settings: map[string]interface{}{}, exp: ""},
+		{name: "custom template", settings: map[string]interface{}{"template": "custom.message"}, exp: `{{ template "custom.message" . }}`},
+		{name: "default template", settings: map[string]interface{}{"template": "default.message"}, exp: `{{ template "default.message" . }}`},
+		{
+			name:     "missing template",
+			settings: map[string]interface{}{"template": "missing.message"},
+			expErr:   alerting.ValidationError{Reason: `Invalid template: Template "missing.message" is not defined`},
+		}, {
+			name:     "together with message",
+			settings: map[string]interface{}{"template": "custom.message", "message": "{{ .Status }}"},
+			expErr:   alerting.ValidationError{Reason: "Invalid template: Must not be set together with a message"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			message, err := parseTemplateName(simplejson.NewFromAny(c.settings), tmpl)
+			if c.expErr != nil {
+				require.Equal(t, c.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, message)
+		})
+	}
+}
+
+func TestNotifiersTemplate(t *testing.T) {
+	tmpl := templateWithCustomMessage(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	t.Run("custom template", func(t *testing.T) {
+		notifiers := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"template": "custom.message"})
+
+		threemaBody, err := url.ParseQuery(sendAndCapture(t, notifiers["threema"]).Body)
+		require.NoError(t, err)
+		require.Contains(t, threemaBody.Get("text"), "Custom: 1 firing alerts")
+
+		lineBody, err := url.ParseQuery(sendAndCapture(t, notifiers["line"]).Body)
+		require.NoError(t, err)
+		require.Contains(t, lineBody.Get("message"), "Custom: 1 firing alerts")
+	})
+
+	t.Run("missing template", func(t *testing.T) {
+		expErr := alerting.ValidationError{Reason: `Invalid template: Template "missing.message" is not defined`}
+
+		_, err := NewThreemaNotifier(&NotificationChannelConfig{
+			Name: "threema_testing",
+			Type: "threema",
+			Settings: simplejson.NewFromAny(map[string]interface{}{
+				"gateway_id":   "*1234567",
+				"recipient_id": "87654321",
+				"api_secret":   "supersecret12345",
+				"template":     "missing.message",
+			}),
+		}, tmpl)
+		require.Equal(t, expErr, err)
+
+		_, err = NewLineNotifier(&NotificationChannelConfig{
+			Name:     "line_testing",
+			Type:     "line",
+			Settings: simplejson.NewFromAny(map[string]interface{}{"token": "sometoken", "template": "missing.message"}),
+		}, tmpl)
+		require.Equal(t, expErr, err)
+	})
+}
+
+// templateWithCustomMessage returns the default template extended with a
+// custom.message template.
+func templateWithCustomMessage(t *testing.T) *template.Template {
+	f, err := ioutil.TempFile("/tmp", "template")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(f.Name()))
+	})
+
+	_, err = f.WriteString(DefaultTemplateString + `{{ define "custom.message" }}Custom: {{ len .Alerts.Firing }} firing alerts{{ end }}`)
+	require.NoError(t, err)
+
+	tmpl, err := NewTemplate([]string{f.Name()})
+	require.NoError(t, err)
+
+	return tmpl
+}
+
+func TestNotifiersCompact(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "severity": "warning", "team": "core"},
+				Annotations: model.LabelSet{"summary": "Latency is high", "description": "p99 latency is above 1s"},
+			},
+		}, {
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert2"},
+			},
+		},
+	}
+
+	// render returns the text of the notifications about alerts by name.
+	render := func(t *testing.T, settings map[string]interface{}) map[string]string {
+		texts := map[string]string{}
+		for name, n := range notifiersWithHTTPOptions(t, tmpl, settings) {
+			var body string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+			ok, err := n.Notify(notifyContext(), alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			texts[name] = values.Get("text") + values.Get("message")
+		}
+		return texts
+	}
+
+	full := render(t, nil)
+	compact := render(t, map[string]interface{}{"compact": true})
+	expPrefixes := map[string]string{"threema": "⚠️ [FIRING:2]", "line": "[FIRING:2]"}
+
+	for name, text := range compact {
+		t.Run(name, func(t *testing.T) {
+			require.Contains(t, full[name], "Labels:\n - alertname = alert1\n - severity = warning\n - team = core\n")
+			require.Contains(t, full[name], " - description = p99 latency is above 1s\n")
+
+			require.True(t, strings.HasPrefix(text, expPrefixes[name]), text)
+			require.Contains(t, text, "\n[FIRING] alert1{severity=warning,team=core} — Latency is high\n[FIRING] alert2\n")
+			require.NotContains(t, text, "Labels:")
+			require.NotContains(t, text, "p99 latency is above 1s")
+			require.Less(t, len(text), len(full[name]))
+		})
+	}
+
+	t.Run("together with a message", func(t *testing.T) {
+		_, err := parseCompact(simplejson.NewFromAny(map[string]interface{}{"compact": true, "message": "{{ .Status }}"}))
+		require.Equal(t, alerting.ValidationError{Reason: "Invalid compact mode: Must not be set together with a message or template"}, err)
+	})
+}
+
+func TestNotifiersMaxValueLength(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	stackTrace := "panic: runtime error: index out of range [3] with length 3\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:8 +0x1d"
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "service": "api-gateway"},
+			Annotations: model.LabelSet{"summary": "Crashed", "stacktrace": model.LabelValue(stackTrace), "owner": "チームプラットフォーム"},
+		},
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"max_value_length": 10}) {
+		t.Run(name, func(t *testing.T) {
+			var body string
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				return nil
+			})
+			ok, err := n.Notify(notifyContext(), alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			values, err := url.ParseQuery(body)
+			require.NoError(t, err)
+			text := values.Get("text") + values.Get("message")
+
+			require.Contains(t, text, " - stacktrace = panic: ru…\n")
+			require.NotContains(t, text, "goroutine")
+			// Values are truncated by characters, not bytes.
+			require.Contains(t, text, " - owner = チームプラットフォ…\n")
+			// Short values are untouched.
+			require.Contains(t, text, " - alertname = alert1\n")
+			require.Contains(t, text, " - summary = Crashed\n")
+			require.Contains(t, text, " - service = api-gatew…\n")
+		})
+	}
+
+	t.Run("Invalid setting", func(t *testing.T) {
+		for _, maxLength := range []interface{}{-1, "ten"} {
+			_, err := parseMaxValueLength(simplejson.NewFromAny(map[string]interface{}{"max_value_length": maxLength}))
+			require.Equal(t, alerting.ValidationError{Reason: "Invalid max value length: Must not be negative"}, err)
+		}
+	})
+
+	t.Run("From a text field", func(t *testing.T) {
+		maxLength, err := parseMaxValueLength(simplejson.NewFromAny(map[string]interface{}{"max_value_length": "10"}))
+		require.NoError(t, err)
+		require.Equal(t, 10, maxLength)
+	})
+}
+
+func TestNotifiersMaxAlerts(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var alerts []*types.Alert
+	for i := 1; i <= 5; i++ {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1", "instance": model.LabelValue(fmt.Sprintf("host-%d", i))},
+			},
+		})
+	}
+
+	render := func(t *testing.T, n notify.Notifier, as []*types.Alert) []string {
+		var texts []string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			values, err := url.ParseQuery(webhook.Body)
+			require.NoError(t, err)
+			texts = append(texts, values.Get("text")+values.Get("message"))
+			return nil
+		})
+		ok, err := n.Notify(notifyContext(), as...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return texts
+	}
+
+	for name, n := range notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"max_alerts": 2}) {
+		t.Run(name, func(t *testing.T) {
+			texts := render(t, n, alerts)
+			require.Len(t, texts, 1)
+			text := texts[0]
+
+			// The title counts all alerts, but only the first are listed.
+			require.Contains(t, text, "[FIRING:5]")
+			require.Contains(t, text, " - instance = host-1\n")
+			require.Contains(t, text, " - instance = host-2\n")
+			require.NotContains(t, text, "host-3")
+			require.Contains(t, text, "\n… and 3 more alerts")
+
+			text = render(t, n, alerts[:3])[0]
+			require.Contains(t, text, "… and 1 more alert")
+			require.NotContains(t, text, "1 more alerts")
+			require.NotContains(t, render(t, n, alerts[:2])[0], "more alert")
+		})
+	}
+
+	t.Run("threema pages", func(t *testing.T) {
+		n := notifiersWithHTTPOptions(t, tmpl, map[string]interface{}{"max_alerts": "3", "max_alerts_per_message": "2"})["threema"]
+		texts := render(t, n, alerts)
+		require.Len(t, texts, 2)
+		require.Contains(t, texts[0], "host-2")
+		require.NotContains(t, texts[0], "more alerts")
+		require.Contains(t, texts[1], "host-3")
+		require.NotContains(t, texts[1], "host-4")
+		require.Contains(t, texts[1], "… and 2 more alerts")
+	})
+
+	t.Run("Invalid setting", func(t *testing.T) {
+		for _, newNotifier := range []func() error{
+			func() error {
+				_, err := NewLineNotifier(&NotificationChannelConfig{
+					Name:     "line_testing",
+					Type:     "line",
+					Settings: simplejson.NewFromAny(map[string]interface{}{"token": "sometoken", "max_alerts": -1}),
+				}, tmpl)
+				return err
+			},
+			func() error {
+				_, err := NewThreemaNotifier(&NotificationChannelConfig{
+					Name: "threema_testing",
+					Type: "threema",
+					Settings: simplejson.NewFromAny(map[string]interface{}{
+						"gateway_id":   "*1234567",
+						"recipient_id": "87654321",
+						"api_secret":   "supersecret12345",
+						"max_alerts":   -1,
+					}),
+				}, tmpl)
+				return err
+			},
+		} {
+			require.Equal(t, alerting.ValidationError{Reason: "Invalid max alerts: Must not be negative"}, newNotifier())
+		}
+	})
+}
+
+func TestNotifiersWithoutAlerts(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	notifiers := notifiersWithHTTPOptions(t, tmpl, nil)
+	lineMessaging, err := NewLineMessagingNotifier(&NotificationChannelConfig{
+		Name:     "line_messaging_testing",
+		Type:     "line-messaging",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"token": "sometoken", "to": "U1234"}),
+	}, tmpl)
+	require.NoError(t, err)
+	notifiers["line-messaging"] = lineMessaging
+
+	for name, n := range notifiers {
+		t.Run(name, func(t *testing.T) {
+			sent := false
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				sent = true
+				return nil
+			})
+
+			ok, err := n.Notify(notifyContext())
+			require.NoError(t, err)
+			require.True(t, ok)
+			ok, err = n.Notify(notifyContext(), []*types.Alert{}...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.False(t, sent)
+		})
+	}
+}
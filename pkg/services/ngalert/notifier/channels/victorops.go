@@ -2,6 +2,7 @@ package channels
 
 import (
 	"context"
+	"net/url"
 	"strings"
 	"time"
 
@@ -24,17 +25,52 @@ const (
 	// victoropsAlertStateCritical - Victorops uses "CRITICAL" string to indicate "Alerting" state
 	victoropsAlertStateCritical = "CRITICAL"
 
+	// victoropsAlertStateWarning - VictorOps "WARNING" message type
+	victoropsAlertStateWarning = "WARNING"
+
+	// victoropsAlertStateInfo - VictorOps "INFO" message type
+	victoropsAlertStateInfo = "INFO"
+
 	// victoropsAlertStateRecovery - VictorOps "RECOVERY" message type
 	victoropsAlertStateRecovery = "RECOVERY"
 )
 
+// victoropsSeverityMessageTypes maps the values of the severity label to
+// VictorOps message types. Alerts without a known severity are critical.
+var victoropsSeverityMessageTypes = map[string]string{
+	"critical": victoropsAlertStateCritical,
+	"error":    victoropsAlertStateCritical,
+	"warning":  victoropsAlertStateWarning,
+	"info":     victoropsAlertStateInfo,
+}
+
+// victoropsMessageTypeRanks orders the message types of firing
+// notifications by urgency.
+var victoropsMessageTypeRanks = map[string]int{
+	victoropsAlertStateInfo:     0,
+	victoropsAlertStateWarning:  1,
+	victoropsAlertStateCritical: 2,
+}
+
 // NewVictoropsNotifier creates an instance of VictoropsNotifier that
 // handles posting notifications to Victorops REST API
 func NewVictoropsNotifier(model *NotificationChannelConfig, t *template.Template) (*VictoropsNotifier, error) {
-	url := model.Settings.Get("url").MustString()
-	if url == "" {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	endpointURL := model.Settings.Get("url").MustString()
+	if endpointURL == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find victorops url property in settings"}
 	}
+	if u, err := url.Parse(endpointURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid VictorOps URL: Must be an absolute URL"}
+	}
+
+	messageType := strings.ToUpper(model.Settings.Get("messageType").MustString())
+	if _, ok := victoropsMessageTypeRanks[messageType]; messageType != "" && !ok {
+		return nil, alerting.ValidationError{Reason: "Invalid message type: Must be one of CRITICAL, WARNING or INFO"}
+	}
 
 	return &VictoropsNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
@@ -44,10 +80,11 @@ func NewVictoropsNotifier(model *NotificationChannelConfig, t *template.Template
 			DisableResolveMessage: model.DisableResolveMessage,
 			Settings:              model.Settings,
 		}),
-		URL:         url,
-		MessageType: strings.ToUpper(model.Settings.Get("messageType").MustString()),
-		log:         log.New("alerting.notifier.victorops"),
-		tmpl:        t,
+		URL:           endpointURL,
+		MessageType:   messageType,
+		SeverityLabel: model.Settings.Get("severity_label").MustString("severity"),
+		log:           log.New("alerting.notifier.victorops"),
+		tmpl:          t,
 	}, nil
 }
 
@@ -56,10 +93,13 @@ func NewVictoropsNotifier(model *NotificationChannelConfig, t *template.Template
 // Victorops specifications (http://victorops.force.com/knowledgebase/articles/Integration/Alert-Ingestion-API-Documentation/)
 type VictoropsNotifier struct {
 	old_notifiers.NotifierBase
-	URL         string
-	MessageType string
-	log         log.Logger
-	tmpl        *template.Template
+	URL string
+	// MessageType is the message type of firing notifications. If it isn't
+	// set, it is derived from the severity label of the alerts.
+	MessageType   string
+	SeverityLabel string
+	log           log.Logger
+	tmpl          *template.Template
 }
 
 // Notify sends notification to Victorops via POST to URL endpoint
@@ -68,7 +108,7 @@ func (vn *VictoropsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bo
 
 	messageType := vn.MessageType
 	if messageType == "" {
-		messageType = victoropsAlertStateCritical
+		messageType = victoropsSeverityMessageType(as, vn.SeverityLabel)
 	}
 	alerts := types.Alerts(as...)
 	if alerts.Status() == model.AlertResolved {
@@ -77,7 +117,7 @@ func (vn *VictoropsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bo
 
 	data := notify.GetTemplateData(ctx, vn.tmpl, as, gokit_log.NewNopLogger())
 	var tmplErr error
-	tmpl := notify.TmplText(vn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, vn.tmpl, data, &tmplErr)
 
 	groupKey, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
@@ -118,3 +158,36 @@ func (vn *VictoropsNotifier) Notify(ctx context.Context, as ...*types.Alert) (bo
 func (vn *VictoropsNotifier) SendResolved() bool {
 	return !vn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (vn *VictoropsNotifier) Type() string {
+	return "victorops"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (vn *VictoropsNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
+
+// victoropsSeverityMessageType returns the most urgent VictorOps message type
+// of the firing alerts in as according to the value of their severity label.
+func victoropsSeverityMessageType(as []*types.Alert, severityLabel string) string {
+	messageType := ""
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		severity := strings.ToLower(string(a.Labels[model.LabelName(severityLabel)]))
+		mt, ok := victoropsSeverityMessageTypes[severity]
+		if !ok {
+			mt = victoropsAlertStateCritical
+		}
+		if messageType == "" || victoropsMessageTypeRanks[mt] > victoropsMessageTypeRanks[messageType] {
+			messageType = mt
+		}
+	}
+	if messageType == "" {
+		return victoropsAlertStateCritical
+	}
+	return messageType
+}
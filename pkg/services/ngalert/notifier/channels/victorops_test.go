@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -78,10 +79,84 @@ func TestVictoropsNotifier(t *testing.T) {
 			}`,
 			expInitError: nil,
 			expMsgError:  nil,
+		}, {
+			name:     "Resolved alert",
+			settings: `{"url": "http://localhost"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1", "severity": "warning"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: `{
+			  "alert_url": "http://localhost/alerting/list",
+			  "entity_display_name": "[RESOLVED]  (val1 warning)",
+			  "entity_id": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+			  "message_type": "RECOVERY",
+			  "monitoring_tool": "Grafana v",
+			  "state_message": "\n\n**Resolved**\nLabels:\n - alertname = alert1\n - lbl1 = val1\n - severity = warning\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n"
+			}`,
+		}, {
+			name:     "Message type from the most urgent severity",
+			settings: `{"url": "http://localhost"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "info"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "Warning"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical"},
+						EndsAt: time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expMsg: `{
+			  "alert_url": "http://localhost/alerting/list",
+			  "entity_display_name": "[FIRING:2]  ",
+			  "entity_id": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+			  "message_type": "WARNING",
+			  "monitoring_tool": "Grafana v",
+			  "state_message": "\n**Firing**\nLabels:\n - alertname = alert1\n - severity = info\nAnnotations:\nSource: \nLabels:\n - alertname = alert1\n - severity = Warning\nAnnotations:\nSource: \n\n\n\n\n**Resolved**\nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\nSource: \n\n\n"
+			}`,
+		}, {
+			name:     "Configured message type takes precedence over the severity",
+			settings: `{"url": "http://localhost", "messageType": "info"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "severity": "critical"},
+					},
+				},
+			},
+			expMsg: `{
+			  "alert_url": "http://localhost/alerting/list",
+			  "entity_display_name": "[FIRING:1]  (critical)",
+			  "entity_id": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+			  "message_type": "INFO",
+			  "monitoring_tool": "Grafana v",
+			  "state_message": "\n**Firing**\nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\nSource: \n\n\n\n\n"
+			}`,
 		}, {
 			name:         "Error in initing, no URL",
 			settings:     `{}`,
 			expInitError: alerting.ValidationError{Reason: "Could not find victorops url property in settings"},
+		}, {
+			name:         "Error in initing, invalid URL",
+			settings:     `{"url": "localhost/integrations/generic"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid VictorOps URL: Must be an absolute URL"},
+		}, {
+			name:         "Error in initing, invalid message type",
+			settings:     `{"url": "http://localhost", "messageType": "ACKNOWLEDGEMENT"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid message type: Must be one of CRITICAL, WARNING or INFO"},
 		},
 	}
 
@@ -134,3 +209,50 @@ func TestVictoropsNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestVictoropsNotifierStableEntityID(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	vn, err := NewVictoropsNotifier(&NotificationChannelConfig{
+		Name:     "victorops_testing",
+		Type:     "victorops",
+		Settings: simplejson.NewFromAny(map[string]interface{}{"url": "http://localhost"}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	send := func(groupKey string, a *types.Alert) *simplejson.Json {
+		var body string
+		bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+			body = webhook.Body
+			return nil
+		})
+		ok, err := vn.Notify(notify.WithGroupKey(context.Background(), groupKey), a)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		j, err := simplejson.NewJson([]byte(body))
+		require.NoError(t, err)
+		return j
+	}
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert1"},
+			StartsAt: time.Now().Add(-2 * time.Hour),
+		},
+	}
+	firing := send("{}:{alertname=\"alert1\"}", alert)
+
+	alert.EndsAt = time.Now().Add(-time.Hour)
+	recovery := send("{}:{alertname=\"alert1\"}", alert)
+	other := send("{}:{alertname=\"alert2\"}", alert)
+
+	require.Equal(t, "CRITICAL", firing.Get("message_type").MustString())
+	require.Equal(t, "RECOVERY", recovery.Get("message_type").MustString())
+	require.Equal(t, firing.Get("entity_id").MustString(), recovery.Get("entity_id").MustString())
+	require.NotEqual(t, firing.Get("entity_id").MustString(), other.Get("entity_id").MustString())
+}
@@ -0,0 +1,186 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+const (
+	// webexMessagesURL creates messages in a room or sends them to a person.
+	webexMessagesURL = "https://webexapis.com/v1/messages"
+
+	// webexMaxMessageSize is the maximum size of a message in bytes.
+	webexMaxMessageSize = 7439
+)
+
+// WebexNotifier is responsible for sending
+// alert notifications to Webex.
+type WebexNotifier struct {
+	old_notifiers.NotifierBase
+	Token         string
+	RoomID        string
+	ToPersonEmail string
+	Title         string
+	Message       string
+	retry         retryOptions
+	httpOptions   httpOptions
+	metrics       *DeliveryMetrics
+	messagesURL   string
+	log           log.Logger
+	tmpl          *template.Template
+}
+
+// NewWebexNotifier is the constructor for the Webex notifier
+func NewWebexNotifier(model *NotificationChannelConfig, t *template.Template) (*WebexNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	token, err := model.ResolvedSecret("bot_token")
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Webex bot token in settings"}
+	}
+
+	// Messages are either posted to a room or sent to a person directly.
+	roomID := model.Settings.Get("room_id").MustString()
+	toPersonEmail := model.Settings.Get("to_person_email").MustString()
+	if (roomID == "") == (toPersonEmail == "") {
+		return nil, alerting.ValidationError{Reason: "Invalid Webex recipient: Exactly one of room ID or person email must be set"}
+	}
+
+	title := model.Settings.Get("title").MustString()
+	if title == "" {
+		title = `{{ template "default.title" . }}`
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.webex")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &WebexNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		Token:         token,
+		RoomID:        roomID,
+		ToPersonEmail: toPersonEmail,
+		Title:         title,
+		Message:       message,
+		retry:         newRetryOptions(maxRetries),
+		httpOptions:   httpOpts,
+		metrics:       model.Metrics,
+		messagesURL:   webexMessagesURL,
+		log:           logger,
+		tmpl:          t,
+	}, nil
+}
+
+// webexMessage is a message of the Webex messages API. Exactly one of
+// RoomID and ToPersonEmail is set.
+type webexMessage struct {
+	RoomID        string `json:"roomId,omitempty"`
+	ToPersonEmail string `json:"toPersonEmail,omitempty"`
+	Markdown      string `json:"markdown"`
+}
+
+// Notify sends an alert notification to Webex
+func (wn *WebexNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	status := types.Alerts(as...).Status()
+	if status == model.AlertResolved && !wn.SendResolved() {
+		return true, nil
+	}
+
+	logger := wn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Webex notification", "notification", wn.Name)
+
+	data := notify.GetTemplateData(ctx, wn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, wn.tmpl, data, &tmplErr)
+
+	ruleURL, err := joinUrlPath(wn.tmpl.ExternalURL.String(), defaultLinkPath)
+	if err != nil {
+		return false, err
+	}
+	markdown := fmt.Sprintf("**%s**\n\n%s\n\n[Alert rules](%s)", tmpl(wn.Title), tmpl(wn.Message), ruleURL)
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Webex message: %w", tmplErr)
+	}
+
+	body, err := json.Marshal(webexMessage{
+		RoomID:        wn.RoomID,
+		ToPersonEmail: wn.ToPersonEmail,
+		Markdown:      truncateUTF8(markdown, webexMaxMessageSize),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        wn.messagesURL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", wn.Token),
+			"Content-Type":  "application/json",
+		},
+		Body: string(body),
+	}
+	if err := wn.httpOptions.apply(ctx, cmd, wn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, wn.retry)
+	wn.metrics.observe("webex", status, start, err)
+	if err != nil {
+		logger.Error("Failed to send Webex notification", "error", err, "webhook", wn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (wn *WebexNotifier) SendResolved() bool {
+	return !wn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (wn *WebexNotifier) Type() string {
+	return "webex"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (wn *WebexNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
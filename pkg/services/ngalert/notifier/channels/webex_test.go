@@ -0,0 +1,151 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestWebexNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expMsg       string
+		expInitError error
+	}{
+		{
+			name:     "One alert to a room",
+			settings: `{"bot_token": "sometoken", "room_id": "Y2lzY29zcGFyazovL3VzL1JPT00vYmJjZWIxYWQ"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg: `{"roomId":"Y2lzY29zcGFyazovL3VzL1JPT00vYmJjZWIxYWQ","markdown":"**[FIRING:1]  (val1)**\n\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n\n\n[Alert rules](http://localhost/alerting/list)"}`,
+		}, {
+			name: "Custom title and message to a person",
+			settings: `{
+				"bot_token": "sometoken",
+				"to_person_email": "oncall@example.org",
+				"title": "{{ .CommonLabels.alertname }}",
+				"message": "{{ len .Alerts.Firing }} firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels: model.LabelSet{"alertname": "alert1", "instance": "b"},
+					},
+				},
+			},
+			expMsg: `{"toPersonEmail":"oncall@example.org","markdown":"**alert1**\n\n2 firing\n\n[Alert rules](http://localhost/alerting/list)"}`,
+		}, {
+			name:         "Missing token",
+			settings:     `{"room_id": "abc"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Webex bot token in settings"},
+		}, {
+			name:         "Missing room and person",
+			settings:     `{"bot_token": "sometoken"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Webex recipient: Exactly one of room ID or person email must be set"},
+		}, {
+			name:         "Both room and person",
+			settings:     `{"bot_token": "sometoken", "room_id": "abc", "to_person_email": "oncall@example.org"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Webex recipient: Exactly one of room ID or person email must be set"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "webex_testing",
+				Type:     "webex",
+				Settings: settingsJSON,
+			}
+
+			wn, err := NewWebexNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var payload *models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				payload = webhook
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := wn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, "https://webexapis.com/v1/messages", payload.Url)
+			require.Equal(t, "Bearer sometoken", payload.HttpHeader["Authorization"])
+			require.JSONEq(t, c.expMsg, payload.Body)
+		})
+	}
+}
+
+func TestWebexNotifierSendResolved(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	wn, err := NewWebexNotifier(&NotificationChannelConfig{
+		Name:                  "webex_testing",
+		Type:                  "webex",
+		DisableResolveMessage: true,
+		Settings:              simplejson.NewFromAny(map[string]interface{}{"bot_token": "sometoken", "room_id": "abc"}),
+	}, tmpl)
+	require.NoError(t, err)
+
+	sent := false
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		sent = true
+		return nil
+	})
+
+	resolved := firingAlert()
+	resolved.EndsAt = time.Now().Add(-time.Hour)
+	ok, err := wn.Notify(notifyContext(), resolved)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.False(t, sent)
+
+	ok, err = wn.Notify(notifyContext(), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, sent)
+}
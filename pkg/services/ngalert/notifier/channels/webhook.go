@@ -28,6 +28,8 @@ type WebhookNotifier struct {
 	Password   string
 	HTTPMethod string
 	MaxAlerts  int
+	body       *bodyTemplate
+	compress   compressOptions
 	log        log.Logger
 	tmpl       *template.Template
 }
@@ -39,6 +41,14 @@ func NewWebHookNotifier(model *NotificationChannelConfig, t *template.Template)
 	if url == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
 	}
+	body, err := parseBodyTemplate(model.Settings)
+	if err != nil {
+		return nil, err
+	}
+	compress, err := parseCompressOptions(model.Settings)
+	if err != nil {
+		return nil, err
+	}
 	return &WebhookNotifier{
 		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
 			Uid:                   model.UID,
@@ -52,6 +62,8 @@ func NewWebHookNotifier(model *NotificationChannelConfig, t *template.Template)
 		Password:   model.DecryptedValue("password", model.Settings.Get("password").MustString()),
 		HTTPMethod: model.Settings.Get("httpMethod").MustString("POST"),
 		MaxAlerts:  model.Settings.Get("maxAlerts").MustInt(0),
+		body:       body,
+		compress:   compress,
 		log:        log.New("alerting.notifier.webhook"),
 		tmpl:       t,
 	}, nil
@@ -84,7 +96,7 @@ func (wn *WebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 	data := notify.GetTemplateData(ctx, wn.tmpl, as, gokit_log.NewLogfmtLogger(logging.NewWrapper(wn.log)))
 
 	var tmplErr error
-	tmpl := notify.TmplText(wn.tmpl, data, &tmplErr)
+	tmpl := tmplText(ctx, wn.tmpl, data, &tmplErr)
 	msg := &webhookMessage{
 		Version:         "1",
 		Data:            data,
@@ -104,18 +116,30 @@ func (wn *WebhookNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool
 		return false, fmt.Errorf("failed to template webhook message: %w", tmplErr)
 	}
 
-	body, err := json.Marshal(msg)
-	if err != nil {
-		return false, err
-	}
-
 	cmd := &models.SendWebhookSync{
 		Url:        wn.URL,
 		User:       wn.User,
 		Password:   wn.Password,
-		Body:       string(body),
 		HttpMethod: wn.HTTPMethod,
 	}
+	// The body template gets the same data as the default JSON body.
+	if wn.body != nil {
+		cmd.Body, err = wn.body.render(msg)
+		if err != nil {
+			return false, err
+		}
+		cmd.ContentType = wn.body.contentType
+	} else {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return false, err
+		}
+		cmd.Body = string(body)
+	}
+	if err := wn.compress.apply(cmd); err != nil {
+		return false, err
+	}
+	setIdempotencyKey(ctx, cmd, idempotencyKeyHeader)
 
 	if err := bus.DispatchCtx(ctx, cmd); err != nil {
 		return false, err
@@ -135,3 +159,13 @@ func truncateAlerts(maxAlerts int, alerts []*types.Alert) ([]*types.Alert, int)
 func (wn *WebhookNotifier) SendResolved() bool {
 	return !wn.GetDisableResolveMessage()
 }
+
+// Type returns the kind of the notification channel.
+func (wn *WebhookNotifier) Type() string {
+	return "webhook"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (wn *WebhookNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
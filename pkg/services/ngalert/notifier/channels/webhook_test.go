@@ -219,3 +219,81 @@ func TestWebhookNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestWebhookNotifierBodyTemplate(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	newNotifier := func(settings map[string]interface{}) (*WebhookNotifier, error) {
+		settings["url"] = "http://localhost/test"
+		return NewWebHookNotifier(&NotificationChannelConfig{
+			Name:     "webhook_testing",
+			Type:     "webhook",
+			Settings: simplejson.NewFromAny(settings),
+		}, tmpl)
+	}
+
+	var payload *models.SendWebhookSync
+	bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+		payload = webhook
+		return nil
+	})
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}, Annotations: model.LabelSet{"summary": "disk full"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}},
+	}
+
+	t.Run("Renders a nested JSON body", func(t *testing.T) {
+		pn, err := newNotifier(map[string]interface{}{
+			"body_template": `{"incident": {"key": {{ toJson .GroupKey }}, "status": {{ toJson .Status }}, "alerts": [
+				{{- range $i, $a := .Alerts }}{{ if $i }}, {{ end }}{"labels": {{ toJson $a.Labels }}, "summary": {{ toJson (index $a.Annotations "summary") }}}{{ end -}}
+			]}}`,
+		})
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.JSONEq(t, `{"incident": {"key": "alertname", "status": "firing", "alerts": [
+			{"labels": {"alertname": "alert1"}, "summary": "disk full"},
+			{"labels": {"alertname": "alert2"}, "summary": ""}
+		]}}`, payload.Body)
+		require.Equal(t, "application/json", payload.ContentType)
+	})
+
+	t.Run("Content type", func(t *testing.T) {
+		pn, err := newNotifier(map[string]interface{}{
+			"body_template": `{{ range .Alerts }}{{ .Labels.alertname }}{{ "\n" }}{{ end }}`,
+			"content_type":  "text/plain",
+		})
+		require.NoError(t, err)
+
+		_, err = pn.Notify(notifyContext(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, "alert1\nalert2\n", payload.Body)
+		require.Equal(t, "text/plain", payload.ContentType)
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		_, err := newNotifier(map[string]interface{}{"body_template": `{"status": {{ .Status }`})
+		require.Error(t, err)
+		require.IsType(t, alerting.ValidationError{}, err)
+		require.Contains(t, err.Error(), "Invalid body template: ")
+	})
+
+	t.Run("Templating errors fail the notification", func(t *testing.T) {
+		payload = nil
+		pn, err := newNotifier(map[string]interface{}{"body_template": `{"status": {{ template "missing" . }}}`})
+		require.NoError(t, err)
+
+		ok, err := pn.Notify(notifyContext(), alerts...)
+		require.False(t, ok)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to render body template: ")
+		require.Nil(t, payload)
+	})
+}
@@ -0,0 +1,168 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+var (
+	WecomWebhookURL = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send"
+)
+
+const (
+	wecomMsgTypeText     = "text"
+	wecomMsgTypeMarkdown = "markdown"
+)
+
+// WecomNotifier is responsible for sending
+// alert notifications to a WeCom group robot.
+type WecomNotifier struct {
+	old_notifiers.NotifierBase
+	URL         string
+	MsgType     string
+	Message     string
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewWecomNotifier is the constructor for the WeCom notifier
+func NewWecomNotifier(model *NotificationChannelConfig, t *template.Template) (*WecomNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	// The robot webhook URL can be configured directly or through its key.
+	webhookURL := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	key := model.DecryptedValue("key", model.Settings.Get("key").MustString())
+	if webhookURL == "" && key == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find WeCom webhook URL or key in settings"}
+	}
+	if webhookURL == "" {
+		webhookURL = WecomWebhookURL + "?key=" + url.QueryEscape(key)
+	} else if u, err := url.Parse(webhookURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid WeCom webhook URL: Must be an absolute URL"}
+	}
+
+	msgType := model.Settings.Get("msgtype").MustString(wecomMsgTypeText)
+	if msgType != wecomMsgTypeText && msgType != wecomMsgTypeMarkdown {
+		return nil, alerting.ValidationError{Reason: "Invalid WeCom message type: Must be text or markdown"}
+	}
+
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.wecom")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &WecomNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:         webhookURL,
+		MsgType:     msgType,
+		Message:     message,
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+// Notify sends an alert notification to WeCom
+func (wn *WecomNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := wn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing WeCom notification", "notification", wn.Name)
+
+	data := notify.GetTemplateData(ctx, wn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, wn.tmpl, data, &tmplErr)
+
+	title := tmpl(`{{ template "default.title" . }}`)
+	message := tmpl(wn.Message)
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template WeCom message: %w", tmplErr)
+	}
+
+	content := fmt.Sprintf("%s\n%s", title, message)
+	if wn.MsgType == wecomMsgTypeMarkdown {
+		content = fmt.Sprintf("**%s**\n%s", title, message)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": wn.MsgType,
+		wn.MsgType: map[string]string{
+			"content": content,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        wn.URL,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+	if err := wn.httpOptions.apply(ctx, cmd, wn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err = sendWithRetry(ctx, cmd, wn.retry)
+	wn.metrics.observe("wecom", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to send WeCom notification", "error", err, "webhook", wn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (wn *WecomNotifier) SendResolved() bool {
+	return !wn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (wn *WecomNotifier) Type() string {
+	return "wecom"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (wn *WecomNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,146 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestWecomNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"url": "http://localhost/cgi-bin/webhook/send?key=somekey"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expURL:       "http://localhost/cgi-bin/webhook/send?key=somekey",
+			expMsg:       `{"msgtype":"text","text":{"content":"[FIRING:1]  (val1)\n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \n\n\n\n\n"}}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Multiple alerts",
+			settings: `{"url": "http://localhost/cgi-bin/webhook/send?key=somekey"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+						Annotations: model.LabelSet{"ann1": "annv2"},
+					},
+				},
+			},
+			expURL:       "http://localhost/cgi-bin/webhook/send?key=somekey",
+			expMsg:       `{"msgtype":"text","text":{"content":"[FIRING:2]  \n\n**Firing**\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSource: \nLabels:\n - alertname = alert1\n - lbl1 = val2\nAnnotations:\n - ann1 = annv2\nSource: \n\n\n\n\n"}}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:     "Markdown with custom message and key",
+			settings: `{"key": "some key", "msgtype": "markdown", "message": "{{ len .Alerts.Resolved }} resolved"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+						StartsAt:    time.Now().Add(-2 * time.Hour),
+						EndsAt:      time.Now().Add(-time.Hour),
+					},
+				},
+			},
+			expURL:       "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=some+key",
+			expMsg:       `{"msgtype":"markdown","markdown":{"content":"**[RESOLVED]  (val1)**\n1 resolved"}}`,
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "URL and key missing",
+			settings:     `{}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find WeCom webhook URL or key in settings"},
+		}, {
+			name:         "Invalid URL",
+			settings:     `{"url": "qyapi.weixin.qq.com/cgi-bin/webhook/send"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid WeCom webhook URL: Must be an absolute URL"},
+		}, {
+			name:         "Invalid message type",
+			settings:     `{"key": "somekey", "msgtype": "news"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid WeCom message type: Must be text or markdown"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "wecom_testing",
+				Type:     "wecom",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewWecomNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			body := ""
+			webhookURL := ""
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				body = webhook.Body
+				webhookURL = webhook.Url
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookURL)
+			require.JSONEq(t, c.expMsg, body)
+		})
+	}
+}
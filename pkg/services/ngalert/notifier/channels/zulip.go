@@ -0,0 +1,166 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+	old_notifiers "github.com/grafana/grafana/pkg/services/alerting/notifiers"
+)
+
+// ZulipNotifier is responsible for sending
+// alert notifications to a stream in Zulip.
+type ZulipNotifier struct {
+	old_notifiers.NotifierBase
+	URL         string
+	BotEmail    string
+	APIKey      string
+	Stream      string
+	Topic       string
+	Message     string
+	retry       retryOptions
+	httpOptions httpOptions
+	metrics     *DeliveryMetrics
+	log         log.Logger
+	tmpl        *template.Template
+}
+
+// NewZulipNotifier is the constructor for the Zulip notifier
+func NewZulipNotifier(model *NotificationChannelConfig, t *template.Template) (*ZulipNotifier, error) {
+	if model.Settings == nil {
+		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
+	}
+
+	realmURL := model.Settings.Get("realm_url").MustString()
+	if realmURL == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Zulip realm URL in settings"}
+	}
+	if u, err := url.Parse(realmURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, alerting.ValidationError{Reason: "Invalid Zulip realm URL: Must be an absolute URL"}
+	}
+	messagesURL, err := joinUrlPath(realmURL, "/api/v1/messages")
+	if err != nil {
+		return nil, err
+	}
+
+	botEmail := model.Settings.Get("bot_email").MustString()
+	if botEmail == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Zulip bot email in settings"}
+	}
+	apiKey := model.DecryptedValue("api_key", model.Settings.Get("api_key").MustString())
+	if apiKey == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Zulip API key in settings"}
+	}
+	stream := model.Settings.Get("stream").MustString()
+	if stream == "" {
+		return nil, alerting.ValidationError{Reason: "Could not find Zulip stream in settings"}
+	}
+
+	topic := model.Settings.Get("topic").MustString()
+	if topic == "" {
+		topic = `{{ .CommonLabels.alertname }}`
+	}
+	message := model.Settings.Get("message").MustString()
+	if message == "" {
+		message = `{{ template "default.message" . }}`
+	}
+
+	maxRetries, ok := intSetting(model.Settings, "max_retries", 0)
+	if !ok || maxRetries < 0 {
+		return nil, alerting.ValidationError{Reason: "Invalid max retries: Must not be negative"}
+	}
+
+	logger := log.New("alerting.notifier.zulip")
+	httpOpts, err := parseHTTPOptions(model)
+	if err != nil {
+		return nil, err
+	}
+	httpOpts.warnInsecure(logger, model)
+
+	return &ZulipNotifier{
+		NotifierBase: old_notifiers.NewNotifierBase(&models.AlertNotification{
+			Uid:                   model.UID,
+			Name:                  model.Name,
+			Type:                  model.Type,
+			DisableResolveMessage: model.DisableResolveMessage,
+			Settings:              model.Settings,
+		}),
+		URL:         messagesURL,
+		BotEmail:    botEmail,
+		APIKey:      apiKey,
+		Stream:      stream,
+		Topic:       topic,
+		Message:     message,
+		retry:       newRetryOptions(maxRetries),
+		httpOptions: httpOpts,
+		metrics:     model.Metrics,
+		log:         logger,
+		tmpl:        t,
+	}, nil
+}
+
+// Notify sends an alert notification to Zulip
+func (zn *ZulipNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	logger := zn.log.New(notificationLogContext(ctx, as)...)
+	logger.Debug("Executing Zulip notification", "notification", zn.Name)
+
+	data := notify.GetTemplateData(ctx, zn.tmpl, as, gokit_log.NewNopLogger())
+	var tmplErr error
+	tmpl := tmplText(ctx, zn.tmpl, data, &tmplErr)
+
+	form := url.Values{}
+	form.Set("type", "stream")
+	form.Set("to", zn.Stream)
+	form.Set("topic", tmpl(zn.Topic))
+	form.Set("content", fmt.Sprintf("%s\n%s", tmpl(`{{ template "default.title" . }}`), tmpl(zn.Message)))
+	if tmplErr != nil {
+		return false, fmt.Errorf("failed to template Zulip message: %w", tmplErr)
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:        zn.URL,
+		User:       zn.BotEmail,
+		Password:   zn.APIKey,
+		HttpMethod: "POST",
+		HttpHeader: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		Body: form.Encode(),
+	}
+	if err := zn.httpOptions.apply(ctx, cmd, zn.tmpl, as); err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	err := sendWithRetry(ctx, cmd, zn.retry)
+	zn.metrics.observe("zulip", types.Alerts(as...).Status(), start, err)
+	if err != nil {
+		logger.Error("Failed to send Zulip notification", "error", err, "webhook", zn.Name)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (zn *ZulipNotifier) SendResolved() bool {
+	return !zn.GetDisableResolveMessage()
+}
+
+// Type returns the kind of the notification channel.
+func (zn *ZulipNotifier) Type() string {
+	return "zulip"
+}
+
+// Ping is not supported, see ErrPingUnsupported.
+func (zn *ZulipNotifier) Ping(ctx context.Context) error {
+	return ErrPingUnsupported
+}
@@ -0,0 +1,145 @@
+package channels
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
+)
+
+func TestZulipNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expURL       string
+		expMsg       string
+		expInitError error
+		expMsgError  error
+	}{
+		{
+			name:     "One alert",
+			settings: `{"realm_url": "https://example.zulipchat.com", "bot_email": "grafana-bot@example.zulipchat.com", "api_key": "somekey", "stream": "alerts"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expURL:       "https://example.zulipchat.com/api/v1/messages",
+			expMsg:       "content=%5BFIRING%3A1%5D++%28val1%29%0A%0A%2A%2AFiring%2A%2A%0ALabels%3A%0A+-+alertname+%3D+alert1%0A+-+lbl1+%3D+val1%0AAnnotations%3A%0A+-+ann1+%3D+annv1%0ASource%3A+%0A%0A%0A%0A%0A&to=alerts&topic=alert1&type=stream",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name: "Custom topic and message",
+			settings: `{
+				"realm_url": "https://zulip.example.org/",
+				"bot_email": "grafana-bot@example.zulipchat.com",
+				"api_key": "somekey",
+				"stream": "ops",
+				"topic": "Grafana: {{ .CommonLabels.alertname }}",
+				"message": "{{ len .Alerts.Firing }} firing"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				}, {
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+						Annotations: model.LabelSet{"ann1": "annv2"},
+					},
+				},
+			},
+			expURL:       "https://zulip.example.org/api/v1/messages",
+			expMsg:       "content=%5BFIRING%3A2%5D++%0A2+firing&to=ops&topic=Grafana%3A+alert1&type=stream",
+			expInitError: nil,
+			expMsgError:  nil,
+		}, {
+			name:         "Realm URL missing",
+			settings:     `{"bot_email": "grafana-bot@example.zulipchat.com", "api_key": "somekey", "stream": "alerts"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Zulip realm URL in settings"},
+		}, {
+			name:         "Invalid realm URL",
+			settings:     `{"realm_url": "example.zulipchat.com", "bot_email": "grafana-bot@example.zulipchat.com", "api_key": "somekey", "stream": "alerts"}`,
+			expInitError: alerting.ValidationError{Reason: "Invalid Zulip realm URL: Must be an absolute URL"},
+		}, {
+			name:         "Bot email missing",
+			settings:     `{"realm_url": "https://example.zulipchat.com", "api_key": "somekey", "stream": "alerts"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Zulip bot email in settings"},
+		}, {
+			name:         "API key missing",
+			settings:     `{"realm_url": "https://example.zulipchat.com", "bot_email": "grafana-bot@example.zulipchat.com", "stream": "alerts"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Zulip API key in settings"},
+		}, {
+			name:         "Stream missing",
+			settings:     `{"realm_url": "https://example.zulipchat.com", "bot_email": "grafana-bot@example.zulipchat.com", "api_key": "somekey"}`,
+			expInitError: alerting.ValidationError{Reason: "Could not find Zulip stream in settings"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				Name:     "zulip_testing",
+				Type:     "zulip",
+				Settings: settingsJSON,
+			}
+
+			pn, err := NewZulipNotifier(m, tmpl)
+			if c.expInitError != nil {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			var sent *models.SendWebhookSync
+			bus.AddHandlerCtx("test", func(ctx context.Context, webhook *models.SendWebhookSync) error {
+				sent = webhook
+				return nil
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			if c.expMsgError != nil {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.NotNil(t, sent)
+			require.Equal(t, c.expURL, sent.Url)
+			require.Equal(t, "grafana-bot@example.zulipchat.com", sent.User)
+			require.Equal(t, "somekey", sent.Password)
+			require.Equal(t, "application/x-www-form-urlencoded", sent.HttpHeader["Content-Type"])
+			require.Equal(t, c.expMsg, sent.Body)
+		})
+	}
+}
@@ -109,6 +109,15 @@ func (ns *NotificationService) SendWebhookSync(ctx context.Context, cmd *models.
 		HttpMethod:  cmd.HttpMethod,
 		HttpHeader:  cmd.HttpHeader,
 		ContentType: cmd.ContentType,
+		ProxyURL:    cmd.ProxyURL,
+		Timeout:     cmd.Timeout,
+		TLSConfig:   cmd.TLSConfig,
+		OnResponse:  cmd.OnResponse,
+
+		TLSConfigKey: cmd.TLSConfigKey,
+
+		IdempotencyKey:    cmd.IdempotencyKey,
+		IdempotencyHeader: cmd.IdempotencyHeader,
 	})
 }
 
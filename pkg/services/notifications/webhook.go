@@ -9,21 +9,32 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context/ctxhttp"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util"
 )
 
 type Webhook struct {
-	Url         string
-	User        string
-	Password    string
-	Body        string
-	HttpMethod  string
-	HttpHeader  map[string]string
-	ContentType string
+	Url          string
+	User         string
+	Password     string
+	Body         string
+	HttpMethod   string
+	HttpHeader   map[string]string
+	ContentType  string
+	ProxyURL     string
+	Timeout      time.Duration
+	TLSConfig    *tls.Config
+	TLSConfigKey string
+	OnResponse   func(status string, body []byte)
+
+	IdempotencyKey    string
+	IdempotencyHeader string
 }
 
 var netTransport = &http.Transport{
@@ -35,12 +46,141 @@ var netTransport = &http.Transport{
 		Timeout: 30 * time.Second,
 	}).Dial,
 	TLSHandshakeTimeout: 5 * time.Second,
+	// Webhooks are sent rarely, so don't keep their connections forever.
+	IdleConnTimeout: 90 * time.Second,
 }
 var netClient = &http.Client{
 	Timeout:   time.Second * 30,
 	Transport: netTransport,
 }
 
+// webhookClientKey identifies the transport settings of a webhook.
+type webhookClientKey struct {
+	proxyURL     string
+	tlsConfigKey string
+	timeout      time.Duration
+}
+
+// webhookClientCache holds the clients of webhooks with dedicated transport
+// settings, so that webhooks with the same settings share a connection pool
+// instead of leaking the connections of a transport per request. Clients
+// with different timeouts share the transport of their proxy and TLS
+// configuration.
+type webhookClientCache struct {
+	sync.Mutex
+	clients    map[webhookClientKey]*http.Client
+	transports map[webhookClientKey]*http.Transport
+}
+
+// maxWebhookTransports is the number of dedicated transports above which the
+// cache is emptied, so that settings which are no longer used don't keep
+// their transports forever.
+const maxWebhookTransports = 64
+
+var webhookClients = &webhookClientCache{
+	clients:    map[webhookClientKey]*http.Client{},
+	transports: map[webhookClientKey]*http.Transport{},
+}
+
+// get returns the client for key, creating it with newTransport if needed.
+func (c *webhookClientCache) get(key webhookClientKey, newTransport func() (*http.Transport, error)) (*http.Client, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if client, ok := c.clients[key]; ok {
+		return client, nil
+	}
+	transportKey := webhookClientKey{proxyURL: key.proxyURL, tlsConfigKey: key.tlsConfigKey}
+	transport, ok := c.transports[transportKey]
+	if !ok {
+		var err error
+		if transport, err = newTransport(); err != nil {
+			return nil, err
+		}
+		if len(c.transports) >= maxWebhookTransports {
+			c.reset()
+		}
+		c.transports[transportKey] = transport
+	}
+	client := &http.Client{Timeout: key.timeout, Transport: transport}
+	c.clients[key] = client
+	return client, nil
+}
+
+// reset empties the cache and closes the idle connections of its dedicated
+// transports. Requests in flight finish on their transports.
+func (c *webhookClientCache) reset() {
+	for _, transport := range c.transports {
+		if transport != netTransport {
+			transport.CloseIdleConnections()
+		}
+	}
+	c.clients = map[webhookClientKey]*http.Client{}
+	c.transports = map[webhookClientKey]*http.Transport{}
+}
+
+// webhookClient returns the HTTP client to send webhook with. The shared
+// client is used unless the webhook needs a dedicated proxy, timeout or TLS
+// configuration. Clients with dedicated settings are cached by them, unless
+// the webhook has a TLS configuration without a key. Such a client is not
+// shared, and the caller must close its idle connections after sending.
+func webhookClient(webhook *Webhook) (client *http.Client, shared bool, err error) {
+	timeout := netClient.Timeout
+	if webhook.Timeout > 0 {
+		timeout = webhook.Timeout
+	}
+	if webhook.ProxyURL == "" && webhook.TLSConfig == nil && timeout == netClient.Timeout {
+		return netClient, true, nil
+	}
+
+	newTransport := func() (*http.Transport, error) {
+		transport := netTransport.Clone()
+		if webhook.ProxyURL != "" {
+			proxyURL, err := url.Parse(webhook.ProxyURL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy URL: %w", err)
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+		if webhook.TLSConfig != nil {
+			tlsConfig := webhook.TLSConfig.Clone()
+			tlsConfig.Renegotiation = netTransport.TLSClientConfig.Renegotiation
+			transport.TLSClientConfig = tlsConfig
+		}
+		return transport, nil
+	}
+
+	if webhook.TLSConfig != nil && webhook.TLSConfigKey == "" {
+		transport, err := newTransport()
+		if err != nil {
+			return nil, false, err
+		}
+		return &http.Client{Timeout: timeout, Transport: transport}, false, nil
+	}
+
+	if webhook.ProxyURL == "" && webhook.TLSConfig == nil {
+		// Only the timeout differs, so the shared transport will do.
+		client, err := webhookClients.get(webhookClientKey{timeout: timeout}, func() (*http.Transport, error) {
+			return netTransport, nil
+		})
+		return client, true, err
+	}
+	client, err = webhookClients.get(webhookClientKey{
+		proxyURL:     webhook.ProxyURL,
+		tlsConfigKey: webhook.TLSConfigKey,
+		timeout:      timeout,
+	}, newTransport)
+	return client, true, err
+}
+
+// WebhookClient returns the client that webhooks with the proxy, timeout and
+// TLS settings of webhook are sent with, so that other requests with the same
+// settings share its connections. Unless the client is shared, the caller
+// must close its idle connections after sending.
+func WebhookClient(webhook *Webhook) (client *http.Client, shared bool, err error) {
+	return webhookClient(webhook)
+}
+
 func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *Webhook) error {
 	ns.log.Debug("Sending webhook", "url", webhook.Url, "http method", webhook.HttpMethod)
 
@@ -72,7 +212,19 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		request.Header.Set(k, v)
 	}
 
-	resp, err := ctxhttp.Do(ctx, netClient, request)
+	if webhook.IdempotencyKey != "" && webhook.IdempotencyHeader != "" {
+		request.Header.Set(webhook.IdempotencyHeader, webhook.IdempotencyKey)
+	}
+
+	client, shared, err := webhookClient(webhook)
+	if err != nil {
+		return err
+	}
+	if !shared {
+		defer client.CloseIdleConnections()
+	}
+
+	resp, err := ctxhttp.Do(ctx, client, request)
 	if err != nil {
 		return err
 	}
@@ -82,7 +234,7 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		}
 	}()
 
-	if resp.StatusCode/100 == 2 {
+	if resp.StatusCode/100 == 2 && webhook.OnResponse == nil {
 		ns.log.Debug("Webhook succeeded", "url", webhook.Url, "statuscode", resp.Status)
 		// flushing the body enables the transport to reuse the same connection
 		if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
@@ -95,7 +247,14 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 	if err != nil {
 		return err
 	}
+	if webhook.OnResponse != nil {
+		webhook.OnResponse(resp.Status, body)
+	}
+	if resp.StatusCode/100 == 2 {
+		ns.log.Debug("Webhook succeeded", "url", webhook.Url, "statuscode", resp.Status)
+		return nil
+	}
 
 	ns.log.Debug("Webhook failed", "url", webhook.Url, "statuscode", resp.Status, "body", string(body))
-	return fmt.Errorf("Webhook response status %v", resp.Status)
+	return models.WebhookStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 }
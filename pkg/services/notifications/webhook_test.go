@@ -0,0 +1,90 @@
+package notifications
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookClient(t *testing.T) {
+	client := func(webhook *Webhook) (*http.Client, bool) {
+		t.Helper()
+		c, shared, err := webhookClient(webhook)
+		require.NoError(t, err)
+		return c, shared
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	settings := func() *Webhook {
+		return &Webhook{
+			ProxyURL:     "http://proxy.internal:3128",
+			Timeout:      10 * time.Second,
+			TLSConfig:    tlsConfig.Clone(),
+			TLSConfigKey: "key1",
+		}
+	}
+
+	t.Run("Default settings use the shared client", func(t *testing.T) {
+		c, shared := client(&Webhook{})
+		require.True(t, shared)
+		require.Same(t, netClient, c)
+	})
+
+	t.Run("Identical settings share a client", func(t *testing.T) {
+		c, shared := client(settings())
+		require.True(t, shared)
+		other, _ := client(settings())
+		require.Same(t, c, other)
+
+		timeout, _ := client(&Webhook{Timeout: time.Minute})
+		otherTimeout, _ := client(&Webhook{Timeout: time.Minute})
+		require.Same(t, timeout, otherTimeout)
+		require.Same(t, netTransport, timeout.Transport)
+	})
+
+	t.Run("Different settings have distinct clients", func(t *testing.T) {
+		c, _ := client(settings())
+
+		proxy := settings()
+		proxy.ProxyURL = "http://other-proxy.internal:3128"
+		tlsKey := settings()
+		tlsKey.TLSConfigKey = "key2"
+		timeout := settings()
+		timeout.Timeout = 20 * time.Second
+		for _, webhook := range []*Webhook{proxy, tlsKey, timeout} {
+			other, _ := client(webhook)
+			require.NotSame(t, c, other)
+		}
+
+		// Clients that differ only in their timeout share the connections.
+		other, _ := client(timeout)
+		require.Same(t, c.Transport, other.Transport)
+		require.Equal(t, 20*time.Second, other.Timeout)
+	})
+
+	t.Run("TLS configuration without a key isn't shared", func(t *testing.T) {
+		webhook := settings()
+		webhook.TLSConfigKey = ""
+		c, shared := client(webhook)
+		require.False(t, shared)
+		other, _ := client(webhook)
+		require.NotSame(t, c, other)
+	})
+	t.Run("Cache is emptied when it is full", func(t *testing.T) {
+		first, _ := client(settings())
+		for i := 0; i < maxWebhookTransports; i++ {
+			webhook := settings()
+			webhook.ProxyURL = fmt.Sprintf("http://proxy%d.internal:3128", i)
+			client(webhook)
+		}
+		webhookClients.Lock()
+		require.LessOrEqual(t, len(webhookClients.transports), maxWebhookTransports)
+		webhookClients.Unlock()
+
+		other, _ := client(settings())
+		require.NotSame(t, first, other)
+	})
+}
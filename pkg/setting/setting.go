@@ -343,6 +343,12 @@ type Cfg struct {
 	DashboardAnnotationCleanupSettings AnnotationCleanupSettings
 	APIAnnotationCleanupSettings       AnnotationCleanupSettings
 
+	// Alert notification channels
+	AlertingSecretReferencesEnabled bool
+	AlertingSecretsDir              string
+	AlertingSecretsEnvPrefix        string
+	AlertingFileNotifierDir         string
+
 	// Sentry config
 	Sentry Sentry
 
@@ -503,6 +509,18 @@ func (cfg *Cfg) readGrafanaEnvironmentMetrics() error {
 	return nil
 }
 
+func (cfg *Cfg) readAlertingChannelSettings() {
+	section := cfg.Raw.Section("alerting")
+	cfg.AlertingSecretReferencesEnabled = section.Key("secret_references_enabled").MustBool(false)
+	if dir := section.Key("secrets_dir").MustString(""); dir != "" {
+		cfg.AlertingSecretsDir = makeAbsolute(dir, HomePath)
+	}
+	cfg.AlertingSecretsEnvPrefix = section.Key("secrets_env_prefix").MustString("ALERTING_SECRET_")
+	if dir := section.Key("file_notifier_dir").MustString(""); dir != "" {
+		cfg.AlertingFileNotifierDir = makeAbsolute(dir, HomePath)
+	}
+}
+
 func (cfg *Cfg) readAnnotationSettings() {
 	section := cfg.Raw.Section("annotations")
 	cfg.AnnotationCleanupJobBatchSize = section.Key("cleanupjob_batchsize").MustInt64(100)
@@ -915,6 +933,7 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	cfg.readSmtpSettings()
 	cfg.readQuotaSettings()
 	cfg.readAnnotationSettings()
+	cfg.readAlertingChannelSettings()
 	cfg.readExpressionsSettings()
 	if err := cfg.readGrafanaEnvironmentMetrics(); err != nil {
 		return err